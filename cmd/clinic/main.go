@@ -1,11 +1,24 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/max-programming/clinic/docs"
 	"github.com/max-programming/clinic/internal/bootstrap"
+	"github.com/max-programming/clinic/internal/config"
+	"github.com/max-programming/clinic/internal/hl7"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/metrics"
 	"github.com/max-programming/clinic/internal/router"
+	"github.com/max-programming/clinic/internal/tracing"
 )
 
 // @title Clinic API
@@ -17,8 +30,127 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 func main() {
-	app := bootstrap.Initialize()
-	r := router.SetupRouter(app.Handlers)
-	r.Run(":8080")
-	log.Println("Server started on :8080")
+	fs := flag.NewFlagSet("clinic", flag.ExitOnError)
+	printConfig := fs.Bool("print-config", false, "print the settings in effect, with secrets redacted, and exit")
+	cfg, err := config.Load(fs, os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+	if *printConfig {
+		return
+	}
+
+	logger, err := logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		logging.Fatal("Failed to set up logging", slog.Any("error", err))
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TraceExporter, cfg.TraceEndpoint, cfg.TraceSampleRatio)
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", slog.Any("error", err))
+	}
+
+	app := bootstrap.Initialize(cfg)
+	srv := newServer(cfg, router.SetupRouter(cfg, app.Handlers))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if app.HL7Server != nil {
+		go func() {
+			if err := app.HL7Server.ListenAndServe(); err != nil && !errors.Is(err, hl7.ErrServerClosed) {
+				logging.Fatal("HL7 listener stopped", slog.Any("error", err))
+			}
+		}()
+	}
+
+	scanCtx, stopScan := context.WithCancel(context.Background())
+	scanDone := make(chan struct{})
+	if app.DuplicateScan != nil {
+		go func() {
+			defer close(scanDone)
+			app.DuplicateScan.Run(scanCtx)
+		}()
+	} else {
+		close(scanDone)
+	}
+
+	var metricsServer *http.Server
+	switch {
+	case cfg.MetricsAddr != "":
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler(cfg.MetricsToken))
+		metricsServer = &http.Server{
+			Addr:              cfg.MetricsAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		}
+		go func() {
+			slog.Info("Metrics server starting", slog.String("addr", cfg.MetricsAddr))
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logging.Fatal("Metrics server stopped", slog.Any("error", err))
+			}
+		}()
+	case cfg.MetricsToken == "":
+		slog.Info("Metrics disabled, set METRICS_ADDR or METRICS_TOKEN to expose them")
+	}
+
+	go func() {
+		slog.Info("Server starting", slog.String("addr", srv.Addr), slog.Bool("tls", srv.TLSConfig != nil || cfg.TLSCertFile != ""))
+		if err := serve(cfg, srv); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Fatal("Server stopped", slog.Any("error", err))
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	slog.Info("Shutting down", slog.Duration("timeout", cfg.ShutdownTimeout))
+
+	// Everything shares one deadline. The HTTP server drains first so
+	// requests in flight still have the database, which is closed last.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	failed := false
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to drain HTTP requests", slog.Any("error", err))
+		failed = true
+	}
+	stopScan()
+	select {
+	case <-scanDone:
+	case <-shutdownCtx.Done():
+		slog.Error("Duplicate patient scan did not stop in time")
+		failed = true
+	}
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to stop background work", slog.Any("error", err))
+		failed = true
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Failed to stop metrics server", slog.Any("error", err))
+			failed = true
+		}
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", slog.Any("error", err))
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+	slog.Info("Server stopped")
 }
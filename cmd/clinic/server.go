@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/max-programming/clinic/internal/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// newServer configures the API server from cfg. It serves TLS when a
+// certificate and key are given, or when AUTOCERT_DOMAINS is set, in which
+// case certificates are obtained from Let's Encrypt over TLS-ALPN-01 and
+// HTTP_ADDR has to be reachable on port 443.
+func newServer(cfg *config.Config, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           handler,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	if domains := autocertDomains(cfg.AutocertDomains); len(domains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+		}
+		srv.TLSConfig = m.TLSConfig()
+	}
+	return srv
+}
+
+// serve blocks until srv is shut down, returning http.ErrServerClosed then.
+func serve(cfg *config.Config, srv *http.Server) error {
+	switch {
+	case cfg.TLSCertFile != "":
+		return srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	case srv.TLSConfig != nil:
+		return srv.ListenAndServeTLS("", "")
+	default:
+		return srv.ListenAndServe()
+	}
+}
+
+func autocertDomains(list string) []string {
+	var domains []string
+	for _, domain := range strings.Split(list, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/hl7"
+)
+
+// hl7send is a stand-in MLLP client for exercising the HL7 listener locally.
+// Each file argument is sent as one message; with no arguments a single
+// message is read from stdin.
+func main() {
+	addr := flag.String("addr", "localhost:2575", "MLLP listener address")
+	timeout := flag.Duration("timeout", 10*time.Second, "dial and ACK timeout")
+	flag.Parse()
+
+	client, err := hl7.Dial(*addr, *timeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	failed := false
+	for _, file := range files {
+		payload, err := readMessage(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ack, err := client.Send(payload)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("--- %s\n%s\n", file, strings.ReplaceAll(strings.TrimRight(string(ack), "\r"), "\r", "\n"))
+
+		if msg, err := hl7.Parse(ack); err != nil || msg.Segment("MSA").Value(1) != hl7.AckAccept {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func readMessage(file string) ([]byte, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\r"))
+	data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r"))
+	return bytes.Trim(data, "\r"), nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/max-programming/clinic/internal/bootstrap"
+	"github.com/max-programming/clinic/internal/config"
+	"github.com/max-programming/clinic/internal/db"
+	"github.com/max-programming/clinic/internal/importer"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+)
+
+// importpatients imports patients from a CSV or XLSX file the same way as
+// POST /api/patient-imports, but waits for the import to finish and prints
+// the rows that were not imported. The import is attributed to -user.
+func main() {
+	username := flag.String("user", "", "username the import is recorded against (required)")
+	columns := flag.String("map", "", `column mapping, e.g. "First Name=givenName,Notes="`)
+	dryRun := flag.Bool("dry-run", false, "only validate the rows")
+	allowDuplicates := flag.Bool("allow-duplicates", false, "import probable duplicates too")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: importpatients -user NAME [flags] FILE\n")
+		flag.PrintDefaults()
+	}
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.ValidateImport(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if *username == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+
+	mapping, err := parseMapping(*columns)
+	if err != nil {
+		log.Fatal(err)
+	}
+	format, err := importer.FormatFromName(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	table, err := importer.ReadTable(file, format, cfg.ImportMaxRows)
+	file.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fields, err := importer.NewMapping(table.Header, mapping)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, phoneIndex, err := bootstrap.LoadEncryption(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
+	db, err := db.Connect(ctx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := repository.NewUserRepository(db).FindByUsername(ctx, *username)
+	if err != nil {
+		log.Fatalf("Failed to find user %q: %v", *username, err)
+	}
+
+	importService := service.NewPatientImportService(
+		repository.NewPatientImportRepository(db),
+		repository.NewPatientReposiory(db, phoneIndex),
+		service.NewAuditService(repository.NewAuditLogRepository(db)),
+		cfg.ImportBatchSize,
+	)
+	job := &models.PatientImport{
+		FileName:        filepath.Base(path),
+		Format:          format,
+		DryRun:          *dryRun,
+		AllowDuplicates: *allowDuplicates,
+		CreatedBy:       user.ID,
+	}
+	err = importService.RunImport(ctx, job, importer.ParseRows(table, fields))
+
+	for _, issue := range job.Issues {
+		if issue.Field != "" {
+			fmt.Printf("row %d: %s: %s\n", issue.Row, issue.Field, issue.Message)
+		} else {
+			fmt.Printf("row %d: %s\n", issue.Row, issue.Message)
+		}
+	}
+	created := "Created"
+	if job.DryRun {
+		created = "Would create"
+	}
+	fmt.Printf("%s %d of %d patients, %d duplicates, %d failed (import %s)\n",
+		created, job.CreatedRows, job.TotalRows, job.DuplicateRows, job.FailedRows, job.ID)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseMapping reads "Header=field" pairs separated by commas. An empty field
+// ignores the column.
+func parseMapping(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		header, field, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping %q, expected Header=field", pair)
+		}
+		mapping[strings.TrimSpace(header)] = strings.TrimSpace(field)
+	}
+	return mapping, nil
+}
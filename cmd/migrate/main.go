@@ -1,45 +1,257 @@
 package main
 
 import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
 
+	"github.com/max-programming/clinic/cmd/migrate/migrations"
+	"github.com/max-programming/clinic/internal/config"
 	"github.com/max-programming/clinic/internal/db"
 )
 
+const usage = `usage: migrate [flags] COMMAND [ARG]
+
+Commands:
+  up [N]      apply all pending migrations, or the next N
+  down [N]    roll back the last N migrations, or all of them
+  goto V      migrate up or down to version V
+  force V     record version V without running anything, after fixing a
+              failed migration by hand; -1 records no version
+  version     print the version the database is at
+  status      list the migrations and whether they have been applied
+
+down, force and a goto that rolls back ask for confirmation unless -yes is
+given.
+
+Flags:
+`
+
+// migrate applies the migrations embedded in the binary to DATABASE_URL.
 func main() {
-	db, err := db.SQLConnect()
+	yes := flag.Bool("yes", false, "do not ask before rolling back or forcing a version")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := cfg.ValidateDatabase(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if flag.NArg() == 0 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	cmd, arg := flag.Arg(0), flag.Arg(1)
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	all, err := migrations.All()
 	if err != nil {
 		log.Fatal(err)
 	}
+	m, err := newMigrate(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer m.Close()
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://cmd/migrate/migrations",
-		"postgres",
-		driver,
-	)
+	current, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		current, err = 0, nil
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
-	cmd := os.Args[(len(os.Args) - 1)]
-	if cmd == "up" {
-		if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-			log.Fatal(err)
+	confirm := func(action string, rollback []migrations.Migration) {
+		if *yes {
+			return
+		}
+		fmt.Printf("This will %s on %s.\n", action, databaseName(cfg.DatabaseURL))
+		for _, migration := range rollback {
+			fmt.Printf("  %d %s\n", migration.Version, migration.Name)
+		}
+		fmt.Print("Continue? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			log.Fatal("Aborted")
+		}
+	}
+
+	// Each command settles what to run, asking first where needed; version
+	// and status only print and leave run nil.
+	var run func() error
+	switch cmd {
+	case "up":
+		if n := optionalCount(arg); n == 0 {
+			run = m.Up
+		} else {
+			run = func() error { return m.Steps(n) }
+		}
+	case "down":
+		applied := appliedMigrations(all, current)
+		n := optionalCount(arg)
+		if len(applied) == 0 {
+			run = func() error { return migrate.ErrNoChange }
+		} else if n == 0 || n >= len(applied) {
+			confirm("roll back every migration and drop all data", reversed(applied))
+			run = m.Down
+		} else {
+			confirm(fmt.Sprintf("roll back %d migrations", n), reversed(applied)[:n])
+			run = func() error { return m.Steps(-n) }
+		}
+	case "goto":
+		target := requiredVersion(arg)
+		if target < current {
+			applied := appliedMigrations(all, current)
+			rollback := reversed(applied[len(appliedMigrations(all, target)):])
+			confirm(fmt.Sprintf("roll back to version %d", target), rollback)
+		}
+		run = func() error { return m.Migrate(target) }
+	case "force":
+		version, convErr := strconv.Atoi(arg)
+		if convErr != nil || version < -1 {
+			log.Fatalf("force needs a version or -1, got %q", arg)
 		}
+		confirm(fmt.Sprintf("record version %d without running any migration", version), nil)
+		run = func() error { return m.Force(version) }
+	case "version":
+		printVersion(current, dirty)
+	case "status":
+		printStatus(all, current, dirty)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if run == nil {
+		return
+	}
+
+	// Only now, so that Ctrl+C at the prompt still aborts. From here on it
+	// lets a running migration finish instead of leaving the database dirty.
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		log.Print("Stopping after the current migration")
+		m.GracefulStop <- true
+	}()
+
+	err = run()
+	if errors.Is(err, migrate.ErrNoChange) {
+		log.Print("No change")
+		err = nil
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func newMigrate(databaseURL string) (*migrate.Migrate, error) {
+	conn, err := db.SQLConnect(databaseURL)
+	if err != nil {
+		return nil, err
 	}
-	if cmd == "down" {
-		if err := m.Down(); err != nil && err != migrate.ErrNoChange {
-			log.Fatal(err)
+	m, err := migrations.New(conn)
+	if err != nil {
+		return nil, err
+	}
+	m.Log = migrateLog{}
+	return m, nil
+}
+
+type migrateLog struct{}
+
+func (migrateLog) Printf(format string, v ...any) {
+	log.Printf(strings.TrimSuffix(format, "\n"), v...)
+}
+
+func (migrateLog) Verbose() bool {
+	return false
+}
+
+func printVersion(current uint, dirty bool) {
+	switch {
+	case current == 0:
+		fmt.Println("No migrations applied")
+	case dirty:
+		fmt.Printf("%d (dirty: the migration failed part way, fix it by hand and use force)\n", current)
+	default:
+		fmt.Println(current)
+	}
+}
+
+func printStatus(all []migrations.Migration, current uint, dirty bool) {
+	known := false
+	for _, migration := range all {
+		status := "pending"
+		switch {
+		case migration.Version == current && dirty:
+			status = "dirty"
+		case migration.Version <= current:
+			status = "applied"
 		}
+		known = known || migration.Version == current
+		fmt.Printf("%-8s %d %s\n", status, migration.Version, migration.Name)
+	}
+	if current != 0 && !known {
+		fmt.Printf("The database is at version %d, which this binary does not know about.\n", current)
+	}
+}
+
+// appliedMigrations returns the migrations up to and including version.
+func appliedMigrations(all []migrations.Migration, version uint) []migrations.Migration {
+	n := 0
+	for n < len(all) && all[n].Version <= version {
+		n++
+	}
+	return all[:n]
+}
+
+func reversed(list []migrations.Migration) []migrations.Migration {
+	out := slices.Clone(list)
+	slices.Reverse(out)
+	return out
+}
+
+func optionalCount(arg string) int {
+	if arg == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n <= 0 {
+		log.Fatalf("N must be a positive number, got %q", arg)
+	}
+	return n
+}
+
+func requiredVersion(arg string) uint {
+	version, err := strconv.ParseUint(arg, 10, 0)
+	if err != nil {
+		log.Fatalf("goto needs a version, got %q", arg)
+	}
+	return uint(version)
+}
+
+// databaseName names the database for the confirmation prompt, leaving out
+// the credentials.
+func databaseName(databaseURL string) string {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "the database"
 	}
+	return fmt.Sprintf("%s at %s", strings.TrimPrefix(u.Path, "/"), u.Host)
 }
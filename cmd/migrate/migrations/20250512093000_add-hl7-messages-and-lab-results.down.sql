@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS lab_results;
+
+DROP TABLE IF EXISTS hl7_messages;
+
+DROP INDEX IF EXISTS idx_patients_mrn;
+
+ALTER TABLE patients
+DROP COLUMN IF EXISTS mrn;
@@ -0,0 +1,45 @@
+ALTER TABLE patients
+ADD COLUMN mrn VARCHAR(50);
+
+CREATE UNIQUE INDEX idx_patients_mrn ON patients (mrn);
+
+create table if not exists hl7_messages (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  control_id VARCHAR(199),
+  message_type VARCHAR(20),
+  sending_app VARCHAR(255),
+  sending_facility VARCHAR(255),
+  raw text not null,
+  status VARCHAR(20) not null check (
+    status in ('received', 'processed', 'failed', 'rejected', 'duplicate')
+  ),
+  error text,
+  patient_id uuid REFERENCES patients (id) ON DELETE SET NULL,
+  received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  processed_at TIMESTAMP
+);
+
+CREATE INDEX idx_hl7_messages_control_id ON hl7_messages (control_id);
+
+CREATE INDEX idx_hl7_messages_patient_id ON hl7_messages (patient_id);
+
+create table if not exists lab_results (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  patient_id uuid not null REFERENCES patients (id) ON DELETE CASCADE,
+  hl7_message_id uuid REFERENCES hl7_messages (id) ON DELETE SET NULL,
+  order_number VARCHAR(255),
+  test_code VARCHAR(255) not null,
+  test_name VARCHAR(255),
+  value_type VARCHAR(10),
+  value text,
+  units VARCHAR(255),
+  reference_range VARCHAR(255),
+  abnormal_flag VARCHAR(10),
+  result_status VARCHAR(10),
+  observed_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_lab_results_patient_id ON lab_results (patient_id);
+
+CREATE INDEX idx_lab_results_hl7_message_id ON lab_results (hl7_message_id);
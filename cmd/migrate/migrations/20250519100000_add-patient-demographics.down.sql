@@ -0,0 +1,46 @@
+ALTER TABLE patients
+ADD COLUMN age INTEGER,
+ADD COLUMN address text;
+
+UPDATE patients
+SET
+  age = COALESCE(
+    date_part('year', age(CURRENT_DATE, date_of_birth))::integer,
+    0
+  ),
+  address = NULLIF(
+    concat_ws(
+      ', ',
+      NULLIF(address_line1, ''),
+      NULLIF(address_line2, ''),
+      NULLIF(address_city, ''),
+      NULLIF(address_state, ''),
+      NULLIF(address_postal_code, ''),
+      NULLIF(address_country, '')
+    ),
+    ''
+  );
+
+ALTER TABLE patients
+ALTER COLUMN age
+SET not null;
+
+ALTER TABLE patients
+DROP COLUMN given_name,
+DROP COLUMN family_name,
+DROP COLUMN preferred_name,
+DROP COLUMN date_of_birth,
+DROP COLUMN date_of_birth_estimated,
+DROP COLUMN sex_at_birth,
+DROP COLUMN gender_identity,
+DROP COLUMN email,
+DROP COLUMN preferred_language,
+DROP COLUMN address_line1,
+DROP COLUMN address_line2,
+DROP COLUMN address_city,
+DROP COLUMN address_state,
+DROP COLUMN address_postal_code,
+DROP COLUMN address_country,
+DROP COLUMN emergency_contact_name,
+DROP COLUMN emergency_contact_relationship,
+DROP COLUMN emergency_contact_phone;
@@ -0,0 +1,41 @@
+ALTER TABLE patients
+ADD COLUMN given_name VARCHAR(100),
+ADD COLUMN family_name VARCHAR(100),
+ADD COLUMN preferred_name VARCHAR(100),
+ADD COLUMN date_of_birth DATE,
+ADD COLUMN date_of_birth_estimated BOOLEAN not null DEFAULT false,
+ADD COLUMN sex_at_birth VARCHAR(20),
+ADD COLUMN gender_identity VARCHAR(50),
+ADD COLUMN email VARCHAR(255),
+ADD COLUMN preferred_language VARCHAR(35),
+ADD COLUMN address_line1 VARCHAR(255),
+ADD COLUMN address_line2 VARCHAR(255),
+ADD COLUMN address_city VARCHAR(100),
+ADD COLUMN address_state VARCHAR(100),
+ADD COLUMN address_postal_code VARCHAR(20),
+ADD COLUMN address_country VARCHAR(100),
+ADD COLUMN emergency_contact_name VARCHAR(255),
+ADD COLUMN emergency_contact_relationship VARCHAR(50),
+ADD COLUMN emergency_contact_phone VARCHAR(20);
+
+-- A patient aged N on created_at was born somewhere in the year before their
+-- Nth birthday, so use the middle of that range and flag it as estimated.
+UPDATE patients
+SET
+  date_of_birth = (
+    created_at - make_interval(years => age, months => 6)
+  )::date,
+  date_of_birth_estimated = true,
+  given_name = CASE
+    WHEN position(' ' in trim(name)) > 0 THEN regexp_replace(trim(name), '\s+\S+$', '')
+    ELSE trim(name)
+  END,
+  family_name = CASE
+    WHEN position(' ' in trim(name)) > 0 THEN regexp_replace(trim(name), '^.*\s', '')
+    ELSE ''
+  END,
+  address_line1 = address;
+
+ALTER TABLE patients
+DROP COLUMN age,
+DROP COLUMN address;
@@ -0,0 +1,23 @@
+ALTER TABLE patients
+ALTER COLUMN mrn
+DROP not null;
+
+UPDATE patients
+SET
+  mrn = (
+    SELECT
+      i.value
+    FROM
+      patient_identifiers i
+    WHERE
+      i.patient_id = patients.id
+      AND i.type = 'hospital_mrn'
+    ORDER BY
+      i.created_at
+    LIMIT
+      1
+  );
+
+DROP SEQUENCE IF EXISTS patient_mrn_seq;
+
+DROP TABLE IF EXISTS patient_identifiers;
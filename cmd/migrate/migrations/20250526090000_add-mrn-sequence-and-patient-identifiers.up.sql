@@ -0,0 +1,119 @@
+create table if not exists patient_identifiers (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  patient_id uuid not null REFERENCES patients (id) ON DELETE CASCADE,
+  type VARCHAR(30) not null check (
+    type in (
+      'national_id',
+      'insurance_member_id',
+      'hospital_mrn',
+      'other'
+    )
+  ),
+  issuer VARCHAR(255) not null DEFAULT '',
+  value VARCHAR(255) not null,
+  created_by uuid REFERENCES users (id),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (type, issuer, value)
+);
+
+CREATE INDEX idx_patient_identifiers_patient_id ON patient_identifiers (patient_id);
+
+CREATE INDEX idx_patient_identifiers_value ON patient_identifiers (value);
+
+-- MRNs received over HL7 so far belong to the sending hospital, so keep them
+-- as external identifiers before assigning clinic MRNs.
+INSERT INTO
+  patient_identifiers (patient_id, type, issuer, value)
+SELECT
+  p.id,
+  'hospital_mrn',
+  COALESCE(
+    (
+      SELECT
+        m.sending_facility
+      FROM
+        hl7_messages m
+      WHERE
+        m.patient_id = p.id
+        AND m.sending_facility IS NOT NULL
+      ORDER BY
+        m.received_at
+      LIMIT
+        1
+    ),
+    ''
+  ),
+  p.mrn
+FROM
+  patients p
+WHERE
+  p.mrn IS NOT NULL;
+
+CREATE SEQUENCE IF NOT EXISTS patient_mrn_seq START 100000 MINVALUE 100000;
+
+-- Mirrors utils.FormatMRN; only used to backfill existing patients.
+CREATE FUNCTION mrn_with_check_digit (n bigint) RETURNS text AS $$
+DECLARE
+  payload text := lpad(n::text, 6, '0');
+  total integer := 0;
+  digit integer;
+BEGIN
+  FOR i IN 1..length(payload) LOOP
+    digit := substr(payload, length(payload) - i + 1, 1)::integer;
+    IF i % 2 = 1 THEN
+      digit := digit * 2;
+      IF digit > 9 THEN
+        digit := digit - 9;
+      END IF;
+    END IF;
+    total := total + digit;
+  END LOOP;
+  RETURN payload || ((10 - total % 10) % 10)::text;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+WITH
+  ordered AS (
+    SELECT
+      id,
+      row_number() OVER (
+        ORDER BY
+          created_at,
+          id
+      ) AS n
+    FROM
+      patients
+  )
+UPDATE patients
+SET
+  mrn = mrn_with_check_digit (99999 + ordered.n)
+FROM
+  ordered
+WHERE
+  patients.id = ordered.id;
+
+SELECT
+  setval(
+    'patient_mrn_seq',
+    GREATEST(
+      (
+        SELECT
+          count(*)
+        FROM
+          patients
+      ) + 99999,
+      100000
+    ),
+    (
+      SELECT
+        count(*)
+      FROM
+        patients
+    ) > 0
+  );
+
+DROP FUNCTION mrn_with_check_digit (bigint);
+
+ALTER TABLE patients
+ALTER COLUMN mrn
+SET not null;
@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS duplicate_candidates;
+
+DROP TABLE IF EXISTS patient_merges;
+
+ALTER TABLE patients
+DROP COLUMN IF EXISTS merged_at,
+DROP COLUMN IF EXISTS merged_into_id;
+
+DROP TABLE IF EXISTS audit_logs;
+
+UPDATE users
+SET
+  role = 'receptionist'
+WHERE
+  role = 'admin';
+
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_role_check;
+
+ALTER TABLE users
+ADD CONSTRAINT users_role_check check (role in ('receptionist', 'doctor'));
@@ -0,0 +1,55 @@
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_role_check;
+
+ALTER TABLE users
+ADD CONSTRAINT users_role_check check (role in ('receptionist', 'doctor', 'admin'));
+
+create table if not exists audit_logs (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  user_id uuid REFERENCES users (id) ON DELETE SET NULL,
+  action VARCHAR(100) not null,
+  entity_type VARCHAR(50) not null,
+  entity_id VARCHAR(255) not null,
+  details jsonb,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_logs_entity ON audit_logs (entity_type, entity_id);
+
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at);
+
+ALTER TABLE patients
+ADD COLUMN merged_into_id uuid REFERENCES patients (id),
+ADD COLUMN merged_at TIMESTAMP;
+
+CREATE INDEX idx_patients_merged_into_id ON patients (merged_into_id);
+
+create table if not exists patient_merges (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  survivor_id uuid not null REFERENCES patients (id) ON DELETE CASCADE,
+  merged_id uuid not null REFERENCES patients (id) ON DELETE CASCADE,
+  moved_records jsonb not null,
+  survivor_notes text not null DEFAULT '',
+  merged_by uuid REFERENCES users (id),
+  merged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  reversed_by uuid REFERENCES users (id),
+  reversed_at TIMESTAMP
+);
+
+CREATE INDEX idx_patient_merges_survivor_id ON patient_merges (survivor_id);
+
+CREATE INDEX idx_patient_merges_merged_id ON patient_merges (merged_id);
+
+create table if not exists duplicate_candidates (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  patient_id uuid not null REFERENCES patients (id) ON DELETE CASCADE,
+  duplicate_patient_id uuid not null REFERENCES patients (id) ON DELETE CASCADE,
+  score INTEGER not null,
+  reasons VARCHAR(255) not null DEFAULT '',
+  status VARCHAR(20) not null DEFAULT 'open' check (status in ('open', 'dismissed', 'merged')),
+  detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (patient_id, duplicate_patient_id)
+);
+
+CREATE INDEX idx_duplicate_candidates_status ON duplicate_candidates (status);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS invoice_items;
+
+DROP TABLE IF EXISTS invoices;
+
+DROP TABLE IF EXISTS invoice_sequences;
+
+DROP TABLE IF EXISTS fees;
@@ -0,0 +1,75 @@
+create table if not exists fees (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  code VARCHAR(50) UNIQUE not null,
+  name VARCHAR(255) not null,
+  description text not null DEFAULT '',
+  price NUMERIC(12, 2) not null check (price >= 0),
+  tax_rate NUMERIC(5, 2) not null DEFAULT 0 check (tax_rate >= 0),
+  active BOOLEAN not null DEFAULT true,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per fiscal year holding the last invoice number handed out. It is
+-- incremented in the same transaction that issues the invoice, so numbers
+-- stay gapless.
+create table if not exists invoice_sequences (
+  fiscal_year INTEGER PRIMARY KEY,
+  last_number INTEGER not null
+);
+
+create table if not exists invoices (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  number VARCHAR(50) UNIQUE,
+  fiscal_year INTEGER,
+  patient_id uuid not null REFERENCES patients (id),
+  appointment_id uuid,
+  status VARCHAR(20) not null check (
+    status in (
+      'draft',
+      'issued',
+      'partially_paid',
+      'paid',
+      'void'
+    )
+  ),
+  currency VARCHAR(3) not null,
+  discount_percent NUMERIC(5, 2) not null DEFAULT 0,
+  subtotal NUMERIC(12, 2) not null DEFAULT 0,
+  discount_total NUMERIC(12, 2) not null DEFAULT 0,
+  tax_total NUMERIC(12, 2) not null DEFAULT 0,
+  total NUMERIC(12, 2) not null DEFAULT 0,
+  amount_paid NUMERIC(12, 2) not null DEFAULT 0,
+  notes text not null DEFAULT '',
+  issued_at TIMESTAMP,
+  due_date TIMESTAMP,
+  voided_at TIMESTAMP,
+  void_reason text not null DEFAULT '',
+  created_by uuid REFERENCES users (id),
+  updated_by uuid REFERENCES users (id),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  check ((status = 'draft') = (number IS NULL))
+);
+
+CREATE INDEX idx_invoices_patient_id ON invoices (patient_id);
+
+CREATE INDEX idx_invoices_status ON invoices (status);
+
+create table if not exists invoice_items (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  invoice_id uuid not null REFERENCES invoices (id) ON DELETE CASCADE,
+  fee_id uuid REFERENCES fees (id),
+  position INTEGER not null,
+  description VARCHAR(255) not null,
+  quantity NUMERIC(10, 2) not null check (quantity > 0),
+  unit_price NUMERIC(12, 2) not null,
+  discount_percent NUMERIC(5, 2) not null DEFAULT 0,
+  tax_rate NUMERIC(5, 2) not null DEFAULT 0,
+  line_subtotal NUMERIC(12, 2) not null,
+  line_discount NUMERIC(12, 2) not null,
+  line_tax NUMERIC(12, 2) not null,
+  line_total NUMERIC(12, 2) not null
+);
+
+CREATE INDEX idx_invoice_items_invoice_id ON invoice_items (invoice_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS payments;
+
+DROP TABLE IF EXISTS day_closes;
+
+DROP SEQUENCE IF EXISTS payment_receipt_seq;
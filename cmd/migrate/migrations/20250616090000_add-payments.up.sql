@@ -0,0 +1,34 @@
+create sequence if not exists payment_receipt_seq;
+
+create table if not exists day_closes (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  business_date DATE UNIQUE not null,
+  payment_count INTEGER not null,
+  net_total NUMERIC(12, 2) not null,
+  notes text not null DEFAULT '',
+  closed_by uuid not null REFERENCES users (id),
+  closed_at TIMESTAMP not null DEFAULT CURRENT_TIMESTAMP
+);
+
+create table if not exists payments (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  receipt_number VARCHAR(50) UNIQUE not null,
+  invoice_id uuid not null REFERENCES invoices (id),
+  kind VARCHAR(10) not null check (kind in ('payment', 'refund')),
+  method VARCHAR(20) not null check (method in ('cash', 'card', 'bank_transfer')),
+  amount NUMERIC(12, 2) not null check (amount > 0),
+  reference VARCHAR(255) not null DEFAULT '',
+  notes text not null DEFAULT '',
+  refund_of_id uuid REFERENCES payments (id),
+  received_by uuid not null REFERENCES users (id),
+  received_at TIMESTAMP not null,
+  business_date DATE not null,
+  day_close_id uuid REFERENCES day_closes (id),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  check (kind = 'refund' or refund_of_id IS NULL)
+);
+
+CREATE INDEX idx_payments_invoice_id ON payments (invoice_id);
+
+CREATE INDEX idx_payments_business_date ON payments (business_date);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS claims;
+
+DROP SEQUENCE IF EXISTS edi_interchange_seq;
+
+DROP SEQUENCE IF EXISTS claim_number_seq;
+
+DROP TABLE IF EXISTS insurance_policies;
@@ -0,0 +1,63 @@
+create table if not exists insurance_policies (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  patient_id uuid not null REFERENCES patients (id) ON DELETE CASCADE,
+  priority VARCHAR(10) not null DEFAULT 'primary' check (priority in ('primary', 'secondary', 'tertiary')),
+  payer_name VARCHAR(255) not null,
+  payer_id VARCHAR(80) not null,
+  plan_name VARCHAR(255) not null DEFAULT '',
+  member_id VARCHAR(80) not null,
+  group_number VARCHAR(50) not null DEFAULT '',
+  relationship VARCHAR(10) not null DEFAULT 'self' check (relationship in ('self', 'spouse', 'child', 'other')),
+  subscriber_given_name VARCHAR(100) not null DEFAULT '',
+  subscriber_family_name VARCHAR(100) not null DEFAULT '',
+  subscriber_date_of_birth DATE,
+  valid_from DATE not null,
+  valid_to DATE,
+  coverage_percent NUMERIC(5, 2) not null check (coverage_percent between 0 and 100),
+  copay NUMERIC(12, 2) not null DEFAULT 0 check (copay >= 0),
+  created_by uuid REFERENCES users (id),
+  updated_by uuid REFERENCES users (id),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  check (valid_to IS NULL or valid_to >= valid_from)
+);
+
+CREATE INDEX idx_insurance_policies_patient_id ON insurance_policies (patient_id);
+
+create sequence if not exists claim_number_seq;
+
+create sequence if not exists edi_interchange_seq;
+
+create table if not exists claims (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  claim_number VARCHAR(20) UNIQUE not null,
+  invoice_id uuid not null REFERENCES invoices (id),
+  policy_id uuid not null REFERENCES insurance_policies (id),
+  status VARCHAR(20) not null check (
+    status in (
+      'draft',
+      'submitted',
+      'accepted',
+      'rejected',
+      'paid'
+    )
+  ),
+  diagnosis_codes text not null DEFAULT '',
+  total_charge NUMERIC(12, 2) not null,
+  expected_amount NUMERIC(12, 2) not null,
+  paid_amount NUMERIC(12, 2),
+  payer_claim_number VARCHAR(80) not null DEFAULT '',
+  rejection_reason text not null DEFAULT '',
+  notes text not null DEFAULT '',
+  interchange_control_number INTEGER,
+  submitted_at TIMESTAMP,
+  adjudicated_at TIMESTAMP,
+  created_by uuid REFERENCES users (id),
+  updated_by uuid REFERENCES users (id),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_claims_invoice_policy ON claims (invoice_id, policy_id);
+
+CREATE INDEX idx_claims_status ON claims (status);
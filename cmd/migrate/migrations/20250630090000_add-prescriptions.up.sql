@@ -0,0 +1,26 @@
+create table if not exists prescriptions (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  patient_id uuid not null REFERENCES patients (id) ON DELETE CASCADE,
+  prescribed_by uuid not null REFERENCES users (id),
+  diagnosis text not null DEFAULT '',
+  notes text not null DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_prescriptions_patient_id ON prescriptions (patient_id);
+
+create table if not exists prescription_items (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  prescription_id uuid not null REFERENCES prescriptions (id) ON DELETE CASCADE,
+  position INTEGER not null,
+  medication VARCHAR(255) not null,
+  dosage VARCHAR(100) not null,
+  route VARCHAR(50) not null DEFAULT '',
+  frequency VARCHAR(100) not null,
+  duration VARCHAR(100) not null DEFAULT '',
+  quantity VARCHAR(50) not null DEFAULT '',
+  instructions text not null DEFAULT ''
+);
+
+CREATE INDEX idx_prescription_items_prescription_id ON prescription_items (prescription_id);
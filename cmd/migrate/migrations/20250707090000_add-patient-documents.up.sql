@@ -0,0 +1,17 @@
+create table if not exists patient_documents (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  patient_id uuid not null REFERENCES patients (id),
+  category VARCHAR(20) not null check (category in ('referral', 'id_card', 'report', 'consent', 'other')),
+  title VARCHAR(255) not null,
+  file_name VARCHAR(255) not null,
+  content_type VARCHAR(100) not null,
+  size_bytes BIGINT not null check (size_bytes > 0),
+  sha256 CHAR(64) not null,
+  storage_key VARCHAR(255) UNIQUE not null,
+  uploaded_by uuid not null REFERENCES users (id),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_patient_documents_patient_id ON patient_documents (patient_id);
+
+CREATE INDEX idx_patient_documents_sha256 ON patient_documents (sha256);
@@ -0,0 +1,27 @@
+-- Encrypted values cannot be read once this is rolled back, and the server
+-- would show them as they are. Refuse until they have been stored as
+-- plaintext again with reencrypt -decrypt.
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM patients
+    WHERE phone LIKE 'enc:%'
+      OR medical_notes LIKE 'enc:%'
+      OR address_line1 LIKE 'enc:%'
+      OR address_line2 LIKE 'enc:%'
+      OR address_city LIKE 'enc:%'
+      OR address_state LIKE 'enc:%'
+      OR address_postal_code LIKE 'enc:%'
+      OR address_country LIKE 'enc:%'
+  ) OR EXISTS (
+    SELECT 1 FROM patient_merges WHERE survivor_notes LIKE 'enc:%'
+  ) THEN
+    RAISE EXCEPTION 'patient fields are still encrypted: run "go run ./cmd/reencrypt -decrypt", then "migrate force 20250714090000" and roll back again';
+  END IF;
+END $$;
+
+-- The columns stay text: encrypted values would not fit the old lengths.
+DROP INDEX IF EXISTS idx_patients_phone_index;
+
+ALTER TABLE patients
+DROP COLUMN phone_index;
@@ -0,0 +1,12 @@
+-- Encrypted values are much longer than the plaintext they replace.
+ALTER TABLE patients
+ALTER COLUMN phone TYPE text,
+ALTER COLUMN address_line1 TYPE text,
+ALTER COLUMN address_line2 TYPE text,
+ALTER COLUMN address_city TYPE text,
+ALTER COLUMN address_state TYPE text,
+ALTER COLUMN address_postal_code TYPE text,
+ALTER COLUMN address_country TYPE text,
+ADD COLUMN phone_index CHAR(64);
+
+CREATE INDEX idx_patients_phone_index ON patients (phone_index);
@@ -0,0 +1,21 @@
+create table if not exists patient_imports (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  file_name VARCHAR(255) not null,
+  format VARCHAR(10) not null check (format in ('csv', 'xlsx')),
+  dry_run BOOLEAN not null DEFAULT false,
+  allow_duplicates BOOLEAN not null DEFAULT false,
+  status VARCHAR(20) not null check (status in ('pending', 'running', 'completed', 'failed')),
+  total_rows INTEGER not null DEFAULT 0,
+  processed_rows INTEGER not null DEFAULT 0,
+  created_rows INTEGER not null DEFAULT 0,
+  duplicate_rows INTEGER not null DEFAULT 0,
+  failed_rows INTEGER not null DEFAULT 0,
+  issues jsonb not null DEFAULT '[]',
+  error text not null DEFAULT '',
+  created_by uuid not null REFERENCES users (id),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_patient_imports_created_at ON patient_imports (created_at);
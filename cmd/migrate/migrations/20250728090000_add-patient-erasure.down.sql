@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS erasure_requests;
+
+DROP TABLE IF EXISTS legal_holds;
+
+ALTER TABLE patients DROP COLUMN IF EXISTS erased_at;
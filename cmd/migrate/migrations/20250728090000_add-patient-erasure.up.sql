@@ -0,0 +1,34 @@
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP;
+
+create table if not exists legal_holds (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  patient_id uuid not null REFERENCES patients (id),
+  reason text not null,
+  placed_by uuid not null REFERENCES users (id),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  released_by uuid REFERENCES users (id),
+  released_at TIMESTAMP
+);
+
+CREATE INDEX idx_legal_holds_patient_id ON legal_holds (patient_id);
+
+create table if not exists erasure_requests (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  patient_id uuid not null REFERENCES patients (id),
+  reason text not null,
+  status VARCHAR(20) not null check (status in ('pending', 'completed', 'cancelled')),
+  requested_by uuid not null REFERENCES users (id),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  completed_by uuid REFERENCES users (id),
+  completed_at TIMESTAMP,
+  cancelled_by uuid REFERENCES users (id),
+  cancelled_at TIMESTAMP,
+  certificate text not null DEFAULT '',
+  signature text not null DEFAULT '',
+  signing_key_id VARCHAR(64) not null DEFAULT ''
+);
+
+CREATE INDEX idx_erasure_requests_patient_id ON erasure_requests (patient_id);
+
+-- At most one open request per patient.
+CREATE UNIQUE INDEX idx_erasure_requests_pending ON erasure_requests (patient_id) WHERE status = 'pending';
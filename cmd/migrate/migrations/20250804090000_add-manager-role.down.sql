@@ -0,0 +1,11 @@
+UPDATE users
+SET
+  role = 'receptionist'
+WHERE
+  role = 'manager';
+
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_role_check;
+
+ALTER TABLE users
+ADD CONSTRAINT users_role_check check (role in ('receptionist', 'doctor', 'admin'));
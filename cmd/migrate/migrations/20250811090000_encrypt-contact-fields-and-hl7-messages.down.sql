@@ -0,0 +1,16 @@
+-- Encrypted values cannot be read once this is rolled back. Refuse until they
+-- have been stored as plaintext again with reencrypt -decrypt.
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM patients
+    WHERE email LIKE 'enc:%'
+      OR emergency_contact_phone LIKE 'enc:%'
+  ) OR EXISTS (
+    SELECT 1 FROM hl7_messages WHERE raw LIKE 'enc:%'
+  ) THEN
+    RAISE EXCEPTION 'contact fields or HL7 messages are still encrypted: run "go run ./cmd/reencrypt -decrypt", then "migrate force 20250811090000" and roll back again';
+  END IF;
+END $$;
+
+-- The columns stay text: encrypted values would not fit the old lengths.
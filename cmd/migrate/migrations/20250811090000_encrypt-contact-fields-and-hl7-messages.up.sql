@@ -0,0 +1,4 @@
+-- Encrypted values are much longer than the plaintext they replace.
+ALTER TABLE patients
+ALTER COLUMN email TYPE text,
+ALTER COLUMN emergency_contact_phone TYPE text;
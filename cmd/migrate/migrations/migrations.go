@@ -0,0 +1,82 @@
+// Package migrations holds the database migrations, embedded so that they
+// ship with the binaries.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"io"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// Source reads the embedded migrations.
+func Source() (source.Driver, error) {
+	return iofs.New(FS, ".")
+}
+
+// New prepares to migrate the database behind conn. Closing the returned
+// Migrate closes conn as well.
+func New(conn *sql.DB) (*migrate.Migrate, error) {
+	driver, err := postgres.WithInstance(conn, &postgres.Config{})
+	if err != nil {
+		return nil, err
+	}
+	src, err := Source()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithInstance("iofs", src, "postgres", driver)
+}
+
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+// All lists the embedded migrations, oldest first.
+func All() ([]Migration, error) {
+	src, err := Source()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var all []Migration
+	version, err := src.First()
+	for err == nil {
+		var r io.ReadCloser
+		var name string
+		if r, name, err = src.ReadUp(version); err != nil {
+			return nil, err
+		}
+		r.Close()
+		all = append(all, Migration{version, name})
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return all, nil
+}
+
+// Latest is the version of the newest embedded migration, which a database
+// is at once every migration has been applied.
+func Latest() (uint, error) {
+	all, err := All()
+	if err != nil {
+		return 0, err
+	}
+	if len(all) == 0 {
+		return 0, errors.New("no migrations embedded")
+	}
+	return all[len(all)-1].Version, nil
+}
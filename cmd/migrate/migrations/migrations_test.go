@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	all, err := All()
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+	if len(all) == 0 {
+		t.Fatal("All() found no migrations")
+	}
+
+	for i, migration := range all {
+		if i > 0 && migration.Version <= all[i-1].Version {
+			t.Errorf("migration %d comes after %d", migration.Version, all[i-1].Version)
+		}
+		if migration.Name == "" {
+			t.Errorf("migration %d has no name", migration.Version)
+		}
+	}
+
+	latest, err := Latest()
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if latest != all[len(all)-1].Version {
+		t.Errorf("Latest() = %d, want %d", latest, all[len(all)-1].Version)
+	}
+}
+
+// Every migration must be reversible for migrate down and goto.
+func TestMigrationsHaveDownFiles(t *testing.T) {
+	files, err := fs.Glob(FS, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool, len(files))
+	for _, file := range files {
+		names[file] = true
+	}
+
+	for _, file := range files {
+		var pair string
+		switch {
+		case strings.HasSuffix(file, ".up.sql"):
+			pair = strings.TrimSuffix(file, ".up.sql") + ".down.sql"
+		case strings.HasSuffix(file, ".down.sql"):
+			pair = strings.TrimSuffix(file, ".down.sql") + ".up.sql"
+		default:
+			t.Errorf("%s is neither an up nor a down migration", file)
+			continue
+		}
+		if !names[pair] {
+			t.Errorf("%s has no matching %s", file, pair)
+		}
+	}
+}
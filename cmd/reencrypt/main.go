@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/max-programming/clinic/internal/bootstrap"
+	"github.com/max-programming/clinic/internal/config"
+	"github.com/max-programming/clinic/internal/db"
+	"github.com/max-programming/clinic/internal/encryption"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+var patientColumns = []string{
+	"email",
+	"phone",
+	"medical_notes",
+	"address_line1",
+	"address_line2",
+	"address_city",
+	"address_state",
+	"address_postal_code",
+	"address_country",
+	"emergency_contact_phone",
+}
+
+var mergeColumns = []string{"survivor_notes"}
+
+var hl7Columns = []string{"raw"}
+
+// reencrypt rewrites encrypted patient fields and HL7 messages that are still
+// plaintext or were written with a key other than the active one, and fills
+// in missing phone hashes. Rows already up to date are skipped, so it can be
+// re-run after an interruption; -all rewrites everything, which is needed
+// after changing BLIND_INDEX_KEY.
+// -decrypt writes everything back as plaintext instead, which the encryption
+// migrations need before they can be rolled back.
+func main() {
+	batchSize := flag.Int("batch", 500, "rows per transaction")
+	all := flag.Bool("all", false, "rewrite every row, not just stale ones")
+	decrypt := flag.Bool("decrypt", false, "store every encrypted field as plaintext again, before rolling back the migrations")
+	genKey := flag.Bool("genkey", false, "print a new random key for ENCRYPTION_KEYS or BLIND_INDEX_KEY and exit")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *genKey {
+		fmt.Println(encryption.GenerateKey())
+		return
+	}
+	if err := cfg.ValidateDatabase(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	keyring, phoneIndex, err := bootstrap.LoadEncryption(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := db.Connect(context.Background(), cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *decrypt {
+		decryptAll(db, *batchSize)
+		return
+	}
+
+	patients := db.Model(&models.Patient{})
+	merges := db.Model(&models.PatientMerge{})
+	messages := db.Model(&models.HL7Message{})
+	if !*all {
+		patients = patients.Where(staleCondition(db, keyring, patientColumns).
+			Or("COALESCE(phone, '') <> '' AND phone_index IS NULL"))
+		merges = merges.Where(staleCondition(db, keyring, mergeColumns))
+		messages = messages.Where(staleCondition(db, keyring, hl7Columns))
+	}
+
+	// The blind index is recomputed along with the phone number.
+	patientUpdates := append([]string{"phone_index"}, patientColumns...)
+	var patientBatch []*models.Patient
+	patientCount := 0
+	err = patients.FindInBatches(&patientBatch, *batchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, patient := range patientBatch {
+				patient.PhoneIndex = phoneIndex.Hash(utils.PhoneDigits(patient.Phone))
+				if err := tx.Model(patient).Select(patientUpdates).UpdateColumns(patient).Error; err != nil {
+					return err
+				}
+			}
+			patientCount += len(patientBatch)
+			log.Printf("Re-encrypted %d patients", patientCount)
+			return nil
+		})
+	}).Error
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var mergeBatch []*models.PatientMerge
+	mergeCount := 0
+	err = merges.FindInBatches(&mergeBatch, *batchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, merge := range mergeBatch {
+				if err := tx.Model(merge).Select(mergeColumns).UpdateColumns(merge).Error; err != nil {
+					return err
+				}
+			}
+			mergeCount += len(mergeBatch)
+			return nil
+		})
+	}).Error
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var messageBatch []*models.HL7Message
+	messageCount := 0
+	err = messages.FindInBatches(&messageBatch, *batchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, message := range messageBatch {
+				if err := tx.Model(message).Select(hl7Columns).UpdateColumns(message).Error; err != nil {
+					return err
+				}
+			}
+			messageCount += len(messageBatch)
+			log.Printf("Re-encrypted %d HL7 messages", messageCount)
+			return nil
+		})
+	}).Error
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Done: %d patients, %d merge records and %d HL7 messages are encrypted with key %q", patientCount, mergeCount, messageCount, keyring.ActiveKey())
+}
+
+// staleCondition matches rows where any of columns holds a value that is not
+// encrypted with the active key.
+func staleCondition(db *gorm.DB, keyring *encryption.Keyring, columns []string) *gorm.DB {
+	prefix := keyring.ActivePrefix()
+	condition := db.Where("1 = 0")
+	for _, column := range columns {
+		condition = condition.Or(fmt.Sprintf("COALESCE(%[1]s, '') <> '' AND LEFT(%[1]s, ?) <> ?", column), len(prefix), prefix)
+	}
+	return condition
+}
+
+// decryptAll reads every row that still holds an encrypted value through the
+// model, which decrypts it, and writes the plaintext back by column name,
+// which bypasses the serializer.
+func decryptAll(db *gorm.DB, batchSize int) {
+	var patientBatch []*models.Patient
+	patientCount := 0
+	err := db.Where(encryptedCondition(db, patientColumns)).FindInBatches(&patientBatch, batchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, patient := range patientBatch {
+				err := tx.Model(patient).UpdateColumns(map[string]any{
+					"email":                   patient.Email,
+					"phone":                   patient.Phone,
+					"medical_notes":           patient.MedicalNotes,
+					"address_line1":           patient.Address.Line1,
+					"address_line2":           patient.Address.Line2,
+					"address_city":            patient.Address.City,
+					"address_state":           patient.Address.State,
+					"address_postal_code":     patient.Address.PostalCode,
+					"address_country":         patient.Address.Country,
+					"emergency_contact_phone": patient.EmergencyContact.Phone,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			patientCount += len(patientBatch)
+			log.Printf("Decrypted %d patients", patientCount)
+			return nil
+		})
+	}).Error
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var mergeBatch []*models.PatientMerge
+	mergeCount := 0
+	err = db.Where(encryptedCondition(db, mergeColumns)).FindInBatches(&mergeBatch, batchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, merge := range mergeBatch {
+				if err := tx.Model(merge).UpdateColumn("survivor_notes", merge.SurvivorNotes).Error; err != nil {
+					return err
+				}
+			}
+			mergeCount += len(mergeBatch)
+			return nil
+		})
+	}).Error
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var messageBatch []*models.HL7Message
+	messageCount := 0
+	err = db.Where(encryptedCondition(db, hl7Columns)).FindInBatches(&messageBatch, batchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, message := range messageBatch {
+				if err := tx.Model(message).UpdateColumn("raw", message.Raw).Error; err != nil {
+					return err
+				}
+			}
+			messageCount += len(messageBatch)
+			log.Printf("Decrypted %d HL7 messages", messageCount)
+			return nil
+		})
+	}).Error
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Done: %d patients, %d merge records and %d HL7 messages are stored as plaintext", patientCount, mergeCount, messageCount)
+}
+
+// encryptedCondition matches rows where any of columns holds an encrypted
+// value.
+func encryptedCondition(db *gorm.DB, columns []string) *gorm.DB {
+	condition := db.Where("1 = 0")
+	for _, column := range columns {
+		condition = condition.Or(fmt.Sprintf("%s LIKE 'enc:%%'", column))
+	}
+	return condition
+}
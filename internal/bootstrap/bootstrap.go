@@ -1,41 +1,406 @@
 package bootstrap
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/max-programming/clinic/cmd/migrate/migrations"
+	"github.com/max-programming/clinic/internal/clamav"
+	"github.com/max-programming/clinic/internal/config"
 	"github.com/max-programming/clinic/internal/db"
+	"github.com/max-programming/clinic/internal/edi"
+	"github.com/max-programming/clinic/internal/encryption"
 	"github.com/max-programming/clinic/internal/handler"
+	"github.com/max-programming/clinic/internal/hl7"
+	"github.com/max-programming/clinic/internal/jobs"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/metrics"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/pdf"
 	"github.com/max-programming/clinic/internal/repository"
 	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/storage"
+	"github.com/max-programming/clinic/internal/tracing"
 )
 
 type BootstrapApp struct {
-	Handlers *handler.HandlerSet
+	Handlers      *handler.HandlerSet
+	HL7Server     *hl7.Server
+	DuplicateScan *jobs.DuplicateScan
+
+	imports service.PatientImportService
+	sqlDB   *sql.DB
 }
 
-func Initialize() *BootstrapApp {
-	db, err := db.Connect()
+// Shutdown stops the HL7 listener and background imports, then closes the
+// database pool. The HTTP server and DuplicateScan must be stopped first.
+func (a *BootstrapApp) Shutdown(ctx context.Context) error {
+	var errs []error
+	if a.HL7Server != nil {
+		if err := a.HL7Server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("hl7 listener: %w", err))
+		}
+	}
+	if err := a.imports.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("patient imports: %w", err))
+	}
+	if err := a.sqlDB.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("database: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func Initialize(cfg *config.Config) *BootstrapApp {
+	_, phoneIndex, err := LoadEncryption(cfg)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatal("Failed to set up encryption", slog.Any("error", err))
+	}
+
+	db, err := db.Connect(context.Background(), cfg)
+	if err != nil {
+		logging.Fatal("Failed to connect to database", slog.Any("error", err))
+	}
+	if err := checkMigrations(cfg); err != nil {
+		logging.Fatal("Database schema is not up to date", slog.Any("error", err))
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		logging.Fatal("Failed to connect to database", slog.Any("error", err))
+	}
+	if err := metrics.RegisterDB(sqlDB); err != nil {
+		logging.Fatal("Failed to register database metrics", slog.Any("error", err))
+	}
+	if err := tracing.InstrumentGorm(db); err != nil {
+		logging.Fatal("Failed to trace database queries", slog.Any("error", err))
+	}
+	if err := repository.TranslateContextErrors(db); err != nil {
+		logging.Fatal("Failed to set up database error handling", slog.Any("error", err))
 	}
 
 	userRepo := repository.NewUserRepository(db)
-	patientRepo := repository.NewPatientReposiory(db)
+	patientRepo := repository.NewPatientReposiory(db, phoneIndex)
+	hl7MessageRepo := repository.NewHL7MessageRepository(db)
+	labResultRepo := repository.NewLabResultRepository(db)
+	patientIdentifierRepo := repository.NewPatientIdentifierRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	duplicateCandidateRepo := repository.NewDuplicateCandidateRepository(db)
+	patientMergeRepo := repository.NewPatientMergeRepository(db)
+	feeRepo := repository.NewFeeRepository(db)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	insurancePolicyRepo := repository.NewInsurancePolicyRepository(db)
+	claimRepo := repository.NewClaimRepository(db)
+	prescriptionRepo := repository.NewPrescriptionRepository(db)
+	patientDocumentRepo := repository.NewPatientDocumentRepository(db)
+	patientImportRepo := repository.NewPatientImportRepository(db)
+	erasureRepo := repository.NewErasureRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	schemaRepo := repository.NewSchemaRepository(db)
 
-	userService := service.NewUserService(userRepo)
-	patientService := service.NewPatientService(patientRepo)
+	userService := service.NewUserService(userRepo, []byte(cfg.JWTSecret))
+	auditService := service.NewAuditService(auditLogRepo)
+	patientService := service.NewPatientService(patientRepo, auditService)
+	labResultService := service.NewLabResultService(labResultRepo)
+	patientIdentifierService := service.NewPatientIdentifierService(patientIdentifierRepo)
+	duplicateService := service.NewDuplicateService(patientRepo, duplicateCandidateRepo, auditService)
+	patientMergeService := service.NewPatientMergeService(patientMergeRepo)
+	feeService := service.NewFeeService(feeRepo)
+	invoiceService := service.NewInvoiceService(invoiceRepo, feeRepo, patientRepo, auditService, service.InvoiceSettings{
+		Currency:             cfg.Currency,
+		NumberPrefix:         cfg.InvoiceNumberPrefix,
+		FiscalYearStartMonth: time.Month(cfg.FiscalYearStartMonth),
+		DueDays:              cfg.InvoiceDueDays,
+	})
+	paymentService := service.NewPaymentService(paymentRepo, auditService, cfg.ReceiptNumberPrefix)
+	insurancePolicyService := service.NewInsurancePolicyService(insurancePolicyRepo)
+	claimService := service.NewClaimService(claimRepo, invoiceRepo, insurancePolicyRepo, feeRepo, auditService, edi.Settings{
+		SubmitterID:   cfg.EDISubmitterID,
+		SubmitterName: cfg.EDISubmitterName,
+		ContactName:   cfg.EDIContactName,
+		ContactPhone:  cfg.EDIContactPhone,
+		ReceiverID:    cfg.EDIReceiverID,
+		ReceiverName:  cfg.EDIReceiverName,
+		Production:    cfg.EDIProduction,
+		ProviderName:  cfg.ProviderName,
+		ProviderNPI:   cfg.ProviderNPI,
+		ProviderTaxID: cfg.ProviderTaxID,
+		ProviderAddr: models.Address{
+			Line1:      cfg.ProviderAddress,
+			City:       cfg.ProviderCity,
+			State:      cfg.ProviderState,
+			PostalCode: cfg.ProviderPostalCode,
+		},
+		PlaceOfService: cfg.PlaceOfService,
+	})
+	prescriptionService := service.NewPrescriptionService(prescriptionRepo, patientRepo)
+	documentMaxSize := int64(cfg.DocumentMaxSizeMB) << 20
+	documentStorage := newDocumentStorage(cfg)
+	patientDocumentService := service.NewPatientDocumentService(patientDocumentRepo, patientRepo, documentStorage, newVirusScanner(cfg), auditService, documentMaxSize)
+	patientExportService := service.NewPatientExportService(
+		patientRepo,
+		labResultRepo,
+		insurancePolicyRepo,
+		invoiceRepo,
+		paymentRepo,
+		claimRepo,
+		prescriptionRepo,
+		patientDocumentRepo,
+		documentStorage,
+		auditService,
+	)
+	erasureService := service.NewErasureService(erasureRepo, documentStorage, newErasureSigner(cfg))
+	reportService := service.NewReportService(reportRepo, cfg.ReportCacheTTL)
+	if err := repository.NotifyChanges(db, reportService.Invalidate); err != nil {
+		logging.Fatal("Failed to watch for report changes", slog.Any("error", err))
+	}
+	patientImportService := service.NewPatientImportService(patientImportRepo, patientRepo, auditService, cfg.ImportBatchSize)
+	hl7Service := service.NewHL7Service(hl7MessageRepo, labResultRepo, patientService, userService, cfg.HL7Username, cfg.HL7AssigningAuthority)
+	healthService := newHealthService(cfg, schemaRepo, documentStorage)
 
 	authHandler := handler.NewAuthHandler(userService)
 	patientHandler := handler.NewPatientHandler(patientService)
-	healthHandler := handler.NewHealthHandler()
+	healthHandler := handler.NewHealthHandler(healthService)
+	hl7Handler := handler.NewHL7Handler(hl7Service)
+	labResultHandler := handler.NewLabResultHandler(labResultService)
+	fhirHandler := handler.NewFHIRHandler(patientService, labResultService)
+	patientIdentifierHandler := handler.NewPatientIdentifierHandler(patientIdentifierService)
+	duplicateHandler := handler.NewDuplicateHandler(duplicateService)
+	patientMergeHandler := handler.NewPatientMergeHandler(patientMergeService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	feeHandler := handler.NewFeeHandler(feeService)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	insurancePolicyHandler := handler.NewInsurancePolicyHandler(insurancePolicyService)
+	claimHandler := handler.NewClaimHandler(claimService)
+	prescriptionHandler := handler.NewPrescriptionHandler(prescriptionService)
+	patientDocumentHandler := handler.NewPatientDocumentHandler(patientDocumentService, documentMaxSize)
+	patientExportHandler := handler.NewPatientExportHandler(patientExportService)
+	erasureHandler := handler.NewErasureHandler(erasureService)
+	reportHandler := handler.NewReportHandler(reportService)
+	patientImportHandler := handler.NewPatientImportHandler(patientImportService, int64(cfg.ImportMaxSizeMB)<<20, cfg.ImportMaxRows)
+	documentHandler := handler.NewDocumentHandler(patientService, prescriptionService, invoiceService, pdf.NewRenderer(pdf.Letterhead{
+		Name:     cfg.ClinicName,
+		Address:  cfg.ClinicAddress,
+		Phone:    cfg.ClinicPhone,
+		Email:    cfg.ClinicEmail,
+		Website:  cfg.ClinicWebsite,
+		LogoPath: cfg.ClinicLogoPath,
+	}))
 
 	handlerSet := &handler.HandlerSet{
-		Auth:    authHandler,
-		Patient: patientHandler,
-		Health:  healthHandler,
+		Auth:              authHandler,
+		Patient:           patientHandler,
+		Health:            healthHandler,
+		HL7:               hl7Handler,
+		LabResult:         labResultHandler,
+		FHIR:              fhirHandler,
+		PatientIdentifier: patientIdentifierHandler,
+		Duplicate:         duplicateHandler,
+		PatientMerge:      patientMergeHandler,
+		Audit:             auditHandler,
+		Fee:               feeHandler,
+		Invoice:           invoiceHandler,
+		Payment:           paymentHandler,
+		InsurancePolicy:   insurancePolicyHandler,
+		Claim:             claimHandler,
+		Prescription:      prescriptionHandler,
+		Document:          documentHandler,
+		PatientDocument:   patientDocumentHandler,
+		PatientImport:     patientImportHandler,
+		PatientExport:     patientExportHandler,
+		Erasure:           erasureHandler,
+		Report:            reportHandler,
+	}
+
+	var hl7Server *hl7.Server
+	if cfg.HL7ListenAddr != "" {
+		hl7Server = hl7.NewServer(cfg.HL7ListenAddr, hl7Service)
+	}
+
+	var duplicateScan *jobs.DuplicateScan
+	if cfg.DuplicateScanInterval > 0 {
+		duplicateScan = jobs.NewDuplicateScan(duplicateService, cfg.DuplicateScanInterval)
 	}
 
 	return &BootstrapApp{
-		Handlers: handlerSet,
+		Handlers:      handlerSet,
+		HL7Server:     hl7Server,
+		DuplicateScan: duplicateScan,
+		imports:       patientImportService,
+		sqlDB:         sqlDB,
+	}
+}
+
+// LoadEncryption reads the keys for encrypted patient fields and registers
+// the serializer that applies them. It has to run before the first query.
+func LoadEncryption(cfg *config.Config) (*encryption.Keyring, *encryption.BlindIndex, error) {
+	keyring, err := encryption.Load(encryption.Config{
+		Keys:      cfg.EncryptionKeys,
+		KeyFile:   cfg.EncryptionKeyFile,
+		ActiveKey: cfg.EncryptionActiveKey,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	phoneIndex, err := encryption.NewBlindIndex(cfg.BlindIndexKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	encryption.Register(keyring)
+	return keyring, phoneIndex, nil
+}
+
+func newDocumentStorage(cfg *config.Config) storage.Storage {
+	switch cfg.DocumentStorage {
+	case "local":
+		local, err := storage.NewLocal(cfg.DocumentStorageDir)
+		if err != nil {
+			logging.Fatal("Failed to prepare document storage", slog.Any("error", err))
+		}
+		return local
+	case "s3":
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		s3, err := storage.NewS3(ctx, storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+		})
+		if err != nil {
+			logging.Fatal("Failed to connect to document storage", slog.Any("error", err))
+		}
+		return s3
+	}
+	logging.Fatal("Unknown DOCUMENT_STORAGE, expected local or s3", slog.String("value", cfg.DocumentStorage))
+	return nil
+}
+
+// newVirusScanner returns nil when no clamd is configured, in which case
+// uploads are stored without scanning.
+func newVirusScanner(cfg *config.Config) service.VirusScanner {
+	if cfg.ClamdAddr == "" {
+		slog.Warn("CLAMD_ADDR is not set, uploaded documents will not be scanned")
+		return nil
+	}
+	return clamav.NewClient(cfg.ClamdAddr, cfg.ClamdTimeout)
+}
+
+// checkMigrations compares the database with the embedded migrations and,
+// depending on STARTUP_MIGRATIONS, fails, warns or applies the pending ones.
+// It uses a connection of its own, without DB_STATEMENT_TIMEOUT, so long
+// migrations are not cancelled.
+func checkMigrations(cfg *config.Config) error {
+	all, err := migrations.All()
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.SQLConnect(cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	m, err := migrations.New(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer m.Close()
+
+	return checkMigrationVersion(m, all, cfg.StartupMigrations)
+}
+
+// migrator is the part of *migrate.Migrate that checkMigrationVersion uses.
+type migrator interface {
+	Version() (version uint, dirty bool, err error)
+	Up() error
+}
+
+func checkMigrationVersion(m migrator, all []migrations.Migration, mode string) error {
+	latest := all[len(all)-1].Version
+	current, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		current, err = 0, nil
+	}
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, migration := range all {
+		if migration.Version > current {
+			pending++
+		}
+	}
+
+	switch {
+	case dirty && mode == "warn":
+		slog.Warn("Database migration failed part way through", slog.Uint64("version", uint64(current)))
+		return nil
+	case dirty:
+		return fmt.Errorf("migration %d failed part way through, fix it by hand and run migrate force", current)
+	case current > latest:
+		slog.Warn("Database is at a newer migration than this build", slog.Uint64("version", uint64(current)), slog.Uint64("latest", uint64(latest)))
+		return nil
+	case pending == 0:
+		return nil
+	}
+
+	switch mode {
+	case "warn":
+		slog.Warn("Database migrations are pending", slog.Uint64("version", uint64(current)), slog.Uint64("latest", uint64(latest)), slog.Int("pending", pending))
+		return nil
+	case "apply":
+		// Up holds a Postgres advisory lock and reads the version again once it
+		// has it, so instances starting together apply each migration once.
+		slog.Info("Applying database migrations", slog.Uint64("version", uint64(current)), slog.Int("pending", pending))
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return err
+		}
+		slog.Info("Database migrations applied", slog.Uint64("version", uint64(latest)))
+		return nil
+	default:
+		return fmt.Errorf("database is at migration %d with %d pending up to %d, run migrate up or set STARTUP_MIGRATIONS=apply", current, pending, latest)
+	}
+}
+
+// newHealthService checks the database, that every embedded migration has
+// been applied, document storage and clamd when it is configured.
+func newHealthService(cfg *config.Config, schemaRepo repository.SchemaRepository, documentStorage storage.Storage) service.HealthService {
+	version, err := migrations.Latest()
+	if err != nil {
+		logging.Fatal("Failed to read migrations", slog.Any("error", err))
+	}
+	checks := []service.HealthCheck{
+		service.DatabaseHealthCheck(schemaRepo),
+		service.MigrationHealthCheck(schemaRepo, version),
+		{Name: "storage", Check: documentStorage.Check},
+	}
+	if cfg.ClamdAddr != "" {
+		checks = append(checks, service.HealthCheck{
+			Name:  "virus_scanner",
+			Check: clamav.NewClient(cfg.ClamdAddr, cfg.ClamdTimeout).Ping,
+		})
+	}
+	return service.NewHealthService(cfg.HealthCheckTimeout, cfg.HealthCacheTTL, checks...)
+}
+
+// newErasureSigner returns nil when no signing key is configured, in which
+// case erasure requests can be opened but not carried out.
+func newErasureSigner(cfg *config.Config) *encryption.Signer {
+	if cfg.ErasureSigningKey == "" {
+		slog.Warn("ERASURE_SIGNING_KEY is not set, patients cannot be erased")
+		return nil
+	}
+	signer, err := encryption.NewSigner(cfg.ErasureSigningKey)
+	if err != nil {
+		logging.Fatal("Invalid ERASURE_SIGNING_KEY", slog.Any("error", err))
 	}
+	return signer
 }
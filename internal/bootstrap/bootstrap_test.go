@@ -0,0 +1,71 @@
+package bootstrap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/max-programming/clinic/cmd/migrate/migrations"
+)
+
+type fakeMigrator struct {
+	version    uint
+	dirty      bool
+	versionErr error
+	upErr      error
+	upCalled   bool
+}
+
+func (m *fakeMigrator) Version() (uint, bool, error) {
+	return m.version, m.dirty, m.versionErr
+}
+
+func (m *fakeMigrator) Up() error {
+	m.upCalled = true
+	return m.upErr
+}
+
+func TestCheckMigrationVersion(t *testing.T) {
+	all := []migrations.Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}, {Version: 3, Name: "c"}}
+	failed := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		mode     string
+		migrator fakeMigrator
+		wantErr  string
+		wantUp   bool
+	}{
+		{name: "up to date", mode: "refuse", migrator: fakeMigrator{version: 3}},
+		{name: "pending refused", mode: "refuse", migrator: fakeMigrator{version: 1}, wantErr: "at migration 1 with 2 pending up to 3"},
+		{name: "empty database refused", mode: "refuse", migrator: fakeMigrator{versionErr: migrate.ErrNilVersion}, wantErr: "at migration 0 with 3 pending"},
+		{name: "pending warns", mode: "warn", migrator: fakeMigrator{version: 2}},
+		{name: "pending applied", mode: "apply", migrator: fakeMigrator{version: 2}, wantUp: true},
+		{name: "empty database applied", mode: "apply", migrator: fakeMigrator{versionErr: migrate.ErrNilVersion}, wantUp: true},
+		{name: "applied by another instance", mode: "apply", migrator: fakeMigrator{version: 2, upErr: migrate.ErrNoChange}, wantUp: true},
+		{name: "apply fails", mode: "apply", migrator: fakeMigrator{version: 2, upErr: failed}, wantErr: "connection refused", wantUp: true},
+		{name: "up to date is not applied", mode: "apply", migrator: fakeMigrator{version: 3}},
+		{name: "dirty refused", mode: "refuse", migrator: fakeMigrator{version: 2, dirty: true}, wantErr: "migration 2 failed part way through"},
+		{name: "dirty is not applied", mode: "apply", migrator: fakeMigrator{version: 2, dirty: true}, wantErr: "failed part way through"},
+		{name: "dirty warns", mode: "warn", migrator: fakeMigrator{version: 2, dirty: true}},
+		{name: "newer database warns", mode: "refuse", migrator: fakeMigrator{version: 4}},
+		{name: "version unreadable", mode: "warn", migrator: fakeMigrator{versionErr: failed}, wantErr: "connection refused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.migrator
+			err := checkMigrationVersion(&m, all, tt.mode)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("checkMigrationVersion() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("checkMigrationVersion() error = %v, want %q", err, tt.wantErr)
+			}
+			if m.upCalled != tt.wantUp {
+				t.Errorf("Up called = %v, want %v", m.upCalled, tt.wantUp)
+			}
+		})
+	}
+}
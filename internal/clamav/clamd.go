@@ -0,0 +1,115 @@
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInfected    = errors.New("file is infected")
+	ErrUnavailable = errors.New("virus scanner is unavailable")
+)
+
+const chunkSize = 64 * 1024
+
+// Client scans files with a clamd daemon over TCP.
+type Client struct {
+	addr    string
+	timeout time.Duration
+}
+
+func NewClient(addr string, timeout time.Duration) *Client {
+	return &Client{addr, timeout}
+}
+
+// Scan streams r to clamd with the INSTREAM command. It returns ErrInfected
+// with the signature name when clamd finds something.
+func (c *Client) Scan(ctx context.Context, r io.Reader) error {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, readErr := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return fmt.Errorf("%w: %v", ErrUnavailable, err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+
+	// Replies look like "stream: OK", "stream: Eicar-Signature FOUND" or
+	// "INSTREAM size limit exceeded. ERROR".
+	switch {
+	case strings.HasSuffix(reply, " OK"):
+		return nil
+	case strings.HasSuffix(reply, " FOUND"):
+		signature := strings.TrimSuffix(strings.TrimPrefix(reply, "stream: "), " FOUND")
+		return fmt.Errorf("%w: %s", ErrInfected, signature)
+	}
+	return fmt.Errorf("%w: %s", ErrUnavailable, reply)
+}
+
+// Ping checks that clamd answers.
+func (c *Client) Ping(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if reply = strings.TrimRight(reply, "\x00"); reply != "PONG" {
+		return fmt.Errorf("%w: %s", ErrUnavailable, reply)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// Config holds the server settings. Each field is read from the environment
+// variable in its env tag, from the same key in lower case in a config file,
+// or from a flag named after it, such as --database-url. Fields tagged secret
+// are redacted by Print.
+type Config struct {
+	DatabaseURL           string        `env:"DATABASE_URL" secret:"password"`
+	DatabaseReplicaURLs   string        `env:"DATABASE_REPLICA_URLS" secret:"password"`
+	DBMaxOpenConns        int           `env:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns        int           `env:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetime     time.Duration `env:"DB_CONN_MAX_LIFETIME"`
+	DBConnMaxIdleTime     time.Duration `env:"DB_CONN_MAX_IDLE_TIME"`
+	DBStatementTimeout    time.Duration `env:"DB_STATEMENT_TIMEOUT"`
+	DBPrepareStatements   bool          `env:"DB_PREPARE_STATEMENTS"`
+	DBConnectTimeout      time.Duration `env:"DB_CONNECT_TIMEOUT"`
+	StartupMigrations     string        `env:"STARTUP_MIGRATIONS"`
+	JWTSecret             string        `env:"JWT_SECRET" secret:"true"`
+	LocalAllowedOrigin    string        `env:"LOCAL_ALLOWED_ORIGIN"`
+	RemoteAllowedOrigin   string        `env:"REMOTE_ALLOWED_ORIGIN"`
+	HL7ListenAddr         string        `env:"HL7_LISTEN_ADDR"`
+	HL7Username           string        `env:"HL7_USERNAME"`
+	HL7AssigningAuthority string        `env:"HL7_ASSIGNING_AUTHORITY"`
+	DuplicateScanInterval time.Duration `env:"DUPLICATE_SCAN_INTERVAL"`
+	Currency              string        `env:"CURRENCY"`
+	FiscalYearStartMonth  int           `env:"FISCAL_YEAR_START_MONTH"`
+	InvoiceNumberPrefix   string        `env:"INVOICE_NUMBER_PREFIX"`
+	InvoiceDueDays        int           `env:"INVOICE_DUE_DAYS"`
+	ReceiptNumberPrefix   string        `env:"RECEIPT_NUMBER_PREFIX"`
+	EDISubmitterID        string        `env:"EDI_SUBMITTER_ID"`
+	EDISubmitterName      string        `env:"EDI_SUBMITTER_NAME"`
+	EDIContactName        string        `env:"EDI_CONTACT_NAME"`
+	EDIContactPhone       string        `env:"EDI_CONTACT_PHONE"`
+	EDIReceiverID         string        `env:"EDI_RECEIVER_ID"`
+	EDIReceiverName       string        `env:"EDI_RECEIVER_NAME"`
+	EDIProduction         bool          `env:"EDI_PRODUCTION"`
+	ProviderName          string        `env:"PROVIDER_NAME"`
+	ProviderNPI           string        `env:"PROVIDER_NPI"`
+	ProviderTaxID         string        `env:"PROVIDER_TAX_ID"`
+	ProviderAddress       string        `env:"PROVIDER_ADDRESS"`
+	ProviderCity          string        `env:"PROVIDER_CITY"`
+	ProviderState         string        `env:"PROVIDER_STATE"`
+	ProviderPostalCode    string        `env:"PROVIDER_POSTAL_CODE"`
+	PlaceOfService        string        `env:"PLACE_OF_SERVICE"`
+	ClinicName            string        `env:"CLINIC_NAME"`
+	ClinicAddress         string        `env:"CLINIC_ADDRESS"`
+	ClinicPhone           string        `env:"CLINIC_PHONE"`
+	ClinicEmail           string        `env:"CLINIC_EMAIL"`
+	ClinicWebsite         string        `env:"CLINIC_WEBSITE"`
+	ClinicLogoPath        string        `env:"CLINIC_LOGO_PATH"`
+	DocumentStorage       string        `env:"DOCUMENT_STORAGE"`
+	DocumentStorageDir    string        `env:"DOCUMENT_STORAGE_DIR"`
+	DocumentMaxSizeMB     int           `env:"DOCUMENT_MAX_SIZE_MB"`
+	S3Endpoint            string        `env:"S3_ENDPOINT"`
+	S3Region              string        `env:"S3_REGION"`
+	S3Bucket              string        `env:"S3_BUCKET"`
+	S3AccessKey           string        `env:"S3_ACCESS_KEY"`
+	S3SecretKey           string        `env:"S3_SECRET_KEY" secret:"true"`
+	S3UseSSL              bool          `env:"S3_USE_SSL"`
+	ClamdAddr             string        `env:"CLAMD_ADDR"`
+	ClamdTimeout          time.Duration `env:"CLAMD_TIMEOUT"`
+	EncryptionKeys        string        `env:"ENCRYPTION_KEYS" secret:"true"`
+	EncryptionKeyFile     string        `env:"ENCRYPTION_KEY_FILE"`
+	EncryptionActiveKey   string        `env:"ENCRYPTION_ACTIVE_KEY"`
+	BlindIndexKey         string        `env:"BLIND_INDEX_KEY" secret:"true"`
+	ImportMaxSizeMB       int           `env:"IMPORT_MAX_SIZE_MB"`
+	ImportMaxRows         int           `env:"IMPORT_MAX_ROWS"`
+	ImportBatchSize       int           `env:"IMPORT_BATCH_SIZE"`
+	ErasureSigningKey     string        `env:"ERASURE_SIGNING_KEY" secret:"true"`
+	ReportCacheTTL        time.Duration `env:"REPORT_CACHE_TTL"`
+	LogLevel              string        `env:"LOG_LEVEL"`
+	LogFormat             string        `env:"LOG_FORMAT"`
+	LogSlowQuery          time.Duration `env:"LOG_SLOW_QUERY"`
+	MetricsAddr           string        `env:"METRICS_ADDR"`
+	MetricsToken          string        `env:"METRICS_TOKEN" secret:"true"`
+	TraceExporter         string        `env:"TRACE_EXPORTER"`
+	TraceEndpoint         string        `env:"TRACE_ENDPOINT"`
+	TraceSampleRatio      float64       `env:"TRACE_SAMPLE_RATIO"`
+	RequestTimeout        time.Duration `env:"REQUEST_TIMEOUT"`
+	LongRequestTimeout    time.Duration `env:"LONG_REQUEST_TIMEOUT"`
+	HealthCheckTimeout    time.Duration `env:"HEALTH_CHECK_TIMEOUT"`
+	HealthCacheTTL        time.Duration `env:"HEALTH_CACHE_TTL"`
+	HTTPAddr              string        `env:"HTTP_ADDR"`
+	HTTPReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT"`
+	HTTPReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT"`
+	HTTPWriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT"`
+	HTTPIdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT"`
+	HTTPMaxHeaderBytes    int           `env:"HTTP_MAX_HEADER_BYTES"`
+	TLSCertFile           string        `env:"TLS_CERT_FILE"`
+	TLSKeyFile            string        `env:"TLS_KEY_FILE"`
+	AutocertDomains       string        `env:"AUTOCERT_DOMAINS"`
+	AutocertCacheDir      string        `env:"AUTOCERT_CACHE_DIR"`
+	ShutdownTimeout       time.Duration `env:"SHUTDOWN_TIMEOUT"`
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() Config {
+	return Config{
+		DBMaxOpenConns:        25,
+		DBMaxIdleConns:        10,
+		DBConnMaxLifetime:     30 * time.Minute,
+		DBConnMaxIdleTime:     5 * time.Minute,
+		DBStatementTimeout:    time.Minute,
+		DBPrepareStatements:   true,
+		DBConnectTimeout:      time.Minute,
+		StartupMigrations:     "refuse",
+		HL7AssigningAuthority: "CLINIC",
+		DuplicateScanInterval: 24 * time.Hour,
+		Currency:              "USD",
+		FiscalYearStartMonth:  1,
+		InvoiceNumberPrefix:   "INV",
+		InvoiceDueDays:        30,
+		ReceiptNumberPrefix:   "RCT",
+		PlaceOfService:        "11",
+		ClinicName:            "Clinic",
+		DocumentStorage:       "local",
+		DocumentStorageDir:    "data/documents",
+		DocumentMaxSizeMB:     20,
+		S3Bucket:              "clinic-documents",
+		S3UseSSL:              true,
+		ClamdTimeout:          30 * time.Second,
+		ImportMaxSizeMB:       10,
+		ImportMaxRows:         20000,
+		ImportBatchSize:       200,
+		ReportCacheTTL:        10 * time.Minute,
+		LogLevel:              "info",
+		LogFormat:             "json",
+		LogSlowQuery:          200 * time.Millisecond,
+		TraceExporter:         "none",
+		TraceSampleRatio:      1,
+		RequestTimeout:        15 * time.Second,
+		LongRequestTimeout:    5 * time.Minute,
+		HealthCheckTimeout:    2 * time.Second,
+		HealthCacheTTL:        5 * time.Second,
+		HTTPAddr:              ":8080",
+		HTTPReadTimeout:       2 * time.Minute,
+		HTTPReadHeaderTimeout: 10 * time.Second,
+		HTTPWriteTimeout:      10 * time.Minute,
+		HTTPIdleTimeout:       2 * time.Minute,
+		HTTPMaxHeaderBytes:    1 << 20,
+		AutocertCacheDir:      "data/autocert",
+		ShutdownTimeout:       30 * time.Second,
+	}
+}
+
+// ReplicaURLs splits DatabaseReplicaURLs.
+func (c *Config) ReplicaURLs() []string {
+	var urls []string
+	for _, u := range strings.Split(c.DatabaseReplicaURLs, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
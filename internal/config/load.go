@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the configuration from, in increasing priority, the defaults,
+// the YAML or TOML file named by --config or CONFIG_FILE, the environment,
+// including a .env file in the working directory, and the flags in args. It
+// adds a flag for every setting to fs before parsing args with it, so
+// commands can define their own flags on fs too. The result is not
+// validated.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	path := fs.String("config", "", "YAML or TOML config file, overrides CONFIG_FILE")
+	fields := configFields()
+	flags := make(map[string]*fieldFlag, len(fields))
+	for _, f := range fields {
+		flags[f.flag] = &fieldFlag{isBool: f.isBool}
+		fs.Var(flags[f.flag], f.flag, "overrides "+f.env)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	godotenv.Load()
+
+	cfg := Default()
+	var errs []error
+
+	if *path == "" {
+		*path = os.Getenv("CONFIG_FILE")
+	}
+	if *path != "" {
+		values, err := readFile(*path)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			if value, ok := values[f.key]; ok {
+				errs = append(errs, f.set(&cfg, value, *path))
+				delete(values, f.key)
+			}
+		}
+		for key := range values {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %q", *path, key))
+		}
+	}
+	for _, f := range fields {
+		if value, ok := os.LookupEnv(f.env); ok {
+			errs = append(errs, f.set(&cfg, value, "environment"))
+		}
+	}
+	for _, f := range fields {
+		if value := flags[f.flag]; value.set {
+			errs = append(errs, f.set(&cfg, value.value, "flag"))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+type field struct {
+	index  int
+	env    string
+	key    string
+	flag   string
+	secret string
+	isBool bool
+}
+
+func configFields() []field {
+	t := reflect.TypeFor[Config]()
+	fields := make([]field, 0, t.NumField())
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		env := sf.Tag.Get("env")
+		fields = append(fields, field{
+			index:  i,
+			env:    env,
+			key:    strings.ToLower(env),
+			flag:   strings.ReplaceAll(strings.ToLower(env), "_", "-"),
+			secret: sf.Tag.Get("secret"),
+			isBool: sf.Type.Kind() == reflect.Bool,
+		})
+	}
+	return fields
+}
+
+func (f field) value(cfg *Config) reflect.Value {
+	return reflect.ValueOf(cfg).Elem().Field(f.index)
+}
+
+// set parses value into the field, naming its source in the error.
+func (f field) set(cfg *Config, value, source string) error {
+	var err error
+	switch p := f.value(cfg).Addr().Interface().(type) {
+	case *string:
+		*p = value
+	case *int:
+		*p, err = strconv.Atoi(value)
+	case *bool:
+		*p, err = strconv.ParseBool(value)
+	case *float64:
+		*p, err = strconv.ParseFloat(value, 64)
+	case *time.Duration:
+		*p, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid value %q from %s", f.env, value, source)
+	}
+	return nil
+}
+
+// readFile returns the top-level settings of a YAML or TOML file, chosen by
+// its extension, as strings.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("%s: config file must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch value := value.(type) {
+		case nil:
+			values[key] = ""
+		case map[string]any, []any:
+			return nil, fmt.Errorf("%s: %s must be a single value", path, key)
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
+
+// fieldFlag records whether a setting was given on the command line, so
+// that unset flags leave lower layers alone.
+type fieldFlag struct {
+	value  string
+	set    bool
+	isBool bool
+}
+
+func (f *fieldFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *fieldFlag) Set(value string) error {
+	f.value = value
+	f.set = true
+	return nil
+}
+
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.isBool
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Print writes the settings as a YAML config file, with secrets redacted. The
+// database URL keeps everything but its password.
+func (c *Config) Print(w io.Writer) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range configFields() {
+		value := f.value(c).Interface()
+		var text string
+		switch value := value.(type) {
+		case time.Duration:
+			text = value.String()
+		default:
+			text = fmt.Sprint(value)
+		}
+		if text != "" {
+			switch f.secret {
+			case "true":
+				text = redacted
+			case "password":
+				text = redactPasswords(text)
+			}
+		}
+
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: text}
+		if _, ok := value.(string); ok {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.key}, node)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// redactPasswords hides the passwords in a comma separated list of
+// database URLs.
+func redactPasswords(list string) string {
+	urls := strings.Split(list, ",")
+	for i, u := range urls {
+		urls[i] = redactPassword(strings.TrimSpace(u))
+	}
+	return strings.Join(urls, ",")
+}
+
+// redactPassword hides the password in a database URL, whether it is given
+// as user info or as a query parameter.
+func redactPassword(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return redacted
+	}
+	if query := u.Query(); query.Has("password") {
+		query.Set("password", "xxxxx")
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
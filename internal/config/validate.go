@@ -0,0 +1,205 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const MinJWTSecretLength = 32
+
+type failFunc func(key, format string, args ...any)
+
+// Validate reports every setting the server needs that is missing or out of
+// range, one per line, so they can all be fixed in one go.
+func (c *Config) Validate() error {
+	return collect(c.checkDatabase, c.checkImport, c.checkServer)
+}
+
+// ValidateDatabase checks only the settings used to connect to the
+// database, for the tools that need nothing else from the configuration.
+func (c *Config) ValidateDatabase() error {
+	return collect(c.checkDatabase)
+}
+
+// ValidateImport checks the database settings and the import limits.
+func (c *Config) ValidateImport() error {
+	return collect(c.checkDatabase, c.checkImport)
+}
+
+func collect(checks ...func(fail failFunc)) error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	for _, check := range checks {
+		check(fail)
+	}
+
+	// Maps are iterated in random order; keep the report stable.
+	slices.SortStableFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
+}
+
+func (c *Config) checkDatabase(fail failFunc) {
+	switch {
+	case c.DatabaseURL == "":
+		fail("DATABASE_URL", "is required")
+	default:
+		if err := checkURL(c.DatabaseURL, "postgres", "postgresql"); err != nil {
+			fail("DATABASE_URL", "%v", err)
+		}
+	}
+	for _, replica := range c.ReplicaURLs() {
+		if err := checkURL(replica, "postgres", "postgresql"); err != nil {
+			fail("DATABASE_REPLICA_URLS", "%v", err)
+		}
+	}
+	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		fail("DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS (%d)", c.DBMaxOpenConns)
+	}
+	for key, n := range map[string]int{
+		"DB_MAX_OPEN_CONNS": c.DBMaxOpenConns,
+		"DB_MAX_IDLE_CONNS": c.DBMaxIdleConns,
+	} {
+		if n < 0 {
+			fail(key, "must not be negative")
+		}
+	}
+	checkDurations(fail, c, true)
+}
+
+func (c *Config) checkImport(fail failFunc) {
+	for key, n := range map[string]int{
+		"IMPORT_MAX_ROWS":   c.ImportMaxRows,
+		"IMPORT_BATCH_SIZE": c.ImportBatchSize,
+	} {
+		if n <= 0 {
+			fail(key, "must be positive")
+		}
+	}
+}
+
+func (c *Config) checkServer(fail failFunc) {
+	switch {
+	case c.JWTSecret == "":
+		fail("JWT_SECRET", "is required")
+	case len(c.JWTSecret) < MinJWTSecretLength:
+		fail("JWT_SECRET", "must be at least %d characters", MinJWTSecretLength)
+	}
+
+	if c.LocalAllowedOrigin == "" && c.RemoteAllowedOrigin == "" {
+		fail("LOCAL_ALLOWED_ORIGIN", "is required unless REMOTE_ALLOWED_ORIGIN is set")
+	}
+	for key, origin := range map[string]string{
+		"LOCAL_ALLOWED_ORIGIN":  c.LocalAllowedOrigin,
+		"REMOTE_ALLOWED_ORIGIN": c.RemoteAllowedOrigin,
+	} {
+		if origin == "" {
+			continue
+		}
+		if err := checkURL(origin, "http", "https"); err != nil {
+			fail(key, "%v", err)
+		} else if u, _ := url.Parse(origin); strings.TrimSuffix(u.Path, "/") != "" || u.RawQuery != "" {
+			fail(key, "must be an origin like https://clinic.example.com, without a path")
+		}
+	}
+	if c.TraceEndpoint != "" {
+		if err := checkURL(c.TraceEndpoint, "http", "https"); err != nil {
+			fail("TRACE_ENDPOINT", "%v", err)
+		}
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		fail("LOG_LEVEL", "must be debug, info, warn or error")
+	}
+	checkOneOf(fail, "LOG_FORMAT", strings.ToLower(c.LogFormat), "json", "text")
+	checkOneOf(fail, "TRACE_EXPORTER", c.TraceExporter, "none", "otlp", "stdout")
+	checkOneOf(fail, "STARTUP_MIGRATIONS", c.StartupMigrations, "refuse", "warn", "apply")
+	checkOneOf(fail, "DOCUMENT_STORAGE", c.DocumentStorage, "local", "s3")
+	if c.DocumentStorage == "s3" {
+		for key, value := range map[string]string{
+			"S3_BUCKET":     c.S3Bucket,
+			"S3_ACCESS_KEY": c.S3AccessKey,
+			"S3_SECRET_KEY": c.S3SecretKey,
+		} {
+			if value == "" {
+				fail(key, "is required when DOCUMENT_STORAGE is s3")
+			}
+		}
+	}
+
+	if c.TraceSampleRatio < 0 || c.TraceSampleRatio > 1 {
+		fail("TRACE_SAMPLE_RATIO", "must be between 0 and 1")
+	}
+	if c.FiscalYearStartMonth < 1 || c.FiscalYearStartMonth > 12 {
+		fail("FISCAL_YEAR_START_MONTH", "must be between 1 and 12")
+	}
+	if c.InvoiceDueDays < 0 {
+		fail("INVOICE_DUE_DAYS", "must not be negative")
+	}
+	for key, n := range map[string]int{
+		"DOCUMENT_MAX_SIZE_MB":  c.DocumentMaxSizeMB,
+		"IMPORT_MAX_SIZE_MB":    c.ImportMaxSizeMB,
+		"HTTP_MAX_HEADER_BYTES": c.HTTPMaxHeaderBytes,
+	} {
+		if n <= 0 {
+			fail(key, "must be positive")
+		}
+	}
+
+	checkDurations(fail, c, false)
+	for key, d := range map[string]time.Duration{
+		"HEALTH_CHECK_TIMEOUT": c.HealthCheckTimeout,
+		"SHUTDOWN_TIMEOUT":     c.ShutdownTimeout,
+	} {
+		if d == 0 {
+			fail(key, "must be positive")
+		}
+	}
+	if c.HTTPWriteTimeout > 0 && c.LongRequestTimeout >= c.HTTPWriteTimeout {
+		fail("HTTP_WRITE_TIMEOUT", "must be longer than LONG_REQUEST_TIMEOUT (%s)", c.LongRequestTimeout)
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		fail("TLS_CERT_FILE", "must be set together with TLS_KEY_FILE")
+	}
+	if c.TLSCertFile != "" && c.AutocertDomains != "" {
+		fail("AUTOCERT_DOMAINS", "cannot be used with TLS_CERT_FILE")
+	}
+}
+
+// checkDurations rejects negative durations among the DB_ settings, or among
+// all the others.
+func checkDurations(fail failFunc, c *Config, database bool) {
+	for _, f := range configFields() {
+		if strings.HasPrefix(f.env, "DB_") != database {
+			continue
+		}
+		if d, ok := f.value(c).Interface().(time.Duration); ok && d < 0 {
+			fail(f.env, "must not be negative")
+		}
+	}
+}
+
+func checkURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("must be a URL like %s://host", schemes[0])
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("must use %s", strings.Join(schemes, " or "))
+	}
+	return nil
+}
+
+func checkOneOf(fail failFunc, key, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		fail(key, "must be one of %s", strings.Join(allowed, ", "))
+	}
+}
@@ -1,24 +1,105 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/max-programming/clinic/internal/config"
+	"github.com/max-programming/clinic/internal/logging"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func Connect() (*gorm.DB, error) {
-	gormDB, err := gorm.Open(postgres.Open(config.Envs.DatabaseURL), &gorm.Config{})
+const (
+	firstRetryDelay = 500 * time.Millisecond
+	maxRetryDelay   = 10 * time.Second
+)
+
+// Connect opens the connection pool and, when DATABASE_REPLICA_URLS is set,
+// one per replica. Reads stay on the primary unless they are marked with
+// Replica. While the primary cannot be reached, Connect retries with growing
+// delays for DB_CONNECT_TIMEOUT or until ctx is done.
+func Connect(ctx context.Context, cfg *config.Config) (*gorm.DB, error) {
+	primary, err := withStatementTimeout(cfg.DatabaseURL, cfg.DBStatementTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("DATABASE_URL: %w", err)
+	}
+
+	gormDB, err := openWithRetry(ctx, primary, cfg)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := gormDB.DB()
 	if err != nil {
-		log.Fatal("Failed to connect to database", err)
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	if err := useReplicas(gormDB, cfg); err != nil {
+		sqlDB.Close()
+		return nil, err
 	}
 	return gormDB, nil
 }
 
-func SQLConnect() (*sql.DB, error) {
-	db, err := sql.Open("postgres", config.Envs.DatabaseURL)
+func openWithRetry(ctx context.Context, dsn string, cfg *config.Config) (*gorm.DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.DBConnectTimeout)
+	defer cancel()
+
+	delay := firstRetryDelay
+	for attempt := 1; ; attempt++ {
+		gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+			TranslateError: true,
+			PrepareStmt:    cfg.DBPrepareStatements,
+			Logger:         logging.NewGormLogger(cfg.LogSlowQuery),
+		})
+		if err == nil {
+			return gormDB, nil
+		}
+		if gormDB != nil {
+			if sqlDB, dbErr := gormDB.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+		}
+
+		slog.Warn("Database not reachable, retrying", slog.Int("attempt", attempt), slog.Duration("delay", delay), slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// withStatementTimeout asks the server to cancel statements that run longer
+// than timeout, unless the URL already sets statement_timeout.
+func withStatementTimeout(databaseURL string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return databaseURL, nil
+	}
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	if !query.Has("statement_timeout") {
+		query.Set("statement_timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
+
+func SQLConnect(databaseURL string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", databaseURL)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/max-programming/clinic/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+const replicaSetting = "clinic:replica"
+
+// Replica sends the queries run on tx to a read replica, when one is
+// configured. It is meant for lists, exports and reports, which can live with
+// replication lag; everything else reads from the primary so that a request
+// sees its own writes.
+func Replica(tx *gorm.DB) *gorm.DB {
+	return tx.Set(replicaSetting, true)
+}
+
+func useReplicas(gormDB *gorm.DB, cfg *config.Config) error {
+	urls := cfg.ReplicaURLs()
+	if len(urls) == 0 {
+		return nil
+	}
+	replicas := make([]gorm.Dialector, len(urls))
+	for i, u := range urls {
+		dsn, err := withStatementTimeout(u, cfg.DBStatementTimeout)
+		if err != nil {
+			return fmt.Errorf("DATABASE_REPLICA_URLS: %w", err)
+		}
+		replicas[i] = postgres.Open(dsn)
+	}
+	if err := registerReplicas(gormDB, replicas, cfg); err != nil {
+		return fmt.Errorf("DATABASE_REPLICA_URLS: %w", err)
+	}
+	return nil
+}
+
+func registerReplicas(gormDB *gorm.DB, replicas []gorm.Dialector, cfg *config.Config) error {
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxOpenConns(cfg.DBMaxOpenConns).
+		SetMaxIdleConns(cfg.DBMaxIdleConns).
+		SetConnMaxLifetime(cfg.DBConnMaxLifetime).
+		SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+	if err := gormDB.Use(resolver); err != nil {
+		return err
+	}
+
+	// dbresolver sends every read to a replica. Only let it route the ones
+	// marked with Replica; the rest stay on the primary pool.
+	callbacks := gormDB.Callback()
+	operations := []struct {
+		get     func(name string) func(*gorm.DB)
+		replace func(name string, fn func(*gorm.DB)) error
+	}{
+		{callbacks.Query().Get, callbacks.Query().Before("*").Replace},
+		{callbacks.Row().Get, callbacks.Row().Before("*").Replace},
+		{callbacks.Raw().Get, callbacks.Raw().Before("*").Replace},
+	}
+	for _, op := range operations {
+		resolve := op.get("gorm:db_resolver")
+		err := op.replace("gorm:db_resolver", func(tx *gorm.DB) {
+			if _, ok := tx.Get(replicaSetting); ok {
+				resolve(tx)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
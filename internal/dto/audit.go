@@ -0,0 +1,18 @@
+package dto
+
+import "encoding/json"
+
+type AuditLogResponse struct {
+	ID         string          `json:"id"`
+	UserID     string          `json:"userId"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entityType"`
+	EntityID   string          `json:"entityId"`
+	Details    json.RawMessage `json:"details" swaggertype:"object"`
+	CreatedAt  string          `json:"createdAt"`
+} //@name AuditLogResponse
+
+type GetAuditLogsResponse struct {
+	Entries []AuditLogResponse `json:"entries"`
+	Total   int64              `json:"total"`
+} //@name GetAuditLogsResponse
@@ -0,0 +1,55 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+type CreateClaimRequest struct {
+	InvoiceID      string   `json:"invoiceId" binding:"required,uuid"`
+	PolicyID       string   `json:"policyId" binding:"required,uuid"`
+	DiagnosisCodes []string `json:"diagnosisCodes" binding:"required,min=1,max=12" example:"J06.9"`
+	Notes          string   `json:"notes"`
+} //@name CreateClaimRequest
+
+type UpdateClaimRequest struct {
+	DiagnosisCodes []string `json:"diagnosisCodes" binding:"required,min=1,max=12" example:"J06.9"`
+	Notes          string   `json:"notes"`
+} //@name UpdateClaimRequest
+
+type ClaimStatusRequest struct {
+	Status           string           `json:"status" binding:"required,oneof=draft submitted accepted rejected paid"`
+	PayerClaimNumber string           `json:"payerClaimNumber" binding:"max=80"`
+	PaidAmount       *decimal.Decimal `json:"paidAmount" swaggertype:"string" example:"80.00"`
+	Reason           string           `json:"reason"`
+} //@name ClaimStatusRequest
+
+type ExportClaimsRequest struct {
+	ClaimIDs []string `json:"claimIds" binding:"required,min=1,dive,uuid"`
+} //@name ExportClaimsRequest
+
+type ClaimResponse struct {
+	ID                       string   `json:"id"`
+	ClaimNumber              string   `json:"claimNumber"`
+	InvoiceID                string   `json:"invoiceId"`
+	InvoiceNumber            string   `json:"invoiceNumber"`
+	PatientID                string   `json:"patientId"`
+	PatientName              string   `json:"patientName"`
+	PolicyID                 string   `json:"policyId"`
+	PayerName                string   `json:"payerName"`
+	MemberID                 string   `json:"memberId"`
+	Status                   string   `json:"status"`
+	DiagnosisCodes           []string `json:"diagnosisCodes"`
+	TotalCharge              string   `json:"totalCharge"`
+	ExpectedAmount           string   `json:"expectedAmount"`
+	PaidAmount               string   `json:"paidAmount,omitempty"`
+	PayerClaimNumber         string   `json:"payerClaimNumber,omitempty"`
+	RejectionReason          string   `json:"rejectionReason,omitempty"`
+	Notes                    string   `json:"notes"`
+	InterchangeControlNumber int      `json:"interchangeControlNumber,omitempty"`
+	SubmittedAt              string   `json:"submittedAt,omitempty"`
+	AdjudicatedAt            string   `json:"adjudicatedAt,omitempty"`
+	CreatedAt                string   `json:"createdAt"`
+	UpdatedAt                string   `json:"updatedAt"`
+} //@name ClaimResponse
+
+type DeleteClaimResponse struct {
+	ID string `json:"id"`
+} //@name DeleteClaimResponse
@@ -0,0 +1,44 @@
+package dto
+
+type DuplicateMatchResponse struct {
+	Patient GetAllPatientsResponse `json:"patient"`
+	Score   int                    `json:"score"`
+	Reasons []string               `json:"reasons"`
+} //@name DuplicateMatchResponse
+
+type DuplicatePatientErrorResponse struct {
+	Success bool                     `json:"success"`
+	Error   string                   `json:"error"`
+	Matches []DuplicateMatchResponse `json:"matches"`
+} //@name DuplicatePatientErrorResponse
+
+type DuplicateCandidateResponse struct {
+	ID               string                 `json:"id"`
+	Patient          GetAllPatientsResponse `json:"patient"`
+	DuplicatePatient GetAllPatientsResponse `json:"duplicatePatient"`
+	Score            int                    `json:"score"`
+	Reasons          []string               `json:"reasons"`
+	Status           string                 `json:"status"`
+	DetectedAt       string                 `json:"detectedAt"`
+} //@name DuplicateCandidateResponse
+
+type ScanDuplicatesResponse struct {
+	Found int `json:"found"`
+} //@name ScanDuplicatesResponse
+
+type MergePatientRequest struct {
+	DuplicatePatientID string `json:"duplicatePatientId" binding:"required,uuid"`
+} //@name MergePatientRequest
+
+type PatientMergeResponse struct {
+	ID           string         `json:"id"`
+	SurvivorID   string         `json:"survivorId"`
+	SurvivorName string         `json:"survivorName"`
+	MergedID     string         `json:"mergedId"`
+	MergedName   string         `json:"mergedName"`
+	MovedRecords map[string]int `json:"movedRecords"`
+	MergedBy     string         `json:"mergedBy"`
+	MergedAt     string         `json:"mergedAt"`
+	ReversedBy   string         `json:"reversedBy,omitempty"`
+	ReversedAt   string         `json:"reversedAt,omitempty"`
+} //@name PatientMergeResponse
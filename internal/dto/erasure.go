@@ -0,0 +1,65 @@
+package dto
+
+import "encoding/json"
+
+type ErasureRequestBody struct {
+	Reason string `json:"reason" binding:"required"`
+} //@name ErasureRequestBody
+
+type ExecuteErasureRequest struct {
+	// ConfirmMRN must repeat the patient's MRN.
+	ConfirmMRN string `json:"confirmMrn" binding:"required"`
+} //@name ExecuteErasureRequest
+
+type ErasureRequestResponse struct {
+	ID          string      `json:"id"`
+	PatientID   string      `json:"patientId"`
+	PatientMRN  string      `json:"patientMrn"`
+	PatientName string      `json:"patientName"`
+	Reason      string      `json:"reason"`
+	Status      string      `json:"status"`
+	RequestedBy PatientUser `json:"requestedBy"`
+	CreatedAt   string      `json:"createdAt"`
+	CompletedBy string      `json:"completedBy,omitempty"`
+	CompletedAt string      `json:"completedAt,omitempty"`
+	CancelledBy string      `json:"cancelledBy,omitempty"`
+	CancelledAt string      `json:"cancelledAt,omitempty"`
+} //@name ErasureRequestResponse
+
+type GetErasureRequestsResponse struct {
+	Requests []ErasureRequestResponse `json:"requests"`
+	Total    int64                    `json:"total"`
+} //@name GetErasureRequestsResponse
+
+// ErasureHoldErrorResponse lists why a patient cannot be erased yet.
+type ErasureHoldErrorResponse struct {
+	Success bool     `json:"success"`
+	Error   string   `json:"error"`
+	Reasons []string `json:"reasons"`
+} //@name ErasureHoldErrorResponse
+
+// ErasureCertificateResponse carries the certificate exactly as signed.
+// Valid reports whether the signature checks out against PublicKey, the
+// server's current key.
+type ErasureCertificateResponse struct {
+	Certificate  json.RawMessage `json:"certificate" swaggertype:"object"`
+	Signature    string          `json:"signature"`
+	Algorithm    string          `json:"algorithm"`
+	SigningKeyID string          `json:"signingKeyId"`
+	PublicKey    string          `json:"publicKey,omitempty"`
+	Valid        bool            `json:"valid"`
+} //@name ErasureCertificateResponse
+
+type LegalHoldRequest struct {
+	Reason string `json:"reason" binding:"required"`
+} //@name LegalHoldRequest
+
+type LegalHoldResponse struct {
+	ID         string       `json:"id"`
+	PatientID  string       `json:"patientId"`
+	Reason     string       `json:"reason"`
+	PlacedBy   PatientUser  `json:"placedBy"`
+	CreatedAt  string       `json:"createdAt"`
+	ReleasedBy *PatientUser `json:"releasedBy,omitempty"`
+	ReleasedAt string       `json:"releasedAt,omitempty"`
+} //@name LegalHoldResponse
@@ -0,0 +1,24 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+type FeeRequest struct {
+	Code        string          `json:"code" binding:"required,max=50"`
+	Name        string          `json:"name" binding:"required,max=255"`
+	Description string          `json:"description"`
+	Price       decimal.Decimal `json:"price" swaggertype:"string" example:"45.00"`
+	TaxRate     decimal.Decimal `json:"taxRate" swaggertype:"string" example:"5.00"`
+	Active      *bool           `json:"active"`
+} //@name FeeRequest
+
+type FeeResponse struct {
+	ID          string `json:"id"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       string `json:"price" example:"45.00"`
+	TaxRate     string `json:"taxRate" example:"5.00"`
+	Active      bool   `json:"active"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+} //@name FeeResponse
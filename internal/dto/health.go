@@ -0,0 +1,17 @@
+package dto
+
+type LivenessResponse struct {
+	Status string `json:"status"`
+} //@name LivenessResponse
+
+type ComponentHealthResponse struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"durationMs"`
+} //@name ComponentHealthResponse
+
+type ReadinessResponse struct {
+	Status     string                    `json:"status"`
+	Components []ComponentHealthResponse `json:"components"`
+	CheckedAt  string                    `json:"checkedAt"`
+} //@name ReadinessResponse
@@ -0,0 +1,26 @@
+package dto
+
+type HL7MessageResponse struct {
+	ID              string `json:"id"`
+	ControlID       string `json:"controlId"`
+	MessageType     string `json:"messageType"`
+	SendingApp      string `json:"sendingApp"`
+	SendingFacility string `json:"sendingFacility"`
+	Status          string `json:"status"`
+	Error           string `json:"error"`
+	PatientID       string `json:"patientId"`
+	ReceivedAt      string `json:"receivedAt"`
+	ProcessedAt     string `json:"processedAt"`
+} //@name HL7MessageResponse
+
+type GetHL7MessageResponse struct {
+	HL7MessageResponse
+	Raw string `json:"raw"`
+} //@name GetHL7MessageResponse
+
+type ReplayHL7MessageResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Ack    string `json:"ack"`
+} //@name ReplayHL7MessageResponse
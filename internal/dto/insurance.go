@@ -0,0 +1,46 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+type InsurancePolicyRequest struct {
+	Priority              string          `json:"priority" binding:"required,oneof=primary secondary tertiary"`
+	PayerName             string          `json:"payerName" binding:"required,max=255"`
+	PayerID               string          `json:"payerId" binding:"required,max=80"`
+	PlanName              string          `json:"planName" binding:"max=255"`
+	MemberID              string          `json:"memberId" binding:"required,max=80"`
+	GroupNumber           string          `json:"groupNumber" binding:"max=50"`
+	Relationship          string          `json:"relationship" binding:"required,oneof=self spouse child other"`
+	SubscriberGivenName   string          `json:"subscriberGivenName" binding:"max=100"`
+	SubscriberFamilyName  string          `json:"subscriberFamilyName" binding:"max=100"`
+	SubscriberDateOfBirth string          `json:"subscriberDateOfBirth" binding:"omitempty,datetime=2006-01-02" example:"1980-04-12"`
+	ValidFrom             string          `json:"validFrom" binding:"required,datetime=2006-01-02" example:"2025-01-01"`
+	ValidTo               string          `json:"validTo" binding:"omitempty,datetime=2006-01-02" example:"2025-12-31"`
+	CoveragePercent       decimal.Decimal `json:"coveragePercent" swaggertype:"string" example:"80"`
+	Copay                 decimal.Decimal `json:"copay" swaggertype:"string" example:"20.00"`
+} //@name InsurancePolicyRequest
+
+type InsurancePolicyResponse struct {
+	ID                    string `json:"id"`
+	PatientID             string `json:"patientId"`
+	Priority              string `json:"priority"`
+	PayerName             string `json:"payerName"`
+	PayerID               string `json:"payerId"`
+	PlanName              string `json:"planName"`
+	MemberID              string `json:"memberId"`
+	GroupNumber           string `json:"groupNumber"`
+	Relationship          string `json:"relationship"`
+	SubscriberGivenName   string `json:"subscriberGivenName,omitempty"`
+	SubscriberFamilyName  string `json:"subscriberFamilyName,omitempty"`
+	SubscriberDateOfBirth string `json:"subscriberDateOfBirth,omitempty"`
+	ValidFrom             string `json:"validFrom"`
+	ValidTo               string `json:"validTo,omitempty"`
+	Active                bool   `json:"active"`
+	CoveragePercent       string `json:"coveragePercent"`
+	Copay                 string `json:"copay"`
+	CreatedAt             string `json:"createdAt"`
+	UpdatedAt             string `json:"updatedAt"`
+} //@name InsurancePolicyResponse
+
+type DeleteInsurancePolicyResponse struct {
+	ID string `json:"id"`
+} //@name DeleteInsurancePolicyResponse
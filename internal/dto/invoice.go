@@ -0,0 +1,69 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+type InvoiceItemRequest struct {
+	FeeID           *string          `json:"feeId" binding:"omitempty,uuid"`
+	Description     string           `json:"description" binding:"max=255"`
+	Quantity        decimal.Decimal  `json:"quantity" swaggertype:"string" example:"1"`
+	UnitPrice       *decimal.Decimal `json:"unitPrice" swaggertype:"string" example:"45.00"`
+	DiscountPercent decimal.Decimal  `json:"discountPercent" swaggertype:"string" example:"0"`
+	TaxRate         *decimal.Decimal `json:"taxRate" swaggertype:"string" example:"5"`
+} //@name InvoiceItemRequest
+
+type InvoiceRequest struct {
+	PatientID       string               `json:"patientId" binding:"required,uuid"`
+	AppointmentID   *string              `json:"appointmentId" binding:"omitempty,uuid"`
+	DiscountPercent decimal.Decimal      `json:"discountPercent" swaggertype:"string" example:"0"`
+	Notes           string               `json:"notes"`
+	Items           []InvoiceItemRequest `json:"items" binding:"dive"`
+} //@name InvoiceRequest
+
+type IssueInvoiceRequest struct {
+	DueDate string `json:"dueDate" binding:"omitempty,datetime=2006-01-02" example:"2025-07-01"`
+} //@name IssueInvoiceRequest
+
+type VoidInvoiceRequest struct {
+	Reason string `json:"reason" binding:"required"`
+} //@name VoidInvoiceRequest
+
+type InvoiceItemResponse struct {
+	ID              string `json:"id"`
+	FeeID           string `json:"feeId,omitempty"`
+	Description     string `json:"description"`
+	Quantity        string `json:"quantity"`
+	UnitPrice       string `json:"unitPrice"`
+	DiscountPercent string `json:"discountPercent"`
+	TaxRate         string `json:"taxRate"`
+	Subtotal        string `json:"subtotal"`
+	Discount        string `json:"discount"`
+	Tax             string `json:"tax"`
+	Total           string `json:"total"`
+} //@name InvoiceItemResponse
+
+type InvoiceResponse struct {
+	ID              string                `json:"id"`
+	Number          string                `json:"number,omitempty"`
+	FiscalYear      int                   `json:"fiscalYear,omitempty"`
+	PatientID       string                `json:"patientId"`
+	PatientName     string                `json:"patientName"`
+	PatientMRN      string                `json:"patientMrn"`
+	AppointmentID   string                `json:"appointmentId,omitempty"`
+	Status          string                `json:"status"`
+	Currency        string                `json:"currency"`
+	DiscountPercent string                `json:"discountPercent"`
+	Subtotal        string                `json:"subtotal"`
+	DiscountTotal   string                `json:"discountTotal"`
+	TaxTotal        string                `json:"taxTotal"`
+	Total           string                `json:"total"`
+	AmountPaid      string                `json:"amountPaid"`
+	Balance         string                `json:"balance"`
+	Notes           string                `json:"notes"`
+	Items           []InvoiceItemResponse `json:"items,omitempty"`
+	IssuedAt        string                `json:"issuedAt,omitempty"`
+	DueDate         string                `json:"dueDate,omitempty"`
+	VoidedAt        string                `json:"voidedAt,omitempty"`
+	VoidReason      string                `json:"voidReason,omitempty"`
+	CreatedAt       string                `json:"createdAt"`
+	UpdatedAt       string                `json:"updatedAt"`
+} //@name InvoiceResponse
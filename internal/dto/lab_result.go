@@ -0,0 +1,16 @@
+package dto
+
+type LabResultResponse struct {
+	ID             string `json:"id"`
+	OrderNumber    string `json:"orderNumber"`
+	TestCode       string `json:"testCode"`
+	TestName       string `json:"testName"`
+	ValueType      string `json:"valueType"`
+	Value          string `json:"value"`
+	Units          string `json:"units"`
+	ReferenceRange string `json:"referenceRange"`
+	AbnormalFlag   string `json:"abnormalFlag"`
+	ResultStatus   string `json:"resultStatus"`
+	ObservedAt     string `json:"observedAt"`
+	CreatedAt      string `json:"createdAt"`
+} //@name LabResultResponse
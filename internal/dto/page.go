@@ -0,0 +1,13 @@
+package dto
+
+type PageQuery struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+func (q PageQuery) LimitOrDefault() int {
+	if q.Limit == 0 {
+		return 50
+	}
+	return q.Limit
+}
@@ -6,27 +6,58 @@ type PatientUser struct {
 	Role     string `json:"role"`
 } //@name PatientUser
 
+type PatientAddress struct {
+	Line1      string `json:"line1" binding:"max=255"`
+	Line2      string `json:"line2" binding:"max=255"`
+	City       string `json:"city" binding:"max=100"`
+	State      string `json:"state" binding:"max=100"`
+	PostalCode string `json:"postalCode" binding:"max=20"`
+	Country    string `json:"country" binding:"max=100"`
+} //@name PatientAddress
+
+type EmergencyContact struct {
+	Name         string `json:"name" binding:"max=255"`
+	Relationship string `json:"relationship" binding:"max=50"`
+	Phone        string `json:"phone" binding:"max=20"`
+} //@name EmergencyContact
+
 type AddPatientRequest struct {
-	Name    string `json:"name" binding:"required,min=3,max=50"`
-	Age     int    `json:"age" binding:"required,min=0,max=120"`
-	Gender  string `json:"gender" binding:"required"`
-	Address string `json:"address"`
-	Phone   string `json:"phone"`
+	GivenName         string           `json:"givenName" binding:"required,max=50"`
+	FamilyName        string           `json:"familyName" binding:"required,max=50"`
+	PreferredName     string           `json:"preferredName" binding:"max=50"`
+	DateOfBirth       string           `json:"dateOfBirth" binding:"required,datetime=2006-01-02" example:"1985-03-14"`
+	Gender            string           `json:"gender" binding:"required"`
+	SexAtBirth        string           `json:"sexAtBirth" binding:"omitempty,oneof=male female intersex unknown"`
+	GenderIdentity    string           `json:"genderIdentity" binding:"max=50"`
+	Email             string           `json:"email" binding:"omitempty,email"`
+	Phone             string           `json:"phone"`
+	PreferredLanguage string           `json:"preferredLanguage" binding:"omitempty,bcp47_language_tag" example:"en"`
+	Address           PatientAddress   `json:"address"`
+	EmergencyContact  EmergencyContact `json:"emergencyContact"`
+	AllowDuplicate    bool             `json:"allowDuplicate"`
 } //@name AddPatientRequest
 
 type AddPatientResponse struct {
 	ID        string `json:"id"`
+	MRN       string `json:"mrn"`
 	CreatedBy string `json:"createdBy"`
 	CreatedAt string `json:"createdAt"`
 } //@name AddPatientResponse
 
 type UpdatePatientRequest struct {
-	Name         string `json:"name" binding:"min=3,max=50"`
-	Age          int    `json:"age" binding:"min=0,max=120"`
-	Gender       string `json:"gender"`
-	Address      string `json:"address"`
-	Phone        string `json:"phone"`
-	MedicalNotes string `json:"medicalNotes"`
+	GivenName         string           `json:"givenName" binding:"max=50"`
+	FamilyName        string           `json:"familyName" binding:"max=50"`
+	PreferredName     string           `json:"preferredName" binding:"max=50"`
+	DateOfBirth       string           `json:"dateOfBirth" binding:"omitempty,datetime=2006-01-02" example:"1985-03-14"`
+	Gender            string           `json:"gender"`
+	SexAtBirth        string           `json:"sexAtBirth" binding:"omitempty,oneof=male female intersex unknown"`
+	GenderIdentity    string           `json:"genderIdentity" binding:"max=50"`
+	Email             string           `json:"email" binding:"omitempty,email"`
+	Phone             string           `json:"phone"`
+	PreferredLanguage string           `json:"preferredLanguage" binding:"omitempty,bcp47_language_tag" example:"en"`
+	Address           PatientAddress   `json:"address"`
+	EmergencyContact  EmergencyContact `json:"emergencyContact"`
+	MedicalNotes      string           `json:"medicalNotes"`
 } //@name UpdatePatientRequest
 
 type UpdatePatientResponse struct {
@@ -44,27 +75,46 @@ type DeletePatientResponse struct {
 } //@name DeletePatientResponse
 
 type GetPatientResponse struct {
-	ID           string      `json:"id"`
-	Name         string      `json:"name"`
-	Age          int         `json:"age"`
-	Gender       string      `json:"gender"`
-	Address      string      `json:"address"`
-	Phone        string      `json:"phone"`
-	MedicalNotes string      `json:"medicalNotes"`
-	CreatedBy    PatientUser `json:"createdBy"`
-	UpdatedBy    PatientUser `json:"updatedBy"`
-	CreatedAt    string      `json:"createdAt"`
-	UpdatedAt    string      `json:"updatedAt"`
+	ID                   string                      `json:"id"`
+	MRN                  string                      `json:"mrn"`
+	Name                 string                      `json:"name"`
+	GivenName            string                      `json:"givenName"`
+	FamilyName           string                      `json:"familyName"`
+	PreferredName        string                      `json:"preferredName"`
+	DateOfBirth          string                      `json:"dateOfBirth"`
+	DateOfBirthEstimated bool                        `json:"dateOfBirthEstimated"`
+	Age                  int                         `json:"age"`
+	AgeMonths            int                         `json:"ageMonths"`
+	Gender               string                      `json:"gender"`
+	SexAtBirth           string                      `json:"sexAtBirth"`
+	GenderIdentity       string                      `json:"genderIdentity"`
+	Email                string                      `json:"email"`
+	Phone                string                      `json:"phone"`
+	PreferredLanguage    string                      `json:"preferredLanguage"`
+	Address              PatientAddress              `json:"address"`
+	EmergencyContact     EmergencyContact            `json:"emergencyContact"`
+	Identifiers          []PatientIdentifierResponse `json:"identifiers"`
+	MedicalNotes         string                      `json:"medicalNotes"`
+	CreatedBy            PatientUser                 `json:"createdBy"`
+	UpdatedBy            PatientUser                 `json:"updatedBy"`
+	CreatedAt            string                      `json:"createdAt"`
+	UpdatedAt            string                      `json:"updatedAt"`
+	MergedIntoID         string                      `json:"mergedIntoId,omitempty"`
 } //@name GetPatientResponse
 
 type GetAllPatientsResponse struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	Age          int    `json:"age"`
-	Gender       string `json:"gender"`
-	Address      string `json:"address"`
-	Phone        string `json:"phone"`
-	MedicalNotes string `json:"medicalNotes"`
-	CreatedAt    string `json:"createdAt"`
-	UpdatedAt    string `json:"updatedAt"`
+	ID                   string         `json:"id"`
+	MRN                  string         `json:"mrn"`
+	Name                 string         `json:"name"`
+	PreferredName        string         `json:"preferredName"`
+	DateOfBirth          string         `json:"dateOfBirth"`
+	DateOfBirthEstimated bool           `json:"dateOfBirthEstimated"`
+	Age                  int            `json:"age"`
+	AgeMonths            int            `json:"ageMonths"`
+	Gender               string         `json:"gender"`
+	Phone                string         `json:"phone"`
+	Address              PatientAddress `json:"address"`
+	MedicalNotes         string         `json:"medicalNotes"`
+	CreatedAt            string         `json:"createdAt"`
+	UpdatedAt            string         `json:"updatedAt"`
 } //@name GetAllPatientsResponse
@@ -0,0 +1,18 @@
+package dto
+
+type PatientDocumentResponse struct {
+	ID          string      `json:"id"`
+	PatientID   string      `json:"patientId"`
+	Category    string      `json:"category"`
+	Title       string      `json:"title"`
+	FileName    string      `json:"fileName"`
+	ContentType string      `json:"contentType"`
+	SizeBytes   int64       `json:"sizeBytes"`
+	SHA256      string      `json:"sha256"`
+	UploadedBy  PatientUser `json:"uploadedBy"`
+	CreatedAt   string      `json:"createdAt"`
+} //@name PatientDocumentResponse
+
+type DeletePatientDocumentResponse struct {
+	ID string `json:"id"`
+} //@name DeletePatientDocumentResponse
@@ -0,0 +1,15 @@
+package dto
+
+// PatientRecordResponse is a patient's full record as exported for a
+// data-subject access request.
+type PatientRecordResponse struct {
+	ExportedAt        string                    `json:"exportedAt"`
+	Patient           GetPatientResponse        `json:"patient"`
+	LabResults        []LabResultResponse       `json:"labResults"`
+	InsurancePolicies []InsurancePolicyResponse `json:"insurancePolicies"`
+	Invoices          []InvoiceResponse         `json:"invoices"`
+	Payments          []PaymentResponse         `json:"payments"`
+	Claims            []ClaimResponse           `json:"claims"`
+	Prescriptions     []PrescriptionResponse    `json:"prescriptions"`
+	Documents         []PatientDocumentResponse `json:"documents"`
+} //@name PatientRecordResponse
@@ -0,0 +1,19 @@
+package dto
+
+type PatientIdentifierResponse struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Issuer    string `json:"issuer"`
+	Value     string `json:"value"`
+	CreatedAt string `json:"createdAt"`
+} //@name PatientIdentifierResponse
+
+type AddPatientIdentifierRequest struct {
+	Type   string `json:"type" binding:"required,oneof=national_id insurance_member_id hospital_mrn other"`
+	Issuer string `json:"issuer" binding:"max=100"`
+	Value  string `json:"value" binding:"required,max=100"`
+} //@name AddPatientIdentifierRequest
+
+type DeletePatientIdentifierResponse struct {
+	ID string `json:"id"`
+} //@name DeletePatientIdentifierResponse
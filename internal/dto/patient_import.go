@@ -0,0 +1,33 @@
+package dto
+
+type PatientImportIssue struct {
+	Row        int      `json:"row"`
+	Field      string   `json:"field,omitempty"`
+	Message    string   `json:"message"`
+	Duplicates []string `json:"duplicates,omitempty"`
+} //@name PatientImportIssue
+
+type PatientImportResponse struct {
+	ID              string               `json:"id"`
+	FileName        string               `json:"fileName"`
+	Format          string               `json:"format"`
+	DryRun          bool                 `json:"dryRun"`
+	AllowDuplicates bool                 `json:"allowDuplicates"`
+	Status          string               `json:"status"`
+	TotalRows       int                  `json:"totalRows"`
+	ProcessedRows   int                  `json:"processedRows"`
+	CreatedRows     int                  `json:"createdRows"`
+	DuplicateRows   int                  `json:"duplicateRows"`
+	FailedRows      int                  `json:"failedRows"`
+	Error           string               `json:"error,omitempty"`
+	Issues          []PatientImportIssue `json:"issues,omitempty"`
+	CreatedBy       PatientUser          `json:"createdBy"`
+	CreatedAt       string               `json:"createdAt"`
+	StartedAt       string               `json:"startedAt,omitempty"`
+	FinishedAt      string               `json:"finishedAt,omitempty"`
+} //@name PatientImportResponse
+
+type GetPatientImportsResponse struct {
+	Imports []PatientImportResponse `json:"imports"`
+	Total   int64                   `json:"total"`
+} //@name GetPatientImportsResponse
@@ -0,0 +1,69 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+type PaymentRequest struct {
+	Method    string          `json:"method" binding:"required,oneof=cash card bank_transfer"`
+	Amount    decimal.Decimal `json:"amount" swaggertype:"string" example:"45.00"`
+	Reference string          `json:"reference" binding:"max=255"`
+	Notes     string          `json:"notes"`
+} //@name PaymentRequest
+
+type RefundRequest struct {
+	PaymentRequest
+	RefundOfID *string `json:"refundOfId" binding:"omitempty,uuid"`
+} //@name RefundRequest
+
+type PaymentResponse struct {
+	ID             string `json:"id"`
+	ReceiptNumber  string `json:"receiptNumber"`
+	InvoiceID      string `json:"invoiceId"`
+	InvoiceNumber  string `json:"invoiceNumber"`
+	PatientID      string `json:"patientId"`
+	PatientName    string `json:"patientName"`
+	Kind           string `json:"kind" enums:"payment,refund"`
+	Method         string `json:"method" enums:"cash,card,bank_transfer"`
+	Amount         string `json:"amount" example:"45.00"`
+	Currency       string `json:"currency"`
+	Reference      string `json:"reference"`
+	Notes          string `json:"notes"`
+	RefundOfID     string `json:"refundOfId,omitempty"`
+	ReceivedBy     string `json:"receivedBy"`
+	ReceivedByName string `json:"receivedByName"`
+	ReceivedAt     string `json:"receivedAt"`
+	BusinessDate   string `json:"businessDate"`
+	Closed         bool   `json:"closed"`
+} //@name PaymentResponse
+
+type DayCloseRequest struct {
+	Date  string `json:"date" binding:"omitempty,datetime=2006-01-02" example:"2025-06-16"`
+	Notes string `json:"notes"`
+} //@name DayCloseRequest
+
+type DayCloseLine struct {
+	ReceivedBy    string `json:"receivedBy"`
+	Username      string `json:"username"`
+	Method        string `json:"method"`
+	PaymentCount  int    `json:"paymentCount"`
+	PaymentsTotal string `json:"paymentsTotal"`
+	RefundCount   int    `json:"refundCount"`
+	RefundsTotal  string `json:"refundsTotal"`
+	Net           string `json:"net"`
+} //@name DayCloseLine
+
+type DayCloseMethodTotal struct {
+	Method string `json:"method"`
+	Net    string `json:"net"`
+} //@name DayCloseMethodTotal
+
+type DayCloseResponse struct {
+	Date         string                `json:"date"`
+	Closed       bool                  `json:"closed"`
+	ClosedBy     string                `json:"closedBy,omitempty"`
+	ClosedByName string                `json:"closedByName,omitempty"`
+	ClosedAt     string                `json:"closedAt,omitempty"`
+	Notes        string                `json:"notes,omitempty"`
+	Lines        []DayCloseLine        `json:"lines"`
+	Totals       []DayCloseMethodTotal `json:"totals"`
+	Net          string                `json:"net"`
+} //@name DayCloseResponse
@@ -0,0 +1,38 @@
+package dto
+
+type PrescriptionItemRequest struct {
+	Medication   string `json:"medication" binding:"required,max=255" example:"Amoxicillin 500 mg capsule"`
+	Dosage       string `json:"dosage" binding:"required,max=100" example:"1 capsule"`
+	Route        string `json:"route" binding:"max=50" example:"oral"`
+	Frequency    string `json:"frequency" binding:"required,max=100" example:"three times a day"`
+	Duration     string `json:"duration" binding:"max=100" example:"7 days"`
+	Quantity     string `json:"quantity" binding:"max=50" example:"21"`
+	Instructions string `json:"instructions" example:"Take with food"`
+} //@name PrescriptionItemRequest
+
+type PrescriptionRequest struct {
+	Diagnosis string                    `json:"diagnosis"`
+	Notes     string                    `json:"notes"`
+	Items     []PrescriptionItemRequest `json:"items" binding:"required,min=1,dive"`
+} //@name PrescriptionRequest
+
+type PrescriptionItemResponse struct {
+	ID           string `json:"id"`
+	Medication   string `json:"medication"`
+	Dosage       string `json:"dosage"`
+	Route        string `json:"route"`
+	Frequency    string `json:"frequency"`
+	Duration     string `json:"duration"`
+	Quantity     string `json:"quantity"`
+	Instructions string `json:"instructions"`
+} //@name PrescriptionItemResponse
+
+type PrescriptionResponse struct {
+	ID           string                     `json:"id"`
+	PatientID    string                     `json:"patientId"`
+	PrescribedBy PatientUser                `json:"prescribedBy"`
+	Diagnosis    string                     `json:"diagnosis"`
+	Notes        string                     `json:"notes"`
+	Items        []PrescriptionItemResponse `json:"items"`
+	CreatedAt    string                     `json:"createdAt"`
+} //@name PrescriptionResponse
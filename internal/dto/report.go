@@ -0,0 +1,59 @@
+package dto
+
+type NewPatientsReportLine struct {
+	Period string `json:"period"`
+	Count  int64  `json:"count"`
+} //@name NewPatientsReportLine
+
+type NewPatientsReport struct {
+	From    string                  `json:"from"`
+	To      string                  `json:"to"`
+	GroupBy string                  `json:"groupBy"`
+	Lines   []NewPatientsReportLine `json:"lines"`
+	Total   int64                   `json:"total"`
+} //@name NewPatientsReport
+
+type DemographicsReportLine struct {
+	Gender  string `json:"gender"`
+	AgeBand string `json:"ageBand"`
+	Count   int64  `json:"count"`
+} //@name DemographicsReportLine
+
+type DemographicsReport struct {
+	From  string                   `json:"from,omitempty"`
+	To    string                   `json:"to"`
+	Lines []DemographicsReportLine `json:"lines"`
+	Total int64                    `json:"total"`
+} //@name DemographicsReport
+
+type VisitsReportLine struct {
+	Period   string `json:"period"`
+	DoctorID string `json:"doctorId"`
+	Doctor   string `json:"doctor"`
+	Visits   int64  `json:"visits"`
+	Patients int64  `json:"patients"`
+} //@name VisitsReportLine
+
+type VisitsReport struct {
+	From    string             `json:"from"`
+	To      string             `json:"to"`
+	GroupBy string             `json:"groupBy"`
+	Lines   []VisitsReportLine `json:"lines"`
+} //@name VisitsReport
+
+type RevenueReportLine struct {
+	Period       string `json:"period"`
+	Currency     string `json:"currency"`
+	InvoiceCount int64  `json:"invoiceCount"`
+	Invoiced     string `json:"invoiced"`
+	Collected    string `json:"collected"`
+	Refunded     string `json:"refunded"`
+	Net          string `json:"net"`
+} //@name RevenueReportLine
+
+type RevenueReport struct {
+	From    string              `json:"from"`
+	To      string              `json:"to"`
+	GroupBy string              `json:"groupBy"`
+	Lines   []RevenueReportLine `json:"lines"`
+} //@name RevenueReport
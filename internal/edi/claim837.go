@@ -0,0 +1,308 @@
+package edi
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+)
+
+// ImplementationGuide identifies the 837 Professional version produced.
+const ImplementationGuide = "005010X222A1"
+
+var (
+	ErrNotConfigured = errors.New("EDI submitter and billing provider are not configured")
+	ErrInvalidClaim  = errors.New("claim cannot be exported")
+)
+
+// Settings identify the clinic as the submitter and billing provider, and the
+// clearinghouse or payer receiving the file.
+type Settings struct {
+	SubmitterID    string
+	SubmitterName  string
+	ContactName    string
+	ContactPhone   string
+	ReceiverID     string
+	ReceiverName   string
+	Production     bool
+	ProviderName   string
+	ProviderNPI    string
+	ProviderTaxID  string
+	ProviderAddr   models.Address
+	PlaceOfService string
+}
+
+func (s Settings) Validate() error {
+	required := []struct{ name, value string }{
+		{"submitter ID", s.SubmitterID},
+		{"submitter name", s.SubmitterName},
+		{"receiver ID", s.ReceiverID},
+		{"receiver name", s.ReceiverName},
+		{"provider name", s.ProviderName},
+		{"provider NPI", s.ProviderNPI},
+		{"provider tax ID", s.ProviderTaxID},
+		{"provider address", s.ProviderAddr.Line1},
+		{"provider city", s.ProviderAddr.City},
+		{"provider state", s.ProviderAddr.State},
+		{"provider postal code", s.ProviderAddr.PostalCode},
+	}
+	var missing []string
+	for _, field := range required {
+		if strings.TrimSpace(field.value) == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrNotConfigured, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Build837P renders claims as a single 837 Professional interchange. Each
+// claim must have its Invoice (with Items and Patient) and Policy loaded;
+// procedureCodes maps fee IDs to the HCPCS/CPT code billed for the line.
+func Build837P(settings Settings, claims []*models.Claim, procedureCodes map[string]string, controlNumber int, now time.Time) ([]byte, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	for _, claim := range claims {
+		if err := checkClaim(claim, procedureCodes); err != nil {
+			return nil, err
+		}
+	}
+
+	usage := "T"
+	if settings.Production {
+		usage = "P"
+	}
+	control := strconv.Itoa(controlNumber)
+
+	w := &writer{}
+	w.segment("ISA",
+		"00", pad("", 10), "00", pad("", 10),
+		"ZZ", pad(settings.SubmitterID, 15), "ZZ", pad(settings.ReceiverID, 15),
+		now.Format("060102"), now.Format("1504"),
+		repetitionSeparator, "00501", fmt.Sprintf("%09d", controlNumber), "0", usage, componentSeparator)
+	w.segment("GS", "HC", text(settings.SubmitterID, 15), text(settings.ReceiverID, 15),
+		now.Format("20060102"), now.Format("1504"), control, "X", ImplementationGuide)
+
+	start := len(w.segments)
+	w.segment("ST", "837", "0001", ImplementationGuide)
+	w.segment("BHT", "0019", "00", control, now.Format("20060102"), now.Format("1504"), "CH")
+
+	// 1000A submitter and 1000B receiver.
+	w.segment("NM1", "41", "2", text(settings.SubmitterName, 60), "", "", "", "", "46", text(settings.SubmitterID, 80))
+	contact := settings.ContactName
+	if contact == "" {
+		contact = settings.SubmitterName
+	}
+	if phone := digits(settings.ContactPhone); phone != "" {
+		w.segment("PER", "IC", text(contact, 60), "TE", phone)
+	} else {
+		w.segment("PER", "IC", text(contact, 60))
+	}
+	w.segment("NM1", "40", "2", text(settings.ReceiverName, 60), "", "", "", "", "46", text(settings.ReceiverID, 80))
+
+	// 2000A/2010AA billing provider.
+	w.segment("HL", "1", "", "20", "1")
+	w.segment("NM1", "85", "2", text(settings.ProviderName, 60), "", "", "", "", "XX", digits(settings.ProviderNPI))
+	writeAddress(w, settings.ProviderAddr)
+	w.segment("REF", "EI", digits(settings.ProviderTaxID))
+
+	hl := 1
+	for _, claim := range claims {
+		hl = writeClaim(w, settings, claim, procedureCodes, hl)
+	}
+
+	w.segment("SE", strconv.Itoa(w.count(start)+1), "0001")
+	w.segment("GE", "1", control)
+	w.segment("IEA", "1", fmt.Sprintf("%09d", controlNumber))
+	return w.bytes(), nil
+}
+
+// writeClaim writes the subscriber, optional patient and claim loops and
+// returns the last hierarchical ID used.
+func writeClaim(w *writer, settings Settings, claim *models.Claim, procedureCodes map[string]string, hl int) int {
+	invoice := claim.Invoice
+	patient := invoice.Patient
+	policy := claim.Policy
+	self := policy.Relationship == models.SubscriberSelf
+
+	subscriberHL := hl + 1
+	hasChild := "1"
+	if self {
+		hasChild = "0"
+	}
+	w.segment("HL", strconv.Itoa(subscriberHL), "1", "22", hasChild)
+
+	relationship := ""
+	if self {
+		relationship = "18"
+	}
+	groupNumber, groupName := text(policy.GroupNumber, 50), ""
+	if groupNumber == "" {
+		groupName = text(policy.PlanName, 60)
+	}
+	w.segment("SBR", priorityCode(policy.Priority), relationship, groupNumber, groupName, "", "", "", "", "CI")
+
+	if self {
+		writeName(w, "IL", patient.FamilyName, patient.GivenName, patient.Name, "MI", policy.MemberID)
+		writeAddress(w, patient.Address)
+		w.segment("DMG", "D8", patient.DateOfBirth.Format("20060102"), genderCode(patient))
+	} else {
+		writeName(w, "IL", policy.SubscriberFamilyName, policy.SubscriberGivenName, "", "MI", policy.MemberID)
+		if policy.SubscriberDateOfBirth != nil {
+			w.segment("DMG", "D8", policy.SubscriberDateOfBirth.Format("20060102"))
+		}
+	}
+	w.segment("NM1", "PR", "2", text(policy.PayerName, 60), "", "", "", "", "PI", text(policy.PayerID, 80))
+
+	last := subscriberHL
+	if !self {
+		last = subscriberHL + 1
+		w.segment("HL", strconv.Itoa(last), strconv.Itoa(subscriberHL), "23", "0")
+		w.segment("PAT", relationshipCode(policy.Relationship))
+		writeName(w, "QC", patient.FamilyName, patient.GivenName, patient.Name, "", "")
+		writeAddress(w, patient.Address)
+		w.segment("DMG", "D8", patient.DateOfBirth.Format("20060102"), genderCode(patient))
+	}
+
+	placeOfService := settings.PlaceOfService
+	if placeOfService == "" {
+		placeOfService = "11"
+	}
+	w.segment("CLM", text(claim.ClaimNumber, 38), claim.TotalCharge.StringFixed(2), "", "",
+		composite(placeOfService, "B", "1"), "Y", "A", "Y", "Y")
+	w.segment("REF", "EA", text(patient.MRN, 50))
+
+	codes := claimDiagnosisCodes(claim)
+	diagnoses := make([]string, len(codes))
+	for i, code := range codes {
+		qualifier := "ABF"
+		if i == 0 {
+			qualifier = "ABK"
+		}
+		diagnoses[i] = composite(qualifier, code)
+	}
+	w.segment("HI", diagnoses...)
+
+	serviceDate := invoice.IssuedAt.Format("20060102")
+	pointers := make([]string, min(len(codes), 4))
+	for i := range pointers {
+		pointers[i] = strconv.Itoa(i + 1)
+	}
+	for i, item := range invoice.Items {
+		w.segment("LX", strconv.Itoa(i+1))
+		w.segment("SV1", composite("HC", text(procedureCodes[*item.FeeID], 48)), item.LineTotal.StringFixed(2),
+			"UN", item.Quantity.String(), "", "", composite(pointers...))
+		w.segment("DTP", "472", "D8", serviceDate)
+	}
+	return last
+}
+
+func checkClaim(claim *models.Claim, procedureCodes map[string]string) error {
+	invoice := claim.Invoice
+	if invoice == nil || invoice.Patient == nil || claim.Policy == nil || invoice.IssuedAt == nil {
+		return fmt.Errorf("%w: claim %s is missing its invoice, patient or policy", ErrInvalidClaim, claim.ClaimNumber)
+	}
+	patient := invoice.Patient
+	if patient.DateOfBirth == nil {
+		return fmt.Errorf("%w: claim %s: patient has no date of birth", ErrInvalidClaim, claim.ClaimNumber)
+	}
+	if patient.Address.Line1 == "" || patient.Address.City == "" || patient.Address.State == "" || patient.Address.PostalCode == "" {
+		return fmt.Errorf("%w: claim %s: patient address is incomplete", ErrInvalidClaim, claim.ClaimNumber)
+	}
+	if claim.Policy.Relationship != models.SubscriberSelf &&
+		(claim.Policy.SubscriberFamilyName == "" || claim.Policy.SubscriberGivenName == "") {
+		return fmt.Errorf("%w: claim %s: policy has no subscriber name", ErrInvalidClaim, claim.ClaimNumber)
+	}
+	if len(claimDiagnosisCodes(claim)) == 0 {
+		return fmt.Errorf("%w: claim %s has no diagnosis codes", ErrInvalidClaim, claim.ClaimNumber)
+	}
+	if len(invoice.Items) == 0 {
+		return fmt.Errorf("%w: claim %s has no service lines", ErrInvalidClaim, claim.ClaimNumber)
+	}
+	for i, item := range invoice.Items {
+		if item.FeeID == nil || procedureCodes[*item.FeeID] == "" {
+			return fmt.Errorf("%w: claim %s line %d has no procedure code", ErrInvalidClaim, claim.ClaimNumber, i+1)
+		}
+	}
+	return nil
+}
+
+func writeName(w *writer, entity, family, given, fallback, idQualifier, id string) {
+	if family == "" {
+		family = fallback
+	}
+	w.segment("NM1", entity, "1", text(family, 60), text(given, 35), "", "", "", idQualifier, text(id, 80))
+}
+
+func writeAddress(w *writer, address models.Address) {
+	w.segment("N3", text(address.Line1, 55), text(address.Line2, 55))
+	w.segment("N4", text(address.City, 30), text(address.State, 2), digits(address.PostalCode), countryCode(address.Country))
+}
+
+// claimDiagnosisCodes returns the claim's ICD-10 codes without dots, at most
+// the 12 an 837P claim can carry.
+func claimDiagnosisCodes(claim *models.Claim) []string {
+	var codes []string
+	for _, code := range strings.Split(claim.DiagnosisCodes, ",") {
+		code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), ".", ""))
+		if code != "" {
+			codes = append(codes, text(code, 30))
+		}
+	}
+	if len(codes) > 12 {
+		codes = codes[:12]
+	}
+	return codes
+}
+
+func priorityCode(priority string) string {
+	switch priority {
+	case models.PolicyPrioritySecondary:
+		return "S"
+	case models.PolicyPriorityTertiary:
+		return "T"
+	default:
+		return "P"
+	}
+}
+
+func relationshipCode(relationship string) string {
+	switch relationship {
+	case models.SubscriberSpouse:
+		return "01"
+	case models.SubscriberChild:
+		return "19"
+	default:
+		return "G8"
+	}
+}
+
+func genderCode(patient *models.Patient) string {
+	gender := patient.SexAtBirth
+	if gender == "" || gender == "unknown" || gender == "intersex" {
+		gender = patient.Gender
+	}
+	switch strings.ToLower(gender) {
+	case "male":
+		return "M"
+	case "female":
+		return "F"
+	default:
+		return "U"
+	}
+}
+
+// countryCode is only sent for addresses outside the United States.
+func countryCode(country string) string {
+	switch strings.ToUpper(strings.TrimSpace(country)) {
+	case "", "US", "USA", "UNITED STATES":
+		return ""
+	}
+	return text(country, 3)
+}
@@ -0,0 +1,87 @@
+package edi
+
+import (
+	"strings"
+)
+
+const (
+	elementSeparator    = "*"
+	componentSeparator  = ":"
+	repetitionSeparator = "^"
+	segmentTerminator   = "~"
+)
+
+var delimiterReplacer = strings.NewReplacer(
+	elementSeparator, " ",
+	componentSeparator, " ",
+	repetitionSeparator, " ",
+	segmentTerminator, " ",
+	"\r", " ",
+	"\n", " ",
+)
+
+// writer accumulates X12 segments. Trailing empty elements are dropped, as
+// the standard requires.
+type writer struct {
+	segments []string
+}
+
+func (w *writer) segment(id string, elements ...string) {
+	last := len(elements)
+	for last > 0 && elements[last-1] == "" {
+		last--
+	}
+	w.segments = append(w.segments, id+joinElements(elements[:last]))
+}
+
+// count returns the number of segments written since index from, inclusive.
+func (w *writer) count(from int) int {
+	return len(w.segments) - from
+}
+
+func (w *writer) bytes() []byte {
+	var b strings.Builder
+	for _, s := range w.segments {
+		b.WriteString(s)
+		b.WriteString(segmentTerminator)
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+func joinElements(elements []string) string {
+	if len(elements) == 0 {
+		return ""
+	}
+	return elementSeparator + strings.Join(elements, elementSeparator)
+}
+
+// composite joins components of a composite element.
+func composite(components ...string) string {
+	return strings.Join(components, componentSeparator)
+}
+
+// text makes a free-text value safe to embed in an element: delimiters are
+// replaced, and the value is upper-cased and trimmed to max characters.
+func text(value string, max int) string {
+	value = strings.ToUpper(strings.Join(strings.Fields(delimiterReplacer.Replace(value)), " "))
+	if max > 0 && len(value) > max {
+		value = strings.TrimSpace(value[:max])
+	}
+	return value
+}
+
+// pad left-aligns value in a fixed-width ISA element.
+func pad(value string, width int) string {
+	value = text(value, width)
+	return value + strings.Repeat(" ", width-len(value))
+}
+
+func digits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+}
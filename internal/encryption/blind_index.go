@@ -0,0 +1,44 @@
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// BlindIndex hashes values with a secret key so encrypted columns can still
+// be searched by exact match without storing anything reversible.
+type BlindIndex struct {
+	key []byte
+}
+
+// NewBlindIndex takes a base64 key of at least 32 bytes. Changing the key
+// invalidates every stored hash until they are recomputed.
+func NewBlindIndex(encoded string) (*BlindIndex, error) {
+	if encoded == "" {
+		return nil, errors.New("no blind index key configured")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.New("blind index key is not valid base64")
+	}
+	if len(key) < keySize {
+		return nil, errors.New("blind index key must be at least 32 bytes")
+	}
+	return &BlindIndex{key}, nil
+}
+
+// Hash returns the hex HMAC-SHA256 of value, or nil for an empty value so
+// that blanks are not indexed.
+func (b *BlindIndex) Hash(value string) *string {
+	if value == "" {
+		return nil
+	}
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(value))
+	hash := hex.EncodeToString(mac.Sum(nil))
+	return &hash
+}
@@ -0,0 +1,57 @@
+package encryption
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewBlindIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "32 bytes", key: GenerateKey()},
+		{name: "64 bytes", key: "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYQ=="},
+		{name: "empty", wantErr: true},
+		{name: "not base64", key: "***", wantErr: true},
+		{name: "short", key: "c2hvcnQ=", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBlindIndex(tt.key)
+			if tt.wantErr != (err != nil) {
+				t.Errorf("NewBlindIndex() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBlindIndexHash(t *testing.T) {
+	key := GenerateKey()
+	index, err := NewBlindIndex(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameKey, _ := NewBlindIndex(key)
+	otherKey, _ := NewBlindIndex(GenerateKey())
+
+	if index.Hash("") != nil {
+		t.Error("Hash(\"\") != nil, blanks must not be indexed")
+	}
+
+	hash := index.Hash("441134960000")
+	if hash == nil || len(*hash) != 64 || strings.Contains(*hash, "441134960000") {
+		t.Fatalf("Hash() = %v, want a 64 character hex digest", hash)
+	}
+	if got := sameKey.Hash("441134960000"); *got != *hash {
+		t.Error("the same key gave a different hash")
+	}
+	if got := index.Hash("441134960001"); *got == *hash {
+		t.Error("different values gave the same hash")
+	}
+	if got := otherKey.Hash("441134960000"); *got == *hash {
+		t.Error("a different key gave the same hash")
+	}
+}
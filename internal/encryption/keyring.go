@@ -0,0 +1,204 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefix marks values written by Encrypt. Anything without it is treated as
+// legacy plaintext so existing rows keep working until they are re-encrypted.
+const prefix = "enc:v1:"
+
+const keySize = 32
+
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+type Config struct {
+	// Keys lists master keys as "id:base64" pairs separated by commas.
+	Keys string
+	// KeyFile holds one "id:base64" pair per line and is read in addition
+	// to Keys. Blank lines and lines starting with # are ignored.
+	KeyFile string
+	// ActiveKey is the ID new values are encrypted with. It defaults to the
+	// last key listed.
+	ActiveKey string
+}
+
+// Keyring encrypts values with a fresh data key each, wrapped by the active
+// master key. Values name the master key they were wrapped with, so old keys
+// only need to stay in the keyring until everything has been re-encrypted.
+type Keyring struct {
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+func Load(cfg Config) (*Keyring, error) {
+	var entries []string
+	for _, entry := range strings.Split(cfg.Keys, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	if cfg.KeyFile != "" {
+		file, err := os.Open(cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				entries = append(entries, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no encryption keys configured")
+	}
+
+	keyring := &Keyring{keys: make(map[string]cipher.AEAD), active: cfg.ActiveKey}
+	for _, entry := range entries {
+		id, encoded, found := strings.Cut(entry, ":")
+		if !found || id == "" {
+			return nil, fmt.Errorf("encryption key %q must look like id:base64", id)
+		}
+		if _, ok := keyring.keys[id]; ok {
+			return nil, fmt.Errorf("encryption key %q is listed twice", id)
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q: %w", id, err)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		keyring.keys[id] = aead
+		if cfg.ActiveKey == "" {
+			keyring.active = id
+		}
+	}
+	if _, ok := keyring.keys[keyring.active]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, keyring.active)
+	}
+	return keyring, nil
+}
+
+// ActiveKey returns the ID of the key new values are wrapped with.
+func (k *Keyring) ActiveKey() string {
+	return k.active
+}
+
+// ActivePrefix is the prefix shared by all values encrypted with the active
+// key, for finding rows that still need re-encrypting.
+func (k *Keyring) ActivePrefix() string {
+	return prefix + k.active + ":"
+}
+
+// Encrypt returns "enc:v1:<key id>:<wrapped data key>:<ciphertext>". aad is
+// authenticated but not stored, so the same aad must be passed to Decrypt.
+func (k *Keyring) Encrypt(plaintext, aad []byte) (string, error) {
+	dataKey := make([]byte, keySize)
+	rand.Read(dataKey)
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped := seal(k.keys[k.active], dataKey, []byte(k.active))
+	ciphertext := seal(data, plaintext, aad)
+	return prefix + k.active + ":" + encode(wrapped) + ":" + encode(ciphertext), nil
+}
+
+func (k *Keyring) Decrypt(value string, aad []byte) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if !IsEncrypted(value) || len(parts) != 3 {
+		return nil, errors.New("malformed encrypted value")
+	}
+	master, ok := k.keys[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, parts[0])
+	}
+	wrapped, err := decode(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := decode(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := open(master, wrapped, []byte(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, ciphertext, aad)
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// GenerateKey returns a new random key, base64 encoded for configuration.
+func GenerateKey() string {
+	key := make([]byte, keySize)
+	rand.Read(key)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.New("key is not valid base64")
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal prepends a random nonce to the ciphertext.
+func seal(aead cipher.AEAD, plaintext, aad []byte) []byte {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	rand.Read(nonce)
+	return aead.Seal(nonce, nonce, plaintext, aad)
+}
+
+func open(aead cipher.AEAD, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, aad)
+}
+
+func encode(b []byte) string {
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(s)
+}
@@ -0,0 +1,182 @@
+package encryption
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, cfg Config) *Keyring {
+	t.Helper()
+	keyring, err := Load(cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return keyring
+}
+
+func TestLoad(t *testing.T) {
+	first, second := GenerateKey(), GenerateKey()
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys")
+	if err := os.WriteFile(keyFile, []byte("# rotated 2025-05\n\nk2:"+second+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		active  string
+		wantErr bool
+	}{
+		{name: "single key", cfg: Config{Keys: "k1:" + first}, active: "k1"},
+		{name: "last key is active", cfg: Config{Keys: "k1:" + first + ", k2:" + second}, active: "k2"},
+		{name: "explicit active key", cfg: Config{Keys: "k1:" + first + ",k2:" + second, ActiveKey: "k1"}, active: "k1"},
+		{name: "key file after keys", cfg: Config{Keys: "k1:" + first, KeyFile: keyFile}, active: "k2"},
+		{name: "no keys", cfg: Config{Keys: " , "}, wantErr: true},
+		{name: "missing key file", cfg: Config{KeyFile: filepath.Join(dir, "missing")}, wantErr: true},
+		{name: "no id", cfg: Config{Keys: ":" + first}, wantErr: true},
+		{name: "no separator", cfg: Config{Keys: first}, wantErr: true},
+		{name: "not base64", cfg: Config{Keys: "k1:not-base64!"}, wantErr: true},
+		{name: "short key", cfg: Config{Keys: "k1:c2hvcnQ="}, wantErr: true},
+		{name: "duplicate id", cfg: Config{Keys: "k1:" + first + ",k1:" + second}, wantErr: true},
+		{name: "unknown active key", cfg: Config{Keys: "k1:" + first, ActiveKey: "k9"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := Load(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if keyring.ActiveKey() != tt.active {
+				t.Errorf("ActiveKey() = %q, want %q", keyring.ActiveKey(), tt.active)
+			}
+			if keyring.ActivePrefix() != "enc:v1:"+tt.active+":" {
+				t.Errorf("ActivePrefix() = %q", keyring.ActivePrefix())
+			}
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyring := testKeyring(t, Config{Keys: "k1:" + GenerateKey()})
+	aad := []byte("patients.phone:7d8f7a2e")
+
+	for _, plaintext := range []string{"", "+44 113 496 0000", "Allergic to penicillin\nsee chart", strings.Repeat("x", 10000)} {
+		value, err := keyring.Encrypt([]byte(plaintext), aad)
+		if err != nil {
+			t.Fatalf("Encrypt() error = %v", err)
+		}
+		if !IsEncrypted(value) || !strings.HasPrefix(value, keyring.ActivePrefix()) {
+			t.Errorf("Encrypt() = %q, want the active key prefix", value)
+		}
+		if plaintext != "" && strings.Contains(value, plaintext) {
+			t.Errorf("Encrypt() leaks the plaintext: %q", value)
+		}
+
+		got, err := keyring.Decrypt(value, aad)
+		if err != nil {
+			t.Fatalf("Decrypt() error = %v", err)
+		}
+		if string(got) != plaintext {
+			t.Errorf("Decrypt() = %q, want %q", got, plaintext)
+		}
+	}
+}
+
+func TestEncryptUsesFreshDataKeys(t *testing.T) {
+	keyring := testKeyring(t, Config{Keys: "k1:" + GenerateKey()})
+	a, _ := keyring.Encrypt([]byte("same"), nil)
+	b, _ := keyring.Encrypt([]byte("same"), nil)
+	if a == b {
+		t.Error("encrypting the same value twice gave the same ciphertext")
+	}
+}
+
+func TestDecryptFailures(t *testing.T) {
+	key := GenerateKey()
+	keyring := testKeyring(t, Config{Keys: "k1:" + key})
+	aad := []byte("patients.phone:7d8f7a2e")
+	value, err := keyring.Encrypt([]byte("+44 113 496 0000"), aad)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	otherKeyring := testKeyring(t, Config{Keys: "k1:" + GenerateKey()})
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		value   string
+		aad     string
+		wantErr error
+	}{
+		{name: "other column", keyring: keyring, value: value, aad: "patients.email:7d8f7a2e"},
+		{name: "other row", keyring: keyring, value: value, aad: "patients.phone:0b7c1a7e"},
+		{name: "no aad", keyring: keyring, value: value},
+		{name: "plaintext", keyring: keyring, value: "+44 113 496 0000", aad: string(aad)},
+		{name: "missing part", keyring: keyring, value: prefix + parts[0] + ":" + parts[1], aad: string(aad)},
+		{name: "unknown key", keyring: keyring, value: prefix + "k9:" + parts[1] + ":" + parts[2], aad: string(aad), wantErr: ErrUnknownKey},
+		{name: "key id swapped", keyring: testKeyring(t, Config{Keys: "k1:" + GenerateKey() + ",k2:" + key}), value: value, aad: string(aad)},
+		{name: "wrong master key", keyring: otherKeyring, value: value, aad: string(aad)},
+		{name: "tampered ciphertext", keyring: keyring, value: value[:len(value)-2] + flip(value[len(value)-2:]), aad: string(aad)},
+		{name: "truncated ciphertext", keyring: keyring, value: prefix + parts[0] + ":" + parts[1] + ":AAAA", aad: string(aad)},
+		{name: "bad base64", keyring: keyring, value: prefix + parts[0] + ":" + parts[1] + ":***", aad: string(aad)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var aad []byte
+			if tt.aad != "" {
+				aad = []byte(tt.aad)
+			}
+			_, err := tt.keyring.Decrypt(tt.value, aad)
+			if err == nil {
+				t.Fatal("Decrypt() error = nil, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKey := GenerateKey(), GenerateKey()
+	before := testKeyring(t, Config{Keys: "k1:" + oldKey})
+	value, err := before.Encrypt([]byte("12 High St"), []byte("patients.address_line1:1"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	after := testKeyring(t, Config{Keys: "k1:" + oldKey + ",k2:" + newKey})
+	if strings.HasPrefix(value, after.ActivePrefix()) {
+		t.Error("a value under the old key looks re-encrypted")
+	}
+	got, err := after.Decrypt(value, []byte("patients.address_line1:1"))
+	if err != nil || string(got) != "12 High St" {
+		t.Errorf("Decrypt() after rotation = %q, %v", got, err)
+	}
+
+	retired := testKeyring(t, Config{Keys: "k2:" + newKey})
+	if _, err := retired.Decrypt(value, []byte("patients.address_line1:1")); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt() with the old key removed error = %v, want ErrUnknownKey", err)
+	}
+}
+
+// flip changes the first character of s to another valid base64 character.
+func flip(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
@@ -0,0 +1,87 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is used in model tags as `gorm:"serializer:encrypted"`.
+const SerializerName = "encrypted"
+
+// Register installs the keyring as the gorm serializer for encrypted string
+// fields. It must run before the first query so that model schemas pick it
+// up.
+func Register(keyring *Keyring) {
+	schema.RegisterSerializer(SerializerName, Serializer{keyring})
+}
+
+// Serializer encrypts string fields on write and decrypts them on read,
+// binding each value to its table, column and row's primary key so
+// ciphertexts cannot be swapped between columns or copied to another row.
+// Empty strings are stored as is.
+//
+// The primary key has to be set on the model being written, and selected
+// before the encrypted columns when reading; models assign their ID before
+// insert for this.
+//
+// Only writes that go through the model are encrypted; updates with a column
+// name and value (Update("phone", v) or a map) bypass it.
+type Serializer struct {
+	keyring *Keyring
+}
+
+func (s Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("cannot decrypt %T into %s", dbValue, field.Name)
+	}
+
+	if IsEncrypted(value) {
+		aad, err := additionalData(ctx, field, dst)
+		if err != nil {
+			return err
+		}
+		plaintext, err := s.keyring.Decrypt(value, aad)
+		if err != nil {
+			return fmt.Errorf("decrypt %s: %w", field.DBName, err)
+		}
+		value = string(plaintext)
+	}
+	return field.Set(ctx, dst, value)
+}
+
+func (s Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot encrypt %T in %s", fieldValue, field.Name)
+	}
+	if value == "" {
+		return "", nil
+	}
+	aad, err := additionalData(ctx, field, dst)
+	if err != nil {
+		return nil, err
+	}
+	return s.keyring.Encrypt([]byte(value), aad)
+}
+
+func additionalData(ctx context.Context, field *schema.Field, dst reflect.Value) ([]byte, error) {
+	primaryKey := field.Schema.PrioritizedPrimaryField
+	if primaryKey == nil {
+		return nil, fmt.Errorf("%s has no primary key to bind %s to", field.Schema.Table, field.DBName)
+	}
+	id, zero := primaryKey.ValueOf(ctx, dst)
+	if zero {
+		return nil, fmt.Errorf("%s.%s: the row's %s is not set", field.Schema.Table, field.DBName, primaryKey.DBName)
+	}
+	return fmt.Appendf(nil, "%s.%s:%v", field.Schema.Table, field.DBName, id), nil
+}
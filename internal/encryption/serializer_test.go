@@ -0,0 +1,135 @@
+package encryption
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+type serializedPatient struct {
+	ID    string `gorm:"primaryKey"`
+	Phone string `gorm:"serializer:encrypted"`
+	Email string `gorm:"serializer:encrypted"`
+}
+
+type serializedNote struct {
+	Text string `gorm:"serializer:encrypted"`
+}
+
+func parseSchema(t *testing.T, model any) *schema.Schema {
+	t.Helper()
+	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse() error = %v", err)
+	}
+	return s
+}
+
+func TestSerializer(t *testing.T) {
+	keyring := testKeyring(t, Config{Keys: "k1:" + GenerateKey()})
+	Register(keyring)
+	serializer := Serializer{keyring}
+	ctx := context.Background()
+	patients := parseSchema(t, &serializedPatient{})
+	phone := patients.LookUpField("Phone")
+	email := patients.LookUpField("Email")
+
+	write := func(field *schema.Field, row *serializedPatient, value string) any {
+		t.Helper()
+		stored, err := serializer.Value(ctx, field, reflect.ValueOf(row).Elem(), value)
+		if err != nil {
+			t.Fatalf("Value() error = %v", err)
+		}
+		return stored
+	}
+	read := func(field *schema.Field, row *serializedPatient, stored any) error {
+		t.Helper()
+		return serializer.Scan(ctx, field, reflect.ValueOf(row).Elem(), stored)
+	}
+
+	alice := &serializedPatient{ID: "a"}
+	bob := &serializedPatient{ID: "b"}
+	stored := write(phone, alice, "+44 113 496 0000")
+
+	t.Run("round trip", func(t *testing.T) {
+		row := &serializedPatient{ID: "a"}
+		if err := read(phone, row, stored); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		if row.Phone != "+44 113 496 0000" {
+			t.Errorf("Phone = %q", row.Phone)
+		}
+		if err := read(phone, row, []byte(stored.(string))); err != nil || row.Phone != "+44 113 496 0000" {
+			t.Errorf("Scan([]byte) = %q, %v", row.Phone, err)
+		}
+	})
+
+	t.Run("empty values are stored as is", func(t *testing.T) {
+		if got := write(phone, alice, ""); got != "" {
+			t.Errorf("Value(\"\") = %q, want empty", got)
+		}
+		row := &serializedPatient{ID: "a", Phone: "stale"}
+		if err := read(phone, row, nil); err != nil || row.Phone != "" {
+			t.Errorf("Scan(nil) = %q, %v", row.Phone, err)
+		}
+	})
+
+	t.Run("plaintext is read as is", func(t *testing.T) {
+		row := &serializedPatient{ID: "a"}
+		if err := read(phone, row, "+44 113 496 0000"); err != nil || row.Phone != "+44 113 496 0000" {
+			t.Errorf("Scan(plaintext) = %q, %v", row.Phone, err)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		field *schema.Field
+		row   *serializedPatient
+	}{
+		{name: "copied to another row", field: phone, row: &serializedPatient{ID: "b"}},
+		{name: "copied to another column", field: email, row: &serializedPatient{ID: "a"}},
+		{name: "read without the primary key", field: phone, row: &serializedPatient{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := read(tt.field, tt.row, stored); err == nil {
+				t.Errorf("Scan() error = nil, want the value to be rejected")
+			}
+		})
+	}
+
+	t.Run("rows get their own ciphertexts", func(t *testing.T) {
+		other := write(phone, bob, "+44 113 496 0000")
+		if other == stored {
+			t.Error("two rows share a ciphertext")
+		}
+	})
+
+	t.Run("write without the primary key", func(t *testing.T) {
+		_, err := serializer.Value(ctx, phone, reflect.ValueOf(&serializedPatient{}).Elem(), "x")
+		if err == nil || !strings.Contains(err.Error(), "not set") {
+			t.Errorf("Value() error = %v, want the missing ID reported", err)
+		}
+	})
+
+	t.Run("model without a primary key", func(t *testing.T) {
+		notes := parseSchema(t, &serializedNote{})
+		_, err := serializer.Value(ctx, notes.LookUpField("Text"), reflect.ValueOf(&serializedNote{}).Elem(), "x")
+		if err == nil {
+			t.Error("Value() error = nil, want an error")
+		}
+	})
+
+	t.Run("non-string field", func(t *testing.T) {
+		if _, err := serializer.Value(ctx, phone, reflect.ValueOf(alice).Elem(), 42); err == nil {
+			t.Error("Value(42) error = nil, want an error")
+		}
+		if err := read(phone, &serializedPatient{ID: "a"}, 42); err == nil {
+			t.Error("Scan(42) error = nil, want an error")
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package encryption
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Signer signs documents with Ed25519 so that anyone holding the public key
+// can check they were issued by this server and not altered since.
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner takes the base64 32-byte seed of the private key, such as one
+// printed by reencrypt -genkey.
+func NewSigner(encoded string) (*Signer, error) {
+	if encoded == "" {
+		return nil, errors.New("no signing key configured")
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.New("signing key is not valid base64")
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("signing key must be 32 bytes")
+	}
+	return &Signer{ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// Sign returns the base64 signature of data.
+func (s *Signer) Sign(data []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, data))
+}
+
+// PublicKey is the base64 public key that verifies signatures.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// KeyID identifies the key pair, so signatures made before a key change can
+// be matched to the right public key.
+func (s *Signer) KeyID() string {
+	sum := sha256.Sum256(s.key.Public().(ed25519.PublicKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Verify reports whether signature is a valid signature of data by s.
+func (s *Signer) Verify(data []byte, signature string) bool {
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(s.key.Public().(ed25519.PublicKey), data, raw)
+}
@@ -0,0 +1,179 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/models"
+)
+
+// PatientColumns are the CSV and XLSX headers. The patient fields are named
+// like the import fields, so an export can be imported elsewhere as is.
+var PatientColumns = []string{
+	"id",
+	"mrn",
+	"givenName",
+	"familyName",
+	"preferredName",
+	"dateOfBirth",
+	"dateOfBirthEstimated",
+	"gender",
+	"sexAtBirth",
+	"genderIdentity",
+	"email",
+	"phone",
+	"preferredLanguage",
+	"address.line1",
+	"address.line2",
+	"address.city",
+	"address.state",
+	"address.postalCode",
+	"address.country",
+	"emergencyContact.name",
+	"emergencyContact.relationship",
+	"emergencyContact.phone",
+	"createdAt",
+	"updatedAt",
+}
+
+// patientLine is an NDJSON line. Medical notes are left out of list exports.
+type patientLine struct {
+	ID                   string               `json:"id"`
+	MRN                  string               `json:"mrn"`
+	GivenName            string               `json:"givenName"`
+	FamilyName           string               `json:"familyName"`
+	PreferredName        string               `json:"preferredName,omitempty"`
+	DateOfBirth          string               `json:"dateOfBirth,omitempty"`
+	DateOfBirthEstimated bool                 `json:"dateOfBirthEstimated"`
+	Gender               string               `json:"gender"`
+	SexAtBirth           string               `json:"sexAtBirth,omitempty"`
+	GenderIdentity       string               `json:"genderIdentity,omitempty"`
+	Email                string               `json:"email,omitempty"`
+	Phone                string               `json:"phone,omitempty"`
+	PreferredLanguage    string               `json:"preferredLanguage,omitempty"`
+	Address              dto.PatientAddress   `json:"address"`
+	EmergencyContact     dto.EmergencyContact `json:"emergencyContact"`
+	CreatedAt            string               `json:"createdAt"`
+	UpdatedAt            string               `json:"updatedAt"`
+}
+
+// PatientWriter writes patients in one of the export formats as they are
+// passed to it. Close must be called to finish the file.
+type PatientWriter struct {
+	table tableWriter
+	json  *json.Encoder
+	buf   *bufio.Writer
+}
+
+func NewPatientWriter(w io.Writer, format string) (*PatientWriter, error) {
+	switch format {
+	case FormatCSV:
+		table := newCSVWriter(w)
+		if err := table.WriteRow(PatientColumns); err != nil {
+			return nil, err
+		}
+		return &PatientWriter{table: table}, nil
+	case FormatXLSX:
+		table, err := newXLSXWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		if err := table.WriteRow(PatientColumns); err != nil {
+			table.Close()
+			return nil, err
+		}
+		return &PatientWriter{table: table}, nil
+	case FormatNDJSON:
+		buf := bufio.NewWriter(w)
+		return &PatientWriter{json: json.NewEncoder(buf), buf: buf}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func (pw *PatientWriter) Write(patient *models.Patient) error {
+	if pw.json != nil {
+		return pw.json.Encode(toPatientLine(patient))
+	}
+	return pw.table.WriteRow(patientRow(patient))
+}
+
+func (pw *PatientWriter) Close() error {
+	if pw.buf != nil {
+		return pw.buf.Flush()
+	}
+	return pw.table.Close()
+}
+
+func toPatientLine(patient *models.Patient) patientLine {
+	return patientLine{
+		ID:                   patient.ID,
+		MRN:                  patient.MRN,
+		GivenName:            patient.GivenName,
+		FamilyName:           patient.FamilyName,
+		PreferredName:        patient.PreferredName,
+		DateOfBirth:          formatDate(patient.DateOfBirth),
+		DateOfBirthEstimated: patient.DateOfBirthEstimated,
+		Gender:               patient.Gender,
+		SexAtBirth:           patient.SexAtBirth,
+		GenderIdentity:       patient.GenderIdentity,
+		Email:                patient.Email,
+		Phone:                patient.Phone,
+		PreferredLanguage:    patient.PreferredLanguage,
+		Address: dto.PatientAddress{
+			Line1:      patient.Address.Line1,
+			Line2:      patient.Address.Line2,
+			City:       patient.Address.City,
+			State:      patient.Address.State,
+			PostalCode: patient.Address.PostalCode,
+			Country:    patient.Address.Country,
+		},
+		EmergencyContact: dto.EmergencyContact{
+			Name:         patient.EmergencyContact.Name,
+			Relationship: patient.EmergencyContact.Relationship,
+			Phone:        patient.EmergencyContact.Phone,
+		},
+		CreatedAt: patient.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: patient.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// patientRow is in the order of PatientColumns.
+func patientRow(patient *models.Patient) []string {
+	return []string{
+		patient.ID,
+		patient.MRN,
+		patient.GivenName,
+		patient.FamilyName,
+		patient.PreferredName,
+		formatDate(patient.DateOfBirth),
+		strconv.FormatBool(patient.DateOfBirthEstimated),
+		patient.Gender,
+		patient.SexAtBirth,
+		patient.GenderIdentity,
+		patient.Email,
+		patient.Phone,
+		patient.PreferredLanguage,
+		patient.Address.Line1,
+		patient.Address.Line2,
+		patient.Address.City,
+		patient.Address.State,
+		patient.Address.PostalCode,
+		patient.Address.Country,
+		patient.EmergencyContact.Name,
+		patient.EmergencyContact.Relationship,
+		patient.EmergencyContact.Phone,
+		patient.CreatedAt.Format(time.RFC3339),
+		patient.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(time.DateOnly)
+}
@@ -0,0 +1,127 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format, expected csv, xlsx or ndjson")
+
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// ContentType is the media type of an export in format.
+func ContentType(format string) (string, error) {
+	contentType, ok := contentTypes[format]
+	if !ok {
+		return "", ErrUnsupportedFormat
+	}
+	return contentType, nil
+}
+
+// WriteCSV writes rows, header first, as CSV.
+func WriteCSV(w io.Writer, rows [][]string) error {
+	out := newCSVWriter(w)
+	for _, row := range rows {
+		if err := out.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
+
+type tableWriter interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{csv.NewWriter(w)}
+}
+
+func (w *csvWriter) WriteRow(values []string) error {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = escapeFormula(value)
+	}
+	return w.w.Write(escaped)
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// xlsxWriter streams rows into a single-sheet workbook. excelize keeps large
+// sheets in a temporary file, and the workbook is written out on Close.
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{out: w, file: file, stream: stream}, nil
+}
+
+func (w *xlsxWriter) WriteRow(values []string) error {
+	w.row++
+	cells := make([]any, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, cells)
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.file.Close()
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	return w.file.Write(w.out)
+}
+
+// escapeFormula stops spreadsheet applications from running a cell as a
+// formula. Phone numbers such as "+1 555 0100" are left alone.
+func escapeFormula(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '@', '\t', '\r':
+		return "'" + value
+	case '+', '-':
+		if strings.Trim(value[1:], "0123456789 ()-.") != "" {
+			return "'" + value
+		}
+	}
+	return value
+}
@@ -0,0 +1,100 @@
+package fhir
+
+import "time"
+
+type CapabilityInteraction struct {
+	Code string `json:"code"`
+}
+
+type CapabilitySearchParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type CapabilityResource struct {
+	Type        string                  `json:"type"`
+	Interaction []CapabilityInteraction `json:"interaction"`
+	SearchParam []CapabilitySearchParam `json:"searchParam,omitempty"`
+}
+
+type CapabilitySecurity struct {
+	Description string `json:"description"`
+}
+
+type CapabilityRest struct {
+	Mode     string               `json:"mode"`
+	Security *CapabilitySecurity  `json:"security,omitempty"`
+	Resource []CapabilityResource `json:"resource"`
+}
+
+type CapabilityImplementation struct {
+	Description string `json:"description"`
+	URL         string `json:"url,omitempty"`
+}
+
+type CapabilityStatement struct {
+	ResourceType   string                    `json:"resourceType"`
+	Status         string                    `json:"status"`
+	Date           string                    `json:"date"`
+	Kind           string                    `json:"kind"`
+	FHIRVersion    string                    `json:"fhirVersion"`
+	Format         []string                  `json:"format"`
+	Implementation *CapabilityImplementation `json:"implementation,omitempty"`
+	Rest           []CapabilityRest          `json:"rest"`
+}
+
+var PatientSearchParams = []CapabilitySearchParam{
+	{Name: "_id", Type: "token"},
+	{Name: "name", Type: "string"},
+	{Name: "birthdate", Type: "date"},
+	{Name: "gender", Type: "token"},
+	{Name: "identifier", Type: "token"},
+	{Name: "phone", Type: "token"},
+}
+
+var ObservationSearchParams = []CapabilitySearchParam{
+	{Name: "patient", Type: "reference"},
+	{Name: "subject", Type: "reference"},
+	{Name: "code", Type: "token"},
+}
+
+func NewCapabilityStatement(baseURL string) CapabilityStatement {
+	return CapabilityStatement{
+		ResourceType: "CapabilityStatement",
+		Status:       "active",
+		Date:         time.Now().Format(time.RFC3339),
+		Kind:         "instance",
+		FHIRVersion:  Version,
+		Format:       []string{"json"},
+		Implementation: &CapabilityImplementation{
+			Description: "Clinic FHIR R4 API",
+			URL:         baseURL,
+		},
+		Rest: []CapabilityRest{{
+			Mode: "server",
+			Security: &CapabilitySecurity{
+				Description: "Requests must carry a bearer token obtained from /api/login.",
+			},
+			Resource: []CapabilityResource{
+				{
+					Type: "Patient",
+					Interaction: []CapabilityInteraction{
+						{Code: "read"},
+						{Code: "search-type"},
+						{Code: "create"},
+						{Code: "update"},
+					},
+					SearchParam: PatientSearchParams,
+				},
+				{
+					Type: "Observation",
+					Interaction: []CapabilityInteraction{
+						{Code: "read"},
+						{Code: "search-type"},
+					},
+					SearchParam: ObservationSearchParams,
+				},
+			},
+		}},
+	}
+}
@@ -0,0 +1,71 @@
+package fhir
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+)
+
+var observationStatuses = map[string]string{
+	"F": "final",
+	"C": "corrected",
+	"P": "preliminary",
+	"R": "preliminary",
+	"S": "preliminary",
+	"I": "registered",
+	"X": "cancelled",
+	"D": "entered-in-error",
+	"W": "entered-in-error",
+}
+
+func FromLabResult(result *models.LabResult) Observation {
+	status, ok := observationStatuses[strings.ToUpper(result.ResultStatus)]
+	if !ok {
+		status = "unknown"
+	}
+
+	resource := Observation{
+		ResourceType: "Observation",
+		ID:           result.ID,
+		Meta:         &Meta{LastUpdated: result.CreatedAt.Format(time.RFC3339)},
+		Status:       status,
+		Category: []CodeableConcept{{Coding: []Coding{{
+			System: "http://terminology.hl7.org/CodeSystem/observation-category",
+			Code:   "laboratory",
+		}}}},
+		Code: CodeableConcept{
+			Coding: []Coding{{Code: result.TestCode, Display: result.TestName}},
+			Text:   result.TestName,
+		},
+		Subject: &Reference{Reference: "Patient/" + result.PatientID},
+	}
+
+	if result.OrderNumber != "" {
+		resource.Identifier = []Identifier{{Value: result.OrderNumber}}
+	}
+
+	if result.ObservedAt != nil {
+		resource.EffectiveDateTime = result.ObservedAt.Format(time.RFC3339)
+	}
+
+	if value, err := strconv.ParseFloat(result.Value, 64); err == nil && result.ValueType == "NM" {
+		resource.ValueQuantity = &Quantity{Value: &value, Unit: result.Units}
+	} else if result.Value != "" {
+		resource.ValueString = result.Value
+	}
+
+	if result.AbnormalFlag != "" {
+		resource.Interpretation = []CodeableConcept{{Coding: []Coding{{
+			System: "http://terminology.hl7.org/CodeSystem/v3-ObservationInterpretation",
+			Code:   result.AbnormalFlag,
+		}}}}
+	}
+
+	if result.ReferenceRange != "" {
+		resource.ReferenceRange = []ObservationReferenceRange{{Text: result.ReferenceRange}}
+	}
+
+	return resource
+}
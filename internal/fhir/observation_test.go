@@ -0,0 +1,67 @@
+package fhir
+
+import (
+	"testing"
+
+	"github.com/max-programming/clinic/internal/models"
+)
+
+func TestFromLabResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      models.LabResult
+		status      string
+		quantity    *float64
+		valueString string
+	}{
+		{
+			name:     "numeric final",
+			result:   models.LabResult{ResultStatus: "F", ValueType: "NM", Value: "5.4", Units: "mmol/L"},
+			status:   "final",
+			quantity: ptr(5.4),
+		},
+		{
+			name:        "numeric text is kept as a string",
+			result:      models.LabResult{ResultStatus: "c", ValueType: "ST", Value: "5.4"},
+			status:      "corrected",
+			valueString: "5.4",
+		},
+		{
+			name:        "unparseable number",
+			result:      models.LabResult{ResultStatus: "P", ValueType: "NM", Value: ">10"},
+			status:      "preliminary",
+			valueString: ">10",
+		},
+		{
+			name:   "unknown status",
+			result: models.LabResult{ResultStatus: "Z"},
+			status: "unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.result.PatientID = "p1"
+			got := FromLabResult(&tt.result)
+			if got.Status != tt.status {
+				t.Errorf("status = %q, want %q", got.Status, tt.status)
+			}
+			if got.Subject == nil || got.Subject.Reference != "Patient/p1" {
+				t.Errorf("subject = %+v, want Patient/p1", got.Subject)
+			}
+			switch {
+			case tt.quantity == nil && got.ValueQuantity != nil:
+				t.Errorf("valueQuantity = %+v, want none", got.ValueQuantity)
+			case tt.quantity != nil && (got.ValueQuantity == nil || *got.ValueQuantity.Value != *tt.quantity):
+				t.Errorf("valueQuantity = %+v, want %v", got.ValueQuantity, *tt.quantity)
+			}
+			if got.ValueString != tt.valueString {
+				t.Errorf("valueString = %q, want %q", got.ValueString, tt.valueString)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,349 @@
+package fhir
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+)
+
+const (
+	BirthSexExtension       = "http://hl7.org/fhir/us/core/StructureDefinition/us-core-birthsex"
+	GenderIdentityExtension = "http://hl7.org/fhir/StructureDefinition/patient-genderIdentity"
+	dateLayout              = "2006-01-02"
+)
+
+var ErrInvalidResource = errors.New("invalid resource")
+
+const (
+	identifierTypeSystem = "http://terminology.hl7.org/CodeSystem/v2-0203"
+	// IdentifierSystemPrefix is followed by the clinic identifier type, e.g.
+	// urn:clinic:identifier:national_id.
+	IdentifierSystemPrefix = "urn:clinic:identifier:"
+)
+
+var identifierTypeCodes = map[string]string{
+	models.IdentifierNationalID:        "NI",
+	models.IdentifierInsuranceMemberID: "MB",
+	models.IdentifierHospitalMRN:       "MR",
+}
+
+func FromPatientIdentifier(identifier *models.PatientIdentifier) Identifier {
+	result := Identifier{
+		Use:    "secondary",
+		System: IdentifierSystemPrefix + identifier.Type,
+		Value:  identifier.Value,
+	}
+	if code, ok := identifierTypeCodes[identifier.Type]; ok {
+		result.Type = &CodeableConcept{Coding: []Coding{{System: identifierTypeSystem, Code: code}}}
+	}
+	if identifier.Issuer != "" {
+		result.Assigner = &Reference{Display: identifier.Issuer}
+	}
+	return result
+}
+
+var birthSexCodes = map[string]string{
+	"male":     "M",
+	"female":   "F",
+	"intersex": "OTH",
+	"unknown":  "UNK",
+}
+
+func FromPatient(patient *models.Patient) Patient {
+	resource := Patient{
+		ResourceType: "Patient",
+		ID:           patient.ID,
+		Meta:         &Meta{LastUpdated: patient.UpdatedAt.Format(time.RFC3339)},
+		Gender:       fhirGender(patient.Gender),
+	}
+
+	// Merged patients are kept as inactive records pointing at the survivor.
+	if patient.MergedIntoID != nil {
+		active := false
+		resource.Active = &active
+		resource.Link = []PatientLink{{
+			Other: Reference{Reference: "Patient/" + *patient.MergedIntoID},
+			Type:  "replaced-by",
+		}}
+	}
+
+	if code, ok := birthSexCodes[patient.SexAtBirth]; ok {
+		resource.Extension = append(resource.Extension, Extension{URL: BirthSexExtension, ValueCode: code})
+	}
+	if patient.GenderIdentity != "" {
+		resource.Extension = append(resource.Extension, Extension{
+			URL:                  GenderIdentityExtension,
+			ValueCodeableConcept: &CodeableConcept{Text: patient.GenderIdentity},
+		})
+	}
+
+	resource.Identifier = append(resource.Identifier, Identifier{
+		Use: "usual",
+		Type: &CodeableConcept{Coding: []Coding{{
+			System: identifierTypeSystem,
+			Code:   "MR",
+		}}},
+		System: MRNSystem,
+		Value:  patient.MRN,
+	})
+	for _, identifier := range patient.Identifiers {
+		resource.Identifier = append(resource.Identifier, FromPatientIdentifier(&identifier))
+	}
+
+	name := HumanName{Use: "official", Text: patient.Name, Family: patient.FamilyName}
+	if patient.GivenName != "" {
+		name.Given = strings.Fields(patient.GivenName)
+	}
+	resource.Name = []HumanName{name}
+	if patient.PreferredName != "" {
+		resource.Name = append(resource.Name, HumanName{Use: "usual", Text: patient.PreferredName})
+	}
+
+	if patient.Phone != "" {
+		resource.Telecom = append(resource.Telecom, ContactPoint{System: "phone", Value: patient.Phone})
+	}
+	if patient.Email != "" {
+		resource.Telecom = append(resource.Telecom, ContactPoint{System: "email", Value: patient.Email})
+	}
+
+	if patient.DateOfBirth != nil {
+		resource.BirthDate = patient.DateOfBirth.Format(dateLayout)
+	}
+
+	if lines := patient.Address.Lines(); len(lines) > 0 {
+		address := Address{
+			Text:       strings.Join(lines, ", "),
+			City:       patient.Address.City,
+			State:      patient.Address.State,
+			PostalCode: patient.Address.PostalCode,
+			Country:    patient.Address.Country,
+		}
+		for _, line := range []string{patient.Address.Line1, patient.Address.Line2} {
+			if line != "" {
+				address.Line = append(address.Line, line)
+			}
+		}
+		resource.Address = []Address{address}
+	}
+
+	if contact := patient.EmergencyContact; contact.Name != "" || contact.Phone != "" {
+		entry := PatientContact{
+			Relationship: []CodeableConcept{{
+				Coding: []Coding{{System: "http://terminology.hl7.org/CodeSystem/v2-0131", Code: "C"}},
+				Text:   contact.Relationship,
+			}},
+		}
+		if contact.Name != "" {
+			entry.Name = &HumanName{Text: contact.Name}
+		}
+		if contact.Phone != "" {
+			entry.Telecom = []ContactPoint{{System: "phone", Value: contact.Phone}}
+		}
+		resource.Contact = []PatientContact{entry}
+	}
+
+	if patient.PreferredLanguage != "" {
+		resource.Communication = []PatientCommunication{{
+			Language: CodeableConcept{Coding: []Coding{{
+				System: "urn:ietf:bcp:47",
+				Code:   patient.PreferredLanguage,
+			}}},
+			Preferred: true,
+		}}
+	}
+
+	return resource
+}
+
+// ToPatient converts a FHIR Patient into the fields the clinic stores. The
+// MRN is not taken from the resource because it is assigned by the clinic.
+func ToPatient(resource *Patient) (*models.Patient, error) {
+	if resource.ResourceType != "Patient" {
+		return nil, fmt.Errorf("%w: resourceType must be Patient", ErrInvalidResource)
+	}
+
+	patient := &models.Patient{}
+
+	for _, name := range resource.Name {
+		switch name.Use {
+		case "usual", "nickname":
+			patient.PreferredName = name.Text
+		default:
+			if patient.GivenName == "" && patient.FamilyName == "" {
+				patient.GivenName = strings.Join(name.Given, " ")
+				patient.FamilyName = name.Family
+			}
+		}
+	}
+	if patient.GivenName == "" || patient.FamilyName == "" {
+		return nil, fmt.Errorf("%w: Patient.name must contain given and family names", ErrInvalidResource)
+	}
+	if len(patient.GivenName) > 50 || len(patient.FamilyName) > 50 {
+		return nil, fmt.Errorf("%w: Patient.name parts must be at most 50 characters", ErrInvalidResource)
+	}
+
+	gender, ok := clinicGender(resource.Gender)
+	if !ok {
+		return nil, fmt.Errorf("%w: Patient.gender must be one of male, female, other or unknown", ErrInvalidResource)
+	}
+	patient.Gender = gender
+
+	if resource.BirthDate == "" {
+		return nil, fmt.Errorf("%w: Patient.birthDate is required", ErrInvalidResource)
+	}
+	dob, err := time.Parse(dateLayout, resource.BirthDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: Patient.birthDate must be a full date (YYYY-MM-DD)", ErrInvalidResource)
+	}
+	patient.DateOfBirth = &dob
+
+	for _, extension := range resource.Extension {
+		switch extension.URL {
+		case BirthSexExtension:
+			for sex, code := range birthSexCodes {
+				if code == extension.ValueCode {
+					patient.SexAtBirth = sex
+				}
+			}
+		case GenderIdentityExtension:
+			if extension.ValueCodeableConcept != nil {
+				patient.GenderIdentity = extension.ValueCodeableConcept.Text
+			}
+		}
+	}
+
+	for _, telecom := range resource.Telecom {
+		switch telecom.System {
+		case "phone":
+			if patient.Phone == "" {
+				patient.Phone = telecom.Value
+			}
+		case "email":
+			if patient.Email == "" {
+				patient.Email = telecom.Value
+			}
+		}
+	}
+
+	if len(resource.Address) > 0 {
+		address := resource.Address[0]
+		patient.Address = models.Address{
+			City:       address.City,
+			State:      address.State,
+			PostalCode: address.PostalCode,
+			Country:    address.Country,
+		}
+		if len(address.Line) > 0 {
+			patient.Address.Line1 = address.Line[0]
+		}
+		if len(address.Line) > 1 {
+			patient.Address.Line2 = strings.Join(address.Line[1:], ", ")
+		}
+		if len(address.Line) == 0 && address.City == "" {
+			patient.Address.Line1 = address.Text
+		}
+	}
+
+	if len(resource.Contact) > 0 {
+		contact := resource.Contact[0]
+		if contact.Name != nil {
+			patient.EmergencyContact.Name = contact.Name.Text
+		}
+		if len(contact.Relationship) > 0 {
+			patient.EmergencyContact.Relationship = contact.Relationship[0].Text
+		}
+		for _, telecom := range contact.Telecom {
+			if telecom.System == "phone" {
+				patient.EmergencyContact.Phone = telecom.Value
+				break
+			}
+		}
+	}
+
+	for _, communication := range resource.Communication {
+		if len(communication.Language.Coding) > 0 && (communication.Preferred || patient.PreferredLanguage == "") {
+			patient.PreferredLanguage = communication.Language.Coding[0].Code
+		}
+	}
+
+	return patient, nil
+}
+
+// BirthDateRange converts a FHIR date search value such as "1985",
+// "ge1985-03" or "eq1985-03-14" into an inclusive lower and exclusive upper
+// bound. Either bound may be nil.
+func BirthDateRange(value string) (*time.Time, *time.Time, error) {
+	prefix := "eq"
+	if len(value) > 2 && value[0] >= 'a' && value[0] <= 'z' {
+		prefix, value = value[:2], value[2:]
+	}
+
+	var start, end time.Time
+	var err error
+	switch len(value) {
+	case 4:
+		start, err = time.Parse("2006", value)
+		end = start.AddDate(1, 0, 0)
+	case 7:
+		start, err = time.Parse("2006-01", value)
+		end = start.AddDate(0, 1, 0)
+	case 10:
+		start, err = time.Parse(dateLayout, value)
+		end = start.AddDate(0, 0, 1)
+	default:
+		err = errors.New("unsupported precision")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid birthdate %q", ErrInvalidResource, value)
+	}
+
+	switch prefix {
+	case "eq":
+		return &start, &end, nil
+	case "lt":
+		return nil, &start, nil
+	case "le":
+		return nil, &end, nil
+	case "gt":
+		return &end, nil, nil
+	case "ge":
+		return &start, nil, nil
+	}
+	return nil, nil, fmt.Errorf("%w: unsupported birthdate prefix %q", ErrInvalidResource, prefix)
+}
+
+func fhirGender(gender string) string {
+	switch strings.ToLower(gender) {
+	case "male":
+		return "male"
+	case "female":
+		return "female"
+	case "other":
+		return "other"
+	default:
+		return "unknown"
+	}
+}
+
+func clinicGender(gender string) (string, bool) {
+	switch gender {
+	case "male":
+		return "Male", true
+	case "female":
+		return "Female", true
+	case "other":
+		return "Other", true
+	case "unknown":
+		return "Unknown", true
+	}
+	return "", false
+}
+
+// ClinicGender maps a FHIR administrative gender search token to the value
+// stored on patients.
+func ClinicGender(gender string) (string, bool) {
+	return clinicGender(strings.ToLower(gender))
+}
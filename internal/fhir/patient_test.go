@@ -0,0 +1,262 @@
+package fhir
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+)
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestPatientRoundTrip(t *testing.T) {
+	original := &models.Patient{
+		ID:                "7d8f7a2e-3c39-4e0b-9f33-2f1d6f4c8a10",
+		MRN:               "MRN-000042",
+		Name:              "Jane Quinn Doe",
+		GivenName:         "Jane Quinn",
+		FamilyName:        "Doe",
+		PreferredName:     "JQ",
+		DateOfBirth:       date(1980, time.January, 1),
+		Gender:            "Female",
+		SexAtBirth:        "female",
+		GenderIdentity:    "woman",
+		Email:             "jane@example.com",
+		Phone:             "+44 113 496 0000",
+		PreferredLanguage: "en-GB",
+		Address: models.Address{
+			Line1:      "12 High St",
+			Line2:      "Flat 3",
+			City:       "Leeds",
+			State:      "West Yorkshire",
+			PostalCode: "LS1 1AA",
+			Country:    "GB",
+		},
+		EmergencyContact: models.EmergencyContact{
+			Name:         "John Doe",
+			Relationship: "spouse",
+			Phone:        "+44 113 496 0001",
+		},
+	}
+
+	resource := FromPatient(original)
+	if resource.Identifier[0].System != MRNSystem || resource.Identifier[0].Value != original.MRN {
+		t.Errorf("first identifier = %+v, want the MRN", resource.Identifier[0])
+	}
+	if resource.BirthDate != "1980-01-01" {
+		t.Errorf("birthDate = %q, want %q", resource.BirthDate, "1980-01-01")
+	}
+	if resource.Active != nil || resource.Link != nil {
+		t.Errorf("unmerged patient has active = %v, link = %v", resource.Active, resource.Link)
+	}
+
+	got, err := ToPatient(&resource)
+	if err != nil {
+		t.Fatalf("ToPatient() error = %v", err)
+	}
+
+	// The clinic assigns the ID, MRN and display name itself.
+	want := *original
+	want.ID, want.MRN, want.Name = "", "", ""
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("ToPatient(FromPatient()) =\n%+v\nwant\n%+v", *got, want)
+	}
+}
+
+func TestFromPatientMerged(t *testing.T) {
+	survivor := "0b7c1a7e-5c55-4f57-8d3f-0f3b9c1a2d44"
+	resource := FromPatient(&models.Patient{ID: "a", MRN: "MRN-000001", Gender: "Male", MergedIntoID: &survivor})
+
+	if resource.Active == nil || *resource.Active {
+		t.Errorf("active = %v, want false", resource.Active)
+	}
+	want := []PatientLink{{Other: Reference{Reference: "Patient/" + survivor}, Type: "replaced-by"}}
+	if !reflect.DeepEqual(resource.Link, want) {
+		t.Errorf("link = %+v, want %+v", resource.Link, want)
+	}
+}
+
+func TestFromPatientIdentifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier models.PatientIdentifier
+		code       string
+		assigner   string
+	}{
+		{
+			name:       "national id",
+			identifier: models.PatientIdentifier{Type: models.IdentifierNationalID, Issuer: "NHS", Value: "9434765919"},
+			code:       "NI",
+			assigner:   "NHS",
+		},
+		{
+			name:       "hospital mrn",
+			identifier: models.PatientIdentifier{Type: models.IdentifierHospitalMRN, Value: "H123"},
+			code:       "MR",
+		},
+		{
+			name:       "unknown type",
+			identifier: models.PatientIdentifier{Type: "passport", Value: "X1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromPatientIdentifier(&tt.identifier)
+			if got.System != IdentifierSystemPrefix+tt.identifier.Type || got.Value != tt.identifier.Value {
+				t.Errorf("system, value = %q, %q", got.System, got.Value)
+			}
+			code := ""
+			if got.Type != nil {
+				code = got.Type.Coding[0].Code
+			}
+			if code != tt.code {
+				t.Errorf("type code = %q, want %q", code, tt.code)
+			}
+			assigner := ""
+			if got.Assigner != nil {
+				assigner = got.Assigner.Display
+			}
+			if assigner != tt.assigner {
+				t.Errorf("assigner = %q, want %q", assigner, tt.assigner)
+			}
+		})
+	}
+}
+
+func TestToPatientValidation(t *testing.T) {
+	valid := func() Patient {
+		return Patient{
+			ResourceType: "Patient",
+			Name:         []HumanName{{Use: "official", Given: []string{"Jane"}, Family: "Doe"}},
+			Gender:       "female",
+			BirthDate:    "1980-01-01",
+		}
+	}
+	long := "Abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz"
+
+	tests := []struct {
+		name    string
+		modify  func(*Patient)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(*Patient) {}},
+		{name: "wrong resource type", modify: func(p *Patient) { p.ResourceType = "Practitioner" }, wantErr: true},
+		{name: "no name", modify: func(p *Patient) { p.Name = nil }, wantErr: true},
+		{name: "only a nickname", modify: func(p *Patient) { p.Name[0].Use = "nickname" }, wantErr: true},
+		{name: "no family name", modify: func(p *Patient) { p.Name[0].Family = "" }, wantErr: true},
+		{name: "family name too long", modify: func(p *Patient) { p.Name[0].Family = long }, wantErr: true},
+		{name: "unknown gender", modify: func(p *Patient) { p.Gender = "Female" }, wantErr: true},
+		{name: "no birth date", modify: func(p *Patient) { p.BirthDate = "" }, wantErr: true},
+		{name: "partial birth date", modify: func(p *Patient) { p.BirthDate = "1980-01" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := valid()
+			tt.modify(&resource)
+			_, err := ToPatient(&resource)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ToPatient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidResource) {
+				t.Errorf("ToPatient() error = %v, want ErrInvalidResource", err)
+			}
+		})
+	}
+}
+
+func TestToPatientAddressText(t *testing.T) {
+	resource := Patient{
+		ResourceType: "Patient",
+		Name:         []HumanName{{Given: []string{"Jane"}, Family: "Doe"}},
+		Gender:       "unknown",
+		BirthDate:    "1980-01-01",
+		Address:      []Address{{Text: "12 High St, Leeds"}},
+	}
+
+	got, err := ToPatient(&resource)
+	if err != nil {
+		t.Fatalf("ToPatient() error = %v", err)
+	}
+	if got.Address.Line1 != "12 High St, Leeds" {
+		t.Errorf("address line 1 = %q, want the address text", got.Address.Line1)
+	}
+}
+
+func TestBirthDateRange(t *testing.T) {
+	tests := []struct {
+		value   string
+		start   *time.Time
+		end     *time.Time
+		wantErr bool
+	}{
+		{value: "1985", start: date(1985, time.January, 1), end: date(1986, time.January, 1)},
+		{value: "1985-03", start: date(1985, time.March, 1), end: date(1985, time.April, 1)},
+		{value: "eq1985-03-14", start: date(1985, time.March, 14), end: date(1985, time.March, 15)},
+		{value: "lt1985-03", end: date(1985, time.March, 1)},
+		{value: "le1985-03", end: date(1985, time.April, 1)},
+		{value: "gt1985", start: date(1986, time.January, 1)},
+		{value: "ge1985-03-14", start: date(1985, time.March, 14)},
+		{value: "ne1985", wantErr: true},
+		{value: "85", wantErr: true},
+		{value: "1985-3-14", wantErr: true},
+		{value: "1985-13", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			start, end, err := BirthDateRange(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidResource) {
+					t.Fatalf("BirthDateRange() error = %v, want ErrInvalidResource", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BirthDateRange() error = %v", err)
+			}
+			if !reflect.DeepEqual(start, tt.start) {
+				t.Errorf("start = %v, want %v", start, tt.start)
+			}
+			if !reflect.DeepEqual(end, tt.end) {
+				t.Errorf("end = %v, want %v", end, tt.end)
+			}
+		})
+	}
+}
+
+func TestGenderMapping(t *testing.T) {
+	tests := []struct {
+		clinic string
+		fhir   string
+	}{
+		{clinic: "Male", fhir: "male"},
+		{clinic: "Female", fhir: "female"},
+		{clinic: "Other", fhir: "other"},
+		{clinic: "Unknown", fhir: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.clinic, func(t *testing.T) {
+			if got := fhirGender(tt.clinic); got != tt.fhir {
+				t.Errorf("fhirGender(%q) = %q, want %q", tt.clinic, got, tt.fhir)
+			}
+			if got, ok := ClinicGender(tt.fhir); !ok || got != tt.clinic {
+				t.Errorf("ClinicGender(%q) = %q, %v, want %q", tt.fhir, got, ok, tt.clinic)
+			}
+		})
+	}
+
+	if got := fhirGender(""); got != "unknown" {
+		t.Errorf("fhirGender(\"\") = %q, want unknown", got)
+	}
+	if _, ok := ClinicGender("nonbinary"); ok {
+		t.Error("ClinicGender(\"nonbinary\") ok = true, want false")
+	}
+}
@@ -0,0 +1,176 @@
+package fhir
+
+const (
+	Version     = "4.0.1"
+	ContentType = "application/fhir+json"
+	MRNSystem   = "urn:clinic:mrn"
+)
+
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Identifier struct {
+	Use      string           `json:"use,omitempty"`
+	Type     *CodeableConcept `json:"type,omitempty"`
+	System   string           `json:"system,omitempty"`
+	Value    string           `json:"value,omitempty"`
+	Assigner *Reference       `json:"assigner,omitempty"`
+}
+
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type ContactPoint struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Use    string `json:"use,omitempty"`
+}
+
+type Address struct {
+	Use        string   `json:"use,omitempty"`
+	Text       string   `json:"text,omitempty"`
+	Line       []string `json:"line,omitempty"`
+	City       string   `json:"city,omitempty"`
+	State      string   `json:"state,omitempty"`
+	PostalCode string   `json:"postalCode,omitempty"`
+	Country    string   `json:"country,omitempty"`
+}
+
+type Extension struct {
+	URL                  string           `json:"url"`
+	ValueCode            string           `json:"valueCode,omitempty"`
+	ValueCodeableConcept *CodeableConcept `json:"valueCodeableConcept,omitempty"`
+}
+
+type PatientContact struct {
+	Relationship []CodeableConcept `json:"relationship,omitempty"`
+	Name         *HumanName        `json:"name,omitempty"`
+	Telecom      []ContactPoint    `json:"telecom,omitempty"`
+}
+
+type PatientCommunication struct {
+	Language  CodeableConcept `json:"language"`
+	Preferred bool            `json:"preferred,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Quantity struct {
+	Value *float64 `json:"value,omitempty"`
+	Unit  string   `json:"unit,omitempty"`
+}
+
+type Patient struct {
+	ResourceType  string                 `json:"resourceType"`
+	ID            string                 `json:"id,omitempty"`
+	Meta          *Meta                  `json:"meta,omitempty"`
+	Extension     []Extension            `json:"extension,omitempty"`
+	Identifier    []Identifier           `json:"identifier,omitempty"`
+	Active        *bool                  `json:"active,omitempty"`
+	Name          []HumanName            `json:"name,omitempty"`
+	Telecom       []ContactPoint         `json:"telecom,omitempty"`
+	Gender        string                 `json:"gender,omitempty"`
+	BirthDate     string                 `json:"birthDate,omitempty"`
+	Address       []Address              `json:"address,omitempty"`
+	Contact       []PatientContact       `json:"contact,omitempty"`
+	Communication []PatientCommunication `json:"communication,omitempty"`
+	Link          []PatientLink          `json:"link,omitempty"`
+}
+
+type PatientLink struct {
+	Other Reference `json:"other"`
+	Type  string    `json:"type"`
+}
+
+type ObservationReferenceRange struct {
+	Text string `json:"text,omitempty"`
+}
+
+type Observation struct {
+	ResourceType      string                      `json:"resourceType"`
+	ID                string                      `json:"id,omitempty"`
+	Meta              *Meta                       `json:"meta,omitempty"`
+	Identifier        []Identifier                `json:"identifier,omitempty"`
+	Status            string                      `json:"status"`
+	Category          []CodeableConcept           `json:"category,omitempty"`
+	Code              CodeableConcept             `json:"code"`
+	Subject           *Reference                  `json:"subject,omitempty"`
+	EffectiveDateTime string                      `json:"effectiveDateTime,omitempty"`
+	ValueQuantity     *Quantity                   `json:"valueQuantity,omitempty"`
+	ValueString       string                      `json:"valueString,omitempty"`
+	Interpretation    []CodeableConcept           `json:"interpretation,omitempty"`
+	ReferenceRange    []ObservationReferenceRange `json:"referenceRange,omitempty"`
+}
+
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+type BundleSearch struct {
+	Mode string `json:"mode"`
+}
+
+type BundleEntry struct {
+	FullURL  string        `json:"fullUrl,omitempty"`
+	Resource any           `json:"resource"`
+	Search   *BundleSearch `json:"search,omitempty"`
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Total        int64         `json:"total"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+func NewOperationOutcome(code, diagnostics string) OperationOutcome {
+	return OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue: []OperationOutcomeIssue{{
+			Severity:    "error",
+			Code:        code,
+			Diagnostics: diagnostics,
+		}},
+	}
+}
+
+func NewSearchBundle(total int64) Bundle {
+	return Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Total:        total,
+		Entry:        []BundleEntry{},
+	}
+}
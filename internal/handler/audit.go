@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+)
+
+type AuditHandler struct {
+	service service.AuditService
+}
+
+func NewAuditHandler(service service.AuditService) *AuditHandler {
+	return &AuditHandler{service}
+}
+
+type auditLogsQuery struct {
+	dto.PageQuery
+	UserID     string `form:"userId"`
+	Action     string `form:"action"`
+	EntityType string `form:"entityType"`
+	EntityID   string `form:"entityId"`
+}
+
+// @Summary Get audit logs
+// @Description Get audit log entries, newest first
+// @Tags admin
+// @Produce json
+// @Param userId query string false "User ID"
+// @Param action query string false "Action, e.g. patient.merge"
+// @Param entityType query string false "Entity type"
+// @Param entityId query string false "Entity ID"
+// @Param limit query int false "Page size" default(50)
+// @Param offset query int false "Page offset"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.GetAuditLogsResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/audit-logs [get]
+// @Security BearerAuth
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	var query auditLogsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	entries, total, err := h.service.GetAuditLogs(c.Request.Context(), repository.AuditLogFilter{
+		UserID:     query.UserID,
+		Action:     query.Action,
+		EntityType: query.EntityType,
+		EntityID:   query.EntityID,
+		Limit:      query.LimitOrDefault(),
+		Offset:     query.Offset,
+	})
+	if err != nil {
+		c.JSON(serverErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.AuditLogResponse, len(entries))
+	for i, entry := range entries {
+		responses[i] = dto.AuditLogResponse{
+			ID:         entry.ID,
+			UserID:     derefString(entry.UserID),
+			Action:     entry.Action,
+			EntityType: entry.EntityType,
+			EntityID:   entry.EntityID,
+			Details:    entry.Details,
+			CreatedAt:  entry.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.GetAuditLogsResponse{Entries: responses, Total: total}))
+}
@@ -28,6 +28,8 @@ func NewAuthHandler(service service.UserService) *AuthHandler {
 // @Success 201 {object} utils.SuccessAPIResponse[dto.RegisterUserResponse]
 // @Failure 400 {object} utils.ErrorAPIResponse
 // @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
 // @Router /register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req dto.RegisterUserRequest
@@ -42,8 +44,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Role:     req.Role,
 	}
 
-	if err := h.service.RegisterUser(&user); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+	if err := h.service.RegisterUser(c.Request.Context(), &user); err != nil {
+		c.JSON(userErrorStatus(err, http.StatusInternalServerError), utils.NewErrorAPIResponse(err.Error()))
 		return
 	}
 
@@ -64,6 +66,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Failure 400 {object} utils.ErrorAPIResponse
 // @Failure 401 {object} utils.ErrorAPIResponse
 // @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
 // @Router /login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req dto.LoginUserRequest
@@ -72,9 +76,9 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.service.LoginUser(req.Username, req.Password)
+	token, err := h.service.LoginUser(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, utils.NewErrorAPIResponse(err.Error()))
+		c.JSON(userErrorStatus(err, http.StatusUnauthorized), utils.NewErrorAPIResponse(err.Error()))
 		return
 	}
 
@@ -109,3 +113,12 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		Role:     authUser.Role,
 	}))
 }
+
+// userErrorStatus is fallback unless the request ran out of time or was
+// canceled.
+func userErrorStatus(err error, fallback int) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	return fallback
+}
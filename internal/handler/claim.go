@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/edi"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type ClaimHandler struct {
+	service service.ClaimService
+}
+
+func NewClaimHandler(service service.ClaimService) *ClaimHandler {
+	return &ClaimHandler{service}
+}
+
+type claimsQuery struct {
+	dto.PageQuery
+	InvoiceID string `form:"invoiceId" binding:"omitempty,uuid"`
+	PatientID string `form:"patientId" binding:"omitempty,uuid"`
+	Status    string `form:"status" binding:"omitempty,oneof=draft submitted accepted rejected paid"`
+}
+
+// @Summary Get claims
+// @Description Get insurance claims, newest first
+// @Tags insurance
+// @Produce json
+// @Param invoiceId query string false "Invoice ID"
+// @Param patientId query string false "Patient ID"
+// @Param status query string false "Claim status" Enums(draft, submitted, accepted, rejected, paid)
+// @Param limit query int false "Page size" default(50)
+// @Param offset query int false "Page offset"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.ClaimResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /claims [get]
+// @Security BearerAuth
+func (h *ClaimHandler) GetClaims(c *gin.Context) {
+	var query claimsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	claims, _, err := h.service.SearchClaims(c.Request.Context(), repository.ClaimFilter{
+		InvoiceID: query.InvoiceID,
+		PatientID: query.PatientID,
+		Status:    query.Status,
+		Limit:     query.LimitOrDefault(),
+		Offset:    query.Offset,
+	})
+	if err != nil {
+		c.JSON(claimErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.ClaimResponse, len(claims))
+	for i, claim := range claims {
+		responses[i] = toClaimResponse(claim)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Get a claim by ID
+// @Description Get an insurance claim
+// @Tags insurance
+// @Produce json
+// @Param id path string true "Claim ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.ClaimResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /claims/{id} [get]
+// @Security BearerAuth
+func (h *ClaimHandler) GetClaimByID(c *gin.Context) {
+	claim, err := h.service.GetClaimByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(claimErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toClaimResponse(claim)))
+}
+
+// @Summary Create a claim
+// @Description Draft a claim for an issued invoice against one of the patient's policies. Every invoice line must come from the fee catalog; the fee code is billed as the procedure code.
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateClaimRequest true "Create Claim Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.ClaimResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /claims [post]
+// @Security BearerAuth
+func (h *ClaimHandler) CreateClaim(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.CreateClaimRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	claim, err := h.service.CreateClaim(c.Request.Context(), body.InvoiceID, body.PolicyID, body.DiagnosisCodes, body.Notes, authUser.ID)
+	if err != nil {
+		c.JSON(claimErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(toClaimResponse(claim)))
+}
+
+// @Summary Update a draft claim
+// @Description Replace the diagnosis codes and notes of a draft claim
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param id path string true "Claim ID"
+// @Param body body dto.UpdateClaimRequest true "Update Claim Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.ClaimResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /claims/{id} [put]
+// @Security BearerAuth
+func (h *ClaimHandler) UpdateClaim(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.UpdateClaimRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	claim, err := h.service.UpdateClaim(c.Request.Context(), c.Param("id"), body.DiagnosisCodes, body.Notes, authUser.ID)
+	if err != nil {
+		c.JSON(claimErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toClaimResponse(claim)))
+}
+
+// @Summary Change a claim's status
+// @Description Record the payer's response to a claim. Drafts are submitted, submitted claims are accepted or rejected, accepted claims are paid, and rejected claims go back to draft to be corrected.
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param id path string true "Claim ID"
+// @Param body body dto.ClaimStatusRequest true "Claim Status Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.ClaimResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /claims/{id}/status [post]
+// @Security BearerAuth
+func (h *ClaimHandler) ChangeClaimStatus(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.ClaimStatusRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	claim, err := h.service.ChangeStatus(c.Request.Context(), c.Param("id"), service.ClaimStatusChange{
+		Status:           body.Status,
+		PayerClaimNumber: body.PayerClaimNumber,
+		PaidAmount:       body.PaidAmount,
+		Reason:           body.Reason,
+	}, authUser.ID)
+	if err != nil {
+		c.JSON(claimErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toClaimResponse(claim)))
+}
+
+// @Summary Delete a draft claim
+// @Description Delete a claim that has not been submitted
+// @Tags insurance
+// @Produce json
+// @Param id path string true "Claim ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.DeleteClaimResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /claims/{id} [delete]
+// @Security BearerAuth
+func (h *ClaimHandler) DeleteClaim(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service.DeleteClaim(c.Request.Context(), id); err != nil {
+		c.JSON(claimErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.DeleteClaimResponse{ID: id}))
+}
+
+// @Summary Export claims as EDI 837P
+// @Description Write claims to an ANSI X12 837 Professional (005010X222A1) file. Draft claims are marked as submitted; submitted claims can be exported again.
+// @Tags insurance
+// @Accept json
+// @Produce plain
+// @Param body body dto.ExportClaimsRequest true "Export Claims Request"
+// @Success 200 {string} string "837P interchange"
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /claims/export [post]
+// @Security BearerAuth
+func (h *ClaimHandler) ExportClaims(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.ExportClaimsRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	export, err := h.service.ExportClaims(c.Request.Context(), body.ClaimIDs, authUser.ID)
+	if err != nil {
+		c.JSON(claimErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	filename := fmt.Sprintf("837P-%09d.x12", export.ControlNumber)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/edi-x12", export.Data)
+}
+
+func claimErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidClaim), errors.Is(err, edi.ErrInvalidClaim):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrDuplicateClaim),
+		errors.Is(err, service.ErrInvalidClaimTransition),
+		errors.Is(err, repository.ErrClaimStatusChanged):
+		return http.StatusConflict
+	case errors.Is(err, edi.ErrNotConfigured):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func toClaimResponse(claim *models.Claim) dto.ClaimResponse {
+	response := dto.ClaimResponse{
+		ID:               claim.ID,
+		ClaimNumber:      claim.ClaimNumber,
+		InvoiceID:        claim.InvoiceID,
+		PolicyID:         claim.PolicyID,
+		Status:           claim.Status,
+		DiagnosisCodes:   strings.Split(claim.DiagnosisCodes, ","),
+		TotalCharge:      formatDecimal(claim.TotalCharge),
+		ExpectedAmount:   formatDecimal(claim.ExpectedAmount),
+		PayerClaimNumber: claim.PayerClaimNumber,
+		RejectionReason:  claim.RejectionReason,
+		Notes:            claim.Notes,
+		SubmittedAt:      formatTime(claim.SubmittedAt),
+		AdjudicatedAt:    formatTime(claim.AdjudicatedAt),
+		CreatedAt:        claim.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        claim.UpdatedAt.Format(time.RFC3339),
+	}
+	if claim.PaidAmount != nil {
+		response.PaidAmount = formatDecimal(*claim.PaidAmount)
+	}
+	if claim.InterchangeControlNumber != nil {
+		response.InterchangeControlNumber = *claim.InterchangeControlNumber
+	}
+	if invoice := claim.Invoice; invoice != nil {
+		response.InvoiceNumber = derefString(invoice.Number)
+		response.PatientID = invoice.PatientID
+		if invoice.Patient != nil {
+			response.PatientName = invoice.Patient.Name
+		}
+	}
+	if policy := claim.Policy; policy != nil {
+		response.PayerName = policy.PayerName
+		response.MemberID = policy.MemberID
+	}
+	return response
+}
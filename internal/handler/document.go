@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/pdf"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type DocumentHandler struct {
+	patientService      service.PatientService
+	prescriptionService service.PrescriptionService
+	invoiceService      service.InvoiceService
+	renderer            *pdf.Renderer
+}
+
+func NewDocumentHandler(
+	patientService service.PatientService,
+	prescriptionService service.PrescriptionService,
+	invoiceService service.InvoiceService,
+	renderer *pdf.Renderer,
+) *DocumentHandler {
+	return &DocumentHandler{patientService, prescriptionService, invoiceService, renderer}
+}
+
+// @Summary Print a patient summary
+// @Description Render a PDF summary of a patient's record with their prescriptions and invoices
+// @Tags printing
+// @Produce application/pdf
+// @Param id path string true "Patient ID"
+// @Success 200 {file} file "Patient summary"
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/summary.pdf [get]
+// @Security BearerAuth
+func (h *DocumentHandler) GetPatientSummary(c *gin.Context) {
+	patient, createdBy, updatedBy, err := h.patientService.GetPatientByIDWithUsers(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(documentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	prescriptions, err := h.prescriptionService.GetPatientPrescriptions(c.Request.Context(), patient.ID)
+	if err != nil {
+		c.JSON(documentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	invoices, _, err := h.invoiceService.SearchInvoices(c.Request.Context(), repository.InvoiceFilter{PatientID: patient.ID})
+	if err != nil {
+		c.JSON(documentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	data, err := h.renderer.PatientSummary(pdf.PatientSummary{
+		Patient:       patient,
+		CreatedBy:     createdBy,
+		UpdatedBy:     updatedBy,
+		Prescriptions: prescriptions,
+		Invoices:      invoices,
+	}, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	writePDF(c, fmt.Sprintf("patient-summary-%s.pdf", patient.MRN), data)
+}
+
+// @Summary Print an invoice
+// @Description Render an invoice as PDF. Drafts and void invoices are marked as such.
+// @Tags printing
+// @Produce application/pdf
+// @Param id path string true "Invoice ID"
+// @Success 200 {file} file "Invoice"
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices/{id}/invoice.pdf [get]
+// @Security BearerAuth
+func (h *DocumentHandler) GetInvoicePDF(c *gin.Context) {
+	invoice, err := h.invoiceService.GetInvoiceByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(documentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	data, err := h.renderer.Invoice(invoice, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	name := "invoice-draft.pdf"
+	if invoice.Number != nil {
+		name = fmt.Sprintf("invoice-%s.pdf", *invoice.Number)
+	}
+	writePDF(c, name, data)
+}
+
+// @Summary Print a prescription
+// @Description Render a prescription as PDF with a line for the prescriber's signature
+// @Tags printing
+// @Produce application/pdf
+// @Param id path string true "Prescription ID"
+// @Success 200 {file} file "Prescription"
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /prescriptions/{id}/prescription.pdf [get]
+// @Security BearerAuth
+func (h *DocumentHandler) GetPrescriptionPDF(c *gin.Context) {
+	prescription, err := h.prescriptionService.GetPrescriptionByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(documentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	data, err := h.renderer.Prescription(prescription, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	writePDF(c, fmt.Sprintf("prescription-%s.pdf", prescription.CreatedAt.Format("2006-01-02")), data)
+}
+
+func documentErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func writePDF(c *gin.Context, filename string, data []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/pdf", data)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type DuplicateHandler struct {
+	service service.DuplicateService
+}
+
+func NewDuplicateHandler(service service.DuplicateService) *DuplicateHandler {
+	return &DuplicateHandler{service}
+}
+
+type duplicateCandidatesQuery struct {
+	dto.PageQuery
+	Status string `form:"status" binding:"omitempty,oneof=open dismissed merged"`
+}
+
+// @Summary Get duplicate patient candidates
+// @Description Get pairs of patients reported as probable duplicates, highest score first
+// @Tags admin
+// @Produce json
+// @Param status query string false "Candidate status" Enums(open, dismissed, merged) default(open)
+// @Param limit query int false "Page size" default(50)
+// @Param offset query int false "Page offset"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.DuplicateCandidateResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/duplicates [get]
+// @Security BearerAuth
+func (h *DuplicateHandler) GetCandidates(c *gin.Context) {
+	query := duplicateCandidatesQuery{Status: models.DuplicateStatusOpen}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	candidates, _, err := h.service.GetCandidates(c.Request.Context(), query.Status, query.LimitOrDefault(), query.Offset)
+	if err != nil {
+		c.JSON(serverErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.DuplicateCandidateResponse, len(candidates))
+	for i, candidate := range candidates {
+		responses[i] = toDuplicateCandidateResponse(candidate)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Scan for duplicate patients
+// @Description Run the duplicate patient scan now instead of waiting for the background job
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.SuccessAPIResponse[dto.ScanDuplicatesResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/duplicates/scan [post]
+// @Security BearerAuth
+func (h *DuplicateHandler) ScanDuplicates(c *gin.Context) {
+	found, err := h.service.ScanDuplicates(c.Request.Context())
+	if err != nil {
+		c.JSON(serverErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.ScanDuplicatesResponse{Found: found}))
+}
+
+// @Summary Dismiss a duplicate candidate
+// @Description Mark a reported pair as not being the same person
+// @Tags admin
+// @Produce json
+// @Param id path string true "Candidate ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.DuplicateCandidateResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/duplicates/{id}/dismiss [post]
+// @Security BearerAuth
+func (h *DuplicateHandler) DismissCandidate(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	candidate, err := h.service.DismissCandidate(c.Request.Context(), c.Param("id"), authUser.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, utils.NewErrorAPIResponse("duplicate candidate not found"))
+			return
+		}
+		c.JSON(serverErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toDuplicateCandidateResponse(candidate)))
+}
+
+func toDuplicateCandidateResponse(candidate *models.DuplicateCandidate) dto.DuplicateCandidateResponse {
+	now := time.Now()
+	return dto.DuplicateCandidateResponse{
+		ID:               candidate.ID,
+		Patient:          toPatientListResponse(candidate.Patient, now),
+		DuplicatePatient: toPatientListResponse(candidate.DuplicatePatient, now),
+		Score:            candidate.Score,
+		Reasons:          strings.Split(candidate.Reasons, ", "),
+		Status:           candidate.Status,
+		DetectedAt:       candidate.DetectedAt.Format(time.RFC3339),
+	}
+}
+
+func toDuplicateMatchResponses(matches []service.DuplicateMatch) []dto.DuplicateMatchResponse {
+	now := time.Now()
+	responses := make([]dto.DuplicateMatchResponse, len(matches))
+	for i, match := range matches {
+		responses[i] = dto.DuplicateMatchResponse{
+			Patient: toPatientListResponse(match.Patient, now),
+			Score:   match.Score,
+			Reasons: match.Reasons,
+		}
+	}
+	return responses
+}
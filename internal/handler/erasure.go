@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type ErasureHandler struct {
+	service service.ErasureService
+}
+
+func NewErasureHandler(service service.ErasureService) *ErasureHandler {
+	return &ErasureHandler{service}
+}
+
+type erasureRequestsQuery struct {
+	dto.PageQuery
+	Status string `form:"status" binding:"omitempty,oneof=pending completed cancelled"`
+}
+
+// @Summary Request erasure of a patient
+// @Description Open a request to anonymize a patient. The request is refused with the reasons while the patient is on legal hold, owes money or has claims with a payer.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param body body dto.ErasureRequestBody true "Erasure Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.ErasureRequestResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} dto.ErasureHoldErrorResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/patients/{id}/erasure-requests [post]
+// @Security BearerAuth
+func (h *ErasureHandler) RequestErasure(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.ErasureRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	request, err := h.service.RequestErasure(c.Request.Context(), c.Param("id"), body.Reason, authUser.ID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(toErasureRequestResponse(request)))
+}
+
+// @Summary Get erasure requests
+// @Description List erasure requests, newest first
+// @Tags admin
+// @Produce json
+// @Param status query string false "Status" Enums(pending, completed, cancelled)
+// @Param limit query int false "Page size" default(50)
+// @Param offset query int false "Page offset"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.GetErasureRequestsResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/erasure-requests [get]
+// @Security BearerAuth
+func (h *ErasureHandler) GetRequests(c *gin.Context) {
+	var query erasureRequestsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	requests, total, err := h.service.GetRequests(c.Request.Context(), query.Status, query.LimitOrDefault(), query.Offset)
+	if err != nil {
+		c.JSON(erasureErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.ErasureRequestResponse, len(requests))
+	for i, request := range requests {
+		responses[i] = toErasureRequestResponse(request)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.GetErasureRequestsResponse{Requests: responses, Total: total}))
+}
+
+// @Summary Get an erasure request
+// @Tags admin
+// @Produce json
+// @Param id path string true "Erasure request ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.ErasureRequestResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/erasure-requests/{id} [get]
+// @Security BearerAuth
+func (h *ErasureHandler) GetRequest(c *gin.Context) {
+	request, err := h.service.GetRequest(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toErasureRequestResponse(request)))
+}
+
+// @Summary Cancel an erasure request
+// @Tags admin
+// @Produce json
+// @Param id path string true "Erasure request ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.ErasureRequestResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/erasure-requests/{id}/cancel [post]
+// @Security BearerAuth
+func (h *ErasureHandler) CancelRequest(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	request, err := h.service.CancelRequest(c.Request.Context(), c.Param("id"), authUser.ID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toErasureRequestResponse(request)))
+}
+
+// @Summary Erase a patient
+// @Description Irreversibly anonymize the patient of a pending request and issue a signed erasure certificate. Legal holds are checked again first. IDs, gender, birth year, region, clinical codes and all amounts are kept for statistics and accounts; names, contact details, identifiers, notes and other free text, documents and identifying HL7 segments are removed, including from merged duplicates.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Erasure request ID"
+// @Param body body dto.ExecuteErasureRequest true "Confirmation"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.ErasureRequestResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} dto.ErasureHoldErrorResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/erasure-requests/{id}/execute [post]
+// @Security BearerAuth
+func (h *ErasureHandler) Erase(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.ExecuteErasureRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	request, err := h.service.Erase(c.Request.Context(), c.Param("id"), body.ConfirmMRN, authUser.ID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toErasureRequestResponse(request)))
+}
+
+// @Summary Get an erasure certificate
+// @Description Get the signed certificate of a completed erasure, with the public key to check it against
+// @Tags admin
+// @Produce json
+// @Param id path string true "Erasure request ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.ErasureCertificateResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/erasure-requests/{id}/certificate [get]
+// @Security BearerAuth
+func (h *ErasureHandler) GetCertificate(c *gin.Context) {
+	request, err := h.service.GetRequest(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	if request.Status != models.ErasureStatusCompleted {
+		c.JSON(http.StatusConflict, utils.NewErrorAPIResponse("erasure has not been carried out"))
+		return
+	}
+
+	publicKey, valid := h.service.VerifyCertificate(c.Request.Context(), request)
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.ErasureCertificateResponse{
+		Certificate:  json.RawMessage(request.Certificate),
+		Signature:    request.Signature,
+		Algorithm:    "Ed25519",
+		SigningKeyID: request.SigningKeyID,
+		PublicKey:    publicKey,
+		Valid:        valid,
+	}))
+}
+
+// @Summary Get a patient's legal holds
+// @Description Get current and released legal holds, newest first
+// @Tags admin
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.LegalHoldResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/patients/{id}/legal-holds [get]
+// @Security BearerAuth
+func (h *ErasureHandler) GetHolds(c *gin.Context) {
+	holds, err := h.service.GetHolds(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(erasureErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.LegalHoldResponse, len(holds))
+	for i, hold := range holds {
+		responses[i] = toLegalHoldResponse(hold)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Place a legal hold
+// @Description Stop a patient from being erased until the hold is released
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param body body dto.LegalHoldRequest true "Legal Hold Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.LegalHoldResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/patients/{id}/legal-holds [post]
+// @Security BearerAuth
+func (h *ErasureHandler) PlaceHold(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.LegalHoldRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	hold, err := h.service.PlaceHold(c.Request.Context(), c.Param("id"), body.Reason, authUser.ID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(toLegalHoldResponse(hold)))
+}
+
+// @Summary Release a legal hold
+// @Tags admin
+// @Produce json
+// @Param id path string true "Legal hold ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.LegalHoldResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/legal-holds/{id}/release [post]
+// @Security BearerAuth
+func (h *ErasureHandler) ReleaseHold(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	hold, err := h.service.ReleaseHold(c.Request.Context(), c.Param("id"), authUser.ID)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toLegalHoldResponse(hold)))
+}
+
+// error answers holds with their reasons and other errors with
+// erasureErrorStatus.
+func (h *ErasureHandler) error(c *gin.Context, err error) {
+	var holdErr *repository.HoldError
+	if errors.As(err, &holdErr) {
+		c.JSON(http.StatusConflict, dto.ErasureHoldErrorResponse{
+			Success: false,
+			Error:   repository.ErrPatientOnHold.Error(),
+			Reasons: holdErr.Reasons,
+		})
+		return
+	}
+	c.JSON(erasureErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+}
+
+func erasureErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidErasure):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrPatientErased),
+		errors.Is(err, repository.ErrPatientAlreadyMerged),
+		errors.Is(err, repository.ErrErasureNotPending),
+		errors.Is(err, repository.ErrErasureRequestExists),
+		errors.Is(err, repository.ErrLegalHoldReleased):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrErasureNotConfigured):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func toErasureRequestResponse(request *models.ErasureRequest) dto.ErasureRequestResponse {
+	response := dto.ErasureRequestResponse{
+		ID:          request.ID,
+		PatientID:   request.PatientID,
+		Reason:      request.Reason,
+		Status:      request.Status,
+		CreatedAt:   request.CreatedAt.Format(time.RFC3339),
+		CompletedBy: derefString(request.CompletedBy),
+		CompletedAt: formatTime(request.CompletedAt),
+		CancelledBy: derefString(request.CancelledBy),
+		CancelledAt: formatTime(request.CancelledAt),
+	}
+	if patient := request.Patient; patient != nil {
+		response.PatientMRN = patient.MRN
+		response.PatientName = patient.Name
+	}
+	if requester := request.Requester; requester != nil {
+		response.RequestedBy = dto.PatientUser{
+			ID:       requester.ID,
+			Username: requester.Username,
+			Role:     requester.Role,
+		}
+	}
+	return response
+}
+
+func toLegalHoldResponse(hold *models.LegalHold) dto.LegalHoldResponse {
+	response := dto.LegalHoldResponse{
+		ID:         hold.ID,
+		PatientID:  hold.PatientID,
+		Reason:     hold.Reason,
+		CreatedAt:  hold.CreatedAt.Format(time.RFC3339),
+		ReleasedAt: formatTime(hold.ReleasedAt),
+	}
+	if placer := hold.Placer; placer != nil {
+		response.PlacedBy = dto.PatientUser{
+			ID:       placer.ID,
+			Username: placer.Username,
+			Role:     placer.Role,
+		}
+	}
+	if releaser := hold.Releaser; releaser != nil {
+		response.ReleasedBy = &dto.PatientUser{
+			ID:       releaser.ID,
+			Username: releaser.Username,
+			Role:     releaser.Role,
+		}
+	}
+	return response
+}
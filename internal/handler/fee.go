@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type FeeHandler struct {
+	service service.FeeService
+}
+
+func NewFeeHandler(service service.FeeService) *FeeHandler {
+	return &FeeHandler{service}
+}
+
+// @Summary Get the fee catalog
+// @Description Get billable services with their prices and tax rates
+// @Tags billing
+// @Produce json
+// @Param includeInactive query bool false "Include inactive fees"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.FeeResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /fees [get]
+// @Security BearerAuth
+func (h *FeeHandler) GetFees(c *gin.Context) {
+	fees, err := h.service.GetFees(c.Request.Context(), c.Query("includeInactive") == "true")
+	if err != nil {
+		c.JSON(feeErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.FeeResponse, len(fees))
+	for i, fee := range fees {
+		responses[i] = toFeeResponse(fee)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Add a fee
+// @Description Add a billable service to the catalog
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param body body dto.FeeRequest true "Fee Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.FeeResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /fees [post]
+// @Security BearerAuth
+func (h *FeeHandler) CreateFee(c *gin.Context) {
+	var body dto.FeeRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	fee := toFeeModel(body)
+	if err := h.service.CreateFee(c.Request.Context(), fee); err != nil {
+		c.JSON(feeErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(toFeeResponse(fee)))
+}
+
+// @Summary Update a fee
+// @Description Update a catalog fee. Existing invoices keep the price they were created with.
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param id path string true "Fee ID"
+// @Param body body dto.FeeRequest true "Fee Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.FeeResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /fees/{id} [put]
+// @Security BearerAuth
+func (h *FeeHandler) UpdateFee(c *gin.Context) {
+	var body dto.FeeRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	fee, err := h.service.UpdateFee(c.Request.Context(), c.Param("id"), toFeeModel(body))
+	if err != nil {
+		c.JSON(feeErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toFeeResponse(fee)))
+}
+
+func feeErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidFee):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrDuplicateFeeCode):
+		return http.StatusConflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func toFeeModel(body dto.FeeRequest) *models.Fee {
+	fee := &models.Fee{
+		Code:        body.Code,
+		Name:        body.Name,
+		Description: body.Description,
+		Price:       body.Price,
+		TaxRate:     body.TaxRate,
+		Active:      true,
+	}
+	if body.Active != nil {
+		fee.Active = *body.Active
+	}
+	return fee
+}
+
+func toFeeResponse(fee *models.Fee) dto.FeeResponse {
+	return dto.FeeResponse{
+		ID:          fee.ID,
+		Code:        fee.Code,
+		Name:        fee.Name,
+		Description: fee.Description,
+		Price:       formatDecimal(fee.Price),
+		TaxRate:     formatDecimal(fee.TaxRate),
+		Active:      fee.Active,
+		CreatedAt:   fee.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   fee.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/fhir"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+	"gorm.io/gorm"
+)
+
+const (
+	fhirDefaultCount = 20
+	fhirMaxCount     = 100
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type FHIRHandler struct {
+	patientService   service.PatientService
+	labResultService service.LabResultService
+}
+
+func NewFHIRHandler(patientService service.PatientService, labResultService service.LabResultService) *FHIRHandler {
+	return &FHIRHandler{patientService, labResultService}
+}
+
+// Format rejects requests for representations other than JSON and makes
+// middleware failures in the FHIR group render as OperationOutcome.
+func (h *FHIRHandler) Format() gin.HandlerFunc {
+	responder := middleware.UseErrorResponder(func(c *gin.Context, status int, message string) {
+		code := "processing"
+		switch status {
+		case http.StatusUnauthorized:
+			code = "login"
+		case http.StatusForbidden:
+			code = "forbidden"
+		}
+		writeOutcome(c, status, code, message)
+	})
+
+	return func(c *gin.Context) {
+		switch c.Query("_format") {
+		case "", "json", "application/json", fhir.ContentType:
+		default:
+			writeOutcome(c, http.StatusNotAcceptable, "not-supported", "only _format=json is supported")
+			c.Abort()
+			return
+		}
+		responder(c)
+	}
+}
+
+func (h *FHIRHandler) Metadata(c *gin.Context) {
+	writeFHIR(c, http.StatusOK, fhir.NewCapabilityStatement(fhirBaseURL(c)))
+}
+
+// Unsupported answers requests for resource types and interactions the API
+// does not serve, such as Encounter: the clinic does not record visits.
+func (h *FHIRHandler) Unsupported(c *gin.Context) {
+	writeOutcome(c, http.StatusNotFound, "not-supported", c.Request.Method+" "+c.Request.URL.Path+" is not supported, see /fhir/R4/metadata")
+}
+
+func (h *FHIRHandler) ReadPatient(c *gin.Context) {
+	id := c.Param("id")
+	if !uuidPattern.MatchString(id) {
+		writeOutcome(c, http.StatusNotFound, "not-found", "Patient/"+id+" not found")
+		return
+	}
+
+	patient, err := h.patientService.GetPatientByID(c.Request.Context(), id)
+	if err != nil {
+		writeServiceError(c, "Patient/"+id, err)
+		return
+	}
+
+	writeFHIR(c, http.StatusOK, fhir.FromPatient(patient))
+}
+
+func (h *FHIRHandler) SearchPatients(c *gin.Context) {
+	count, offset, ok := fhirPaging(c)
+	if !ok {
+		return
+	}
+
+	filter := repository.PatientFilter{
+		Name:   c.Query("name"),
+		Phone:  c.Query("phone"),
+		Limit:  count,
+		Offset: offset,
+	}
+
+	if id := c.Query("_id"); id != "" {
+		if !uuidPattern.MatchString(id) {
+			writeFHIR(c, http.StatusOK, fhir.NewSearchBundle(0))
+			return
+		}
+		filter.ID = id
+	}
+
+	if gender := c.Query("gender"); gender != "" {
+		value, ok := fhir.ClinicGender(gender)
+		if !ok {
+			writeOutcome(c, http.StatusBadRequest, "invalid", "unknown gender "+gender)
+			return
+		}
+		filter.Gender = value
+	}
+
+	for _, value := range c.QueryArray("birthdate") {
+		from, before, err := fhir.BirthDateRange(value)
+		if err != nil {
+			writeOutcome(c, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+		if from != nil && (filter.BornFrom == nil || from.After(*filter.BornFrom)) {
+			filter.BornFrom = from
+		}
+		if before != nil && (filter.BornBefore == nil || before.Before(*filter.BornBefore)) {
+			filter.BornBefore = before
+		}
+	}
+
+	if identifier := c.Query("identifier"); identifier != "" {
+		system, value, found := strings.Cut(identifier, "|")
+		if !found {
+			value = system
+			system = ""
+		}
+		switch {
+		case system == "":
+			filter.Identifier = value
+		case system == fhir.MRNSystem:
+			filter.MRN = value
+		case strings.HasPrefix(system, fhir.IdentifierSystemPrefix):
+			filter.Identifier = value
+			filter.IdentifierType = strings.TrimPrefix(system, fhir.IdentifierSystemPrefix)
+		default:
+			writeFHIR(c, http.StatusOK, fhir.NewSearchBundle(0))
+			return
+		}
+	}
+
+	patients, total, err := h.patientService.SearchPatients(c.Request.Context(), filter)
+	if err != nil {
+		writeServiceError(c, "Patient", err)
+		return
+	}
+
+	bundle := fhir.NewSearchBundle(total)
+	base := fhirBaseURL(c)
+	bundle.Link = searchLinks(c, base+"/Patient", total, count, offset)
+	for _, patient := range patients {
+		bundle.Entry = append(bundle.Entry, fhir.BundleEntry{
+			FullURL:  base + "/Patient/" + patient.ID,
+			Resource: fhir.FromPatient(patient),
+			Search:   &fhir.BundleSearch{Mode: "match"},
+		})
+	}
+
+	writeFHIR(c, http.StatusOK, bundle)
+}
+
+func (h *FHIRHandler) CreatePatient(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var resource fhir.Patient
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		writeOutcome(c, http.StatusBadRequest, "structure", err.Error())
+		return
+	}
+
+	patient, err := fhir.ToPatient(&resource)
+	if err != nil {
+		writeOutcome(c, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	patient.CreatedBy = authUser.ID
+	patient.UpdatedBy = authUser.ID
+
+	if err := h.patientService.CreatePatient(c.Request.Context(), patient); err != nil {
+		writeServiceError(c, "Patient", err)
+		return
+	}
+
+	c.Header("Location", fhirBaseURL(c)+"/Patient/"+patient.ID)
+	writeFHIR(c, http.StatusCreated, fhir.FromPatient(patient))
+}
+
+func (h *FHIRHandler) UpdatePatient(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+	id := c.Param("id")
+
+	var resource fhir.Patient
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		writeOutcome(c, http.StatusBadRequest, "structure", err.Error())
+		return
+	}
+	if resource.ID != id {
+		writeOutcome(c, http.StatusBadRequest, "invalid", "resource id must match the id in the URL")
+		return
+	}
+	if !uuidPattern.MatchString(id) {
+		writeOutcome(c, http.StatusNotFound, "not-found", "Patient/"+id+" not found")
+		return
+	}
+
+	if _, err := h.patientService.GetPatientByID(c.Request.Context(), id); err != nil {
+		writeServiceError(c, "Patient/"+id, err)
+		return
+	}
+
+	patient, err := fhir.ToPatient(&resource)
+	if err != nil {
+		writeOutcome(c, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	patient.UpdatedBy = authUser.ID
+
+	if err := h.patientService.UpdatePatient(c.Request.Context(), id, patient); err != nil {
+		writeServiceError(c, "Patient/"+id, err)
+		return
+	}
+
+	updated, err := h.patientService.GetPatientByID(c.Request.Context(), id)
+	if err != nil {
+		writeServiceError(c, "Patient/"+id, err)
+		return
+	}
+
+	writeFHIR(c, http.StatusOK, fhir.FromPatient(updated))
+}
+
+func (h *FHIRHandler) ReadObservation(c *gin.Context) {
+	id := c.Param("id")
+	if !uuidPattern.MatchString(id) {
+		writeOutcome(c, http.StatusNotFound, "not-found", "Observation/"+id+" not found")
+		return
+	}
+
+	result, err := h.labResultService.GetLabResultByID(c.Request.Context(), id)
+	if err != nil {
+		writeServiceError(c, "Observation/"+id, err)
+		return
+	}
+
+	writeFHIR(c, http.StatusOK, fhir.FromLabResult(result))
+}
+
+func (h *FHIRHandler) SearchObservations(c *gin.Context) {
+	count, offset, ok := fhirPaging(c)
+	if !ok {
+		return
+	}
+
+	filter := repository.LabResultFilter{Limit: count, Offset: offset}
+
+	patient := c.Query("patient")
+	if patient == "" {
+		patient = c.Query("subject")
+	}
+	if patient != "" {
+		patient = strings.TrimPrefix(patient, "Patient/")
+		if !uuidPattern.MatchString(patient) {
+			writeFHIR(c, http.StatusOK, fhir.NewSearchBundle(0))
+			return
+		}
+		filter.PatientID = patient
+	}
+
+	if code := c.Query("code"); code != "" {
+		if _, value, found := strings.Cut(code, "|"); found {
+			code = value
+		}
+		filter.Code = code
+	}
+
+	results, total, err := h.labResultService.SearchLabResults(c.Request.Context(), filter)
+	if err != nil {
+		writeServiceError(c, "Observation", err)
+		return
+	}
+
+	bundle := fhir.NewSearchBundle(total)
+	base := fhirBaseURL(c)
+	bundle.Link = searchLinks(c, base+"/Observation", total, count, offset)
+	for _, result := range results {
+		bundle.Entry = append(bundle.Entry, fhir.BundleEntry{
+			FullURL:  base + "/Observation/" + result.ID,
+			Resource: fhir.FromLabResult(result),
+			Search:   &fhir.BundleSearch{Mode: "match"},
+		})
+	}
+
+	writeFHIR(c, http.StatusOK, bundle)
+}
+
+func writeFHIR(c *gin.Context, status int, body any) {
+	c.Header("Content-Type", fhir.ContentType+"; charset=utf-8")
+	c.JSON(status, body)
+}
+
+func writeOutcome(c *gin.Context, status int, code, diagnostics string) {
+	writeFHIR(c, status, fhir.NewOperationOutcome(code, diagnostics))
+}
+
+func writeServiceError(c *gin.Context, resource string, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeOutcome(c, http.StatusNotFound, "not-found", resource+" not found")
+		return
+	}
+	if errors.Is(err, service.ErrInvalidPatient) {
+		writeOutcome(c, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	if status, ok := contextErrorStatus(err); ok {
+		writeOutcome(c, status, "timeout", err.Error())
+		return
+	}
+	writeOutcome(c, http.StatusInternalServerError, "exception", err.Error())
+}
+
+func fhirPaging(c *gin.Context) (int, int, bool) {
+	count := fhirDefaultCount
+	if value := c.Query("_count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeOutcome(c, http.StatusBadRequest, "invalid", "_count must be a non-negative integer")
+			return 0, 0, false
+		}
+		count = min(n, fhirMaxCount)
+	}
+
+	offset := 0
+	if value := c.Query("_offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeOutcome(c, http.StatusBadRequest, "invalid", "_offset must be a non-negative integer")
+			return 0, 0, false
+		}
+		offset = n
+	}
+
+	return count, offset, true
+}
+
+func searchLinks(c *gin.Context, endpoint string, total int64, count, offset int) []fhir.BundleLink {
+	link := func(relation string, offset int) fhir.BundleLink {
+		query := url.Values{}
+		for key, values := range c.Request.URL.Query() {
+			if key != "_offset" && key != "_count" {
+				query[key] = values
+			}
+		}
+		query.Set("_count", strconv.Itoa(count))
+		query.Set("_offset", strconv.Itoa(offset))
+		return fhir.BundleLink{Relation: relation, URL: endpoint + "?" + query.Encode()}
+	}
+
+	links := []fhir.BundleLink{link("self", offset)}
+	if count > 0 && int64(offset+count) < total {
+		links = append(links, link("next", offset+count))
+	}
+	if offset > 0 {
+		links = append(links, link("previous", max(offset-count, 0)))
+	}
+	return links
+}
+
+func fhirBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return fmt.Sprintf("%s://%s/fhir/R4", scheme, c.Request.Host)
+}
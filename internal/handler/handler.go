@@ -1,7 +1,57 @@
 package handler
 
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/max-programming/clinic/internal/repository"
+)
+
 type HandlerSet struct {
-	Auth    *AuthHandler
-	Patient *PatientHandler
-	Health  *HealthHandler
+	Auth              *AuthHandler
+	Patient           *PatientHandler
+	Health            *HealthHandler
+	HL7               *HL7Handler
+	LabResult         *LabResultHandler
+	FHIR              *FHIRHandler
+	PatientIdentifier *PatientIdentifierHandler
+	Duplicate         *DuplicateHandler
+	PatientMerge      *PatientMergeHandler
+	Audit             *AuditHandler
+	Fee               *FeeHandler
+	Invoice           *InvoiceHandler
+	Payment           *PaymentHandler
+	InsurancePolicy   *InsurancePolicyHandler
+	Claim             *ClaimHandler
+	Prescription      *PrescriptionHandler
+	Document          *DocumentHandler
+	PatientDocument   *PatientDocumentHandler
+	PatientImport     *PatientImportHandler
+	PatientExport     *PatientExportHandler
+	Erasure           *ErasureHandler
+	Report            *ReportHandler
+}
+
+// contextErrorStatus reports whether err comes from the request's context
+// ending, and the status to answer with: 504 when the route's timeout
+// passed, 503 when the request was canceled, such as by the client going
+// away or the server shutting down.
+func contextErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, repository.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, true
+	case errors.Is(err, repository.ErrCanceled), errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable, true
+	}
+	return 0, false
+}
+
+// serverErrorStatus is 500 unless the request ran out of time or was
+// canceled.
+func serverErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	return http.StatusInternalServerError
 }
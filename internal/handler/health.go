@@ -2,25 +2,57 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/service"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	service service.HealthService
+}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(service service.HealthService) *HealthHandler {
+	return &HealthHandler{service}
 }
 
-// @Summary Health check endpoint
-// @Description Returns 200 OK if the service is healthy
+// @Summary Liveness check
+// @Description Returns 200 while the server is running. It checks no dependencies, so a failing database does not get the server restarted.
 // @Tags health
 // @Produce json
-// @Success 200 {object} map[string]interface{}
-// @Router /health [get]
-func (h *HealthHandler) Check(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "ok",
-		"message": "Service is healthy",
-	})
+// @Success 200 {object} dto.LivenessResponse
+// @Router /health/live [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.LivenessResponse{Status: service.HealthStatusOK})
+}
+
+// @Summary Readiness check
+// @Description Checks the database, its migration version, document storage and the virus scanner when one is configured. Returns 503 unless every check passes. Results are reused for a few seconds.
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.ReadinessResponse
+// @Failure 503 {object} dto.ReadinessResponse
+// @Router /health/ready [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.service.Ready(c.Request.Context())
+
+	response := dto.ReadinessResponse{
+		Status:     report.Status,
+		Components: make([]dto.ComponentHealthResponse, len(report.Components)),
+		CheckedAt:  report.CheckedAt.Format(time.RFC3339),
+	}
+	for i, component := range report.Components {
+		response.Components[i] = dto.ComponentHealthResponse{
+			Name:       component.Name,
+			Status:     component.Status,
+			DurationMs: component.Duration.Milliseconds(),
+		}
+	}
+
+	status := http.StatusOK
+	if report.Status != service.HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
 }
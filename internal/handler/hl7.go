@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type HL7Handler struct {
+	service service.HL7Service
+}
+
+func NewHL7Handler(service service.HL7Service) *HL7Handler {
+	return &HL7Handler{service}
+}
+
+// @Summary Get all HL7 messages
+// @Description Get all inbound HL7 messages, newest first
+// @Tags hl7
+// @Produce json
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.HL7MessageResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /hl7/messages [get]
+// @Security BearerAuth
+func (h *HL7Handler) GetAllMessages(c *gin.Context) {
+	messages, err := h.service.GetAllMessages(c.Request.Context())
+	if err != nil {
+		c.JSON(hl7ErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.HL7MessageResponse, len(messages))
+	for i, message := range messages {
+		responses[i] = toHL7MessageResponse(message)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Get an HL7 message by ID
+// @Description Get an inbound HL7 message including its raw payload
+// @Tags hl7
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.GetHL7MessageResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /hl7/messages/{id} [get]
+// @Security BearerAuth
+func (h *HL7Handler) GetMessageByID(c *gin.Context) {
+	message, err := h.service.GetMessageByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(hl7ErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.GetHL7MessageResponse{
+		HL7MessageResponse: toHL7MessageResponse(message),
+		Raw:                strings.ReplaceAll(message.Raw, "\r", "\n"),
+	}))
+}
+
+// @Summary Replay an HL7 message
+// @Description Reprocess a stored HL7 message and return the resulting ACK
+// @Tags hl7
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.ReplayHL7MessageResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /hl7/messages/{id}/replay [post]
+// @Security BearerAuth
+func (h *HL7Handler) ReplayMessage(c *gin.Context) {
+	message, ack, err := h.service.ReplayMessage(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(hl7ErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.ReplayHL7MessageResponse{
+		ID:     message.ID,
+		Status: message.Status,
+		Error:  message.Error,
+		Ack:    strings.ReplaceAll(string(ack), "\r", "\n"),
+	}))
+}
+
+func toHL7MessageResponse(message *models.HL7Message) dto.HL7MessageResponse {
+	response := dto.HL7MessageResponse{
+		ID:              message.ID,
+		ControlID:       message.ControlID,
+		MessageType:     message.MessageType,
+		SendingApp:      message.SendingApp,
+		SendingFacility: message.SendingFacility,
+		Status:          message.Status,
+		Error:           message.Error,
+		ReceivedAt:      message.ReceivedAt.Format(time.RFC3339),
+	}
+	if message.PatientID != nil {
+		response.PatientID = *message.PatientID
+	}
+	if message.ProcessedAt != nil {
+		response.ProcessedAt = message.ProcessedAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+func hl7ErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type InsurancePolicyHandler struct {
+	service service.InsurancePolicyService
+}
+
+func NewInsurancePolicyHandler(service service.InsurancePolicyService) *InsurancePolicyHandler {
+	return &InsurancePolicyHandler{service}
+}
+
+// @Summary Get a patient's insurance policies
+// @Description Get the insurance policies of a patient, primary first
+// @Tags insurance
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.InsurancePolicyResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/insurance-policies [get]
+// @Security BearerAuth
+func (h *InsurancePolicyHandler) GetPatientPolicies(c *gin.Context) {
+	policies, err := h.service.GetPatientPolicies(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(policyErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	responses := make([]dto.InsurancePolicyResponse, len(policies))
+	for i, policy := range policies {
+		responses[i] = toPolicyResponse(policy, now)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Add an insurance policy
+// @Description Record an insurance policy for a patient
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param body body dto.InsurancePolicyRequest true "Insurance Policy Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.InsurancePolicyResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/insurance-policies [post]
+// @Security BearerAuth
+func (h *InsurancePolicyHandler) AddPolicy(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.InsurancePolicyRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	policy := toPolicyModel(body)
+	policy.PatientID = c.Param("id")
+	policy.CreatedBy = authUser.ID
+	policy.UpdatedBy = authUser.ID
+	if err := h.service.AddPolicy(c.Request.Context(), policy); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, utils.NewErrorAPIResponse("patient not found"))
+			return
+		}
+		c.JSON(policyErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(toPolicyResponse(policy, time.Now())))
+}
+
+// @Summary Update an insurance policy
+// @Description Replace the details of a patient's insurance policy
+// @Tags insurance
+// @Accept json
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param policyId path string true "Policy ID"
+// @Param body body dto.InsurancePolicyRequest true "Insurance Policy Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.InsurancePolicyResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/insurance-policies/{policyId} [put]
+// @Security BearerAuth
+func (h *InsurancePolicyHandler) UpdatePolicy(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.InsurancePolicyRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	updated := toPolicyModel(body)
+	updated.UpdatedBy = authUser.ID
+	policy, err := h.service.UpdatePolicy(c.Request.Context(), c.Param("id"), c.Param("policyId"), updated)
+	if err != nil {
+		c.JSON(policyErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPolicyResponse(policy, time.Now())))
+}
+
+// @Summary Delete an insurance policy
+// @Description Delete a policy that has no claims
+// @Tags insurance
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param policyId path string true "Policy ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.DeleteInsurancePolicyResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/insurance-policies/{policyId} [delete]
+// @Security BearerAuth
+func (h *InsurancePolicyHandler) DeletePolicy(c *gin.Context) {
+	id := c.Param("policyId")
+
+	if err := h.service.DeletePolicy(c.Request.Context(), c.Param("id"), id); err != nil {
+		c.JSON(policyErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.DeleteInsurancePolicyResponse{ID: id}))
+}
+
+func policyErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidPolicy):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrPolicyInUse):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func toPolicyModel(body dto.InsurancePolicyRequest) *models.InsurancePolicy {
+	policy := &models.InsurancePolicy{
+		Priority:              body.Priority,
+		PayerName:             body.PayerName,
+		PayerID:               body.PayerID,
+		PlanName:              body.PlanName,
+		MemberID:              body.MemberID,
+		GroupNumber:           body.GroupNumber,
+		Relationship:          body.Relationship,
+		SubscriberDateOfBirth: parseDate(body.SubscriberDateOfBirth),
+		ValidTo:               parseDate(body.ValidTo),
+		CoveragePercent:       body.CoveragePercent,
+		Copay:                 body.Copay,
+	}
+	if validFrom := parseDate(body.ValidFrom); validFrom != nil {
+		policy.ValidFrom = *validFrom
+	}
+	// The subscriber is the patient for self-held policies.
+	if body.Relationship != models.SubscriberSelf {
+		policy.SubscriberGivenName = body.SubscriberGivenName
+		policy.SubscriberFamilyName = body.SubscriberFamilyName
+	} else {
+		policy.SubscriberDateOfBirth = nil
+	}
+	return policy
+}
+
+func toPolicyResponse(policy *models.InsurancePolicy, now time.Time) dto.InsurancePolicyResponse {
+	return dto.InsurancePolicyResponse{
+		ID:                    policy.ID,
+		PatientID:             policy.PatientID,
+		Priority:              policy.Priority,
+		PayerName:             policy.PayerName,
+		PayerID:               policy.PayerID,
+		PlanName:              policy.PlanName,
+		MemberID:              policy.MemberID,
+		GroupNumber:           policy.GroupNumber,
+		Relationship:          policy.Relationship,
+		SubscriberGivenName:   policy.SubscriberGivenName,
+		SubscriberFamilyName:  policy.SubscriberFamilyName,
+		SubscriberDateOfBirth: formatDate(policy.SubscriberDateOfBirth),
+		ValidFrom:             policy.ValidFrom.Format(time.DateOnly),
+		ValidTo:               formatDate(policy.ValidTo),
+		Active:                policy.CoversDate(now),
+		CoveragePercent:       formatDecimal(policy.CoveragePercent),
+		Copay:                 formatDecimal(policy.Copay),
+		CreatedAt:             policy.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:             policy.UpdatedAt.Format(time.RFC3339),
+	}
+}
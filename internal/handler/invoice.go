@@ -0,0 +1,368 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type InvoiceHandler struct {
+	service service.InvoiceService
+}
+
+func NewInvoiceHandler(service service.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{service}
+}
+
+type invoicesQuery struct {
+	dto.PageQuery
+	PatientID  string `form:"patientId" binding:"omitempty,uuid"`
+	Status     string `form:"status" binding:"omitempty,oneof=draft issued partially_paid paid void"`
+	FiscalYear int    `form:"fiscalYear"`
+}
+
+// @Summary Get invoices
+// @Description Get invoices, newest first
+// @Tags billing
+// @Produce json
+// @Param patientId query string false "Patient ID"
+// @Param status query string false "Invoice status" Enums(draft, issued, partially_paid, paid, void)
+// @Param fiscalYear query int false "Fiscal year"
+// @Param limit query int false "Page size" default(50)
+// @Param offset query int false "Page offset"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.InvoiceResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices [get]
+// @Security BearerAuth
+func (h *InvoiceHandler) GetInvoices(c *gin.Context) {
+	var query invoicesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	invoices, _, err := h.service.SearchInvoices(c.Request.Context(), repository.InvoiceFilter{
+		PatientID:  query.PatientID,
+		Status:     query.Status,
+		FiscalYear: query.FiscalYear,
+		Limit:      query.LimitOrDefault(),
+		Offset:     query.Offset,
+	})
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.InvoiceResponse, len(invoices))
+	for i, invoice := range invoices {
+		responses[i] = toInvoiceResponse(invoice)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Get an invoice by ID
+// @Description Get an invoice with its line items
+// @Tags billing
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.InvoiceResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices/{id} [get]
+// @Security BearerAuth
+func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
+	invoice, err := h.service.GetInvoiceByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toInvoiceResponse(invoice)))
+}
+
+// @Summary Create an invoice
+// @Description Create a draft invoice. Items referencing a fee default to the fee's description, price and tax rate.
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param body body dto.InvoiceRequest true "Invoice Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.InvoiceResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices [post]
+// @Security BearerAuth
+func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.InvoiceRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	invoice := &models.Invoice{
+		PatientID:       body.PatientID,
+		AppointmentID:   body.AppointmentID,
+		DiscountPercent: body.DiscountPercent,
+		Notes:           body.Notes,
+		CreatedBy:       authUser.ID,
+		UpdatedBy:       authUser.ID,
+	}
+	if err := h.service.CreateInvoice(c.Request.Context(), invoice, toInvoiceItemInputs(body.Items)); err != nil {
+		c.JSON(invoiceErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	created, err := h.service.GetInvoiceByID(c.Request.Context(), invoice.ID)
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(toInvoiceResponse(created)))
+}
+
+// @Summary Update a draft invoice
+// @Description Replace the items, discount and notes of a draft invoice
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Param body body dto.InvoiceRequest true "Invoice Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.InvoiceResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices/{id} [put]
+// @Security BearerAuth
+func (h *InvoiceHandler) UpdateInvoice(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.InvoiceRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	invoice, err := h.service.UpdateInvoice(c.Request.Context(), c.Param("id"), &models.Invoice{
+		AppointmentID:   body.AppointmentID,
+		DiscountPercent: body.DiscountPercent,
+		Notes:           body.Notes,
+		UpdatedBy:       authUser.ID,
+	}, toInvoiceItemInputs(body.Items))
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toInvoiceResponse(invoice)))
+}
+
+// @Summary Issue an invoice
+// @Description Finalize a draft invoice and assign the next invoice number for the current fiscal year
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Param body body dto.IssueInvoiceRequest false "Issue Invoice Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.InvoiceResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices/{id}/issue [post]
+// @Security BearerAuth
+func (h *InvoiceHandler) IssueInvoice(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.IssueInvoiceRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+			return
+		}
+	}
+
+	invoice, err := h.service.IssueInvoice(c.Request.Context(), c.Param("id"), authUser.ID, parseDate(body.DueDate))
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toInvoiceResponse(invoice)))
+}
+
+// @Summary Void an invoice
+// @Description Void an issued invoice that has no payments. The invoice number is kept; drafts are deleted instead.
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Param body body dto.VoidInvoiceRequest true "Void Invoice Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.InvoiceResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices/{id}/void [post]
+// @Security BearerAuth
+func (h *InvoiceHandler) VoidInvoice(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.VoidInvoiceRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	invoice, err := h.service.VoidInvoice(c.Request.Context(), c.Param("id"), authUser.ID, body.Reason)
+	if err != nil {
+		c.JSON(invoiceErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toInvoiceResponse(invoice)))
+}
+
+// @Summary Delete a draft invoice
+// @Description Delete a draft invoice. Issued invoices can only be voided.
+// @Tags billing
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.InvoiceResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices/{id} [delete]
+// @Security BearerAuth
+func (h *InvoiceHandler) DeleteInvoice(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service.DeleteInvoice(c.Request.Context(), id); err != nil {
+		c.JSON(invoiceErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.InvoiceResponse{ID: id}))
+}
+
+func invoiceErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidInvoice):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrInvoiceNotDraft),
+		errors.Is(err, repository.ErrInvoiceIsDraft),
+		errors.Is(err, repository.ErrInvoiceVoided),
+		errors.Is(err, repository.ErrInvoiceHasPayments):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func toInvoiceItemInputs(items []dto.InvoiceItemRequest) []service.InvoiceItemInput {
+	inputs := make([]service.InvoiceItemInput, len(items))
+	for i, item := range items {
+		inputs[i] = service.InvoiceItemInput{
+			FeeID:           item.FeeID,
+			Description:     item.Description,
+			Quantity:        item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			DiscountPercent: item.DiscountPercent,
+			TaxRate:         item.TaxRate,
+		}
+	}
+	return inputs
+}
+
+func toInvoiceResponse(invoice *models.Invoice) dto.InvoiceResponse {
+	response := dto.InvoiceResponse{
+		ID:              invoice.ID,
+		Number:          derefString(invoice.Number),
+		PatientID:       invoice.PatientID,
+		AppointmentID:   derefString(invoice.AppointmentID),
+		Status:          invoice.Status,
+		Currency:        invoice.Currency,
+		DiscountPercent: formatDecimal(invoice.DiscountPercent),
+		Subtotal:        formatDecimal(invoice.Subtotal),
+		DiscountTotal:   formatDecimal(invoice.DiscountTotal),
+		TaxTotal:        formatDecimal(invoice.TaxTotal),
+		Total:           formatDecimal(invoice.Total),
+		AmountPaid:      formatDecimal(invoice.AmountPaid),
+		Balance:         formatDecimal(invoice.Balance()),
+		Notes:           invoice.Notes,
+		IssuedAt:        formatTime(invoice.IssuedAt),
+		DueDate:         formatDate(invoice.DueDate),
+		VoidedAt:        formatTime(invoice.VoidedAt),
+		VoidReason:      invoice.VoidReason,
+		CreatedAt:       invoice.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       invoice.UpdatedAt.Format(time.RFC3339),
+	}
+	if invoice.FiscalYear != nil {
+		response.FiscalYear = *invoice.FiscalYear
+	}
+	if invoice.Patient != nil {
+		response.PatientName = invoice.Patient.Name
+		response.PatientMRN = invoice.Patient.MRN
+	}
+	for _, item := range invoice.Items {
+		response.Items = append(response.Items, dto.InvoiceItemResponse{
+			ID:              item.ID,
+			FeeID:           derefString(item.FeeID),
+			Description:     item.Description,
+			Quantity:        formatDecimal(item.Quantity),
+			UnitPrice:       formatDecimal(item.UnitPrice),
+			DiscountPercent: formatDecimal(item.DiscountPercent),
+			TaxRate:         formatDecimal(item.TaxRate),
+			Subtotal:        formatDecimal(item.LineSubtotal),
+			Discount:        formatDecimal(item.LineDiscount),
+			Tax:             formatDecimal(item.LineTax),
+			Total:           formatDecimal(item.LineTotal),
+		})
+	}
+	return response
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatDecimal renders amounts with two decimal places, e.g. "14.50".
+func formatDecimal(value decimal.Decimal) string {
+	return value.StringFixed(2)
+}
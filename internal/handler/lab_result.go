@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+)
+
+type LabResultHandler struct {
+	service service.LabResultService
+}
+
+func NewLabResultHandler(service service.LabResultService) *LabResultHandler {
+	return &LabResultHandler{service}
+}
+
+// @Summary Get a patient's lab results
+// @Description Get lab results received for a patient, most recent first
+// @Tags patients
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.LabResultResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/lab-results [get]
+// @Security BearerAuth
+func (h *LabResultHandler) GetPatientLabResults(c *gin.Context) {
+	results, err := h.service.GetPatientLabResults(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(serverErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.LabResultResponse, len(results))
+	for i, result := range results {
+		responses[i] = toLabResultResponse(result)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+func toLabResultResponse(result *models.LabResult) dto.LabResultResponse {
+	response := dto.LabResultResponse{
+		ID:             result.ID,
+		OrderNumber:    result.OrderNumber,
+		TestCode:       result.TestCode,
+		TestName:       result.TestName,
+		ValueType:      result.ValueType,
+		Value:          result.Value,
+		Units:          result.Units,
+		ReferenceRange: result.ReferenceRange,
+		AbnormalFlag:   result.AbnormalFlag,
+		ResultStatus:   result.ResultStatus,
+		CreatedAt:      result.CreatedAt.Format(time.RFC3339),
+	}
+	if result.ObservedAt != nil {
+		response.ObservedAt = result.ObservedAt.Format(time.RFC3339)
+	}
+	return response
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,8 +9,10 @@ import (
 	"github.com/max-programming/clinic/internal/dto"
 	"github.com/max-programming/clinic/internal/middleware"
 	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
 	"github.com/max-programming/clinic/internal/service"
 	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
 )
 
 type PatientHandler struct {
@@ -21,14 +24,17 @@ func NewPatientHandler(service service.PatientService) *PatientHandler {
 }
 
 // @Summary Add a new patient
-// @Description Add a new patient
+// @Description Add a new patient. If probable duplicates are found the patient is not created and the matches are returned with a 409; resend with allowDuplicate set to register anyway.
 // @Tags patients
 // @Accept json
 // @Produce json
 // @Param body body dto.AddPatientRequest true "Add Patient Request"
 // @Success 201 {object} utils.SuccessAPIResponse[dto.AddPatientResponse]
 // @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} dto.DuplicatePatientErrorResponse
 // @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
 // @Router /patients [post]
 // @Security BearerAuth
 func (h *PatientHandler) AddPatient(c *gin.Context) {
@@ -41,23 +47,40 @@ func (h *PatientHandler) AddPatient(c *gin.Context) {
 	}
 
 	patient := &models.Patient{
-		Name:         body.Name,
-		Age:          body.Age,
-		Gender:       body.Gender,
-		Phone:        body.Phone,
-		Address:      body.Address,
-		MedicalNotes: "",
-		CreatedBy:    authUser.ID,
-		UpdatedBy:    authUser.ID,
+		GivenName:         body.GivenName,
+		FamilyName:        body.FamilyName,
+		PreferredName:     body.PreferredName,
+		DateOfBirth:       parseDate(body.DateOfBirth),
+		Gender:            body.Gender,
+		SexAtBirth:        body.SexAtBirth,
+		GenderIdentity:    body.GenderIdentity,
+		Email:             body.Email,
+		Phone:             body.Phone,
+		PreferredLanguage: body.PreferredLanguage,
+		Address:           toAddressModel(body.Address),
+		EmergencyContact:  toEmergencyContactModel(body.EmergencyContact),
+		MedicalNotes:      "",
+		CreatedBy:         authUser.ID,
+		UpdatedBy:         authUser.ID,
 	}
 
-	if err := h.service.CreatePatient(patient); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+	if err := h.service.RegisterPatient(c.Request.Context(), patient, body.AllowDuplicate); err != nil {
+		var duplicateErr *service.DuplicatePatientError
+		if errors.As(err, &duplicateErr) {
+			c.JSON(http.StatusConflict, dto.DuplicatePatientErrorResponse{
+				Success: false,
+				Error:   err.Error(),
+				Matches: toDuplicateMatchResponses(duplicateErr.Matches),
+			})
+			return
+		}
+		c.JSON(patientErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
 		return
 	}
 
 	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(dto.AddPatientResponse{
 		ID:        patient.ID,
+		MRN:       patient.MRN,
 		CreatedBy: authUser.Username,
 		CreatedAt: patient.CreatedAt.Format(time.RFC3339),
 	}))
@@ -70,31 +93,60 @@ func (h *PatientHandler) AddPatient(c *gin.Context) {
 // @Produce json
 // @Success 200 {array} dto.GetAllPatientsResponse
 // @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
 // @Router /patients [get]
 // @Security BearerAuth
 func (h *PatientHandler) GetAllPatients(c *gin.Context) {
-	patients, err := h.service.GetAllPatients()
+	patients, err := h.service.GetAllPatients(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+		c.JSON(patientErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
 		return
 	}
 
-	patientResponses := make([]dto.GetAllPatientsResponse, len(patients))
-	for i, patient := range patients {
-		patientResponses[i] = dto.GetAllPatientsResponse{
-			ID:           patient.ID,
-			Name:         patient.Name,
-			Age:          patient.Age,
-			Gender:       patient.Gender,
-			Address:      patient.Address,
-			Phone:        patient.Phone,
-			MedicalNotes: patient.MedicalNotes,
-			CreatedAt:    patient.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:    patient.UpdatedAt.Format(time.RFC3339),
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPatientListResponses(patients)))
+}
+
+// @Summary Look up patients by identifier
+// @Description Find patients by clinic MRN, by an external identifier or by exact phone number
+// @Tags patients
+// @Produce json
+// @Param mrn query string false "Medical record number"
+// @Param type query string false "Identifier type" Enums(national_id, insurance_member_id, hospital_mrn, other)
+// @Param issuer query string false "Identifier issuer"
+// @Param value query string false "Identifier value"
+// @Param phone query string false "Phone number, formatting is ignored"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.GetAllPatientsResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/lookup [get]
+// @Security BearerAuth
+func (h *PatientHandler) LookupPatients(c *gin.Context) {
+	lookup := repository.PatientLookup{
+		MRN:            c.Query("mrn"),
+		IdentifierType: c.Query("type"),
+		Issuer:         c.Query("issuer"),
+		Value:          c.Query("value"),
+		Phone:          c.Query("phone"),
+	}
+	if lookup.MRN == "" && lookup.Value == "" && lookup.Phone == "" {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse("one of mrn, value or phone is required"))
+		return
+	}
+
+	patients, err := h.service.LookupPatients(c.Request.Context(), lookup)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMRN) {
+			c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+			return
 		}
+		c.JSON(patientErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
 	}
 
-	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(patientResponses))
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPatientListResponses(patients)))
 }
 
 // @Summary Get a patient by ID
@@ -106,48 +158,19 @@ func (h *PatientHandler) GetAllPatients(c *gin.Context) {
 // @Success 200 {object} utils.SuccessAPIResponse[dto.GetPatientResponse]
 // @Failure 404 {object} utils.ErrorAPIResponse
 // @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
 // @Router /patients/{id} [get]
 // @Security BearerAuth
 func (h *PatientHandler) GetPatientByID(c *gin.Context) {
 	id := c.Param("id")
-	patient, createdByUser, updatedByUser, err := h.service.GetPatientByIDWithUsers(id)
+	patient, createdByUser, updatedByUser, err := h.service.GetPatientByIDWithUsers(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+		c.JSON(patientErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
 		return
 	}
 
-	// Prepare user information for response
-	var createdByResponse, updatedByResponse dto.PatientUser
-
-	if createdByUser != nil {
-		createdByResponse = dto.PatientUser{
-			ID:       createdByUser.ID,
-			Username: createdByUser.Username,
-			Role:     createdByUser.Role,
-		}
-	}
-
-	if updatedByUser != nil {
-		updatedByResponse = dto.PatientUser{
-			ID:       updatedByUser.ID,
-			Username: updatedByUser.Username,
-			Role:     updatedByUser.Role,
-		}
-	}
-
-	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.GetPatientResponse{
-		ID:           patient.ID,
-		Name:         patient.Name,
-		Age:          patient.Age,
-		Gender:       patient.Gender,
-		Address:      patient.Address,
-		Phone:        patient.Phone,
-		MedicalNotes: patient.MedicalNotes,
-		CreatedBy:    createdByResponse,
-		UpdatedBy:    updatedByResponse,
-		CreatedAt:    patient.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    patient.UpdatedAt.Format(time.RFC3339),
-	}))
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPatientResponse(patient, createdByUser, updatedByUser)))
 }
 
 // @Summary Update a patient
@@ -160,7 +183,10 @@ func (h *PatientHandler) GetPatientByID(c *gin.Context) {
 // @Success 200 {object} utils.SuccessAPIResponse[dto.UpdatePatientResponse]
 // @Failure 400 {object} utils.ErrorAPIResponse
 // @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
 // @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
 // @Router /patients/{id} [put]
 // @Security BearerAuth
 func (h *PatientHandler) UpdatePatient(c *gin.Context) {
@@ -175,17 +201,24 @@ func (h *PatientHandler) UpdatePatient(c *gin.Context) {
 	}
 
 	patient := &models.Patient{
-		Name:         body.Name,
-		Age:          body.Age,
-		Gender:       body.Gender,
-		Phone:        body.Phone,
-		Address:      body.Address,
-		MedicalNotes: body.MedicalNotes,
-		UpdatedBy:    authUser.ID,
+		GivenName:         body.GivenName,
+		FamilyName:        body.FamilyName,
+		PreferredName:     body.PreferredName,
+		DateOfBirth:       parseDate(body.DateOfBirth),
+		Gender:            body.Gender,
+		SexAtBirth:        body.SexAtBirth,
+		GenderIdentity:    body.GenderIdentity,
+		Email:             body.Email,
+		Phone:             body.Phone,
+		PreferredLanguage: body.PreferredLanguage,
+		Address:           toAddressModel(body.Address),
+		EmergencyContact:  toEmergencyContactModel(body.EmergencyContact),
+		MedicalNotes:      body.MedicalNotes,
+		UpdatedBy:         authUser.ID,
 	}
 
-	if err := h.service.UpdatePatient(id, patient); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+	if err := h.service.UpdatePatient(c.Request.Context(), id, patient); err != nil {
+		c.JSON(patientErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
 		return
 	}
 
@@ -206,7 +239,10 @@ func (h *PatientHandler) UpdatePatient(c *gin.Context) {
 // @Success 200 {object} utils.SuccessAPIResponse[dto.UpdatePatientResponse]
 // @Failure 400 {object} utils.ErrorAPIResponse
 // @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
 // @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
 // @Router /patients/{id}/notes [patch]
 // @Security BearerAuth
 func (h *PatientHandler) UpdatePatientNotes(c *gin.Context) {
@@ -225,8 +261,8 @@ func (h *PatientHandler) UpdatePatientNotes(c *gin.Context) {
 		UpdatedBy:    authUser.ID,
 	}
 
-	if err := h.service.UpdatePatient(id, patient); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+	if err := h.service.UpdatePatient(c.Request.Context(), id, patient); err != nil {
+		c.JSON(patientErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
 		return
 	}
 
@@ -245,16 +281,179 @@ func (h *PatientHandler) UpdatePatientNotes(c *gin.Context) {
 // @Param id path string true "Patient ID"
 // @Success 200 {object} utils.SuccessAPIResponse[dto.DeletePatientResponse]
 // @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
 // @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
 // @Router /patients/{id} [delete]
 // @Security BearerAuth
 func (h *PatientHandler) DeletePatient(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.service.DeletePatient(id); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+	if err := h.service.DeletePatient(c.Request.Context(), id); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			c.JSON(http.StatusConflict, utils.NewErrorAPIResponse("patient has invoices or documents and cannot be deleted"))
+			return
+		}
+		c.JSON(patientErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
 		return
 	}
 
 	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.DeletePatientResponse{ID: id}))
 }
+
+func toPatientResponse(patient *models.Patient, createdByUser, updatedByUser *models.User) dto.GetPatientResponse {
+	// Prepare user information for response
+	var createdByResponse, updatedByResponse dto.PatientUser
+
+	if createdByUser != nil {
+		createdByResponse = dto.PatientUser{
+			ID:       createdByUser.ID,
+			Username: createdByUser.Username,
+			Role:     createdByUser.Role,
+		}
+	}
+
+	if updatedByUser != nil {
+		updatedByResponse = dto.PatientUser{
+			ID:       updatedByUser.ID,
+			Username: updatedByUser.Username,
+			Role:     updatedByUser.Role,
+		}
+	}
+
+	age, ageMonths := patient.Age(time.Now())
+
+	return dto.GetPatientResponse{
+		ID:                   patient.ID,
+		MRN:                  patient.MRN,
+		Name:                 patient.Name,
+		GivenName:            patient.GivenName,
+		FamilyName:           patient.FamilyName,
+		PreferredName:        patient.PreferredName,
+		DateOfBirth:          formatDate(patient.DateOfBirth),
+		DateOfBirthEstimated: patient.DateOfBirthEstimated,
+		Age:                  age,
+		AgeMonths:            ageMonths,
+		Gender:               patient.Gender,
+		SexAtBirth:           patient.SexAtBirth,
+		GenderIdentity:       patient.GenderIdentity,
+		Email:                patient.Email,
+		Phone:                patient.Phone,
+		PreferredLanguage:    patient.PreferredLanguage,
+		Address:              toAddressResponse(patient.Address),
+		EmergencyContact:     toEmergencyContactResponse(patient.EmergencyContact),
+		Identifiers:          toIdentifierResponses(patient.Identifiers),
+		MedicalNotes:         patient.MedicalNotes,
+		CreatedBy:            createdByResponse,
+		UpdatedBy:            updatedByResponse,
+		CreatedAt:            patient.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:            patient.UpdatedAt.Format(time.RFC3339),
+		MergedIntoID:         derefString(patient.MergedIntoID),
+	}
+}
+
+func toPatientListResponses(patients []*models.Patient) []dto.GetAllPatientsResponse {
+	now := time.Now()
+	responses := make([]dto.GetAllPatientsResponse, len(patients))
+	for i, patient := range patients {
+		responses[i] = toPatientListResponse(patient, now)
+	}
+	return responses
+}
+
+func toPatientListResponse(patient *models.Patient, now time.Time) dto.GetAllPatientsResponse {
+	age, ageMonths := patient.Age(now)
+	return dto.GetAllPatientsResponse{
+		ID:                   patient.ID,
+		MRN:                  patient.MRN,
+		Name:                 patient.Name,
+		PreferredName:        patient.PreferredName,
+		DateOfBirth:          formatDate(patient.DateOfBirth),
+		DateOfBirthEstimated: patient.DateOfBirthEstimated,
+		Age:                  age,
+		AgeMonths:            ageMonths,
+		Gender:               patient.Gender,
+		Address:              toAddressResponse(patient.Address),
+		Phone:                patient.Phone,
+		MedicalNotes:         patient.MedicalNotes,
+		CreatedAt:            patient.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:            patient.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func patientErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidPatient):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrPatientErased):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func parseDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil
+	}
+	return &date
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(time.DateOnly)
+}
+
+func toAddressModel(address dto.PatientAddress) models.Address {
+	return models.Address{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		State:      address.State,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+func toAddressResponse(address models.Address) dto.PatientAddress {
+	return dto.PatientAddress{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		State:      address.State,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+func toEmergencyContactModel(contact dto.EmergencyContact) models.EmergencyContact {
+	return models.EmergencyContact{
+		Name:         contact.Name,
+		Relationship: contact.Relationship,
+		Phone:        contact.Phone,
+	}
+}
+
+func toEmergencyContactResponse(contact models.EmergencyContact) dto.EmergencyContact {
+	return dto.EmergencyContact{
+		Name:         contact.Name,
+		Relationship: contact.Relationship,
+		Phone:        contact.Phone,
+	}
+}
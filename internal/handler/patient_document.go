@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/clamav"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/storage"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead allows for the form fields and boundaries around the
+// file when capping the request body.
+const multipartOverhead = 1 << 20
+
+type PatientDocumentHandler struct {
+	service service.PatientDocumentService
+	maxSize int64
+}
+
+func NewPatientDocumentHandler(service service.PatientDocumentService, maxSize int64) *PatientDocumentHandler {
+	return &PatientDocumentHandler{service, maxSize}
+}
+
+type documentsQuery struct {
+	Category string `form:"category" binding:"omitempty,oneof=referral id_card report consent other"`
+}
+
+type documentUploadForm struct {
+	Category string `form:"category" binding:"required,oneof=referral id_card report consent other"`
+	Title    string `form:"title" binding:"max=255"`
+}
+
+// @Summary Get a patient's documents
+// @Description List the files attached to a patient, newest first
+// @Tags documents
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param category query string false "Category" Enums(referral, id_card, report, consent, other)
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.PatientDocumentResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/documents [get]
+// @Security BearerAuth
+func (h *PatientDocumentHandler) GetPatientDocuments(c *gin.Context) {
+	var query documentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	documents, err := h.service.GetPatientDocuments(c.Request.Context(), c.Param("id"), query.Category)
+	if err != nil {
+		c.JSON(patientDocumentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.PatientDocumentResponse, len(documents))
+	for i, document := range documents {
+		responses[i] = toPatientDocumentResponse(document)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Upload a document
+// @Description Attach a scanned letter, ID card, report or other file to a patient. The type is detected from the content; PDF, common image formats, plain text and Word documents are accepted.
+// @Tags documents
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param file formData file true "File"
+// @Param category formData string true "Category" Enums(referral, id_card, report, consent, other)
+// @Param title formData string false "Title, defaults to the file name"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.PatientDocumentResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 413 {object} utils.ErrorAPIResponse
+// @Failure 415 {object} utils.ErrorAPIResponse
+// @Failure 422 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/documents [post]
+// @Security BearerAuth
+func (h *PatientDocumentHandler) UploadDocument(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+
+	var form documentUploadForm
+	if err := c.ShouldBind(&form); err != nil {
+		c.JSON(uploadErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	if header.Size > h.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, utils.NewErrorAPIResponse(fmt.Sprintf("file exceeds the %d byte limit", h.maxSize)))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	defer file.Close()
+
+	document, err := h.service.UploadDocument(c.Request.Context(), service.DocumentUpload{
+		PatientID:  c.Param("id"),
+		Category:   form.Category,
+		Title:      form.Title,
+		FileName:   header.Filename,
+		Content:    file,
+		UploadedBy: authUser.ID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, utils.NewErrorAPIResponse("patient not found"))
+			return
+		}
+		c.JSON(documentUploadErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(toPatientDocumentResponse(document)))
+}
+
+// @Summary Get a document's details
+// @Description Get the details of a file attached to a patient
+// @Tags documents
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param documentId path string true "Document ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.PatientDocumentResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/documents/{documentId} [get]
+// @Security BearerAuth
+func (h *PatientDocumentHandler) GetDocument(c *gin.Context) {
+	document, err := h.service.GetDocument(c.Request.Context(), c.Param("id"), c.Param("documentId"))
+	if err != nil {
+		c.JSON(patientDocumentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPatientDocumentResponse(document)))
+}
+
+// @Summary Download a document
+// @Description Stream the file. Downloads are recorded in the audit log.
+// @Tags documents
+// @Produce octet-stream
+// @Param id path string true "Patient ID"
+// @Param documentId path string true "Document ID"
+// @Success 200 {file} file "Document"
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/documents/{documentId}/content [get]
+// @Security BearerAuth
+func (h *PatientDocumentHandler) DownloadDocument(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	document, content, err := h.service.OpenDocument(c.Request.Context(), c.Param("id"), c.Param("documentId"), authUser.ID)
+	if err != nil {
+		c.JSON(patientDocumentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Type", document.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(document.SizeBytes, 10))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": document.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, content); err != nil {
+		logging.FromContext(c.Request.Context()).Warn("Failed to stream document", slog.String("document_id", document.ID), slog.Any("error", err))
+	}
+}
+
+// @Summary Delete a document
+// @Description Remove a file attached to a patient
+// @Tags documents
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param documentId path string true "Document ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.DeletePatientDocumentResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/documents/{documentId} [delete]
+// @Security BearerAuth
+func (h *PatientDocumentHandler) DeleteDocument(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+	id := c.Param("documentId")
+
+	if err := h.service.DeleteDocument(c.Request.Context(), c.Param("id"), id, authUser.ID); err != nil {
+		c.JSON(patientDocumentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.DeletePatientDocumentResponse{ID: id}))
+}
+
+func patientDocumentErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, storage.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func uploadErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func documentUploadErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidDocument):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrDocumentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedDocumentType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, clamav.ErrInfected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, clamav.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func toPatientDocumentResponse(document *models.PatientDocument) dto.PatientDocumentResponse {
+	response := dto.PatientDocumentResponse{
+		ID:          document.ID,
+		PatientID:   document.PatientID,
+		Category:    document.Category,
+		Title:       document.Title,
+		FileName:    document.FileName,
+		ContentType: document.ContentType,
+		SizeBytes:   document.SizeBytes,
+		SHA256:      document.SHA256,
+		CreatedAt:   document.CreatedAt.Format(time.RFC3339),
+	}
+	if uploader := document.Uploader; uploader != nil {
+		response.UploadedBy = dto.PatientUser{
+			ID:       uploader.ID,
+			Username: uploader.Username,
+			Role:     uploader.Role,
+		}
+	}
+	return response
+}
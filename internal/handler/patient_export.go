@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/exporter"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/storage"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type PatientExportHandler struct {
+	service service.PatientExportService
+}
+
+func NewPatientExportHandler(service service.PatientExportService) *PatientExportHandler {
+	return &PatientExportHandler{service}
+}
+
+type patientExportQuery struct {
+	Format         string `form:"format" binding:"omitempty,oneof=csv xlsx ndjson"`
+	Name           string `form:"name"`
+	Gender         string `form:"gender"`
+	MRN            string `form:"mrn"`
+	Identifier     string `form:"identifier"`
+	IdentifierType string `form:"identifierType"`
+	Phone          string `form:"phone"`
+	BornFrom       string `form:"bornFrom" binding:"omitempty,datetime=2006-01-02"`
+	BornBefore     string `form:"bornBefore" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type patientRecordQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=json zip"`
+}
+
+// @Summary Export patients
+// @Description Download the patients matching the filters, oldest ID first, as CSV, XLSX or NDJSON (one JSON object per line). Rows are streamed as they are read, so large exports start immediately. Medical notes are not included. Every export is written to the audit log.
+// @Tags patients
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/x-ndjson
+// @Param format query string false "File format" Enums(csv, xlsx, ndjson) default(csv)
+// @Param name query string false "Part of the name or preferred name"
+// @Param gender query string false "Gender"
+// @Param mrn query string false "Medical record number"
+// @Param identifier query string false "MRN or external identifier value"
+// @Param identifierType query string false "Restrict identifier to this type"
+// @Param phone query string false "Phone number, formatting is ignored"
+// @Param bornFrom query string false "Born on or after (YYYY-MM-DD)"
+// @Param bornBefore query string false "Born before (YYYY-MM-DD)"
+// @Success 200 {file} file
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/export [get]
+// @Security BearerAuth
+func (h *PatientExportHandler) ExportPatients(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var query patientExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	if query.Format == "" {
+		query.Format = exporter.FormatCSV
+	}
+	contentType, err := exporter.ContentType(query.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	filter := repository.PatientFilter{
+		Name:           query.Name,
+		Gender:         query.Gender,
+		MRN:            query.MRN,
+		Identifier:     query.Identifier,
+		IdentifierType: query.IdentifierType,
+		Phone:          query.Phone,
+		BornFrom:       parseDate(query.BornFrom),
+		BornBefore:     parseDate(query.BornBefore),
+	}
+
+	filename := fmt.Sprintf("patients-%s.%s", time.Now().Format("20060102-150405"), query.Format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	if _, err := h.service.ExportPatients(c.Request.Context(), c.Writer, query.Format, filter, authUser.ID); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Failed to export patients", slog.Any("error", err))
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.JSON(patientExportErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		}
+	}
+}
+
+// @Summary Export a patient's full record
+// @Description Download everything held about a patient for a data-subject access request: demographics, notes, identifiers, lab results, insurance, billing, prescriptions and document details. The zip format adds the uploaded documents themselves next to record.json. Every export is written to the audit log.
+// @Tags patients
+// @Produce json,application/zip
+// @Param id path string true "Patient ID"
+// @Param format query string false "Package format" Enums(json, zip) default(json)
+// @Success 200 {object} dto.PatientRecordResponse
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/export [get]
+// @Security BearerAuth
+func (h *PatientExportHandler) ExportPatientRecord(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var query patientRecordQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	if query.Format == "" {
+		query.Format = "json"
+	}
+
+	record, err := h.service.GetPatientRecord(c.Request.Context(), c.Param("id"), query.Format, authUser.ID)
+	if err != nil {
+		c.JSON(patientExportErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	response := toPatientRecordResponse(record, time.Now())
+
+	basename := "patient-" + record.Patient.MRN
+	c.Header("Cache-Control", "no-store")
+	if query.Format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", basename+".json"))
+		c.IndentedJSON(http.StatusOK, response)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", basename+".zip"))
+	c.Status(http.StatusOK)
+	if err := h.writeRecordArchive(c, c.Writer, record, response); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Failed to export patient record", slog.String("patient_id", record.Patient.ID), slog.Any("error", err))
+	}
+}
+
+// writeRecordArchive writes record.json followed by each document under
+// documents/. Documents missing from storage are logged and left out.
+func (h *PatientExportHandler) writeRecordArchive(c *gin.Context, w io.Writer, record *service.PatientRecord, response dto.PatientRecordResponse) error {
+	archive := zip.NewWriter(w)
+	file, err := archive.Create("record.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(response); err != nil {
+		return err
+	}
+
+	for _, document := range record.Documents {
+		content, err := h.service.OpenDocument(c.Request.Context(), document)
+		if errors.Is(err, storage.ErrNotFound) {
+			logging.FromContext(c.Request.Context()).Warn("Document is missing from storage", slog.String("document_id", document.ID), slog.String("patient_id", record.Patient.ID))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     fmt.Sprintf("documents/%s-%s", document.ID, document.FileName),
+			Method:   zip.Deflate,
+			Modified: document.CreatedAt,
+		})
+		if err == nil {
+			_, err = io.Copy(file, content)
+		}
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func patientExportErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func toPatientRecordResponse(record *service.PatientRecord, now time.Time) dto.PatientRecordResponse {
+	response := dto.PatientRecordResponse{
+		ExportedAt:        now.Format(time.RFC3339),
+		Patient:           toPatientResponse(record.Patient, record.CreatedBy, record.UpdatedBy),
+		LabResults:        make([]dto.LabResultResponse, len(record.LabResults)),
+		InsurancePolicies: make([]dto.InsurancePolicyResponse, len(record.InsurancePolicies)),
+		Invoices:          make([]dto.InvoiceResponse, len(record.Invoices)),
+		Payments:          toPaymentResponses(record.Payments),
+		Claims:            make([]dto.ClaimResponse, len(record.Claims)),
+		Prescriptions:     make([]dto.PrescriptionResponse, len(record.Prescriptions)),
+		Documents:         make([]dto.PatientDocumentResponse, len(record.Documents)),
+	}
+	for i, result := range record.LabResults {
+		response.LabResults[i] = toLabResultResponse(result)
+	}
+	for i, policy := range record.InsurancePolicies {
+		response.InsurancePolicies[i] = toPolicyResponse(policy, now)
+	}
+	for i, invoice := range record.Invoices {
+		response.Invoices[i] = toInvoiceResponse(invoice)
+	}
+	for i, claim := range record.Claims {
+		response.Claims[i] = toClaimResponse(claim)
+	}
+	for i, prescription := range record.Prescriptions {
+		response.Prescriptions[i] = toPrescriptionResponse(prescription)
+	}
+	for i, document := range record.Documents {
+		response.Documents[i] = toPatientDocumentResponse(document)
+	}
+	return response
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type PatientIdentifierHandler struct {
+	service service.PatientIdentifierService
+}
+
+func NewPatientIdentifierHandler(service service.PatientIdentifierService) *PatientIdentifierHandler {
+	return &PatientIdentifierHandler{service}
+}
+
+// @Summary Get a patient's identifiers
+// @Description Get the external identifiers recorded for a patient
+// @Tags patients
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.PatientIdentifierResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/identifiers [get]
+// @Security BearerAuth
+func (h *PatientIdentifierHandler) GetPatientIdentifiers(c *gin.Context) {
+	identifiers, err := h.service.GetPatientIdentifiers(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(serverErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.PatientIdentifierResponse, len(identifiers))
+	for i, identifier := range identifiers {
+		responses[i] = toIdentifierResponse(identifier)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Add a patient identifier
+// @Description Record an external identifier such as a national ID or insurance member ID
+// @Tags patients
+// @Accept json
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param body body dto.AddPatientIdentifierRequest true "Add Patient Identifier Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.PatientIdentifierResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/identifiers [post]
+// @Security BearerAuth
+func (h *PatientIdentifierHandler) AddPatientIdentifier(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.AddPatientIdentifierRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	identifier := &models.PatientIdentifier{
+		PatientID: c.Param("id"),
+		Type:      body.Type,
+		Issuer:    body.Issuer,
+		Value:     body.Value,
+		CreatedBy: &authUser.ID,
+	}
+
+	if err := h.service.AddIdentifier(c.Request.Context(), identifier); err != nil {
+		switch {
+		case errors.Is(err, service.ErrDuplicateIdentifier):
+			c.JSON(http.StatusConflict, utils.NewErrorAPIResponse(err.Error()))
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, utils.NewErrorAPIResponse("patient not found"))
+		default:
+			c.JSON(serverErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(toIdentifierResponse(identifier)))
+}
+
+// @Summary Delete a patient identifier
+// @Description Delete an external identifier from a patient
+// @Tags patients
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param identifierId path string true "Identifier ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.DeletePatientIdentifierResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/identifiers/{identifierId} [delete]
+// @Security BearerAuth
+func (h *PatientIdentifierHandler) DeletePatientIdentifier(c *gin.Context) {
+	id := c.Param("identifierId")
+
+	if err := h.service.DeleteIdentifier(c.Request.Context(), c.Param("id"), id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, utils.NewErrorAPIResponse("identifier not found"))
+			return
+		}
+		c.JSON(serverErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.DeletePatientIdentifierResponse{ID: id}))
+}
+
+func toIdentifierResponse(identifier *models.PatientIdentifier) dto.PatientIdentifierResponse {
+	return dto.PatientIdentifierResponse{
+		ID:        identifier.ID,
+		Type:      identifier.Type,
+		Issuer:    identifier.Issuer,
+		Value:     identifier.Value,
+		CreatedAt: identifier.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toIdentifierResponses(identifiers []models.PatientIdentifier) []dto.PatientIdentifierResponse {
+	responses := make([]dto.PatientIdentifierResponse, len(identifiers))
+	for i := range identifiers {
+		responses[i] = toIdentifierResponse(&identifiers[i])
+	}
+	return responses
+}
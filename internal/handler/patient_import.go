@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/importer"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type PatientImportHandler struct {
+	service service.PatientImportService
+	maxSize int64
+	maxRows int
+}
+
+func NewPatientImportHandler(service service.PatientImportService, maxSize int64, maxRows int) *PatientImportHandler {
+	return &PatientImportHandler{service, maxSize, maxRows}
+}
+
+type patientImportForm struct {
+	Mapping         string `form:"mapping"`
+	DryRun          bool   `form:"dryRun"`
+	AllowDuplicates bool   `form:"allowDuplicates"`
+}
+
+// @Summary Import patients
+// @Description Import patients from a CSV or XLSX file with a header row. Columns are matched to fields by name unless mapped explicitly with a JSON object from column header to field, e.g. {"First Name": "givenName", "Notes": ""}. Fields are named as in AddPatientRequest, with nested fields like address.city. Rows are validated like AddPatientRequest and probable duplicates are skipped unless allowDuplicates is set. The import runs in the background; poll the returned job for progress and the per-row report. A dry run checks every row without creating anything.
+// @Tags patients
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Param mapping formData string false "Column mapping as JSON"
+// @Param dryRun formData bool false "Only validate the rows"
+// @Param allowDuplicates formData bool false "Import probable duplicates too"
+// @Success 202 {object} utils.SuccessAPIResponse[dto.PatientImportResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 413 {object} utils.ErrorAPIResponse
+// @Failure 415 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patient-imports [post]
+// @Security BearerAuth
+func (h *PatientImportHandler) CreateImport(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+
+	var form patientImportForm
+	if err := c.ShouldBind(&form); err != nil {
+		c.JSON(uploadErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	if header.Size > h.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, utils.NewErrorAPIResponse(fmt.Sprintf("file exceeds the %d byte limit", h.maxSize)))
+		return
+	}
+	format, err := importer.FormatFromName(header.Filename)
+	if err != nil {
+		c.JSON(http.StatusUnsupportedMediaType, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	var mapping map[string]string
+	if form.Mapping != "" {
+		if err := json.Unmarshal([]byte(form.Mapping), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse("mapping must be a JSON object of column names to fields"))
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	defer file.Close()
+
+	table, err := importer.ReadTable(file, format, h.maxRows)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	columns, err := importer.NewMapping(table.Header, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	job := &models.PatientImport{
+		FileName:        header.Filename,
+		Format:          format,
+		DryRun:          form.DryRun,
+		AllowDuplicates: form.AllowDuplicates,
+		CreatedBy:       authUser.ID,
+	}
+	if err := h.service.StartImport(c.Request.Context(), job, importer.ParseRows(table, columns)); err != nil {
+		c.JSON(patientImportErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	job.Creator = &models.User{ID: authUser.ID, Username: authUser.Username, Role: authUser.Role}
+
+	c.Header("Location", "/api/patient-imports/"+job.ID)
+	c.JSON(http.StatusAccepted, utils.NewSuccessAPIResponse(toPatientImportResponse(job)))
+}
+
+// @Summary Get patient imports
+// @Description List imports, newest first, without their per-row reports
+// @Tags patients
+// @Produce json
+// @Param limit query int false "Page size" default(50)
+// @Param offset query int false "Page offset"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.GetPatientImportsResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patient-imports [get]
+// @Security BearerAuth
+func (h *PatientImportHandler) GetImports(c *gin.Context) {
+	var query dto.PageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	jobs, total, err := h.service.GetImports(c.Request.Context(), query.LimitOrDefault(), query.Offset)
+	if err != nil {
+		c.JSON(patientImportErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.PatientImportResponse, len(jobs))
+	for i, job := range jobs {
+		responses[i] = toPatientImportResponse(job)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(dto.GetPatientImportsResponse{Imports: responses, Total: total}))
+}
+
+// @Summary Get a patient import
+// @Description Get an import's progress and the rows that were not imported, with the reason
+// @Tags patients
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.PatientImportResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patient-imports/{id} [get]
+// @Security BearerAuth
+func (h *PatientImportHandler) GetImport(c *gin.Context) {
+	job, err := h.service.GetImport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(patientImportErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPatientImportResponse(job)))
+}
+
+func patientImportErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func toPatientImportResponse(job *models.PatientImport) dto.PatientImportResponse {
+	response := dto.PatientImportResponse{
+		ID:              job.ID,
+		FileName:        job.FileName,
+		Format:          job.Format,
+		DryRun:          job.DryRun,
+		AllowDuplicates: job.AllowDuplicates,
+		Status:          job.Status,
+		TotalRows:       job.TotalRows,
+		ProcessedRows:   job.ProcessedRows,
+		CreatedRows:     job.CreatedRows,
+		DuplicateRows:   job.DuplicateRows,
+		FailedRows:      job.FailedRows,
+		Error:           job.Error,
+		CreatedAt:       job.CreatedAt.Format(time.RFC3339),
+		StartedAt:       formatTime(job.StartedAt),
+		FinishedAt:      formatTime(job.FinishedAt),
+	}
+	for _, issue := range job.Issues {
+		response.Issues = append(response.Issues, dto.PatientImportIssue{
+			Row:        issue.Row,
+			Field:      issue.Field,
+			Message:    issue.Message,
+			Duplicates: issue.Duplicates,
+		})
+	}
+	if creator := job.Creator; creator != nil {
+		response.CreatedBy = dto.PatientUser{
+			ID:       creator.ID,
+			Username: creator.Username,
+			Role:     creator.Role,
+		}
+	}
+	return response
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type PatientMergeHandler struct {
+	service service.PatientMergeService
+}
+
+func NewPatientMergeHandler(service service.PatientMergeService) *PatientMergeHandler {
+	return &PatientMergeHandler{service}
+}
+
+// @Summary Merge a duplicate patient
+// @Description Move all records of the duplicate patient onto this patient. The duplicate is kept as a tombstone pointing at this patient.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Surviving patient ID"
+// @Param body body dto.MergePatientRequest true "Merge Patient Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.PatientMergeResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/patients/{id}/merge [post]
+// @Security BearerAuth
+func (h *PatientMergeHandler) MergePatient(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.MergePatientRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	merge, err := h.service.MergePatients(c.Request.Context(), c.Param("id"), body.DuplicatePatientID, authUser.ID)
+	if err != nil {
+		c.JSON(mergeErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPatientMergeResponse(merge)))
+}
+
+// @Summary Get patient merges
+// @Description Get all patient merges, newest first
+// @Tags admin
+// @Produce json
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.PatientMergeResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/merges [get]
+// @Security BearerAuth
+func (h *PatientMergeHandler) GetMerges(c *gin.Context) {
+	merges, err := h.service.GetMerges(c.Request.Context())
+	if err != nil {
+		c.JSON(mergeErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.PatientMergeResponse, len(merges))
+	for i, merge := range merges {
+		responses[i] = toPatientMergeResponse(merge)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Reverse a patient merge
+// @Description Move the records back to the merged patient and restore it
+// @Tags admin
+// @Produce json
+// @Param id path string true "Merge ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.PatientMergeResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /admin/merges/{id}/reverse [post]
+// @Security BearerAuth
+func (h *PatientMergeHandler) ReverseMerge(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	merge, err := h.service.ReverseMerge(c.Request.Context(), c.Param("id"), authUser.ID)
+	if err != nil {
+		c.JSON(mergeErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPatientMergeResponse(merge)))
+}
+
+func mergeErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidMerge):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrPatientAlreadyMerged),
+		errors.Is(err, repository.ErrMergeAlreadyReversed),
+		errors.Is(err, repository.ErrPatientErased):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func toPatientMergeResponse(merge *models.PatientMerge) dto.PatientMergeResponse {
+	response := dto.PatientMergeResponse{
+		ID:           merge.ID,
+		SurvivorID:   merge.SurvivorID,
+		MergedID:     merge.MergedID,
+		MovedRecords: make(map[string]int),
+		MergedBy:     merge.MergedBy,
+		MergedAt:     merge.MergedAt.Format(time.RFC3339),
+		ReversedBy:   derefString(merge.ReversedBy),
+	}
+	if merge.Survivor != nil {
+		response.SurvivorName = merge.Survivor.Name
+	}
+	if merge.Merged != nil {
+		response.MergedName = merge.Merged.Name
+	}
+	if merge.ReversedAt != nil {
+		response.ReversedAt = merge.ReversedAt.Format(time.RFC3339)
+	}
+
+	var moved map[string][]string
+	if err := json.Unmarshal(merge.MovedRecords, &moved); err == nil {
+		for key, ids := range moved {
+			response.MovedRecords[key] = len(ids)
+		}
+	}
+	return response
+}
@@ -0,0 +1,479 @@
+package handler
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+//go:embed templates/receipt.html
+var receiptTemplateFS embed.FS
+
+var receiptTemplate = template.Must(template.ParseFS(receiptTemplateFS, "templates/receipt.html"))
+
+type PaymentHandler struct {
+	service service.PaymentService
+}
+
+func NewPaymentHandler(service service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service}
+}
+
+type paymentsQuery struct {
+	dto.PageQuery
+	InvoiceID  string `form:"invoiceId" binding:"omitempty,uuid"`
+	ReceivedBy string `form:"receivedBy" binding:"omitempty,uuid"`
+	Method     string `form:"method" binding:"omitempty,oneof=cash card bank_transfer"`
+	Date       string `form:"date" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type dayCloseQuery struct {
+	Date string `form:"date" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// @Summary Record a payment
+// @Description Record a payment against an issued invoice. The invoice becomes partially paid or paid.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Param body body dto.PaymentRequest true "Payment Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.PaymentResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices/{id}/payments [post]
+// @Security BearerAuth
+func (h *PaymentHandler) RecordPayment(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.PaymentRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	payment := toPaymentModel(body)
+	payment.InvoiceID = c.Param("id")
+	payment.ReceivedBy = authUser.ID
+	if err := h.service.RecordPayment(c.Request.Context(), payment); err != nil {
+		c.JSON(paymentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	h.respondWithPayment(c, http.StatusCreated, payment.ID)
+}
+
+// @Summary Record a refund
+// @Description Hand money back on an invoice, optionally against a specific payment
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Param body body dto.RefundRequest true "Refund Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.PaymentResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices/{id}/refunds [post]
+// @Security BearerAuth
+func (h *PaymentHandler) RecordRefund(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.RefundRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	refund := toPaymentModel(body.PaymentRequest)
+	refund.InvoiceID = c.Param("id")
+	refund.RefundOfID = body.RefundOfID
+	refund.ReceivedBy = authUser.ID
+	if err := h.service.RecordRefund(c.Request.Context(), refund); err != nil {
+		c.JSON(paymentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	h.respondWithPayment(c, http.StatusCreated, refund.ID)
+}
+
+// @Summary Get payments of an invoice
+// @Description Get the payments and refunds recorded against an invoice, newest first
+// @Tags payments
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.PaymentResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /invoices/{id}/payments [get]
+// @Security BearerAuth
+func (h *PaymentHandler) GetInvoicePayments(c *gin.Context) {
+	payments, _, err := h.service.SearchPayments(c.Request.Context(), repository.PaymentFilter{InvoiceID: c.Param("id")})
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPaymentResponses(payments)))
+}
+
+// @Summary Get payments
+// @Description Get payments and refunds, newest first
+// @Tags payments
+// @Produce json
+// @Param invoiceId query string false "Invoice ID"
+// @Param receivedBy query string false "ID of the user who took the payment"
+// @Param method query string false "Payment method" Enums(cash, card, bank_transfer)
+// @Param date query string false "Business date (YYYY-MM-DD)"
+// @Param limit query int false "Page size" default(50)
+// @Param offset query int false "Page offset"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.PaymentResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /payments [get]
+// @Security BearerAuth
+func (h *PaymentHandler) GetPayments(c *gin.Context) {
+	var query paymentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	payments, _, err := h.service.SearchPayments(c.Request.Context(), repository.PaymentFilter{
+		InvoiceID:    query.InvoiceID,
+		ReceivedBy:   query.ReceivedBy,
+		Method:       query.Method,
+		BusinessDate: parseDate(query.Date),
+		Limit:        query.LimitOrDefault(),
+		Offset:       query.Offset,
+	})
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPaymentResponses(payments)))
+}
+
+// @Summary Get a payment by ID
+// @Description Get a payment or refund
+// @Tags payments
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.PaymentResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /payments/{id} [get]
+// @Security BearerAuth
+func (h *PaymentHandler) GetPaymentByID(c *gin.Context) {
+	h.respondWithPayment(c, http.StatusOK, c.Param("id"))
+}
+
+// @Summary Update a payment
+// @Description Correct the method, amount, reference or notes of a payment. Payments of a closed day cannot be changed.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Param body body dto.PaymentRequest true "Payment Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.PaymentResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /payments/{id} [put]
+// @Security BearerAuth
+func (h *PaymentHandler) UpdatePayment(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.PaymentRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	payment, err := h.service.UpdatePayment(c.Request.Context(), c.Param("id"), authUser.ID, toPaymentModel(body))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPaymentResponse(payment)))
+}
+
+// @Summary Print a receipt
+// @Description Render a printable HTML receipt for a payment or refund
+// @Tags payments
+// @Produce html
+// @Param id path string true "Payment ID"
+// @Success 200 {string} string "Receipt"
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /payments/{id}/receipt [get]
+// @Security BearerAuth
+func (h *PaymentHandler) GetReceipt(c *gin.Context) {
+	payment, err := h.service.GetPaymentByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	data := map[string]any{
+		"Title":         "Payment Receipt",
+		"AmountLabel":   "Amount paid",
+		"ReceiptNumber": payment.ReceiptNumber,
+		"ReceivedAt":    payment.ReceivedAt.Format("Jan 02, 2006 15:04"),
+		"Method":        paymentMethodLabel(payment.Method),
+		"Reference":     payment.Reference,
+		"Amount":        formatDecimal(payment.Amount),
+	}
+	if payment.Kind == models.PaymentKindRefund {
+		data["Title"] = "Refund Receipt"
+		data["AmountLabel"] = "Amount refunded"
+	}
+	if payment.Receiver != nil {
+		data["ReceivedBy"] = payment.Receiver.Username
+	}
+	if invoice := payment.Invoice; invoice != nil {
+		data["InvoiceNumber"] = derefString(invoice.Number)
+		data["Currency"] = invoice.Currency
+		data["InvoiceTotal"] = formatDecimal(invoice.Total)
+		data["Balance"] = formatDecimal(invoice.Balance())
+		if invoice.Patient != nil {
+			data["PatientName"] = invoice.Patient.Name
+			data["PatientMRN"] = invoice.Patient.MRN
+		}
+	}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := receiptTemplate.Execute(c.Writer, data); err != nil {
+		c.Error(err)
+	}
+}
+
+// @Summary Get a day's takings
+// @Description Summarize payments and refunds per receptionist and method for a business date, and whether the day has been closed
+// @Tags payments
+// @Produce json
+// @Param date query string false "Business date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.DayCloseResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /payments/day-close [get]
+// @Security BearerAuth
+func (h *PaymentHandler) GetDayClose(c *gin.Context) {
+	var query dayCloseQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	summary, err := h.service.GetDaySummary(c.Request.Context(), businessDateOrToday(query.Date))
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toDayCloseResponse(summary)))
+}
+
+// @Summary Close a day
+// @Description Close a business date. Its payments can no longer be changed and no more payments can be taken on it.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param body body dto.DayCloseRequest false "Day Close Request"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.DayCloseResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 409 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /payments/day-close [post]
+// @Security BearerAuth
+func (h *PaymentHandler) CloseDay(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.DayCloseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+			return
+		}
+	}
+
+	summary, err := h.service.CloseDay(c.Request.Context(), businessDateOrToday(body.Date), authUser.ID, body.Notes)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toDayCloseResponse(summary)))
+}
+
+func (h *PaymentHandler) respondWithPayment(c *gin.Context, status int, id string) {
+	payment, err := h.service.GetPaymentByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(status, utils.NewSuccessAPIResponse(toPaymentResponse(payment)))
+}
+
+func paymentErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidPayment):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrDayClosed),
+		errors.Is(err, repository.ErrDayAlreadyClosed),
+		errors.Is(err, repository.ErrInvoiceNotPayable),
+		errors.Is(err, repository.ErrPaymentExceedsBalance),
+		errors.Is(err, repository.ErrRefundExceedsPaid):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func businessDateOrToday(value string) time.Time {
+	if date := parseDate(value); date != nil {
+		return *date
+	}
+	return utils.BusinessDate(time.Now())
+}
+
+func paymentMethodLabel(method string) string {
+	if method == models.PaymentMethodBankTransfer {
+		return "Bank transfer"
+	}
+	return strings.ToUpper(method[:1]) + method[1:]
+}
+
+func toPaymentModel(body dto.PaymentRequest) *models.Payment {
+	return &models.Payment{
+		Method:    body.Method,
+		Amount:    body.Amount,
+		Reference: body.Reference,
+		Notes:     body.Notes,
+	}
+}
+
+func toPaymentResponses(payments []*models.Payment) []dto.PaymentResponse {
+	responses := make([]dto.PaymentResponse, len(payments))
+	for i, payment := range payments {
+		responses[i] = toPaymentResponse(payment)
+	}
+	return responses
+}
+
+func toPaymentResponse(payment *models.Payment) dto.PaymentResponse {
+	response := dto.PaymentResponse{
+		ID:            payment.ID,
+		ReceiptNumber: payment.ReceiptNumber,
+		InvoiceID:     payment.InvoiceID,
+		Kind:          payment.Kind,
+		Method:        payment.Method,
+		Amount:        formatDecimal(payment.Amount),
+		Reference:     payment.Reference,
+		Notes:         payment.Notes,
+		RefundOfID:    derefString(payment.RefundOfID),
+		ReceivedBy:    payment.ReceivedBy,
+		ReceivedAt:    payment.ReceivedAt.Format(time.RFC3339),
+		BusinessDate:  payment.BusinessDate.Format(time.DateOnly),
+		Closed:        payment.DayCloseID != nil,
+	}
+	if payment.Receiver != nil {
+		response.ReceivedByName = payment.Receiver.Username
+	}
+	if invoice := payment.Invoice; invoice != nil {
+		response.InvoiceNumber = derefString(invoice.Number)
+		response.Currency = invoice.Currency
+		response.PatientID = invoice.PatientID
+		if invoice.Patient != nil {
+			response.PatientName = invoice.Patient.Name
+		}
+	}
+	return response
+}
+
+func toDayCloseResponse(summary *service.DaySummary) dto.DayCloseResponse {
+	response := dto.DayCloseResponse{
+		Date:   summary.Date.Format(time.DateOnly),
+		Closed: summary.Close != nil,
+		Lines:  make([]dto.DayCloseLine, len(summary.Lines)),
+	}
+	if dayClose := summary.Close; dayClose != nil {
+		response.ClosedBy = dayClose.ClosedBy
+		response.ClosedAt = dayClose.ClosedAt.Format(time.RFC3339)
+		response.Notes = dayClose.Notes
+		if dayClose.Closer != nil {
+			response.ClosedByName = dayClose.Closer.Username
+		}
+	}
+
+	net := decimal.Zero
+	methodTotals := make(map[string]decimal.Decimal)
+	var methods []string
+	for i, line := range summary.Lines {
+		lineNet := line.PaymentsTotal.Sub(line.RefundsTotal)
+		response.Lines[i] = dto.DayCloseLine{
+			ReceivedBy:    line.ReceivedBy,
+			Username:      line.Username,
+			Method:        line.Method,
+			PaymentCount:  line.PaymentCount,
+			PaymentsTotal: formatDecimal(line.PaymentsTotal),
+			RefundCount:   line.RefundCount,
+			RefundsTotal:  formatDecimal(line.RefundsTotal),
+			Net:           formatDecimal(lineNet),
+		}
+		if _, ok := methodTotals[line.Method]; !ok {
+			methods = append(methods, line.Method)
+		}
+		methodTotals[line.Method] = methodTotals[line.Method].Add(lineNet)
+		net = net.Add(lineNet)
+	}
+
+	response.Totals = make([]dto.DayCloseMethodTotal, len(methods))
+	for i, method := range methods {
+		response.Totals[i] = dto.DayCloseMethodTotal{Method: method, Net: formatDecimal(methodTotals[method])}
+	}
+	response.Net = formatDecimal(net)
+	return response
+}
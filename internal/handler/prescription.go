@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/middleware"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+type PrescriptionHandler struct {
+	service service.PrescriptionService
+}
+
+func NewPrescriptionHandler(service service.PrescriptionService) *PrescriptionHandler {
+	return &PrescriptionHandler{service}
+}
+
+// @Summary Get a patient's prescriptions
+// @Description Get the prescriptions written for a patient, newest first
+// @Tags prescriptions
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Success 200 {object} utils.SuccessAPIResponse[[]dto.PrescriptionResponse]
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/prescriptions [get]
+// @Security BearerAuth
+func (h *PrescriptionHandler) GetPatientPrescriptions(c *gin.Context) {
+	prescriptions, err := h.service.GetPatientPrescriptions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(prescriptionErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	responses := make([]dto.PrescriptionResponse, len(prescriptions))
+	for i, prescription := range prescriptions {
+		responses[i] = toPrescriptionResponse(prescription)
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(responses))
+}
+
+// @Summary Write a prescription
+// @Description Prescribe one or more medications for a patient. Prescriptions cannot be edited once written.
+// @Tags prescriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Patient ID"
+// @Param body body dto.PrescriptionRequest true "Prescription Request"
+// @Success 201 {object} utils.SuccessAPIResponse[dto.PrescriptionResponse]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /patients/{id}/prescriptions [post]
+// @Security BearerAuth
+func (h *PrescriptionHandler) AddPrescription(c *gin.Context) {
+	authUser := middleware.GetAuthUser(c)
+
+	var body dto.PrescriptionRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	prescription := &models.Prescription{
+		PatientID:    c.Param("id"),
+		PrescribedBy: authUser.ID,
+		Diagnosis:    body.Diagnosis,
+		Notes:        body.Notes,
+		Items:        make([]models.PrescriptionItem, len(body.Items)),
+	}
+	for i, item := range body.Items {
+		prescription.Items[i] = models.PrescriptionItem{
+			Medication:   item.Medication,
+			Dosage:       item.Dosage,
+			Route:        item.Route,
+			Frequency:    item.Frequency,
+			Duration:     item.Duration,
+			Quantity:     item.Quantity,
+			Instructions: item.Instructions,
+		}
+	}
+
+	created, err := h.service.CreatePrescription(c.Request.Context(), prescription)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, utils.NewErrorAPIResponse("patient not found"))
+			return
+		}
+		c.JSON(prescriptionErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.NewSuccessAPIResponse(toPrescriptionResponse(created)))
+}
+
+// @Summary Get a prescription
+// @Description Get a prescription by ID
+// @Tags prescriptions
+// @Produce json
+// @Param id path string true "Prescription ID"
+// @Success 200 {object} utils.SuccessAPIResponse[dto.PrescriptionResponse]
+// @Failure 404 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /prescriptions/{id} [get]
+// @Security BearerAuth
+func (h *PrescriptionHandler) GetPrescriptionByID(c *gin.Context) {
+	prescription, err := h.service.GetPrescriptionByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(prescriptionErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(toPrescriptionResponse(prescription)))
+}
+
+func prescriptionErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidPrescription):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func toPrescriptionResponse(prescription *models.Prescription) dto.PrescriptionResponse {
+	response := dto.PrescriptionResponse{
+		ID:        prescription.ID,
+		PatientID: prescription.PatientID,
+		Diagnosis: prescription.Diagnosis,
+		Notes:     prescription.Notes,
+		Items:     make([]dto.PrescriptionItemResponse, len(prescription.Items)),
+		CreatedAt: prescription.CreatedAt.Format(time.RFC3339),
+	}
+	if prescriber := prescription.Prescriber; prescriber != nil {
+		response.PrescribedBy = dto.PatientUser{
+			ID:       prescriber.ID,
+			Username: prescriber.Username,
+			Role:     prescriber.Role,
+		}
+	}
+	for i, item := range prescription.Items {
+		response.Items[i] = dto.PrescriptionItemResponse{
+			ID:           item.ID,
+			Medication:   item.Medication,
+			Dosage:       item.Dosage,
+			Route:        item.Route,
+			Frequency:    item.Frequency,
+			Duration:     item.Duration,
+			Quantity:     item.Quantity,
+			Instructions: item.Instructions,
+		}
+	}
+	return response
+}
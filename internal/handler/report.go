@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/exporter"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/service"
+	"github.com/max-programming/clinic/internal/utils"
+)
+
+type ReportHandler struct {
+	service service.ReportService
+}
+
+func NewReportHandler(service service.ReportService) *ReportHandler {
+	return &ReportHandler{service}
+}
+
+type reportQuery struct {
+	From    string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To      string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	GroupBy string `form:"groupBy" binding:"omitempty,oneof=day week month"`
+	Format  string `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+type demographicsQuery struct {
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Format string `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+// @Summary New patients report
+// @Description Count patients registered per period. Duplicates merged into another patient are not counted. Periods without registrations are left out.
+// @Tags reports
+// @Produce json,text/csv
+// @Param from query string false "First day (YYYY-MM-DD), defaults to twelve months before to"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Param groupBy query string false "Period" Enums(day, week, month) default(month)
+// @Param format query string false "Response format" Enums(json, csv) default(json)
+// @Success 200 {object} utils.SuccessAPIResponse[dto.NewPatientsReport]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /reports/new-patients [get]
+// @Security BearerAuth
+func (h *ReportHandler) GetNewPatients(c *gin.Context) {
+	query, rng, ok := bindReportQuery(c)
+	if !ok {
+		return
+	}
+
+	counts, err := h.service.NewPatients(c.Request.Context(), rng)
+	if err != nil {
+		c.JSON(reportErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	report := dto.NewPatientsReport{
+		From:    rng.From.Format(time.DateOnly),
+		To:      rng.To.Format(time.DateOnly),
+		GroupBy: rng.GroupBy,
+		Lines:   make([]dto.NewPatientsReportLine, len(counts)),
+	}
+	for i, count := range counts {
+		report.Lines[i] = dto.NewPatientsReportLine{Period: formatPeriod(count.Period), Count: count.Count}
+		report.Total += count.Count
+	}
+
+	if query.Format == exporter.FormatCSV {
+		rows := [][]string{{"period", "count"}}
+		for _, line := range report.Lines {
+			rows = append(rows, []string{line.Period, strconv.FormatInt(line.Count, 10)})
+		}
+		writeReportCSV(c, "new-patients", report.From, report.To, rows)
+		return
+	}
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(report))
+}
+
+// @Summary Demographics report
+// @Description Count patients by gender and age band (0-17, 18-34, 35-49, 50-64, 65+ or unknown). Ages are as of to. Without from, every patient registered up to to is counted.
+// @Tags reports
+// @Produce json,text/csv
+// @Param from query string false "Registered on or after (YYYY-MM-DD)"
+// @Param to query string false "Registered on or before (YYYY-MM-DD), defaults to today"
+// @Param format query string false "Response format" Enums(json, csv) default(json)
+// @Success 200 {object} utils.SuccessAPIResponse[dto.DemographicsReport]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /reports/demographics [get]
+// @Security BearerAuth
+func (h *ReportHandler) GetDemographics(c *gin.Context) {
+	var query demographicsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+	to := businessDateOrToday(query.To)
+	from := parseDate(query.From)
+
+	lines, err := h.service.Demographics(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(reportErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	report := dto.DemographicsReport{
+		To:    to.Format(time.DateOnly),
+		Lines: make([]dto.DemographicsReportLine, len(lines)),
+	}
+	if from != nil {
+		report.From = from.Format(time.DateOnly)
+	}
+	for i, line := range lines {
+		report.Lines[i] = dto.DemographicsReportLine{Gender: line.Gender, AgeBand: line.AgeBand, Count: line.Count}
+		report.Total += line.Count
+	}
+
+	if query.Format == exporter.FormatCSV {
+		rows := [][]string{{"gender", "age_band", "count"}}
+		for _, line := range report.Lines {
+			rows = append(rows, []string{line.Gender, line.AgeBand, strconv.FormatInt(line.Count, 10)})
+		}
+		writeReportCSV(c, "demographics", report.From, report.To, rows)
+		return
+	}
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(report))
+}
+
+// @Summary Visits per doctor report
+// @Description Count visits per doctor and period. A visit is a day on which the doctor wrote at least one prescription for a patient. Patients counts the different patients seen.
+// @Tags reports
+// @Produce json,text/csv
+// @Param from query string false "First day (YYYY-MM-DD), defaults to twelve months before to"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Param groupBy query string false "Period" Enums(day, week, month) default(month)
+// @Param format query string false "Response format" Enums(json, csv) default(json)
+// @Success 200 {object} utils.SuccessAPIResponse[dto.VisitsReport]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /reports/visits [get]
+// @Security BearerAuth
+func (h *ReportHandler) GetVisits(c *gin.Context) {
+	query, rng, ok := bindReportQuery(c)
+	if !ok {
+		return
+	}
+
+	lines, err := h.service.VisitsByDoctor(c.Request.Context(), rng)
+	if err != nil {
+		c.JSON(reportErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	report := dto.VisitsReport{
+		From:    rng.From.Format(time.DateOnly),
+		To:      rng.To.Format(time.DateOnly),
+		GroupBy: rng.GroupBy,
+		Lines:   make([]dto.VisitsReportLine, len(lines)),
+	}
+	for i, line := range lines {
+		report.Lines[i] = dto.VisitsReportLine{
+			Period:   formatPeriod(line.Period),
+			DoctorID: line.DoctorID,
+			Doctor:   line.Username,
+			Visits:   line.Visits,
+			Patients: line.Patients,
+		}
+	}
+
+	if query.Format == exporter.FormatCSV {
+		rows := [][]string{{"period", "doctor_id", "doctor", "visits", "patients"}}
+		for _, line := range report.Lines {
+			rows = append(rows, []string{
+				line.Period,
+				line.DoctorID,
+				line.Doctor,
+				strconv.FormatInt(line.Visits, 10),
+				strconv.FormatInt(line.Patients, 10),
+			})
+		}
+		writeReportCSV(c, "visits", report.From, report.To, rows)
+		return
+	}
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(report))
+}
+
+// @Summary Revenue report
+// @Description Total invoices issued and payments taken per period and currency. Invoiced counts issued invoices by issue date, excluding voided ones; collected and refunded count payments by business date.
+// @Tags reports
+// @Produce json,text/csv
+// @Param from query string false "First day (YYYY-MM-DD), defaults to twelve months before to"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Param groupBy query string false "Period" Enums(day, week, month) default(month)
+// @Param format query string false "Response format" Enums(json, csv) default(json)
+// @Success 200 {object} utils.SuccessAPIResponse[dto.RevenueReport]
+// @Failure 400 {object} utils.ErrorAPIResponse
+// @Failure 500 {object} utils.ErrorAPIResponse
+// @Failure 503 {object} utils.ErrorAPIResponse
+// @Failure 504 {object} utils.ErrorAPIResponse
+// @Router /reports/revenue [get]
+// @Security BearerAuth
+func (h *ReportHandler) GetRevenue(c *gin.Context) {
+	query, rng, ok := bindReportQuery(c)
+	if !ok {
+		return
+	}
+
+	lines, err := h.service.Revenue(c.Request.Context(), rng)
+	if err != nil {
+		c.JSON(reportErrorStatus(err), utils.NewErrorAPIResponse(err.Error()))
+		return
+	}
+
+	report := dto.RevenueReport{
+		From:    rng.From.Format(time.DateOnly),
+		To:      rng.To.Format(time.DateOnly),
+		GroupBy: rng.GroupBy,
+		Lines:   make([]dto.RevenueReportLine, len(lines)),
+	}
+	for i, line := range lines {
+		report.Lines[i] = dto.RevenueReportLine{
+			Period:       formatPeriod(line.Period),
+			Currency:     line.Currency,
+			InvoiceCount: line.InvoiceCount,
+			Invoiced:     formatDecimal(line.Invoiced),
+			Collected:    formatDecimal(line.Collected),
+			Refunded:     formatDecimal(line.Refunded),
+			Net:          formatDecimal(line.Net()),
+		}
+	}
+
+	if query.Format == exporter.FormatCSV {
+		rows := [][]string{{"period", "currency", "invoice_count", "invoiced", "collected", "refunded", "net"}}
+		for _, line := range report.Lines {
+			rows = append(rows, []string{
+				line.Period,
+				line.Currency,
+				strconv.FormatInt(line.InvoiceCount, 10),
+				line.Invoiced,
+				line.Collected,
+				line.Refunded,
+				line.Net,
+			})
+		}
+		writeReportCSV(c, "revenue", report.From, report.To, rows)
+		return
+	}
+	c.JSON(http.StatusOK, utils.NewSuccessAPIResponse(report))
+}
+
+// bindReportQuery reads the range of a periodic report. It defaults to the
+// twelve months up to today, by month.
+func bindReportQuery(c *gin.Context) (reportQuery, service.ReportRange, bool) {
+	var query reportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, utils.NewErrorAPIResponse(err.Error()))
+		return query, service.ReportRange{}, false
+	}
+
+	rng := service.ReportRange{To: businessDateOrToday(query.To), GroupBy: query.GroupBy}
+	if from := parseDate(query.From); from != nil {
+		rng.From = *from
+	} else {
+		rng.From = rng.To.AddDate(-1, 0, 1)
+	}
+	if rng.GroupBy == "" {
+		rng.GroupBy = "month"
+	}
+	return query, rng, true
+}
+
+func writeReportCSV(c *gin.Context, name, from, to string, rows [][]string) {
+	contentType, _ := exporter.ContentType(exporter.FormatCSV)
+	filename := fmt.Sprintf("%s-%s.csv", name, to)
+	if from != "" {
+		filename = fmt.Sprintf("%s-%s-%s.csv", name, from, to)
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if err := exporter.WriteCSV(c.Writer, rows); err != nil {
+		logging.FromContext(c.Request.Context()).Warn("Failed to write report", slog.String("report", name), slog.Any("error", err))
+	}
+}
+
+// formatPeriod renders the first day of a period.
+func formatPeriod(period time.Time) string {
+	return period.UTC().Format(time.DateOnly)
+}
+
+func reportErrorStatus(err error) int {
+	if status, ok := contextErrorStatus(err); ok {
+		return status
+	}
+	if errors.Is(err, service.ErrInvalidReport) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,74 @@
+package hl7
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AckAccept = "AA"
+	AckError  = "AE"
+	AckReject = "AR"
+)
+
+// BuildAck returns an ACK for msg. When msg could not be parsed it may be
+// nil, in which case a minimal header is produced so that the sender still
+// receives a negative acknowledgement.
+func BuildAck(msg *Message, code, text string) []byte {
+	d := DefaultDelimiters
+	var sendingApp, sendingFacility, receivingApp, receivingFacility string
+	var trigger, controlID, processingID, version string
+	if msg != nil {
+		d = msg.Delims
+		header := msg.Header()
+		sendingApp = header.Field(5)
+		sendingFacility = header.Field(6)
+		receivingApp = header.Field(3)
+		receivingFacility = header.Field(4)
+		trigger = msg.Trigger()
+		controlID = msg.ControlID()
+		processingID = header.Field(11)
+		version = header.Field(12)
+	}
+	if processingID == "" {
+		processingID = "P"
+	}
+	if version == "" {
+		version = "2.5.1"
+	}
+
+	now := time.Now()
+	sep := string(d.Field)
+	encoding := string([]byte{d.Component, d.Repetition, d.Escape, d.Subcomponent})
+	messageType := "ACK"
+	if trigger != "" {
+		messageType = "ACK" + string(d.Component) + trigger + string(d.Component) + "ACK"
+	}
+
+	msh := strings.Join([]string{
+		"MSH",
+		encoding,
+		sendingApp,
+		sendingFacility,
+		receivingApp,
+		receivingFacility,
+		FormatTime(now),
+		"",
+		messageType,
+		"ACK" + strconv.FormatInt(now.UnixNano(), 10),
+		processingID,
+		version,
+	}, sep)
+
+	msa := strings.Join([]string{"MSA", code, d.Encode(controlID), d.Encode(text)}, sep)
+
+	segments := []string{msh, msa}
+	if code != AckAccept && text != "" {
+		// ERR-3 207 is "application internal error" in HL7 table 0357.
+		errorCode := "207" + string(d.Component) + "Application internal error" + string(d.Component) + "HL70357"
+		segments = append(segments, strings.Join([]string{"ERR", "", "", errorCode, "E", "", "", "", d.Encode(text)}, sep))
+	}
+
+	return []byte(strings.Join(segments, "\r") + "\r")
+}
@@ -0,0 +1,111 @@
+package hl7
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildAck(t *testing.T) {
+	msg, err := Parse([]byte(admitMessage))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		msg         *Message
+		code        string
+		text        string
+		messageType string
+		receiving   string
+		controlID   string
+		wantErr     bool
+	}{
+		{
+			name:        "accept",
+			msg:         msg,
+			code:        AckAccept,
+			messageType: "ACK^A04^ACK",
+			receiving:   "ADT",
+			controlID:   "MSG0001",
+		},
+		{
+			name:        "error with text",
+			msg:         msg,
+			code:        AckError,
+			text:        "no patient with MRN MRN-000042",
+			messageType: "ACK^A04^ACK",
+			receiving:   "ADT",
+			controlID:   "MSG0001",
+			wantErr:     true,
+		},
+		{
+			name:        "reject unparsed message",
+			code:        AckReject,
+			text:        "invalid HL7 message",
+			messageType: "ACK",
+			wantErr:     true,
+		},
+		{
+			name:        "error without text",
+			msg:         msg,
+			code:        AckError,
+			messageType: "ACK^A04^ACK",
+			receiving:   "ADT",
+			controlID:   "MSG0001",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ack, err := Parse(BuildAck(tt.msg, tt.code, tt.text))
+			if err != nil {
+				t.Fatalf("ACK does not parse: %v", err)
+			}
+			header := ack.Header()
+			if got := header.Field(9); got != tt.messageType {
+				t.Errorf("MSH-9 = %q, want %q", got, tt.messageType)
+			}
+			if got := header.Component(5, 1); got != tt.receiving {
+				t.Errorf("MSH-5 = %q, want %q", got, tt.receiving)
+			}
+			if got := ack.Version(); got != "2.5.1" {
+				t.Errorf("MSH-12 = %q, want %q", got, "2.5.1")
+			}
+
+			msa := ack.Segment("MSA")
+			if got := msa.Value(1); got != tt.code {
+				t.Errorf("MSA-1 = %q, want %q", got, tt.code)
+			}
+			if got := msa.Value(2); got != tt.controlID {
+				t.Errorf("MSA-2 = %q, want %q", got, tt.controlID)
+			}
+			if got := msa.Value(3); got != tt.text {
+				t.Errorf("MSA-3 = %q, want %q", got, tt.text)
+			}
+
+			errSegment := ack.Segment("ERR")
+			if (errSegment != nil) != tt.wantErr {
+				t.Fatalf("ERR segment present = %v, want %v", errSegment != nil, tt.wantErr)
+			}
+			if errSegment != nil && errSegment.Value(8) != tt.text {
+				t.Errorf("ERR-8 = %q, want %q", errSegment.Value(8), tt.text)
+			}
+		})
+	}
+}
+
+func TestBuildAckEscapesText(t *testing.T) {
+	ack := string(BuildAck(nil, AckError, "field|component^line\nbreak"))
+	if !strings.Contains(ack, "field\\F\\component\\S\\line\\.br\\break") {
+		t.Errorf("ACK text not escaped: %q", ack)
+	}
+
+	parsed, err := Parse([]byte(ack))
+	if err != nil {
+		t.Fatalf("ACK does not parse: %v", err)
+	}
+	if got := parsed.Segment("MSA").Value(3); got != "field|component^line\nbreak" {
+		t.Errorf("MSA-3 = %q", got)
+	}
+}
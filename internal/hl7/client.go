@@ -0,0 +1,36 @@
+package hl7
+
+import (
+	"bufio"
+	"net"
+	"time"
+)
+
+type Client struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+}
+
+func Dial(addr string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, reader: bufio.NewReader(conn), timeout: timeout}, nil
+}
+
+// Send writes a single message and waits for the acknowledgement.
+func (c *Client) Send(payload []byte) ([]byte, error) {
+	if c.timeout > 0 {
+		c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if err := WriteFrame(c.conn, payload); err != nil {
+		return nil, err
+	}
+	return ReadFrame(c.reader)
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
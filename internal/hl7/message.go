@@ -0,0 +1,243 @@
+package hl7
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidMessage = errors.New("invalid HL7 message")
+
+type Delimiters struct {
+	Field        byte
+	Component    byte
+	Repetition   byte
+	Escape       byte
+	Subcomponent byte
+}
+
+var DefaultDelimiters = Delimiters{
+	Field:        '|',
+	Component:    '^',
+	Repetition:   '~',
+	Escape:       '\\',
+	Subcomponent: '&',
+}
+
+type Segment struct {
+	Name   string
+	fields []string
+	delims Delimiters
+}
+
+type Message struct {
+	Segments []*Segment
+	Delims   Delimiters
+}
+
+func Parse(raw []byte) (*Message, error) {
+	text := strings.ReplaceAll(string(raw), "\r\n", "\r")
+	text = strings.ReplaceAll(text, "\n", "\r")
+	text = strings.Trim(text, "\r")
+
+	if !strings.HasPrefix(text, "MSH") || len(text) < 8 {
+		return nil, ErrInvalidMessage
+	}
+
+	delims := Delimiters{
+		Field:        text[3],
+		Component:    text[4],
+		Repetition:   text[5],
+		Escape:       text[6],
+		Subcomponent: text[7],
+	}
+
+	msg := &Message{Delims: delims}
+	for line := range strings.SplitSeq(text, "\r") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, string(delims.Field))
+		if len(fields[0]) != 3 {
+			return nil, ErrInvalidMessage
+		}
+		msg.Segments = append(msg.Segments, &Segment{
+			Name:   fields[0],
+			fields: fields,
+			delims: delims,
+		})
+	}
+
+	return msg, nil
+}
+
+// Field returns the raw value of field n using HL7 numbering. MSH is special
+// cased because MSH-1 is the field separator itself.
+func (s *Segment) Field(n int) string {
+	if s == nil || n < 1 {
+		return ""
+	}
+	if s.Name == "MSH" {
+		if n == 1 {
+			return string(s.delims.Field)
+		}
+		n--
+	}
+	if n >= len(s.fields) {
+		return ""
+	}
+	return s.fields[n]
+}
+
+func (s *Segment) Repetitions(n int) []string {
+	field := s.Field(n)
+	if field == "" {
+		return nil
+	}
+	if s.Name == "MSH" && n == 2 {
+		return []string{field}
+	}
+	return strings.Split(field, string(s.delims.Repetition))
+}
+
+// Component returns component c of the first repetition of field n, unescaped.
+func (s *Segment) Component(n, c int) string {
+	reps := s.Repetitions(n)
+	if len(reps) == 0 {
+		return ""
+	}
+	return s.component(reps[0], c)
+}
+
+func (s *Segment) RepetitionComponent(rep string, c int) string {
+	return s.component(rep, c)
+}
+
+func (s *Segment) component(value string, c int) string {
+	components := strings.Split(value, string(s.delims.Component))
+	if c < 1 || c > len(components) {
+		return ""
+	}
+	return s.delims.Decode(components[c-1])
+}
+
+// Value returns field n unescaped, without splitting components.
+func (s *Segment) Value(n int) string {
+	return s.delims.Decode(s.Field(n))
+}
+
+func (m *Message) Segment(name string) *Segment {
+	for _, seg := range m.Segments {
+		if seg.Name == name {
+			return seg
+		}
+	}
+	return nil
+}
+
+func (m *Message) Header() *Segment {
+	return m.Segment("MSH")
+}
+
+func (m *Message) Type() string {
+	return m.Header().Component(9, 1)
+}
+
+func (m *Message) Trigger() string {
+	return m.Header().Component(9, 2)
+}
+
+func (m *Message) ControlID() string {
+	return m.Header().Value(10)
+}
+
+func (m *Message) SendingApplication() string {
+	return m.Header().Component(3, 1)
+}
+
+func (m *Message) SendingFacility() string {
+	return m.Header().Component(4, 1)
+}
+
+func (m *Message) Version() string {
+	return m.Header().Component(12, 1)
+}
+
+func (d Delimiters) Decode(value string) string {
+	if strings.IndexByte(value, d.Escape) < 0 {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != d.Escape {
+			b.WriteByte(value[i])
+			continue
+		}
+		end := strings.IndexByte(value[i+1:], d.Escape)
+		if end < 0 {
+			b.WriteString(value[i:])
+			break
+		}
+		seq := value[i+1 : i+1+end]
+		switch seq {
+		case "F":
+			b.WriteByte(d.Field)
+		case "S":
+			b.WriteByte(d.Component)
+		case "T":
+			b.WriteByte(d.Subcomponent)
+		case "R":
+			b.WriteByte(d.Repetition)
+		case "E":
+			b.WriteByte(d.Escape)
+		case ".br":
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(d.Escape)
+			b.WriteString(seq)
+			b.WriteByte(d.Escape)
+		}
+		i += end + 1
+	}
+	return b.String()
+}
+
+func (d Delimiters) Encode(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case d.Escape:
+			b.WriteString(string(d.Escape) + "E" + string(d.Escape))
+		case d.Field:
+			b.WriteString(string(d.Escape) + "F" + string(d.Escape))
+		case d.Component:
+			b.WriteString(string(d.Escape) + "S" + string(d.Escape))
+		case d.Subcomponent:
+			b.WriteString(string(d.Escape) + "T" + string(d.Escape))
+		case d.Repetition:
+			b.WriteString(string(d.Escape) + "R" + string(d.Escape))
+		case '\r', '\n':
+			b.WriteString(string(d.Escape) + ".br" + string(d.Escape))
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// RemoveSegments returns raw without the named segments, with segments
+// separated by carriage returns.
+func RemoveSegments(raw string, names ...string) string {
+	text := strings.ReplaceAll(raw, "\r\n", "\r")
+	text = strings.ReplaceAll(text, "\n", "\r")
+
+	var kept []string
+	for line := range strings.SplitSeq(strings.Trim(text, "\r"), "\r") {
+		if len(line) >= 3 && slices.Contains(names, line[:3]) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\r")
+}
@@ -0,0 +1,204 @@
+package hl7
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+const admitMessage = "MSH|^~\\&|ADT|HOSP|CLINIC|MAIN|20250512103000||ADT^A04|MSG0001|P|2.5.1\r" +
+	"PID|1||MRN-000042^^^CLINIC^MR~99887766^^^NHS^NH||Doe^Jane^Q||19800101|F|||12 High St^^Leeds\r" +
+	"NTE|1||Allergic to penicillin \\T\\ latex\\.br\\see chart\r"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		segments []string
+		wantErr  error
+	}{
+		{name: "carriage returns", raw: admitMessage, segments: []string{"MSH", "PID", "NTE"}},
+		{name: "line feeds", raw: "MSH|^~\\&|A|B\nPID|1\n", segments: []string{"MSH", "PID"}},
+		{name: "crlf and blank lines", raw: "\r\nMSH|^~\\&|A|B\r\n\r\nPID|1\r\n", segments: []string{"MSH", "PID"}},
+		{name: "custom delimiters", raw: "MSH#$*@%#A#B\rPID#1", segments: []string{"MSH", "PID"}},
+		{name: "empty", raw: "", wantErr: ErrInvalidMessage},
+		{name: "no header", raw: "PID|1||MRN-000042", wantErr: ErrInvalidMessage},
+		{name: "truncated header", raw: "MSH|^~", wantErr: ErrInvalidMessage},
+		{name: "bad segment name", raw: "MSH|^~\\&|A\rPIDX|1", wantErr: ErrInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Parse([]byte(tt.raw))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var names []string
+			for _, seg := range msg.Segments {
+				names = append(names, seg.Name)
+			}
+			if !slices.Equal(names, tt.segments) {
+				t.Errorf("segments = %v, want %v", names, tt.segments)
+			}
+		})
+	}
+}
+
+func TestMessageHeader(t *testing.T) {
+	msg, err := Parse([]byte(admitMessage))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "type", got: msg.Type(), want: "ADT"},
+		{name: "trigger", got: msg.Trigger(), want: "A04"},
+		{name: "control id", got: msg.ControlID(), want: "MSG0001"},
+		{name: "sending application", got: msg.SendingApplication(), want: "ADT"},
+		{name: "sending facility", got: msg.SendingFacility(), want: "HOSP"},
+		{name: "version", got: msg.Version(), want: "2.5.1"},
+		{name: "MSH-1", got: msg.Header().Field(1), want: "|"},
+		{name: "MSH-2", got: msg.Header().Field(2), want: "^~\\&"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmentFields(t *testing.T) {
+	msg, err := Parse([]byte(admitMessage))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	pid := msg.Segment("PID")
+	nte := msg.Segment("NTE")
+	var missing *Segment
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "field", got: pid.Field(7), want: "19800101"},
+		{name: "field past the end", got: pid.Field(40), want: ""},
+		{name: "field zero", got: pid.Field(0), want: ""},
+		{name: "component", got: pid.Component(5, 2), want: "Jane"},
+		{name: "component past the end", got: pid.Component(5, 9), want: ""},
+		{name: "component of first repetition", got: pid.Component(3, 1), want: "MRN-000042"},
+		{name: "escapes decoded", got: nte.Value(3), want: "Allergic to penicillin & latex\nsee chart"},
+		{name: "missing segment", got: missing.Field(1), want: ""},
+		{name: "missing segment component", got: msg.Segment("PV1").Component(3, 1), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+
+	reps := pid.Repetitions(3)
+	if len(reps) != 2 {
+		t.Fatalf("Repetitions(3) = %v, want 2 repetitions", reps)
+	}
+	if got := pid.RepetitionComponent(reps[1], 4); got != "NHS" {
+		t.Errorf("RepetitionComponent() = %q, want %q", got, "NHS")
+	}
+}
+
+func TestDelimitersEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		decoded string
+		encoded string
+	}{
+		{name: "plain", decoded: "Jane Doe", encoded: "Jane Doe"},
+		{name: "field separator", decoded: "a|b", encoded: "a\\F\\b"},
+		{name: "component separator", decoded: "a^b", encoded: "a\\S\\b"},
+		{name: "repetition separator", decoded: "a~b", encoded: "a\\R\\b"},
+		{name: "subcomponent separator", decoded: "a&b", encoded: "a\\T\\b"},
+		{name: "escape character", decoded: "a\\b", encoded: "a\\E\\b"},
+		{name: "line break", decoded: "a\nb", encoded: "a\\.br\\b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultDelimiters.Encode(tt.decoded); got != tt.encoded {
+				t.Errorf("Encode(%q) = %q, want %q", tt.decoded, got, tt.encoded)
+			}
+			if got := DefaultDelimiters.Decode(tt.encoded); got != tt.decoded {
+				t.Errorf("Decode(%q) = %q, want %q", tt.encoded, got, tt.decoded)
+			}
+		})
+	}
+}
+
+func TestDecodeKeepsUnknownEscapes(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "\\H\\bold\\N\\", want: "\\H\\bold\\N\\"},
+		{value: "trailing \\", want: "trailing \\"},
+	}
+
+	for _, tt := range tests {
+		if got := DefaultDelimiters.Decode(tt.value); got != tt.want {
+			t.Errorf("Decode(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveSegments(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		names []string
+		want  string
+	}{
+		{
+			name:  "removes every occurrence",
+			raw:   "MSH|^~\\&|A\rNTE|1||one\rPID|1\rNTE|2||two\r",
+			names: []string{"NTE"},
+			want:  "MSH|^~\\&|A\rPID|1",
+		},
+		{
+			name:  "several names",
+			raw:   "MSH|^~\\&|A\rPID|1\rNK1|1\rPV1|1",
+			names: []string{"PID", "NK1"},
+			want:  "MSH|^~\\&|A\rPV1|1",
+		},
+		{
+			name:  "normalizes line endings",
+			raw:   "MSH|^~\\&|A\r\nPID|1\nPV1|1\n",
+			names: []string{"PID"},
+			want:  "MSH|^~\\&|A\rPV1|1",
+		},
+		{
+			name: "nothing to remove",
+			raw:  "MSH|^~\\&|A\rPV1|1",
+			want: "MSH|^~\\&|A\rPV1|1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RemoveSegments(tt.raw, tt.names...); got != tt.want {
+				t.Errorf("RemoveSegments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package hl7
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+const (
+	startBlock     byte = 0x0b
+	endBlock       byte = 0x1c
+	carriageReturn byte = 0x0d
+)
+
+var ErrInvalidFrame = errors.New("invalid MLLP frame")
+
+// ReadFrame reads a single MLLP framed message, discarding any bytes that
+// precede the start block.
+func ReadFrame(r *bufio.Reader) ([]byte, error) {
+	if _, err := r.ReadBytes(startBlock); err != nil {
+		return nil, err
+	}
+
+	payload, err := r.ReadBytes(endBlock)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	trailer, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if trailer != carriageReturn {
+		return nil, ErrInvalidFrame
+	}
+
+	return payload[:len(payload)-1], nil
+}
+
+func WriteFrame(w io.Writer, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+3)
+	frame = append(frame, startBlock)
+	frame = append(frame, payload...)
+	frame = append(frame, endBlock, carriageReturn)
+	_, err := w.Write(frame)
+	return err
+}
@@ -0,0 +1,59 @@
+package hl7
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "frame", input: "\x0bMSH|^~\\&|A\r\x1c\r", want: "MSH|^~\\&|A\r"},
+		{name: "leading noise", input: "junk\x0bMSH|^~\\&|A\x1c\r", want: "MSH|^~\\&|A"},
+		{name: "empty payload", input: "\x0b\x1c\r", want: ""},
+		{name: "no start block", input: "MSH|^~\\&|A", wantErr: io.EOF},
+		{name: "no end block", input: "\x0bMSH|^~\\&|A", wantErr: io.ErrUnexpectedEOF},
+		{name: "no trailer", input: "\x0bMSH|^~\\&|A\x1c", wantErr: io.EOF},
+		{name: "bad trailer", input: "\x0bMSH|^~\\&|A\x1cX", wantErr: ErrInvalidFrame},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFrame(bufio.NewReader(strings.NewReader(tt.input)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadFrame() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && string(got) != tt.want {
+				t.Errorf("ReadFrame() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	for _, payload := range []string{admitMessage, "MSH|^~\\&|B"} {
+		if err := WriteFrame(&buf, []byte(payload)); err != nil {
+			t.Fatalf("WriteFrame() error = %v", err)
+		}
+	}
+
+	r := bufio.NewReader(&buf)
+	for _, want := range []string{admitMessage, "MSH|^~\\&|B"} {
+		got, err := ReadFrame(r)
+		if err != nil {
+			t.Fatalf("ReadFrame() error = %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("ReadFrame() = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package hl7
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/max-programming/clinic/internal/logging"
+)
+
+// Handler processes a message and returns the ACK. ctx carries a logger for
+// the connection the message arrived on.
+type Handler interface {
+	HandleMessage(ctx context.Context, raw []byte) []byte
+}
+
+type Server struct {
+	Addr        string
+	Handler     Handler
+	IdleTimeout time.Duration
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+func NewServer(addr string, handler Handler) *Server {
+	return &Server{
+		Addr:        addr,
+		Handler:     handler,
+		IdleTimeout: 5 * time.Minute,
+		conns:       make(map[net.Conn]struct{}),
+	}
+}
+
+var ErrServerClosed = errors.New("hl7: server closed")
+
+func (s *Server) ListenAndServe() error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ln.Close()
+		return ErrServerClosed
+	}
+	s.listener = ln
+	s.mu.Unlock()
+
+	slog.Info("HL7 MLLP listener started", slog.String("addr", ln.Addr().String()))
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		if !s.trackConn(conn, true) {
+			conn.Close()
+			continue
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return err
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Shutdown stops accepting connections and lets messages being handled get
+// their ACK before closing each connection. Connections waiting for a message
+// are closed straight away. If ctx is done first, the rest are closed as by
+// Close and ctx's error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		// Interrupts a pending read; a handler that is running finishes and
+		// serveConn returns before reading again.
+		conn.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+}
+
+// trackConn reports false when a connection is added after the server has
+// been closed.
+func (s *Server) trackConn(conn net.Conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if add {
+		if s.closed {
+			return false
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+	} else {
+		delete(s.conns, conn)
+		s.wg.Done()
+	}
+	return true
+}
+
+// waitForFrame sets the deadline for the next frame, reporting false once
+// the server is shutting down.
+func (s *Server) waitForFrame(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.IdleTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(s.IdleTimeout))
+	}
+	return true
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.trackConn(conn, false)
+	}()
+
+	logger := slog.Default().With(slog.String("remote_addr", conn.RemoteAddr().String()))
+	ctx := logging.WithContext(context.Background(), logger)
+
+	reader := bufio.NewReader(conn)
+	for {
+		if !s.waitForFrame(conn) {
+			return
+		}
+
+		payload, err := ReadFrame(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) && !s.isClosed() {
+				logger.Warn("HL7 connection failed", slog.Any("error", err))
+			}
+			return
+		}
+
+		ack := s.Handler.HandleMessage(ctx, payload)
+		if err := WriteFrame(conn, ack); err != nil {
+			logger.Warn("Failed to write HL7 ACK", slog.Any("error", err))
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package hl7
+
+import (
+	"strings"
+	"time"
+)
+
+const timestampLayout = "20060102150405"
+
+// ParseTime parses an HL7 DTM value such as 20250512, 202505121030 or
+// 20250512103000.1234+0200.
+func ParseTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	zone := ""
+	if i := strings.IndexAny(value, "+-"); i > 0 {
+		zone = value[i:]
+		value = value[:i]
+	}
+	if i := strings.IndexByte(value, '.'); i > 0 {
+		value = value[:i]
+	}
+
+	layouts := map[int]string{
+		4:  "2006",
+		6:  "200601",
+		8:  "20060102",
+		10: "2006010215",
+		12: "200601021504",
+		14: timestampLayout,
+	}
+	layout, ok := layouts[len(value)]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	if zone != "" {
+		t, err := time.Parse(layout+"-0700", value+zone)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+
+	t, err := time.ParseInLocation(layout, value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func FormatTime(t time.Time) string {
+	return t.Format(timestampLayout)
+}
@@ -0,0 +1,52 @@
+package hl7
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{value: "2025", want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local), wantOK: true},
+		{value: "202505", want: time.Date(2025, 5, 1, 0, 0, 0, 0, time.Local), wantOK: true},
+		{value: "20250512", want: time.Date(2025, 5, 12, 0, 0, 0, 0, time.Local), wantOK: true},
+		{value: "2025051210", want: time.Date(2025, 5, 12, 10, 0, 0, 0, time.Local), wantOK: true},
+		{value: "202505121030", want: time.Date(2025, 5, 12, 10, 30, 0, 0, time.Local), wantOK: true},
+		{value: "20250512103045", want: time.Date(2025, 5, 12, 10, 30, 45, 0, time.Local), wantOK: true},
+		{value: " 20250512 ", want: time.Date(2025, 5, 12, 0, 0, 0, 0, time.Local), wantOK: true},
+		{value: "20250512103045.1234+0200", want: time.Date(2025, 5, 12, 8, 30, 45, 0, time.UTC), wantOK: true},
+		{value: "202505121030-0500", want: time.Date(2025, 5, 12, 15, 30, 0, 0, time.UTC), wantOK: true},
+		{value: ""},
+		{value: "2025051"},
+		{value: "20251332"},
+		{value: "20250512+99xx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParseTime(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseTime(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("ParseTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTimeRoundTrip(t *testing.T) {
+	want := time.Date(2025, 5, 12, 10, 30, 45, 0, time.Local)
+	formatted := FormatTime(want)
+	if formatted != "20250512103045" {
+		t.Fatalf("FormatTime() = %q", formatted)
+	}
+	got, ok := ParseTime(formatted)
+	if !ok || !got.Equal(want) {
+		t.Errorf("ParseTime(FormatTime()) = %v, %v, want %v", got, ok, want)
+	}
+}
@@ -0,0 +1,279 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/max-programming/clinic/internal/dto"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// Fields are the patient fields a column can be mapped to, named after the
+// JSON fields of dto.AddPatientRequest.
+var Fields = []string{
+	"givenName",
+	"familyName",
+	"preferredName",
+	"dateOfBirth",
+	"gender",
+	"sexAtBirth",
+	"genderIdentity",
+	"email",
+	"phone",
+	"preferredLanguage",
+	"address.line1",
+	"address.line2",
+	"address.city",
+	"address.state",
+	"address.postalCode",
+	"address.country",
+	"emergencyContact.name",
+	"emergencyContact.relationship",
+	"emergencyContact.phone",
+}
+
+var requiredFields = []string{"givenName", "familyName", "dateOfBirth", "gender"}
+
+// aliases are other common headers for fields, normalized. Headers are also
+// matched against the field names themselves and the address fields without
+// their "address." prefix.
+var aliases = map[string]string{
+	"firstname":    "givenName",
+	"lastname":     "familyName",
+	"surname":      "familyName",
+	"dob":          "dateOfBirth",
+	"birthdate":    "dateOfBirth",
+	"emailaddress": "email",
+	"phonenumber":  "phone",
+	"mobile":       "phone",
+	"language":     "preferredLanguage",
+	"zip":          "address.postalCode",
+	"zipcode":      "address.postalCode",
+}
+
+// Mapping assigns columns, by index, to patient fields.
+type Mapping map[int]string
+
+// Row is a parsed data row. Patient is nil when the row has issues.
+type Row struct {
+	Line    int
+	Patient *models.Patient
+	Issues  []models.ImportIssue
+}
+
+// NewMapping maps the header to fields. custom maps column headers to
+// fields and takes precedence; an empty field ignores the column. Other
+// columns are matched by name, ignoring case, spaces and punctuation, and
+// left out if nothing matches.
+func NewMapping(header []string, custom map[string]string) (Mapping, error) {
+	known := make(map[string]string)
+	for _, field := range Fields {
+		known[normalize(field)] = field
+		if suffix, ok := strings.CutPrefix(field, "address."); ok {
+			known[normalize(suffix)] = field
+		}
+	}
+	for alias, field := range aliases {
+		known[alias] = field
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	customized := make(map[int]bool)
+	mapping := make(Mapping)
+	for column, field := range custom {
+		i, ok := columns[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			return nil, fmt.Errorf("column %q is not in the file", column)
+		}
+		customized[i] = true
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(Fields, field) {
+			return nil, fmt.Errorf("column %q is mapped to unknown field %q", column, field)
+		}
+		mapping[i] = field
+	}
+	for i, column := range header {
+		if field, ok := known[normalize(column)]; ok && !customized[i] {
+			mapping[i] = field
+		}
+	}
+
+	mapped := make(map[string]int)
+	for i, field := range mapping {
+		if j, ok := mapped[field]; ok {
+			return nil, fmt.Errorf("columns %q and %q are both mapped to %s", header[min(i, j)], header[max(i, j)], field)
+		}
+		mapped[field] = i
+	}
+	var missing []string
+	for _, field := range requiredFields {
+		if _, ok := mapped[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no column is mapped to %s", strings.Join(missing, ", "))
+	}
+	return mapping, nil
+}
+
+// ParseRows validates every row with the rules of dto.AddPatientRequest and
+// converts the valid ones to patients.
+func ParseRows(table *Table, mapping Mapping) []Row {
+	rows := make([]Row, len(table.Rows))
+	for i, tableRow := range table.Rows {
+		values := make(map[string]string, len(mapping))
+		for column, field := range mapping {
+			if column < len(tableRow.Values) {
+				values[field] = strings.TrimSpace(tableRow.Values[column])
+			}
+		}
+		if table.Format == FormatXLSX {
+			values["dateOfBirth"] = excelDate(values["dateOfBirth"])
+		}
+
+		rows[i] = Row{Line: tableRow.Line}
+		request := toRequest(values)
+		if err := binding.Validator.ValidateStruct(&request); err != nil {
+			rows[i].Issues = toIssues(tableRow.Line, err)
+			continue
+		}
+		rows[i].Patient = toPatient(request)
+	}
+	return rows
+}
+
+func toRequest(values map[string]string) dto.AddPatientRequest {
+	return dto.AddPatientRequest{
+		GivenName:         values["givenName"],
+		FamilyName:        values["familyName"],
+		PreferredName:     values["preferredName"],
+		DateOfBirth:       values["dateOfBirth"],
+		Gender:            values["gender"],
+		SexAtBirth:        strings.ToLower(values["sexAtBirth"]),
+		GenderIdentity:    values["genderIdentity"],
+		Email:             values["email"],
+		Phone:             values["phone"],
+		PreferredLanguage: values["preferredLanguage"],
+		Address: dto.PatientAddress{
+			Line1:      values["address.line1"],
+			Line2:      values["address.line2"],
+			City:       values["address.city"],
+			State:      values["address.state"],
+			PostalCode: values["address.postalCode"],
+			Country:    values["address.country"],
+		},
+		EmergencyContact: dto.EmergencyContact{
+			Name:         values["emergencyContact.name"],
+			Relationship: values["emergencyContact.relationship"],
+			Phone:        values["emergencyContact.phone"],
+		},
+	}
+}
+
+func toPatient(request dto.AddPatientRequest) *models.Patient {
+	patient := &models.Patient{
+		GivenName:         request.GivenName,
+		FamilyName:        request.FamilyName,
+		PreferredName:     request.PreferredName,
+		Gender:            request.Gender,
+		SexAtBirth:        request.SexAtBirth,
+		GenderIdentity:    request.GenderIdentity,
+		Email:             request.Email,
+		Phone:             request.Phone,
+		PreferredLanguage: request.PreferredLanguage,
+		Address: models.Address{
+			Line1:      request.Address.Line1,
+			Line2:      request.Address.Line2,
+			City:       request.Address.City,
+			State:      request.Address.State,
+			PostalCode: request.Address.PostalCode,
+			Country:    request.Address.Country,
+		},
+		EmergencyContact: models.EmergencyContact{
+			Name:         request.EmergencyContact.Name,
+			Relationship: request.EmergencyContact.Relationship,
+			Phone:        request.EmergencyContact.Phone,
+		},
+	}
+	if dob, err := time.Parse(time.DateOnly, request.DateOfBirth); err == nil {
+		patient.DateOfBirth = &dob
+	}
+	return patient
+}
+
+func toIssues(line int, err error) []models.ImportIssue {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []models.ImportIssue{{Row: line, Message: err.Error()}}
+	}
+	issues := make([]models.ImportIssue, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		issues[i] = models.ImportIssue{Row: line, Field: fieldName(fieldErr), Message: issueMessage(fieldErr)}
+	}
+	return issues
+}
+
+// fieldName turns "AddPatientRequest.Address.PostalCode" into
+// "address.postalCode".
+func fieldName(fieldErr validator.FieldError) string {
+	parts := strings.Split(fieldErr.StructNamespace(), ".")[1:]
+	for i, part := range parts {
+		parts[i] = strings.ToLower(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, ".")
+}
+
+func issueMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fieldErr.Param())
+	case "datetime":
+		return "must be a date like 1985-03-14"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "email":
+		return "must be an email address"
+	case "bcp47_language_tag":
+		return "must be a language tag like en"
+	}
+	return "is invalid"
+}
+
+// excelDate converts a date stored as a spreadsheet serial number, as dates
+// are when read raw from a workbook. Anything else is returned unchanged.
+func excelDate(value string) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 1 {
+		return value
+	}
+	date, err := excelize.ExcelDateToTime(serial, false)
+	if err != nil {
+		return value
+	}
+	return date.Format(time.DateOnly)
+}
+
+func normalize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported file format, expected .csv or .xlsx")
+
+// Table is a spreadsheet read into memory. Line is the row number a user
+// would see in their spreadsheet, so that errors can point at it.
+type Table struct {
+	Format string
+	Header []string
+	Rows   []TableRow
+}
+
+type TableRow struct {
+	Line   int
+	Values []string
+}
+
+// FormatFromName picks the format from the file extension.
+func FormatFromName(name string) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ReadTable reads the first row as the header and every non-blank row after
+// it. Only the first sheet of a workbook is read. It fails when the file has
+// more than maxRows data rows.
+func ReadTable(r io.Reader, format string, maxRows int) (*Table, error) {
+	table := &Table{Format: format}
+	add := func(line int, values []string) error {
+		if table.Header == nil {
+			if isBlank(values) {
+				return errors.New("the header row is empty")
+			}
+			table.Header = values
+			return nil
+		}
+		if isBlank(values) {
+			return nil
+		}
+		if len(table.Rows) == maxRows {
+			return fmt.Errorf("file has more than %d rows", maxRows)
+		}
+		table.Rows = append(table.Rows, TableRow{line, values})
+		return nil
+	}
+
+	switch format {
+	case FormatCSV:
+		if err := readCSV(r, add); err != nil {
+			return nil, err
+		}
+	case FormatXLSX:
+		if err := readXLSX(r, add); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	if table.Header == nil {
+		return nil, errors.New("file is empty")
+	}
+	// Excel and friends like to start CSV exports with a byte order mark.
+	table.Header[0] = strings.TrimPrefix(table.Header[0], "\ufeff")
+	return table, nil
+}
+
+func readCSV(r io.Reader, add func(line int, values []string) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		if err := add(line, record); err != nil {
+			return err
+		}
+	}
+}
+
+// readXLSX reads raw cell values, so dates come through as serial numbers
+// rather than in whatever display format the sheet uses.
+func readXLSX(r io.Reader, add func(line int, values []string) error) error {
+	workbook, err := excelize.OpenReader(r)
+	if err != nil {
+		return fmt.Errorf("cannot read workbook: %w", err)
+	}
+	defer workbook.Close()
+
+	sheets := workbook.GetSheetList()
+	if len(sheets) == 0 {
+		return errors.New("workbook has no sheets")
+	}
+	rows, err := workbook.Rows(sheets[0])
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for line := 1; rows.Next(); line++ {
+		values, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return err
+		}
+		if err := add(line, values); err != nil {
+			return err
+		}
+	}
+	return rows.Error()
+}
+
+func isBlank(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/max-programming/clinic/internal/service"
+)
+
+// DuplicateScan periodically looks for probable duplicate patients so admins
+// can review them under /api/admin/duplicates.
+type DuplicateScan struct {
+	service  service.DuplicateService
+	interval time.Duration
+}
+
+func NewDuplicateScan(service service.DuplicateService, interval time.Duration) *DuplicateScan {
+	return &DuplicateScan{service, interval}
+}
+
+// Run scans once straight away and then every interval until ctx is done.
+func (j *DuplicateScan) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.scan(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *DuplicateScan) scan(ctx context.Context) {
+	found, err := j.service.ScanDuplicates(ctx)
+	if err != nil {
+		slog.Error("Duplicate patient scan failed", slog.Any("error", err))
+		return
+	}
+	slog.Info("Duplicate patient scan finished", slog.Int("candidates", found))
+}
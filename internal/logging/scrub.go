@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// Redacted replaces the values of attributes that may hold patient details.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeyParts mark an attribute as patient details when its key,
+// lowercased and without separators, contains one of them. Keys ending in
+// "name" are redacted too, apart from allowedNameKeys.
+var sensitiveKeyParts = []string{
+	"phone",
+	"mobile",
+	"email",
+	"address",
+	"note",
+	"birth",
+	"dob",
+	"ssn",
+	"instructions",
+	"diagnosis",
+	"subscriber",
+	"member",
+	"emergencycontact",
+}
+
+var allowedNameKeys = map[string]bool{
+	"username": true,
+	"hostname": true,
+}
+
+// Scrub wraps handler so that attributes whose keys name patient details,
+// such as "name", "phone" or "medicalNotes", are logged as Redacted at any
+// depth of grouping. Errors marked with Sensitive are logged with the
+// patient details they carry replaced by Redacted, whatever their key. Log
+// messages are not inspected: they must be constant strings, with anything
+// variable passed as an attribute.
+func Scrub(handler slog.Handler) slog.Handler {
+	return &scrubHandler{handler}
+}
+
+type scrubHandler struct {
+	next slog.Handler
+}
+
+func (h *scrubHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *scrubHandler) Handle(ctx context.Context, record slog.Record) error {
+	scrubbed := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		scrubbed.AddAttrs(scrubAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, scrubbed)
+}
+
+func (h *scrubHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scrubbed := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		scrubbed[i] = scrubAttr(attr)
+	}
+	return &scrubHandler{h.next.WithAttrs(scrubbed)}
+}
+
+func (h *scrubHandler) WithGroup(name string) slog.Handler {
+	return &scrubHandler{h.next.WithGroup(name)}
+}
+
+func scrubAttr(attr slog.Attr) slog.Attr {
+	if IsSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	value := attr.Value.Resolve()
+	if err, ok := value.Any().(error); ok && value.Kind() == slog.KindAny {
+		if values := sensitiveValues(err); len(values) > 0 {
+			return slog.String(attr.Key, redactValues(err.Error(), values))
+		}
+	}
+	if value.Kind() != slog.KindGroup {
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+	group := value.Group()
+	scrubbed := make([]slog.Attr, len(group))
+	for i, a := range group {
+		scrubbed[i] = scrubAttr(a)
+	}
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(scrubbed...)}
+}
+
+// SensitiveError is an error whose message names a patient, such as a lookup
+// by MRN that found nobody. The message is returned unchanged to API clients
+// and HL7 senders; only the scrubbing handler redacts Values from it.
+type SensitiveError struct {
+	Err    error
+	Values []string
+}
+
+// Sensitive marks the patient details in err's message so that logging it,
+// or any error wrapping it, redacts them. Empty values are ignored.
+func Sensitive(err error, values ...string) error {
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return &SensitiveError{Err: err, Values: kept}
+}
+
+func (e *SensitiveError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SensitiveError) Unwrap() error {
+	return e.Err
+}
+
+// sensitiveValues collects the Values of every SensitiveError in err's tree.
+func sensitiveValues(err error) []string {
+	var values []string
+	var walk func(error)
+	walk = func(err error) {
+		if sensitive, ok := err.(*SensitiveError); ok {
+			values = append(values, sensitive.Values...)
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			if next := e.Unwrap(); next != nil {
+				walk(next)
+			}
+		case interface{ Unwrap() []error }:
+			for _, next := range e.Unwrap() {
+				walk(next)
+			}
+		}
+	}
+	walk(err)
+	return values
+}
+
+func redactValues(message string, values []string) string {
+	for _, value := range values {
+		message = strings.ReplaceAll(message, value, Redacted)
+	}
+	return message
+}
+
+// IsSensitiveKey reports whether values logged under key are redacted.
+func IsSensitiveKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "", ".", "", " ", "").Replace(strings.ToLower(key))
+	if strings.HasSuffix(normalized, "name") && !allowedNameKeys[normalized] {
+		return true
+	}
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(normalized, part) {
+			return true
+		}
+	}
+	return false
+}
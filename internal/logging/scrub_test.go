@@ -0,0 +1,216 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestIsSensitiveKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "name", want: true},
+		{key: "givenName", want: true},
+		{key: "family_name", want: true},
+		{key: "phone", want: true},
+		{key: "mobileNumber", want: true},
+		{key: "Email", want: true},
+		{key: "address_line1", want: true},
+		{key: "medicalNotes", want: true},
+		{key: "date-of-birth", want: true},
+		{key: "dob", want: true},
+		{key: "ssn", want: true},
+		{key: "instructions", want: true},
+		{key: "diagnosisCodes", want: true},
+		{key: "subscriber_id", want: true},
+		{key: "memberId", want: true},
+		{key: "emergency_contact.phone", want: true},
+		{key: "username"},
+		{key: "host_name"},
+		{key: "patient_id"},
+		{key: "mrn"},
+		{key: "error"},
+		{key: "status"},
+		{key: "nameservers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := IsSensitiveKey(tt.key); got != tt.want {
+				t.Errorf("IsSensitiveKey(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+type loggedPatient struct {
+	id, name string
+}
+
+func (p loggedPatient) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", p.id), slog.String("name", p.name))
+}
+
+func TestScrub(t *testing.T) {
+	notFound := Sensitive(fmt.Errorf("no patient with MRN %s", "MRN-000042"), "MRN-000042")
+
+	tests := []struct {
+		name string
+		log  func(*slog.Logger)
+		want map[string]any
+	}{
+		{
+			name: "sensitive keys",
+			log: func(l *slog.Logger) {
+				l.Info("m", slog.String("name", "Jane Doe"), slog.String("phone", "+44 113 496 0000"), slog.Int("age", 45))
+			},
+			want: map[string]any{"name": Redacted, "phone": Redacted, "age": float64(45)},
+		},
+		{
+			name: "sensitive key holding a group",
+			log: func(l *slog.Logger) {
+				l.Info("m", slog.Group("emergencyContact", slog.String("relationship", "spouse")))
+			},
+			want: map[string]any{"emergencyContact": Redacted},
+		},
+		{
+			name: "nested groups",
+			log: func(l *slog.Logger) {
+				l.Info("m", slog.Group("patient", slog.String("id", "p1"), slog.Group("address", slog.String("city", "Leeds"))))
+			},
+			want: map[string]any{"patient": map[string]any{"id": "p1", "address": Redacted}},
+		},
+		{
+			name: "log valuer",
+			log: func(l *slog.Logger) {
+				l.Info("m", slog.Any("patient", loggedPatient{"p1", "Jane Doe"}))
+			},
+			want: map[string]any{"patient": map[string]any{"id": "p1", "name": Redacted}},
+		},
+		{
+			name: "with attrs",
+			log: func(l *slog.Logger) {
+				l.With(slog.String("email", "jane@example.com"), slog.String("request_id", "r1")).Info("m")
+			},
+			want: map[string]any{"email": Redacted, "request_id": "r1"},
+		},
+		{
+			name: "with group",
+			log: func(l *slog.Logger) {
+				l.WithGroup("patient").Info("m", slog.String("dob", "1980-01-01"))
+			},
+			want: map[string]any{"patient": map[string]any{"dob": Redacted}},
+		},
+		{
+			name: "plain error",
+			log: func(l *slog.Logger) {
+				l.Info("m", slog.Any("error", errors.New("connection refused")))
+			},
+			want: map[string]any{"error": "connection refused"},
+		},
+		{
+			name: "sensitive error",
+			log: func(l *slog.Logger) {
+				l.Info("m", slog.Any("error", notFound))
+			},
+			want: map[string]any{"error": "no patient with MRN " + Redacted},
+		},
+		{
+			name: "wrapped sensitive error",
+			log: func(l *slog.Logger) {
+				l.Info("m", slog.Any("error", fmt.Errorf("process ADT^A08: %w", notFound)))
+			},
+			want: map[string]any{"error": "process ADT^A08: no patient with MRN " + Redacted},
+		},
+		{
+			name: "joined sensitive errors",
+			log: func(l *slog.Logger) {
+				other := Sensitive(errors.New("NHS identifier 9434765919 is taken"), "9434765919")
+				l.Info("m", slog.Any("error", errors.Join(notFound, other)))
+			},
+			want: map[string]any{"error": "no patient with MRN " + Redacted + "\nNHS identifier " + Redacted + " is taken"},
+		},
+		{
+			name: "sensitive error in a group",
+			log: func(l *slog.Logger) {
+				l.Info("m", slog.Group("hl7", slog.Any("cause", notFound)))
+			},
+			want: map[string]any{"hl7": map[string]any{"cause": "no patient with MRN " + Redacted}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(slog.New(Scrub(slog.NewJSONHandler(&buf, nil))))
+
+			var got map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("invalid log line %q: %v", buf.String(), err)
+			}
+			delete(got, "time")
+			delete(got, "level")
+			delete(got, "msg")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("logged %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSensitiveErrorKeepsMessage(t *testing.T) {
+	cause := errors.New("record not found")
+	err := Sensitive(fmt.Errorf("no patient with MRN MRN-000042: %w", cause), "MRN-000042", "")
+
+	if err.Error() != "no patient with MRN MRN-000042: record not found" {
+		t.Errorf("Error() = %q, want the full message", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Sensitive() hides the wrapped error from errors.Is")
+	}
+	var sensitive *SensitiveError
+	if !errors.As(err, &sensitive) || !reflect.DeepEqual(sensitive.Values, []string{"MRN-000042"}) {
+		t.Errorf("Values = %v, want the MRN without blanks", sensitive.Values)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		level   string
+		format  string
+		prefix  string
+		wantErr bool
+	}{
+		{level: "info", format: "", prefix: "{"},
+		{level: "DEBUG", format: "JSON", prefix: "{"},
+		{level: "warn", format: "text", prefix: "time="},
+		{level: "verbose", format: "json", wantErr: true},
+		{level: "info", format: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level+"/"+tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := New(&buf, tt.level, tt.format)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			logger.Error("m", slog.String("phone", "+44 113 496 0000"))
+			if !bytes.HasPrefix(buf.Bytes(), []byte(tt.prefix)) {
+				t.Errorf("logged %q, want it to start with %q", buf.String(), tt.prefix)
+			}
+			if bytes.Contains(buf.Bytes(), []byte("496")) {
+				t.Errorf("logged %q, want the phone number redacted", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "clinic"
+
+const (
+	SourceRegistration = "registration"
+	SourceImport       = "import"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.With(registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	logins = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts, by result.",
+	}, []string{"result"})
+
+	patientsCreated = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "patients_created_total",
+		Help:      "Patients created, by source.",
+	}, []string{"source"})
+
+	patientNotesUpdated = promauto.With(registry).NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "patient_notes_updated_total",
+		Help:      "Changes to patients' medical notes.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	for _, result := range []string{"success", "failure"} {
+		logins.WithLabelValues(result)
+	}
+	for _, source := range []string{SourceRegistration, SourceImport} {
+		patientsCreated.WithLabelValues(source)
+	}
+}
+
+// ObserveRequest records a served request. route is the route template,
+// such as /api/patients/:id, so that IDs do not become labels.
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+func ObserveLogin(success bool) {
+	if success {
+		logins.WithLabelValues("success").Inc()
+	} else {
+		logins.WithLabelValues("failure").Inc()
+	}
+}
+
+func AddPatientsCreated(source string, n int) {
+	patientsCreated.WithLabelValues(source).Add(float64(n))
+}
+
+func IncPatientNotesUpdated() {
+	patientNotesUpdated.Inc()
+}
+
+// RegisterDB exposes the connection pool statistics of db.
+func RegisterDB(db *sql.DB) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler serves the metrics. When token is set, scrapers must send it as a
+// bearer token.
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
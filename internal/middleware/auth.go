@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -8,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/max-programming/clinic/internal/logging"
 	"github.com/max-programming/clinic/internal/utils"
 )
 
@@ -17,49 +19,49 @@ type AuthUser struct {
 	Role     string
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(jwtSecret []byte) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorAPIResponse("Authorization header is required"))
+			abortWithError(c, http.StatusUnauthorized, "Authorization header is required")
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorAPIResponse("Invalid authorization format"))
+			abortWithError(c, http.StatusUnauthorized, "Invalid authorization format")
 			return
 		}
 
 		tokenString := parts[1]
 
-		token, err := utils.VerifyJWT(tokenString)
+		token, err := utils.VerifyJWT(jwtSecret, tokenString)
 		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorAPIResponse("Invalid or expired token"))
+			abortWithError(c, http.StatusUnauthorized, "Invalid or expired token")
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorAPIResponse("Invalid token claims"))
+			abortWithError(c, http.StatusUnauthorized, "Invalid token claims")
 			return
 		}
 
 		userID, ok := claims["userID"].(string)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorAPIResponse("Invalid user ID in token"))
+			abortWithError(c, http.StatusUnauthorized, "Invalid user ID in token")
 			return
 		}
 
 		username, ok := claims["username"].(string)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorAPIResponse("Invalid username in token"))
+			abortWithError(c, http.StatusUnauthorized, "Invalid username in token")
 			return
 		}
 
 		role, ok := claims["role"].(string)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.NewErrorAPIResponse("Invalid role in token"))
+			abortWithError(c, http.StatusUnauthorized, "Invalid role in token")
 			return
 		}
 
@@ -69,6 +71,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			Role:     role,
 		}
 		c.Set("user", authUser)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), slog.String("user_id", userID)))
 
 		c.Next()
 	}
@@ -95,7 +98,7 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 		allowed := slices.Contains(roles, authUser.Role)
 
 		if !allowed {
-			c.AbortWithStatusJSON(http.StatusForbidden, utils.NewErrorAPIResponse("Forbidden: insufficient permissions"))
+			abortWithError(c, http.StatusForbidden, "Forbidden: insufficient permissions")
 			return
 		}
 
@@ -110,3 +113,13 @@ func RequireReceptionist() gin.HandlerFunc {
 func RequireDoctor() gin.HandlerFunc {
 	return RequireRole("doctor")
 }
+
+func RequireAdmin() gin.HandlerFunc {
+	return RequireRole("admin")
+}
+
+// RequirePatientAccess lets every role but managers, who only see aggregate
+// reports, read patient records.
+func RequirePatientAccess() gin.HandlerFunc {
+	return RequireRole("receptionist", "doctor", "admin")
+}
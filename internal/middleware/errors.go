@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/utils"
+)
+
+// ErrorResponder writes the body for requests rejected by middleware, so that
+// route groups with their own error format (such as FHIR) stay consistent.
+type ErrorResponder func(c *gin.Context, status int, message string)
+
+const errorResponderKey = "errorResponder"
+
+func UseErrorResponder(responder ErrorResponder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(errorResponderKey, responder)
+		c.Next()
+	}
+}
+
+func abortWithError(c *gin.Context, status int, message string) {
+	if value, exists := c.Get(errorResponderKey); exists {
+		responder := value.(ErrorResponder)
+		responder(c, status, message)
+		c.Abort()
+		return
+	}
+	c.AbortWithStatusJSON(status, utils.NewErrorAPIResponse(message))
+}
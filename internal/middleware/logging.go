@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/utils"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID takes the request ID from the X-Request-ID header, or makes one
+// up when it is missing or malformed, and echoes it in the response. The
+// request's context carries a logger that adds the ID to every entry; get
+// it with logging.FromContext(c.Request.Context()).
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, id)
+
+		logger := slog.Default().With(slog.String("request_id", id))
+		c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), logger))
+		c.Next()
+	}
+}
+
+// AccessLog logs every request once it has been served. The query string is
+// left out, as searches carry patient names and phone numbers.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		ctx := c.Request.Context()
+		args := []any{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+			args = append(args, slog.String("error", errs.String()))
+		}
+		logging.FromContext(ctx).Log(ctx, level, "request", args...)
+	}
+}
+
+// Recovery answers a panicking request with a 500 and logs the panic with
+// its stack.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		ctx := c.Request.Context()
+		logging.FromContext(ctx).ErrorContext(ctx, "panic",
+			slog.String("error", fmt.Sprint(recovered)),
+			slog.String("stack", string(debug.Stack())),
+		)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, utils.NewErrorAPIResponse("internal server error"))
+	})
+}
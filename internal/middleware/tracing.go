@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a span for every request, continuing the trace from the
+// W3C traceparent header when the caller sends one.
+func Tracing() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName)
+}
+
+// TraceLog adds the trace ID to the request logger, so that log entries can
+// be matched with their trace. It must run after Tracing and RequestID.
+func TraceLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			c.Request = c.Request.WithContext(logging.With(ctx, slog.String("trace_id", spanContext.TraceID().String())))
+		}
+		c.Next()
+	}
+}
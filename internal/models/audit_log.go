@@ -0,0 +1,16 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditLog struct {
+	ID         string          `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID     *string         `gorm:"type:uuid;index"`
+	Action     string          `gorm:"not null"`
+	EntityType string          `gorm:"not null"`
+	EntityID   string          `gorm:"not null"`
+	Details    json.RawMessage `gorm:"type:jsonb"`
+	CreatedAt  time.Time
+}
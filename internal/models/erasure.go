@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	ErasureStatusPending   = "pending"
+	ErasureStatusCompleted = "completed"
+	ErasureStatusCancelled = "cancelled"
+)
+
+// LegalHold stops a patient from being erased until it is released, e.g.
+// while litigation or an investigation is pending.
+type LegalHold struct {
+	ID         string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	PatientID  string `gorm:"type:uuid;not null;index"`
+	Reason     string `gorm:"not null"`
+	PlacedBy   string `gorm:"type:uuid;not null"`
+	Placer     *User  `gorm:"foreignKey:PlacedBy"`
+	CreatedAt  time.Time
+	ReleasedBy *string `gorm:"type:uuid"`
+	Releaser   *User   `gorm:"foreignKey:ReleasedBy"`
+	ReleasedAt *time.Time
+}
+
+// ErasureRequest asks for a patient to be anonymized. Once completed,
+// Certificate holds the JSON erasure certificate exactly as it was signed;
+// SigningKeyID names the key that made Signature.
+type ErasureRequest struct {
+	ID           string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	PatientID    string `gorm:"type:uuid;not null;index"`
+	Patient      *Patient
+	Reason       string `gorm:"not null"`
+	Status       string `gorm:"not null"`
+	RequestedBy  string `gorm:"type:uuid;not null"`
+	Requester    *User  `gorm:"foreignKey:RequestedBy"`
+	CreatedAt    time.Time
+	CompletedBy  *string `gorm:"type:uuid"`
+	CompletedAt  *time.Time
+	CancelledBy  *string `gorm:"type:uuid"`
+	CancelledAt  *time.Time
+	Certificate  string `gorm:"type:text;not null"`
+	Signature    string `gorm:"not null"`
+	SigningKeyID string `gorm:"not null"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fee is an entry in the catalog of billable services. TaxRate is a
+// percentage, e.g. 5 for 5%.
+type Fee struct {
+	ID          string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Code        string `gorm:"uniqueIndex;not null"`
+	Name        string `gorm:"not null"`
+	Description string
+	Price       decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	TaxRate     decimal.Decimal `gorm:"type:numeric(5,2);not null"`
+	Active      bool            `gorm:"not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type HL7Message struct {
+	ID              string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	ControlID       string `gorm:"index"`
+	MessageType     string
+	SendingApp      string
+	SendingFacility string
+	// Raw is the message as received, including the patient's name, address
+	// and phone number from PID.
+	Raw         string `gorm:"not null;serializer:encrypted"`
+	Status      string `gorm:"type:varchar(20);not null"`
+	Error       string
+	PatientID   *string `gorm:"type:uuid;index"`
+	ReceivedAt  time.Time
+	ProcessedAt *time.Time
+}
+
+// BeforeCreate assigns the ID instead of leaving it to the database default,
+// as the raw message is encrypted and bound to it.
+func (m *HL7Message) BeforeCreate(*gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PolicyPriorityPrimary   = "primary"
+	PolicyPrioritySecondary = "secondary"
+	PolicyPriorityTertiary  = "tertiary"
+)
+
+// Relationship of the patient to the policy's subscriber.
+const (
+	SubscriberSelf   = "self"
+	SubscriberSpouse = "spouse"
+	SubscriberChild  = "child"
+	SubscriberOther  = "other"
+)
+
+const (
+	ClaimStatusDraft     = "draft"
+	ClaimStatusSubmitted = "submitted"
+	ClaimStatusAccepted  = "accepted"
+	ClaimStatusRejected  = "rejected"
+	ClaimStatusPaid      = "paid"
+)
+
+// InsurancePolicy is a patient's coverage with a payer. The subscriber fields
+// are only used when the patient is a dependent on someone else's policy.
+// CoveragePercent is the share of charges the payer is expected to pay after
+// the Copay.
+type InsurancePolicy struct {
+	ID                    string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	PatientID             string `gorm:"type:uuid;not null;index"`
+	Priority              string `gorm:"not null"`
+	PayerName             string `gorm:"not null"`
+	PayerID               string `gorm:"not null"`
+	PlanName              string
+	MemberID              string `gorm:"not null"`
+	GroupNumber           string
+	Relationship          string `gorm:"not null"`
+	SubscriberGivenName   string
+	SubscriberFamilyName  string
+	SubscriberDateOfBirth *time.Time      `gorm:"type:date"`
+	ValidFrom             time.Time       `gorm:"type:date;not null"`
+	ValidTo               *time.Time      `gorm:"type:date"`
+	CoveragePercent       decimal.Decimal `gorm:"type:numeric(5,2);not null"`
+	Copay                 decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	CreatedBy             string          `gorm:"type:uuid"`
+	UpdatedBy             string          `gorm:"type:uuid"`
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// CoversDate reports whether the policy is valid on date.
+func (p *InsurancePolicy) CoversDate(date time.Time) bool {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Before(p.ValidFrom) {
+		return false
+	}
+	return p.ValidTo == nil || !day.After(*p.ValidTo)
+}
+
+// ExpectedPayment is what the payer should pay on total under this policy.
+func (p *InsurancePolicy) ExpectedPayment(total decimal.Decimal) decimal.Decimal {
+	covered := total.Sub(p.Copay)
+	if !covered.IsPositive() {
+		return decimal.Zero
+	}
+	return percentOf(covered, p.CoveragePercent)
+}
+
+// Claim bills an invoice to an insurance policy. DiagnosisCodes holds
+// comma-separated ICD-10 codes, the first being the principal diagnosis.
+type Claim struct {
+	ID                       string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	ClaimNumber              string `gorm:"uniqueIndex;not null"`
+	InvoiceID                string `gorm:"type:uuid;not null;index"`
+	PolicyID                 string `gorm:"type:uuid;not null"`
+	Status                   string `gorm:"not null"`
+	DiagnosisCodes           string
+	TotalCharge              decimal.Decimal  `gorm:"type:numeric(12,2);not null"`
+	ExpectedAmount           decimal.Decimal  `gorm:"type:numeric(12,2);not null"`
+	PaidAmount               *decimal.Decimal `gorm:"type:numeric(12,2)"`
+	PayerClaimNumber         string
+	RejectionReason          string
+	Notes                    string
+	InterchangeControlNumber *int
+	Invoice                  *Invoice
+	Policy                   *InsurancePolicy `gorm:"foreignKey:PolicyID"`
+	SubmittedAt              *time.Time
+	AdjudicatedAt            *time.Time
+	CreatedBy                string `gorm:"type:uuid"`
+	UpdatedBy                string `gorm:"type:uuid"`
+	CreatedAt                time.Time
+	UpdatedAt                time.Time
+}
+
+// claimTransitions lists the statuses a claim can move to from each status.
+// A rejected claim goes back to draft to be corrected and sent again.
+var claimTransitions = map[string][]string{
+	ClaimStatusDraft:     {ClaimStatusSubmitted},
+	ClaimStatusSubmitted: {ClaimStatusAccepted, ClaimStatusRejected},
+	ClaimStatusAccepted:  {ClaimStatusPaid, ClaimStatusRejected},
+	ClaimStatusRejected:  {ClaimStatusDraft},
+}
+
+func (c *Claim) CanTransitionTo(status string) bool {
+	return slices.Contains(claimTransitions[c.Status], status)
+}
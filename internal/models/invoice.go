@@ -0,0 +1,116 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	InvoiceStatusDraft         = "draft"
+	InvoiceStatusIssued        = "issued"
+	InvoiceStatusPartiallyPaid = "partially_paid"
+	InvoiceStatusPaid          = "paid"
+	InvoiceStatusVoid          = "void"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+type Invoice struct {
+	ID              string  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Number          *string `gorm:"uniqueIndex"`
+	FiscalYear      *int
+	PatientID       string          `gorm:"type:uuid;not null;index"`
+	AppointmentID   *string         `gorm:"type:uuid"`
+	Status          string          `gorm:"not null"`
+	Currency        string          `gorm:"not null"`
+	DiscountPercent decimal.Decimal `gorm:"type:numeric(5,2);not null"`
+	Subtotal        decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	DiscountTotal   decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	TaxTotal        decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	Total           decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	AmountPaid      decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	Notes           string
+	Items           []InvoiceItem `gorm:"constraint:OnDelete:CASCADE"`
+	Patient         *Patient
+	IssuedAt        *time.Time
+	DueDate         *time.Time
+	VoidedAt        *time.Time
+	VoidReason      string
+	CreatedBy       string `gorm:"type:uuid"`
+	UpdatedBy       string `gorm:"type:uuid"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// InvoiceItem percentages (DiscountPercent, TaxRate) are stored as e.g. 10
+// for 10%. The Line* amounts are derived by Invoice.Recalculate.
+type InvoiceItem struct {
+	ID              string          `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	InvoiceID       string          `gorm:"type:uuid;not null;index"`
+	FeeID           *string         `gorm:"type:uuid"`
+	Position        int             `gorm:"not null"`
+	Description     string          `gorm:"not null"`
+	Quantity        decimal.Decimal `gorm:"type:numeric(10,2);not null"`
+	UnitPrice       decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	DiscountPercent decimal.Decimal `gorm:"type:numeric(5,2);not null"`
+	TaxRate         decimal.Decimal `gorm:"type:numeric(5,2);not null"`
+	LineSubtotal    decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	LineDiscount    decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	LineTax         decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	LineTotal       decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+}
+
+// Recalculate derives line and invoice totals. Each line is rounded to cents
+// on its own: the line discount is applied first, then the invoice-wide
+// discount to what remains, and tax is charged on the discounted amount.
+func (inv *Invoice) Recalculate() {
+	inv.Subtotal = decimal.Zero
+	inv.DiscountTotal = decimal.Zero
+	inv.TaxTotal = decimal.Zero
+	inv.Total = decimal.Zero
+
+	for i := range inv.Items {
+		item := &inv.Items[i]
+		item.Position = i + 1
+
+		subtotal := item.Quantity.Mul(item.UnitPrice).Round(2)
+		lineDiscount := percentOf(subtotal, item.DiscountPercent)
+		invoiceDiscount := percentOf(subtotal.Sub(lineDiscount), inv.DiscountPercent)
+		discount := lineDiscount.Add(invoiceDiscount)
+		taxable := subtotal.Sub(discount)
+		tax := percentOf(taxable, item.TaxRate)
+
+		item.LineSubtotal = subtotal
+		item.LineDiscount = discount
+		item.LineTax = tax
+		item.LineTotal = taxable.Add(tax)
+
+		inv.Subtotal = inv.Subtotal.Add(subtotal)
+		inv.DiscountTotal = inv.DiscountTotal.Add(discount)
+		inv.TaxTotal = inv.TaxTotal.Add(tax)
+		inv.Total = inv.Total.Add(item.LineTotal)
+	}
+}
+
+// Balance is the amount still owed.
+func (inv *Invoice) Balance() decimal.Decimal {
+	return inv.Total.Sub(inv.AmountPaid)
+}
+
+// UpdatePaymentStatus moves an issued invoice between issued, partially
+// paid and paid according to AmountPaid.
+func (inv *Invoice) UpdatePaymentStatus() {
+	switch {
+	case !inv.AmountPaid.IsPositive():
+		inv.Status = InvoiceStatusIssued
+	case inv.AmountPaid.LessThan(inv.Total):
+		inv.Status = InvoiceStatusPartiallyPaid
+	default:
+		inv.Status = InvoiceStatusPaid
+	}
+}
+
+func percentOf(amount, percent decimal.Decimal) decimal.Decimal {
+	return amount.Mul(percent).Div(hundred).Round(2)
+}
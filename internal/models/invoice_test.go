@@ -0,0 +1,153 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func TestInvoiceRecalculate(t *testing.T) {
+	type line struct {
+		subtotal, discount, tax, total string
+	}
+
+	tests := []struct {
+		name            string
+		discountPercent string
+		items           []InvoiceItem
+		lines           []line
+		subtotal        string
+		discount        string
+		tax             string
+		total           string
+	}{
+		{
+			name: "no items",
+			// Totals are reset even when there is nothing to bill.
+			discountPercent: "0",
+			subtotal:        "0",
+			discount:        "0",
+			tax:             "0",
+			total:           "0",
+		},
+		{
+			name:            "single line with tax",
+			discountPercent: "0",
+			items: []InvoiceItem{
+				{Quantity: dec("1"), UnitPrice: dec("80.00"), TaxRate: dec("20")},
+			},
+			lines:    []line{{"80.00", "0", "16.00", "96.00"}},
+			subtotal: "80.00",
+			discount: "0",
+			tax:      "16.00",
+			total:    "96.00",
+		},
+		{
+			name:            "line discount before invoice discount before tax",
+			discountPercent: "5",
+			items: []InvoiceItem{
+				{Quantity: dec("2"), UnitPrice: dec("50.00"), DiscountPercent: dec("10"), TaxRate: dec("20")},
+				{Quantity: dec("1.5"), UnitPrice: dec("33.33")},
+			},
+			lines: []line{
+				{"100.00", "14.50", "17.10", "102.60"},
+				{"50.00", "2.50", "0", "47.50"},
+			},
+			subtotal: "150.00",
+			discount: "17.00",
+			tax:      "17.10",
+			total:    "150.10",
+		},
+		{
+			name:            "each line rounded to cents",
+			discountPercent: "0",
+			items: []InvoiceItem{
+				{Quantity: dec("3"), UnitPrice: dec("0.335"), TaxRate: dec("10")},
+				{Quantity: dec("3"), UnitPrice: dec("0.335"), TaxRate: dec("10")},
+			},
+			lines: []line{
+				{"1.01", "0", "0.10", "1.11"},
+				{"1.01", "0", "0.10", "1.11"},
+			},
+			subtotal: "2.02",
+			discount: "0",
+			tax:      "0.20",
+			total:    "2.22",
+		},
+		{
+			name:            "full discount",
+			discountPercent: "100",
+			items: []InvoiceItem{
+				{Quantity: dec("1"), UnitPrice: dec("45.00"), TaxRate: dec("20")},
+			},
+			lines:    []line{{"45.00", "45.00", "0", "0"}},
+			subtotal: "45.00",
+			discount: "45.00",
+			tax:      "0",
+			total:    "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := &Invoice{
+				DiscountPercent: dec(tt.discountPercent),
+				Subtotal:        dec("999"),
+				Total:           dec("999"),
+				Items:           tt.items,
+			}
+			inv.Recalculate()
+
+			for i, want := range tt.lines {
+				item := inv.Items[i]
+				if item.Position != i+1 {
+					t.Errorf("item %d position = %d", i, item.Position)
+				}
+				checkAmount(t, "line subtotal", item.LineSubtotal, want.subtotal)
+				checkAmount(t, "line discount", item.LineDiscount, want.discount)
+				checkAmount(t, "line tax", item.LineTax, want.tax)
+				checkAmount(t, "line total", item.LineTotal, want.total)
+			}
+			checkAmount(t, "subtotal", inv.Subtotal, tt.subtotal)
+			checkAmount(t, "discount total", inv.DiscountTotal, tt.discount)
+			checkAmount(t, "tax total", inv.TaxTotal, tt.tax)
+			checkAmount(t, "total", inv.Total, tt.total)
+		})
+	}
+}
+
+func TestInvoiceUpdatePaymentStatus(t *testing.T) {
+	tests := []struct {
+		paid    string
+		status  string
+		balance string
+	}{
+		{paid: "0", status: InvoiceStatusIssued, balance: "100.00"},
+		{paid: "-5.00", status: InvoiceStatusIssued, balance: "105.00"},
+		{paid: "40.00", status: InvoiceStatusPartiallyPaid, balance: "60.00"},
+		{paid: "100.00", status: InvoiceStatusPaid, balance: "0"},
+		{paid: "120.00", status: InvoiceStatusPaid, balance: "-20.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.paid, func(t *testing.T) {
+			inv := &Invoice{Total: dec("100.00"), AmountPaid: dec(tt.paid)}
+			inv.UpdatePaymentStatus()
+			if inv.Status != tt.status {
+				t.Errorf("status = %q, want %q", inv.Status, tt.status)
+			}
+			checkAmount(t, "balance", inv.Balance(), tt.balance)
+		})
+	}
+}
+
+func checkAmount(t *testing.T, name string, got decimal.Decimal, want string) {
+	t.Helper()
+	if !got.Equal(dec(want)) {
+		t.Errorf("%s = %s, want %s", name, got, want)
+	}
+}
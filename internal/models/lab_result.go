@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type LabResult struct {
+	ID             string  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	PatientID      string  `gorm:"type:uuid;not null;index"`
+	HL7MessageID   *string `gorm:"type:uuid;index"`
+	OrderNumber    string
+	TestCode       string `gorm:"not null"`
+	TestName       string
+	ValueType      string
+	Value          string
+	Units          string
+	ReferenceRange string
+	AbnormalFlag   string
+	ResultStatus   string
+	ObservedAt     *time.Time
+	CreatedAt      time.Time
+}
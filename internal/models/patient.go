@@ -1,17 +1,101 @@
 package models
 
-import "time"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Address, Phone and MedicalNotes are encrypted at rest; see the encryption
+// package.
+type Address struct {
+	Line1      string `gorm:"serializer:encrypted"`
+	Line2      string `gorm:"serializer:encrypted"`
+	City       string `gorm:"serializer:encrypted"`
+	State      string `gorm:"serializer:encrypted"`
+	PostalCode string `gorm:"serializer:encrypted"`
+	Country    string `gorm:"serializer:encrypted"`
+}
+
+type EmergencyContact struct {
+	Name         string
+	Relationship string
+	Phone        string `gorm:"serializer:encrypted"`
+}
 
 type Patient struct {
-	ID           string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
-	Name         string `gorm:"not null"`
-	Age          int    `gorm:"not null"`
-	Gender       string `gorm:"not null"`
-	Address      string
-	Phone        string
-	MedicalNotes string
-	CreatedBy    string `gorm:"type:uuid;index"`
-	UpdatedBy    string `gorm:"type:uuid;index"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID                   string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	MRN                  string `gorm:"uniqueIndex;not null"`
+	Name                 string `gorm:"not null"`
+	GivenName            string
+	FamilyName           string
+	PreferredName        string
+	DateOfBirth          *time.Time `gorm:"type:date"`
+	DateOfBirthEstimated bool
+	Gender               string `gorm:"not null"`
+	SexAtBirth           string
+	GenderIdentity       string
+	Email                string `gorm:"serializer:encrypted"`
+	Phone                string `gorm:"serializer:encrypted"`
+	// PhoneIndex is a blind index of the phone number's digits, used to
+	// find patients by phone without decrypting every row.
+	PhoneIndex        *string
+	PreferredLanguage string
+	Address           Address          `gorm:"embedded;embeddedPrefix:address_"`
+	EmergencyContact  EmergencyContact `gorm:"embedded;embeddedPrefix:emergency_contact_"`
+	Identifiers       []PatientIdentifier
+	MedicalNotes      string  `gorm:"serializer:encrypted"`
+	MergedIntoID      *string `gorm:"type:uuid;index"`
+	MergedAt          *time.Time
+	// ErasedAt is set once the patient has been anonymized.
+	ErasedAt  *time.Time
+	CreatedBy string `gorm:"type:uuid;index"`
+	UpdatedBy string `gorm:"type:uuid;index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// BeforeCreate assigns the ID instead of leaving it to the database default,
+// as the encrypted fields are bound to it.
+func (p *Patient) BeforeCreate(*gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.NewString()
+	}
+	return nil
+}
+
+// LogValue keeps patient details out of logs: a logged patient shows only
+// its ID and MRN.
+func (p Patient) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", p.ID), slog.String("mrn", p.MRN))
+}
+
+// Age returns the completed years and months between the date of birth and
+// at. Both are zero when the date of birth is unknown.
+func (p *Patient) Age(at time.Time) (years int, months int) {
+	if p.DateOfBirth == nil {
+		return 0, 0
+	}
+	dob := *p.DateOfBirth
+
+	months = (at.Year()-dob.Year())*12 + int(at.Month()) - int(dob.Month())
+	if at.Day() < dob.Day() {
+		months--
+	}
+	if months < 0 {
+		return 0, 0
+	}
+	return months / 12, months
+}
+
+func (a Address) Lines() []string {
+	var lines []string
+	for _, line := range []string{a.Line1, a.Line2, a.City, a.State, a.PostalCode, a.Country} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
 }
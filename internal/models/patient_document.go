@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	DocumentCategoryReferral = "referral"
+	DocumentCategoryIDCard   = "id_card"
+	DocumentCategoryReport   = "report"
+	DocumentCategoryConsent  = "consent"
+	DocumentCategoryOther    = "other"
+)
+
+// PatientDocument describes an uploaded file. The content itself is kept in
+// document storage under StorageKey.
+type PatientDocument struct {
+	ID          string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	PatientID   string `gorm:"type:uuid;not null;index"`
+	Category    string `gorm:"not null"`
+	Title       string `gorm:"not null"`
+	FileName    string `gorm:"not null"`
+	ContentType string `gorm:"not null"`
+	SizeBytes   int64  `gorm:"not null"`
+	SHA256      string `gorm:"column:sha256;not null"`
+	StorageKey  string `gorm:"uniqueIndex;not null"`
+	UploadedBy  string `gorm:"type:uuid;not null"`
+	Uploader    *User  `gorm:"foreignKey:UploadedBy"`
+	CreatedAt   time.Time
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const (
+	IdentifierNationalID        = "national_id"
+	IdentifierInsuranceMemberID = "insurance_member_id"
+	IdentifierHospitalMRN       = "hospital_mrn"
+	IdentifierOther             = "other"
+)
+
+type PatientIdentifier struct {
+	ID        string  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	PatientID string  `gorm:"type:uuid;not null;index"`
+	Type      string  `gorm:"type:varchar(30);not null"`
+	Issuer    string  `gorm:"not null"`
+	Value     string  `gorm:"not null;index"`
+	CreatedBy *string `gorm:"type:uuid"`
+	CreatedAt time.Time
+}
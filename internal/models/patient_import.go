@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// ImportIssue is a row that was not imported. Duplicates lists the MRNs of
+// the existing patients it probably duplicates.
+type ImportIssue struct {
+	Row        int      `json:"row"`
+	Field      string   `json:"field,omitempty"`
+	Message    string   `json:"message"`
+	Duplicates []string `json:"duplicates,omitempty"`
+}
+
+// PatientImport tracks a bulk import. In a dry run CreatedRows counts the
+// rows that would have been created.
+type PatientImport struct {
+	ID              string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	FileName        string `gorm:"not null"`
+	Format          string `gorm:"not null"`
+	DryRun          bool
+	AllowDuplicates bool
+	Status          string `gorm:"not null"`
+	TotalRows       int
+	ProcessedRows   int
+	CreatedRows     int
+	DuplicateRows   int
+	FailedRows      int
+	Issues          []ImportIssue `gorm:"type:jsonb;serializer:json;not null"`
+	Error           string
+	CreatedBy       string `gorm:"type:uuid;not null"`
+	Creator         *User  `gorm:"foreignKey:CreatedBy"`
+	CreatedAt       time.Time
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DuplicateStatusOpen      = "open"
+	DuplicateStatusDismissed = "dismissed"
+	DuplicateStatusMerged    = "merged"
+)
+
+// PatientMerge records a merge of MergedID into SurvivorID. MovedRecords maps
+// each table to the IDs of the rows that were re-pointed, which is what a
+// reversal moves back.
+type PatientMerge struct {
+	ID            string          `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	SurvivorID    string          `gorm:"type:uuid;not null;index"`
+	MergedID      string          `gorm:"type:uuid;not null;index"`
+	MovedRecords  json.RawMessage `gorm:"type:jsonb;not null"`
+	SurvivorNotes string          `gorm:"serializer:encrypted"`
+	MergedBy      string          `gorm:"type:uuid"`
+	MergedAt      time.Time
+	ReversedBy    *string `gorm:"type:uuid"`
+	ReversedAt    *time.Time
+	Survivor      *Patient `gorm:"foreignKey:SurvivorID"`
+	Merged        *Patient `gorm:"foreignKey:MergedID"`
+}
+
+// BeforeCreate assigns the ID instead of leaving it to the database default,
+// as the encrypted fields are bound to it.
+func (m *PatientMerge) BeforeCreate(*gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	return nil
+}
+
+type DuplicateCandidate struct {
+	ID                 string   `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	PatientID          string   `gorm:"type:uuid;not null"`
+	DuplicatePatientID string   `gorm:"type:uuid;not null"`
+	Score              int      `gorm:"not null"`
+	Reasons            string   `gorm:"not null"`
+	Status             string   `gorm:"not null"`
+	Patient            *Patient `gorm:"foreignKey:PatientID"`
+	DuplicatePatient   *Patient `gorm:"foreignKey:DuplicatePatientID"`
+	DetectedAt         time.Time
+	UpdatedAt          time.Time
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PaymentMethodCash         = "cash"
+	PaymentMethodCard         = "card"
+	PaymentMethodBankTransfer = "bank_transfer"
+)
+
+const (
+	PaymentKindPayment = "payment"
+	PaymentKindRefund  = "refund"
+)
+
+// Payment is money taken at the desk against an invoice, or handed back when
+// Kind is refund. Amount is always positive.
+type Payment struct {
+	ID            string          `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	ReceiptNumber string          `gorm:"uniqueIndex;not null"`
+	InvoiceID     string          `gorm:"type:uuid;not null;index"`
+	Kind          string          `gorm:"not null"`
+	Method        string          `gorm:"not null"`
+	Amount        decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	Reference     string
+	Notes         string
+	RefundOfID    *string   `gorm:"type:uuid"`
+	ReceivedBy    string    `gorm:"type:uuid;not null"`
+	ReceivedAt    time.Time `gorm:"not null"`
+	BusinessDate  time.Time `gorm:"type:date;not null;index"`
+	DayCloseID    *string   `gorm:"type:uuid"`
+	Invoice       *Invoice
+	Receiver      *User `gorm:"foreignKey:ReceivedBy"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// SignedAmount is the payment's effect on the invoice's amount paid.
+func (p *Payment) SignedAmount() decimal.Decimal {
+	if p.Kind == PaymentKindRefund {
+		return p.Amount.Neg()
+	}
+	return p.Amount
+}
+
+// DayClose records the end-of-day close of a business date. Payments of a
+// closed day can no longer be changed.
+type DayClose struct {
+	ID           string          `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	BusinessDate time.Time       `gorm:"type:date;uniqueIndex;not null"`
+	PaymentCount int             `gorm:"not null"`
+	NetTotal     decimal.Decimal `gorm:"type:numeric(12,2);not null"`
+	Notes        string
+	ClosedBy     string `gorm:"type:uuid;not null"`
+	Closer       *User  `gorm:"foreignKey:ClosedBy"`
+	ClosedAt     time.Time
+}
@@ -0,0 +1,20 @@
+package models
+
+import "testing"
+
+func TestPaymentSignedAmount(t *testing.T) {
+	tests := []struct {
+		kind string
+		want string
+	}{
+		{kind: PaymentKindPayment, want: "12.50"},
+		{kind: PaymentKindRefund, want: "-12.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			payment := &Payment{Kind: tt.kind, Amount: dec("12.50")}
+			checkAmount(t, "signed amount", payment.SignedAmount(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Prescription is issued by a doctor and cannot be changed afterwards; a
+// correction is a new prescription.
+type Prescription struct {
+	ID           string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	PatientID    string `gorm:"type:uuid;not null;index"`
+	PrescribedBy string `gorm:"type:uuid;not null"`
+	Diagnosis    string
+	Notes        string
+	Items        []PrescriptionItem `gorm:"constraint:OnDelete:CASCADE"`
+	Patient      *Patient
+	Prescriber   *User `gorm:"foreignKey:PrescribedBy"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type PrescriptionItem struct {
+	ID             string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	PrescriptionID string `gorm:"type:uuid;not null;index"`
+	Position       int    `gorm:"not null"`
+	Medication     string `gorm:"not null"`
+	Dosage         string `gorm:"not null"`
+	Route          string
+	Frequency      string `gorm:"not null"`
+	Duration       string
+	Quantity       string
+	Instructions   string
+}
@@ -0,0 +1,283 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	pageMargin   = 15.0
+	bottomMargin = 20.0
+	lineHeight   = 5.0
+	cellHeight   = 4.5
+	labelWidth   = 42.0
+	logoHeight   = 18.0
+
+	dateLayout     = "02 Jan 2006"
+	dateTimeLayout = "02 Jan 2006 15:04"
+)
+
+// Letterhead is printed at the top of every page.
+type Letterhead struct {
+	Name     string
+	Address  string
+	Phone    string
+	Email    string
+	Website  string
+	LogoPath string
+}
+
+func (l Letterhead) contactLine() string {
+	var parts []string
+	for _, part := range []string{l.Phone, l.Email, l.Website} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "  |  ")
+}
+
+// Renderer lays documents out on A4 pages with the clinic letterhead. It uses
+// the PDF core fonts, so text outside Windows-1252 is replaced.
+type Renderer struct {
+	letterhead Letterhead
+}
+
+func NewRenderer(letterhead Letterhead) *Renderer {
+	if letterhead.LogoPath != "" {
+		probe := gofpdf.New("P", "mm", "A4", "")
+		probe.RegisterImageOptions(letterhead.LogoPath, gofpdf.ImageOptions{ReadDpi: true})
+		if probe.Err() {
+			slog.Warn("Ignoring letterhead logo", slog.String("path", letterhead.LogoPath), slog.Any("error", probe.Error()))
+			letterhead.LogoPath = ""
+		}
+	}
+	return &Renderer{letterhead}
+}
+
+type field struct {
+	label string
+	value string
+}
+
+type column struct {
+	title string
+	width float64 // share of the page width
+	align string
+}
+
+// document wraps a gofpdf document with the layout shared by all documents.
+type document struct {
+	pdf   *gofpdf.Fpdf
+	tr    func(string) string
+	width float64
+}
+
+func (r *Renderer) newDocument(title, footer string, now time.Time) *document {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, bottomMargin)
+	pdf.AliasNbPages("")
+	pdf.SetTitle(title, true)
+	pdf.SetAuthor(r.letterhead.Name, true)
+	pdf.SetCreationDate(now)
+
+	pageWidth, _ := pdf.GetPageSize()
+	d := &document{
+		pdf:   pdf,
+		tr:    pdf.UnicodeTranslatorFromDescriptor(""),
+		width: pageWidth - 2*pageMargin,
+	}
+	pdf.SetHeaderFunc(func() { d.letterhead(r.letterhead) })
+	pdf.SetFooterFunc(func() { d.footer(footer) })
+	pdf.AddPage()
+	return d
+}
+
+func (d *document) letterhead(l Letterhead) {
+	pdf := d.pdf
+	top := pdf.GetY()
+	x := pageMargin
+	bottom := top
+	if l.LogoPath != "" {
+		info := pdf.RegisterImageOptions(l.LogoPath, gofpdf.ImageOptions{ReadDpi: true})
+		if info != nil {
+			width := info.Width() * logoHeight / info.Height()
+			pdf.ImageOptions(l.LogoPath, x, top, width, logoHeight, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+			x += width + 4
+			bottom = top + logoHeight
+		}
+	}
+
+	pdf.SetXY(x, top)
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.CellFormat(0, 7, d.tr(l.Name), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetTextColor(90, 90, 90)
+	for _, line := range []string{l.Address, l.contactLine()} {
+		if line != "" {
+			pdf.SetX(x)
+			pdf.CellFormat(0, cellHeight, d.tr(line), "", 1, "L", false, 0, "")
+		}
+	}
+
+	y := max(pdf.GetY(), bottom) + 2
+	pdf.SetDrawColor(160, 160, 160)
+	pdf.Line(pageMargin, y, pageMargin+d.width, y)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetY(y + 4)
+}
+
+func (d *document) footer(text string) {
+	pdf := d.pdf
+	pdf.SetY(-bottomMargin + 6)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetTextColor(120, 120, 120)
+	pdf.CellFormat(d.width/2, cellHeight, d.tr(text), "", 0, "L", false, 0, "")
+	pdf.CellFormat(d.width/2, cellHeight, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// title writes the document title with a right-aligned line such as the
+// document number or date.
+func (d *document) title(title, aside string) {
+	pdf := d.pdf
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(d.width/2, 8, d.tr(title), "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(d.width/2, 8, d.tr(aside), "", 1, "R", false, 0, "")
+	pdf.Ln(2)
+}
+
+func (d *document) section(title string) {
+	pdf := d.pdf
+	pdf.Ln(3)
+	// Keep a heading together with at least a few lines of its content.
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY() > pageHeight-bottomMargin-25 {
+		pdf.AddPage()
+	}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetFillColor(235, 235, 235)
+	pdf.CellFormat(0, 7, d.tr(title), "", 1, "L", true, 0, "")
+	pdf.Ln(1.5)
+}
+
+// fields writes label/value rows, skipping empty values.
+func (d *document) fields(fields ...field) {
+	pdf := d.pdf
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(90, 90, 90)
+		pdf.CellFormat(labelWidth, lineHeight, d.tr(f.label), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.MultiCell(d.width-labelWidth, lineHeight, d.tr(f.value), "", "L", false)
+	}
+}
+
+func (d *document) paragraph(text string) {
+	d.pdf.SetFont("Helvetica", "", 10)
+	d.pdf.MultiCell(0, lineHeight, d.tr(text), "", "L", false)
+}
+
+// note writes a muted line, e.g. to say a section is empty.
+func (d *document) note(text string) {
+	pdf := d.pdf
+	pdf.SetFont("Helvetica", "I", 9)
+	pdf.SetTextColor(110, 110, 110)
+	pdf.MultiCell(0, lineHeight, d.tr(text), "", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// table writes rows under a header that is repeated on every page the table
+// spans. Cells wrap and each row is kept on one page.
+func (d *document) table(columns []column, rows [][]string) {
+	pdf := d.pdf
+	widths := make([]float64, len(columns))
+	for i, col := range columns {
+		widths[i] = col.width * d.width
+	}
+	_, pageHeight := pdf.GetPageSize()
+
+	d.tableHeader(columns, widths)
+	pdf.SetDrawColor(210, 210, 210)
+	for _, row := range rows {
+		pdf.SetFont("Helvetica", "", 9)
+		lines := 1
+		for i, cell := range row {
+			lines = max(lines, len(pdf.SplitLines([]byte(d.tr(cell)), widths[i])))
+		}
+		height := float64(lines)*cellHeight + 1.5
+		if pdf.GetY()+height > pageHeight-bottomMargin {
+			pdf.AddPage()
+			d.tableHeader(columns, widths)
+			pdf.SetFont("Helvetica", "", 9)
+		}
+
+		x, y := pageMargin, pdf.GetY()
+		for i, cell := range row {
+			pdf.SetXY(x, y+0.75)
+			pdf.MultiCell(widths[i], cellHeight, d.tr(cell), "", columns[i].align, false)
+			x += widths[i]
+		}
+		pdf.Line(pageMargin, y+height, pageMargin+d.width, y+height)
+		pdf.SetXY(pageMargin, y+height)
+	}
+}
+
+func (d *document) tableHeader(columns []column, widths []float64) {
+	pdf := d.pdf
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(245, 245, 245)
+	for i, col := range columns {
+		pdf.CellFormat(widths[i], 6, d.tr(col.title), "B", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+type totalRow struct {
+	label  string
+	value  string
+	strong bool
+}
+
+// totals writes amount rows aligned to the right margin.
+func (d *document) totals(rows ...totalRow) {
+	pdf := d.pdf
+	const keyWidth, valueWidth = 45.0, 35.0
+	for _, row := range rows {
+		style := ""
+		if row.strong {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.SetX(pageMargin + d.width - keyWidth - valueWidth)
+		pdf.CellFormat(keyWidth, lineHeight+0.5, d.tr(row.label), "", 0, "L", false, 0, "")
+		pdf.CellFormat(valueWidth, lineHeight+0.5, d.tr(row.value), "", 1, "R", false, 0, "")
+	}
+}
+
+func (d *document) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
@@ -0,0 +1,111 @@
+package pdf
+
+import (
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// Invoice renders an invoice with its items and patient loaded. Drafts and
+// void invoices are titled as such so they cannot pass for a bill.
+func (r *Renderer) Invoice(invoice *models.Invoice, now time.Time) ([]byte, error) {
+	title := "Invoice"
+	switch invoice.Status {
+	case models.InvoiceStatusDraft:
+		title = "Draft Invoice"
+	case models.InvoiceStatusVoid:
+		title = "Void Invoice"
+	}
+
+	d := r.newDocument(title, "Invoice "+invoiceNumber(invoice), now)
+	d.title(title, invoiceNumber(invoice))
+
+	d.section("Bill to")
+	if patient := invoice.Patient; patient != nil {
+		d.fields(
+			field{"Patient", patient.Name},
+			field{"MRN", patient.MRN},
+			field{"Address", strings.Join(patient.Address.Lines(), ", ")},
+		)
+	}
+	d.fields(
+		field{"Issued", formatDate(invoice.IssuedAt)},
+		field{"Due", formatDate(invoice.DueDate)},
+		field{"Status", statusLabel(invoice.Status)},
+	)
+	if invoice.Status == models.InvoiceStatusVoid {
+		d.fields(
+			field{"Voided", formatDate(invoice.VoidedAt)},
+			field{"Reason", invoice.VoidReason},
+		)
+	}
+
+	d.section("Items")
+	rows := make([][]string, len(invoice.Items))
+	for i, item := range invoice.Items {
+		rows[i] = []string{
+			item.Description,
+			item.Quantity.String(),
+			item.UnitPrice.StringFixed(2),
+			percent(item.DiscountPercent),
+			percent(item.TaxRate),
+			item.LineTotal.StringFixed(2),
+		}
+	}
+	d.table([]column{
+		{"Description", 0.4, "L"},
+		{"Qty", 0.08, "R"},
+		{"Unit price", 0.14, "R"},
+		{"Discount", 0.12, "R"},
+		{"Tax", 0.1, "R"},
+		{"Amount", 0.16, "R"},
+	}, rows)
+
+	d.pdf.Ln(3)
+	totals := []totalRow{{label: "Subtotal", value: money(invoice.Currency, invoice.Subtotal)}}
+	if invoice.DiscountTotal.IsPositive() {
+		totals = append(totals, totalRow{label: "Discount", value: "-" + money(invoice.Currency, invoice.DiscountTotal)})
+	}
+	totals = append(totals,
+		totalRow{label: "Tax", value: money(invoice.Currency, invoice.TaxTotal)},
+		totalRow{label: "Total", value: money(invoice.Currency, invoice.Total), strong: true},
+	)
+	if invoice.Status != models.InvoiceStatusDraft && invoice.Status != models.InvoiceStatusVoid {
+		totals = append(totals,
+			totalRow{label: "Paid", value: money(invoice.Currency, invoice.AmountPaid)},
+			totalRow{label: "Balance due", value: money(invoice.Currency, invoice.Balance()), strong: true},
+		)
+	}
+	d.totals(totals...)
+
+	if strings.TrimSpace(invoice.Notes) != "" {
+		d.section("Notes")
+		d.paragraph(invoice.Notes)
+	}
+	return d.bytes()
+}
+
+func invoiceNumber(invoice *models.Invoice) string {
+	if invoice.Number == nil {
+		return "Draft"
+	}
+	return *invoice.Number
+}
+
+func statusLabel(status string) string {
+	if status == "" {
+		return ""
+	}
+	status = strings.ReplaceAll(status, "_", " ")
+	return strings.ToUpper(status[:1]) + status[1:]
+}
+
+func money(currency string, amount decimal.Decimal) string {
+	return currency + " " + amount.StringFixed(2)
+}
+
+func percent(value decimal.Decimal) string {
+	return value.String() + "%"
+}
@@ -0,0 +1,177 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+)
+
+// PatientSummary is the data printed on a patient summary.
+type PatientSummary struct {
+	Patient       *models.Patient
+	CreatedBy     *models.User
+	UpdatedBy     *models.User
+	Prescriptions []*models.Prescription
+	Invoices      []*models.Invoice
+}
+
+func (r *Renderer) PatientSummary(summary PatientSummary, now time.Time) ([]byte, error) {
+	patient := summary.Patient
+	d := r.newDocument("Patient Summary", "Confidential patient information", now)
+	d.title("Patient Summary", "Printed "+now.Format(dateTimeLayout))
+
+	d.section("Patient")
+	d.fields(
+		field{"Name", patient.Name},
+		field{"Preferred name", patient.PreferredName},
+		field{"MRN", patient.MRN},
+		field{"Date of birth", dateOfBirth(patient, now)},
+		field{"Gender", patient.Gender},
+		field{"Sex at birth", patient.SexAtBirth},
+		field{"Gender identity", patient.GenderIdentity},
+		field{"Preferred language", patient.PreferredLanguage},
+		field{"Phone", patient.Phone},
+		field{"Email", patient.Email},
+		field{"Address", strings.Join(patient.Address.Lines(), ", ")},
+		field{"Emergency contact", emergencyContact(patient.EmergencyContact)},
+		field{"Merged into", derefString(patient.MergedIntoID)},
+	)
+
+	if len(patient.Identifiers) > 0 {
+		d.section("Identifiers")
+		rows := make([][]string, len(patient.Identifiers))
+		for i, identifier := range patient.Identifiers {
+			rows[i] = []string{identifierLabel(identifier.Type), identifier.Issuer, identifier.Value}
+		}
+		d.table([]column{
+			{"Type", 0.3, "L"},
+			{"Issuer", 0.35, "L"},
+			{"Value", 0.35, "L"},
+		}, rows)
+	}
+
+	d.section("Medical notes")
+	if strings.TrimSpace(patient.MedicalNotes) == "" {
+		d.note("No medical notes recorded.")
+	} else {
+		d.paragraph(patient.MedicalNotes)
+	}
+
+	d.section("Prescriptions")
+	if len(summary.Prescriptions) == 0 {
+		d.note("No prescriptions recorded.")
+	} else {
+		var rows [][]string
+		for _, prescription := range summary.Prescriptions {
+			for _, item := range prescription.Items {
+				rows = append(rows, []string{
+					prescription.CreatedAt.Format(dateLayout),
+					item.Medication,
+					dosageLine(item),
+					item.Duration,
+					prescriberName(prescription),
+				})
+			}
+		}
+		d.table([]column{
+			{"Date", 0.14, "L"},
+			{"Medication", 0.3, "L"},
+			{"Dosage", 0.28, "L"},
+			{"Duration", 0.13, "L"},
+			{"Prescriber", 0.15, "L"},
+		}, rows)
+	}
+
+	d.section("Invoices")
+	if len(summary.Invoices) == 0 {
+		d.note("No invoices recorded.")
+	} else {
+		rows := make([][]string, len(summary.Invoices))
+		for i, invoice := range summary.Invoices {
+			rows[i] = []string{
+				invoiceNumber(invoice),
+				formatDate(invoice.IssuedAt),
+				statusLabel(invoice.Status),
+				money(invoice.Currency, invoice.Total),
+				money(invoice.Currency, invoice.AmountPaid),
+				money(invoice.Currency, invoice.Balance()),
+			}
+		}
+		d.table([]column{
+			{"Invoice", 0.2, "L"},
+			{"Issued", 0.15, "L"},
+			{"Status", 0.17, "L"},
+			{"Total", 0.16, "R"},
+			{"Paid", 0.16, "R"},
+			{"Balance", 0.16, "R"},
+		}, rows)
+	}
+
+	d.pdf.Ln(4)
+	d.note(recordHistory(summary))
+	return d.bytes()
+}
+
+func dateOfBirth(patient *models.Patient, now time.Time) string {
+	if patient.DateOfBirth == nil {
+		return ""
+	}
+	value := patient.DateOfBirth.Format(dateLayout)
+	if patient.DateOfBirthEstimated {
+		value += " (estimated)"
+	}
+	years, months := patient.Age(now)
+	if years > 0 {
+		return fmt.Sprintf("%s, %d years", value, years)
+	}
+	return fmt.Sprintf("%s, %d months", value, months)
+}
+
+func emergencyContact(contact models.EmergencyContact) string {
+	if contact.Name == "" {
+		return ""
+	}
+	value := contact.Name
+	if contact.Relationship != "" {
+		value += " (" + contact.Relationship + ")"
+	}
+	if contact.Phone != "" {
+		value += ", " + contact.Phone
+	}
+	return value
+}
+
+func identifierLabel(identifierType string) string {
+	switch identifierType {
+	case models.IdentifierNationalID:
+		return "National ID"
+	case models.IdentifierInsuranceMemberID:
+		return "Insurance member ID"
+	case models.IdentifierHospitalMRN:
+		return "Hospital MRN"
+	default:
+		return "Other"
+	}
+}
+
+func recordHistory(summary PatientSummary) string {
+	patient := summary.Patient
+	history := "Registered " + patient.CreatedAt.Format(dateTimeLayout)
+	if summary.CreatedBy != nil {
+		history += " by " + summary.CreatedBy.Username
+	}
+	history += "; last updated " + patient.UpdatedAt.Format(dateTimeLayout)
+	if summary.UpdatedBy != nil {
+		history += " by " + summary.UpdatedBy.Username
+	}
+	return history + "."
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
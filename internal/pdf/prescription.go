@@ -0,0 +1,87 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+)
+
+// Prescription renders a prescription with its items, patient and
+// prescriber loaded, ending with a line for the prescriber's signature.
+func (r *Renderer) Prescription(prescription *models.Prescription, now time.Time) ([]byte, error) {
+	d := r.newDocument("Prescription", "Prescription written "+prescription.CreatedAt.Format(dateLayout), now)
+	d.title("Prescription", prescription.CreatedAt.Format(dateLayout))
+
+	d.section("Patient")
+	if patient := prescription.Patient; patient != nil {
+		d.fields(
+			field{"Name", patient.Name},
+			field{"MRN", patient.MRN},
+			field{"Date of birth", dateOfBirth(patient, prescription.CreatedAt)},
+			field{"Address", strings.Join(patient.Address.Lines(), ", ")},
+		)
+	}
+	d.fields(field{"Diagnosis", prescription.Diagnosis})
+
+	d.section("Medications")
+	pdf := d.pdf
+	for i, item := range prescription.Items {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.MultiCell(0, lineHeight, d.tr(fmt.Sprintf("%d. %s", i+1, item.Medication)), "", "L", false)
+		pdf.SetX(pageMargin + 5)
+		details := dosageLine(item)
+		if item.Duration != "" {
+			details += " for " + item.Duration
+		}
+		if item.Quantity != "" {
+			details += ". Dispense: " + item.Quantity
+		}
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(d.width-5, lineHeight, d.tr(details), "", "L", false)
+		if item.Instructions != "" {
+			pdf.SetX(pageMargin + 5)
+			pdf.SetFont("Helvetica", "I", 10)
+			pdf.MultiCell(d.width-5, lineHeight, d.tr(item.Instructions), "", "L", false)
+		}
+		pdf.Ln(2)
+	}
+
+	if strings.TrimSpace(prescription.Notes) != "" {
+		d.section("Notes")
+		d.paragraph(prescription.Notes)
+	}
+
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY() > pageHeight-bottomMargin-30 {
+		pdf.AddPage()
+	}
+	pdf.Ln(15)
+	x := pageMargin + d.width - 70
+	y := pdf.GetY()
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Line(x, y, x+70, y)
+	pdf.SetXY(x, y+1)
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(70, cellHeight, d.tr(prescriberName(prescription)), "", 1, "C", false, 0, "")
+	return d.bytes()
+}
+
+// dosageLine joins dosage, route and frequency, e.g. "1 capsule, oral,
+// three times a day".
+func dosageLine(item models.PrescriptionItem) string {
+	parts := []string{item.Dosage}
+	if item.Route != "" {
+		parts = append(parts, item.Route)
+	}
+	parts = append(parts, item.Frequency)
+	return strings.Join(parts, ", ")
+}
+
+func prescriberName(prescription *models.Prescription) string {
+	if prescription.Prescriber == nil {
+		return ""
+	}
+	return prescription.Prescriber.Username
+}
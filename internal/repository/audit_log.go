@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/db"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+)
+
+type AuditLogFilter struct {
+	UserID     string
+	Action     string
+	EntityType string
+	EntityID   string
+	Limit      int
+	Offset     int
+}
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	Search(ctx context.Context, filter AuditLogFilter) ([]*models.AuditLog, int64, error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditLogRepository) Search(ctx context.Context, filter AuditLogFilter) ([]*models.AuditLog, int64, error) {
+	query := db.Replica(r.db).WithContext(ctx).Model(&models.AuditLog{})
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var entries []*models.AuditLog
+	if err := query.Order("created_at DESC").Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrClaimStatusChanged = errors.New("claim status has changed")
+
+type ClaimFilter struct {
+	InvoiceID string
+	PatientID string
+	Status    string
+	Limit     int
+	Offset    int
+}
+
+type ClaimRepository interface {
+	Create(ctx context.Context, claim *models.Claim) error
+	GetByID(ctx context.Context, id string) (*models.Claim, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*models.Claim, error)
+	Search(ctx context.Context, filter ClaimFilter) ([]*models.Claim, int64, error)
+	Update(ctx context.Context, claim *models.Claim, fromStatus string) error
+	Delete(ctx context.Context, id string) error
+	NextInterchangeControlNumber(ctx context.Context) (int, error)
+	MarkSubmitted(ctx context.Context, ids []string, controlNumber int, submittedAt time.Time, userID string) error
+}
+
+type claimRepository struct {
+	db *gorm.DB
+}
+
+func NewClaimRepository(db *gorm.DB) ClaimRepository {
+	return &claimRepository{db}
+}
+
+func (r *claimRepository) Create(ctx context.Context, claim *models.Claim) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var seq int64
+		if err := tx.Raw("SELECT nextval('claim_number_seq')").Scan(&seq).Error; err != nil {
+			return err
+		}
+		claim.ClaimNumber = utils.FormatClaimNumber(seq)
+		return tx.Omit("Invoice", "Policy").Create(claim).Error
+	})
+}
+
+func (r *claimRepository) GetByID(ctx context.Context, id string) (*models.Claim, error) {
+	var claim models.Claim
+	if err := r.preload(r.db.WithContext(ctx)).First(&claim, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (r *claimRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Claim, error) {
+	var claims []*models.Claim
+	if err := r.preload(r.db.WithContext(ctx)).Where("id IN ?", ids).Order("claim_number").Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (r *claimRepository) Search(ctx context.Context, filter ClaimFilter) ([]*models.Claim, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Claim{})
+	if filter.InvoiceID != "" {
+		query = query.Where("invoice_id = ?", filter.InvoiceID)
+	}
+	if filter.PatientID != "" {
+		query = query.Where("invoice_id IN (?)",
+			r.db.WithContext(ctx).Model(&models.Invoice{}).Select("id").Where("patient_id = ?", filter.PatientID))
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var claims []*models.Claim
+	if err := query.Preload("Invoice.Patient").Preload("Policy").Order("created_at DESC").Find(&claims).Error; err != nil {
+		return nil, 0, err
+	}
+	return claims, total, nil
+}
+
+// Update saves the claim if its status is still fromStatus, so that two
+// users acting on the same claim cannot both move it on.
+func (r *claimRepository) Update(ctx context.Context, claim *models.Claim, fromStatus string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Claim
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", claim.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != fromStatus {
+			return ErrClaimStatusChanged
+		}
+		return tx.Omit("Invoice", "Policy").Save(claim).Error
+	})
+}
+
+// Delete removes a draft claim.
+func (r *claimRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND status = ?", id, models.ClaimStatusDraft).Delete(&models.Claim{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return ErrClaimStatusChanged
+	}
+	return nil
+}
+
+func (r *claimRepository) NextInterchangeControlNumber(ctx context.Context) (int, error) {
+	var seq int
+	if err := r.db.WithContext(ctx).Raw("SELECT nextval('edi_interchange_seq')").Scan(&seq).Error; err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// MarkSubmitted records the interchange the claims were exported in and moves
+// drafts to submitted. Claims that were already submitted keep their status.
+func (r *claimRepository) MarkSubmitted(ctx context.Context, ids []string, controlNumber int, submittedAt time.Time, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Claim{}).
+			Where("id IN ? AND status = ?", ids, models.ClaimStatusDraft).
+			Updates(map[string]any{
+				"status":       models.ClaimStatusSubmitted,
+				"submitted_at": submittedAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Claim{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"interchange_control_number": controlNumber,
+				"updated_by":                 userID,
+				"updated_at":                 time.Now(),
+			}).Error
+	})
+}
+
+func (r *claimRepository) preload(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Invoice.Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Invoice.Patient").
+		Preload("Policy")
+}
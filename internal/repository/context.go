@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTimeout  = errors.New("database query timed out")
+	ErrCanceled = errors.New("request was canceled")
+)
+
+// queryCanceled is the SQLSTATE of a statement canceled by the server, which
+// is what statement_timeout does once the context has no say.
+const queryCanceled = "57014"
+
+// TranslateContextErrors makes statements run through db that are cut short
+// by their context fail with ErrTimeout when it ran out of time, or with
+// ErrCanceled when it was canceled. Statements that hit DB_STATEMENT_TIMEOUT
+// fail with ErrTimeout too. The original error stays wrapped.
+func TranslateContextErrors(db *gorm.DB) error {
+	translate := func(tx *gorm.DB) {
+		var pgErr *pgconn.PgError
+		switch {
+		case tx.Error == nil:
+		case errors.Is(tx.Error, context.DeadlineExceeded):
+			tx.Error = fmt.Errorf("%w: %w", ErrTimeout, tx.Error)
+		case errors.Is(tx.Error, context.Canceled):
+			tx.Error = fmt.Errorf("%w: %w", ErrCanceled, tx.Error)
+		case errors.As(tx.Error, &pgErr) && pgErr.Code == queryCanceled:
+			tx.Error = fmt.Errorf("%w: %w", ErrTimeout, tx.Error)
+		}
+	}
+	callbacks := db.Callback()
+	operations := []struct {
+		name     string
+		register func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().After("*").Register},
+		{"query", callbacks.Query().After("*").Register},
+		{"update", callbacks.Update().After("*").Register},
+		{"delete", callbacks.Delete().After("*").Register},
+		{"row", callbacks.Row().After("*").Register},
+		{"raw", callbacks.Raw().After("*").Register},
+	}
+	for _, op := range operations {
+		if err := op.register("clinic:context_errors_"+op.name, translate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DuplicateCandidateRepository interface {
+	Upsert(ctx context.Context, candidates []*models.DuplicateCandidate) error
+	GetByID(ctx context.Context, id string) (*models.DuplicateCandidate, error)
+	GetByStatus(ctx context.Context, status string, limit, offset int) ([]*models.DuplicateCandidate, int64, error)
+	UpdateStatus(ctx context.Context, id, status string) error
+}
+
+type duplicateCandidateRepository struct {
+	db *gorm.DB
+}
+
+func NewDuplicateCandidateRepository(db *gorm.DB) DuplicateCandidateRepository {
+	return &duplicateCandidateRepository{db}
+}
+
+// Upsert refreshes the score of pairs that were already reported but leaves
+// their status alone, so dismissed pairs stay dismissed.
+func (r *duplicateCandidateRepository) Upsert(ctx context.Context, candidates []*models.DuplicateCandidate) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "patient_id"}, {Name: "duplicate_patient_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "reasons", "updated_at"}),
+	}).CreateInBatches(candidates, 500).Error
+}
+
+func (r *duplicateCandidateRepository) GetByID(ctx context.Context, id string) (*models.DuplicateCandidate, error) {
+	var candidate models.DuplicateCandidate
+	if err := r.db.WithContext(ctx).Preload("Patient").Preload("DuplicatePatient").First(&candidate, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+// GetByStatus skips pairs where either patient has since been merged away.
+func (r *duplicateCandidateRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]*models.DuplicateCandidate, int64, error) {
+	merged := r.db.WithContext(ctx).Model(&models.Patient{}).Select("id").Where("merged_into_id IS NOT NULL")
+	query := r.db.WithContext(ctx).Model(&models.DuplicateCandidate{}).
+		Where("patient_id NOT IN (?) AND duplicate_patient_id NOT IN (?)", merged, merged)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	var candidates []*models.DuplicateCandidate
+	err := query.
+		Preload("Patient").
+		Preload("DuplicatePatient").
+		Order("score DESC, detected_at DESC").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return candidates, total, nil
+}
+
+func (r *duplicateCandidateRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	result := r.db.WithContext(ctx).Model(&models.DuplicateCandidate{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"status": status, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
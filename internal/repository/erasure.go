@@ -0,0 +1,515 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/hl7"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPatientErased        = errors.New("patient has been erased")
+	ErrPatientOnHold        = errors.New("patient is on legal hold")
+	ErrErasureNotPending    = errors.New("erasure request is no longer pending")
+	ErrErasureRequestExists = errors.New("an erasure request for this patient is already pending")
+	ErrLegalHoldReleased    = errors.New("legal hold has already been released")
+)
+
+// anonymizedPatientFields are the columns Erase overwrites. They are named by
+// column because field names like Name and Phone are ambiguous with the
+// embedded emergency contact.
+var anonymizedPatientFields = []string{
+	"mrn",
+	"name",
+	"given_name",
+	"family_name",
+	"preferred_name",
+	"date_of_birth",
+	"date_of_birth_estimated",
+	"gender_identity",
+	"email",
+	"phone",
+	"phone_index",
+	"address_line1",
+	"address_line2",
+	"address_city",
+	"address_postal_code",
+	"emergency_contact_name",
+	"emergency_contact_relationship",
+	"emergency_contact_phone",
+	"medical_notes",
+	"erased_at",
+	"updated_by",
+}
+
+// identifyingHL7Segments are removed from stored messages: patient
+// identification, next of kin, guarantor, insurance and notes.
+var identifyingHL7Segments = []string{"PID", "PD1", "NK1", "GT1", "IN1", "IN2", "IN3", "NTE"}
+
+// HoldError lists why a patient cannot be erased yet.
+type HoldError struct {
+	Reasons []string
+}
+
+func (e *HoldError) Error() string {
+	return ErrPatientOnHold.Error() + ": " + strings.Join(e.Reasons, "; ")
+}
+
+func (e *HoldError) Unwrap() error {
+	return ErrPatientOnHold
+}
+
+// ErasureResult is what Erase changed: the number of rows touched per table
+// and the storage keys of the documents that were deleted, which the caller
+// removes from document storage once the transaction has committed.
+type ErasureResult struct {
+	Rows        map[string]int64
+	StorageKeys []string
+	ErasedAt    time.Time
+}
+
+type ErasureRepository interface {
+	CreateRequest(ctx context.Context, request *models.ErasureRequest, entry *models.AuditLog) error
+	GetRequest(ctx context.Context, id string) (*models.ErasureRequest, error)
+	GetRequests(ctx context.Context, status string, limit, offset int) ([]*models.ErasureRequest, int64, error)
+	CancelRequest(ctx context.Context, request *models.ErasureRequest, entry *models.AuditLog) error
+	Erase(ctx context.Context, request *models.ErasureRequest, entry *models.AuditLog, certify func(result *ErasureResult) error) (*ErasureResult, error)
+	CheckHolds(ctx context.Context, patientID string) error
+	CreateHold(ctx context.Context, hold *models.LegalHold, entry *models.AuditLog) error
+	GetHolds(ctx context.Context, patientID string) ([]*models.LegalHold, error)
+	ReleaseHold(ctx context.Context, hold *models.LegalHold, entry *models.AuditLog) error
+	GetHold(ctx context.Context, id string) (*models.LegalHold, error)
+}
+
+type erasureRepository struct {
+	db *gorm.DB
+}
+
+func NewErasureRepository(db *gorm.DB) ErasureRepository {
+	return &erasureRepository{db}
+}
+
+// CreateRequest records a pending request after checking the patient can be
+// erased. The audit entry is written in the same transaction.
+func (r *erasureRepository) CreateRequest(ctx context.Context, request *models.ErasureRequest, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockErasablePatient(tx, request.PatientID); err != nil {
+			return err
+		}
+		if err := checkHolds(tx, request.PatientID); err != nil {
+			return err
+		}
+		var pending int64
+		err := tx.Model(&models.ErasureRequest{}).
+			Where("patient_id = ? AND status = ?", request.PatientID, models.ErasureStatusPending).
+			Count(&pending).Error
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrErasureRequestExists
+		}
+
+		request.Status = models.ErasureStatusPending
+		if err := tx.Omit(clause.Associations).Create(request).Error; err != nil {
+			return err
+		}
+		entry.EntityID = request.ID
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *erasureRepository) GetRequest(ctx context.Context, id string) (*models.ErasureRequest, error) {
+	var request models.ErasureRequest
+	if err := r.db.WithContext(ctx).Preload("Patient").Preload("Requester").First(&request, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *erasureRepository) GetRequests(ctx context.Context, status string, limit, offset int) ([]*models.ErasureRequest, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.ErasureRequest{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var requests []*models.ErasureRequest
+	err := query.Preload("Patient").Preload("Requester").Order("created_at DESC").Limit(limit).Offset(offset).Find(&requests).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+func (r *erasureRepository) CancelRequest(ctx context.Context, request *models.ErasureRequest, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockPendingRequest(tx, request.ID); err != nil {
+			return err
+		}
+		now := time.Now()
+		err := tx.Model(&models.ErasureRequest{ID: request.ID}).Updates(map[string]any{
+			"status":       models.ErasureStatusCancelled,
+			"cancelled_by": request.CancelledBy,
+			"cancelled_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		request.Status = models.ErasureStatusCancelled
+		request.CancelledAt = &now
+
+		entry.EntityID = request.ID
+		return tx.Create(entry).Error
+	})
+}
+
+// Erase anonymizes the patient of a pending request, along with any
+// tombstones merged into it, in one transaction. Legal holds are checked
+// again under the patient's row lock.
+//
+// IDs, gender, sex at birth, birth year, state, country and language are
+// kept so the patient still counts in statistics, as are clinical codes and
+// every amount on invoices, payments and claims. Names, contact details,
+// identifiers, notes and other free text are removed, as are uploaded
+// documents and the identifying segments of stored HL7 messages. certify is
+// called before the transaction commits to sign the request's certificate.
+func (r *erasureRepository) Erase(ctx context.Context, request *models.ErasureRequest, entry *models.AuditLog, certify func(result *ErasureResult) error) (*ErasureResult, error) {
+	result := &ErasureResult{Rows: make(map[string]int64)}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockPendingRequest(tx, request.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := lockErasablePatient(tx, locked.PatientID); err != nil {
+			return err
+		}
+		if err := checkHolds(tx, locked.PatientID); err != nil {
+			return err
+		}
+
+		var patients []*models.Patient
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? OR merged_into_id = ?", locked.PatientID, locked.PatientID).
+			Order("id").
+			Find(&patients).Error
+		if err != nil {
+			return err
+		}
+		ids := make([]string, len(patients))
+		mrns := make([]string, len(patients))
+		for i, patient := range patients {
+			ids[i] = patient.ID
+			mrns[i] = patient.MRN
+		}
+
+		result.ErasedAt = time.Now()
+		for _, patient := range patients {
+			anonymized := anonymizePatient(patient, result.ErasedAt, *request.CompletedBy)
+			if err := tx.Model(patient).Select(anonymizedPatientFields).Updates(anonymized).Error; err != nil {
+				return err
+			}
+		}
+		result.Rows["patients"] = int64(len(patients))
+
+		if err := tx.Model(&models.PatientDocument{}).Where("patient_id IN ?", ids).Pluck("storage_key", &result.StorageKeys).Error; err != nil {
+			return err
+		}
+		invoices := tx.Model(&models.Invoice{}).Select("id").Where("patient_id IN ?", ids)
+		prescriptions := tx.Model(&models.Prescription{}).Select("id").Where("patient_id IN ?", ids)
+		steps := []struct {
+			table string
+			run   func() *gorm.DB
+		}{
+			{"patient_identifiers", func() *gorm.DB {
+				return tx.Where("patient_id IN ?", ids).Delete(&models.PatientIdentifier{})
+			}},
+			{"patient_documents", func() *gorm.DB {
+				return tx.Where("patient_id IN ?", ids).Delete(&models.PatientDocument{})
+			}},
+			{"duplicate_candidates", func() *gorm.DB {
+				return tx.Where("patient_id IN ? OR duplicate_patient_id IN ?", ids, ids).Delete(&models.DuplicateCandidate{})
+			}},
+			{"insurance_policies", func() *gorm.DB {
+				return tx.Model(&models.InsurancePolicy{}).Where("patient_id IN ?", ids).Updates(map[string]any{
+					"member_id":                "ERASED",
+					"group_number":             "",
+					"subscriber_given_name":    "",
+					"subscriber_family_name":   "",
+					"subscriber_date_of_birth": nil,
+				})
+			}},
+			{"invoices", func() *gorm.DB {
+				return tx.Model(&models.Invoice{}).Where("patient_id IN ? AND notes <> ''", ids).Update("notes", "")
+			}},
+			{"payments", func() *gorm.DB {
+				return tx.Model(&models.Payment{}).Where("invoice_id IN (?) AND notes <> ''", invoices).Update("notes", "")
+			}},
+			{"claims", func() *gorm.DB {
+				return tx.Model(&models.Claim{}).Where("invoice_id IN (?) AND notes <> ''", invoices).Update("notes", "")
+			}},
+			{"prescriptions", func() *gorm.DB {
+				return tx.Model(&models.Prescription{}).Where("patient_id IN ? AND notes <> ''", ids).Update("notes", "")
+			}},
+			{"prescription_items", func() *gorm.DB {
+				return tx.Model(&models.PrescriptionItem{}).
+					Where("prescription_id IN (?) AND instructions <> ''", prescriptions).
+					Update("instructions", "")
+			}},
+			{"patient_merges", func() *gorm.DB {
+				// Through the model, as survivor notes are encrypted.
+				return tx.Model(&models.PatientMerge{}).
+					Where("survivor_id IN ? OR merged_id IN ?", ids, ids).
+					Select("SurvivorNotes").
+					Updates(&models.PatientMerge{})
+			}},
+			{"audit_logs", func() *gorm.DB {
+				return tx.Model(&models.AuditLog{}).
+					Where("(entity_type = 'patient_document' AND details->>'patientId' IN ?) OR (entity_type = 'patient' AND entity_id IN ?)", ids, ids).
+					Update("details", gorm.Expr("details - 'fileName' - 'mrn'"))
+			}},
+		}
+		for _, step := range steps {
+			query := step.run()
+			if query.Error != nil {
+				return query.Error
+			}
+			result.Rows[step.table] = query.RowsAffected
+		}
+
+		var messages []*models.HL7Message
+		if err := tx.Where("patient_id IN ?", ids).Find(&messages).Error; err != nil {
+			return err
+		}
+		// Messages that failed before they were linked to a patient can
+		// still name one of the MRNs. The raw text is encrypted, so they are
+		// checked one by one.
+		var unlinked []*models.HL7Message
+		if err := tx.Where("patient_id IS NULL").Find(&unlinked).Error; err != nil {
+			return err
+		}
+		for _, message := range unlinked {
+			if namesMRN(message.Raw, mrns) {
+				messages = append(messages, message)
+			}
+		}
+		for _, message := range messages {
+			// Written through the model so that the remainder is encrypted.
+			raw := &models.HL7Message{ID: message.ID, Raw: hl7.RemoveSegments(message.Raw, identifyingHL7Segments...)}
+			if err := tx.Model(message).Select("Raw").Updates(raw).Error; err != nil {
+				return err
+			}
+		}
+		result.Rows["hl7_messages"] = int64(len(messages))
+
+		request.Status = models.ErasureStatusCompleted
+		request.CompletedAt = &result.ErasedAt
+		if err := certify(result); err != nil {
+			return err
+		}
+		err = tx.Model(&models.ErasureRequest{ID: request.ID}).Updates(map[string]any{
+			"status":         request.Status,
+			"completed_by":   request.CompletedBy,
+			"completed_at":   request.CompletedAt,
+			"certificate":    request.Certificate,
+			"signature":      request.Signature,
+			"signing_key_id": request.SigningKeyID,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		entry.EntityID = request.ID
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *erasureRepository) CheckHolds(ctx context.Context, patientID string) error {
+	return checkHolds(r.db.WithContext(ctx), patientID)
+}
+
+func (r *erasureRepository) CreateHold(ctx context.Context, hold *models.LegalHold, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var patient models.Patient
+		if err := tx.First(&patient, "id = ?", hold.PatientID).Error; err != nil {
+			return err
+		}
+		if patient.ErasedAt != nil {
+			return ErrPatientErased
+		}
+		if err := tx.Omit(clause.Associations).Create(hold).Error; err != nil {
+			return err
+		}
+		entry.EntityID = hold.ID
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *erasureRepository) GetHolds(ctx context.Context, patientID string) ([]*models.LegalHold, error) {
+	var holds []*models.LegalHold
+	err := r.db.WithContext(ctx).Preload("Placer").Preload("Releaser").
+		Where("patient_id = ?", patientID).
+		Order("created_at DESC").
+		Find(&holds).Error
+	if err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+func (r *erasureRepository) GetHold(ctx context.Context, id string) (*models.LegalHold, error) {
+	var hold models.LegalHold
+	if err := r.db.WithContext(ctx).Preload("Placer").Preload("Releaser").First(&hold, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r *erasureRepository) ReleaseHold(ctx context.Context, hold *models.LegalHold, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.LegalHold{}).
+			Where("id = ? AND released_at IS NULL", hold.ID).
+			Updates(map[string]any{"released_by": hold.ReleasedBy, "released_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLegalHoldReleased
+		}
+		hold.ReleasedAt = &now
+
+		entry.EntityID = hold.ID
+		return tx.Create(entry).Error
+	})
+}
+
+// checkHolds fails with a HoldError while the patient, or a patient merged
+// into it, has an active legal hold, or while money or a claim for either is
+// still outstanding.
+func checkHolds(db *gorm.DB, patientID string) error {
+	ids := db.Model(&models.Patient{}).Select("id").Where("id = ? OR merged_into_id = ?", patientID, patientID)
+
+	var holds []*models.LegalHold
+	if err := db.Where("patient_id IN (?) AND released_at IS NULL", ids).Order("created_at").Find(&holds).Error; err != nil {
+		return err
+	}
+	var reasons []string
+	for _, hold := range holds {
+		reasons = append(reasons, "legal hold: "+hold.Reason)
+	}
+
+	var invoices int64
+	err := db.Model(&models.Invoice{}).
+		Where("patient_id IN (?) AND status IN ?", ids, []string{models.InvoiceStatusIssued, models.InvoiceStatusPartiallyPaid}).
+		Count(&invoices).Error
+	if err != nil {
+		return err
+	}
+	if invoices > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d invoice(s) have an outstanding balance", invoices))
+	}
+
+	var claims int64
+	err = db.Model(&models.Claim{}).
+		Joins("JOIN invoices ON invoices.id = claims.invoice_id").
+		Where("invoices.patient_id IN (?) AND claims.status IN ?", ids, []string{models.ClaimStatusSubmitted, models.ClaimStatusAccepted}).
+		Count(&claims).Error
+	if err != nil {
+		return err
+	}
+	if claims > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d insurance claim(s) are still with the payer", claims))
+	}
+
+	if len(reasons) > 0 {
+		return &HoldError{reasons}
+	}
+	return nil
+}
+
+// namesMRN reports whether one of the PID-3 identifiers of the HL7 message
+// raw is one of mrns.
+func namesMRN(raw string, mrns []string) bool {
+	msg, err := hl7.Parse([]byte(raw))
+	if err != nil {
+		return false
+	}
+	pid := msg.Segment("PID")
+	if pid == nil {
+		return false
+	}
+	for _, rep := range pid.Repetitions(3) {
+		if slices.Contains(mrns, pid.RepetitionComponent(rep, 1)) {
+			return true
+		}
+	}
+	return false
+}
+
+func lockPendingRequest(tx *gorm.DB, id string) (*models.ErasureRequest, error) {
+	var request models.ErasureRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if request.Status != models.ErasureStatusPending {
+		return nil, ErrErasureNotPending
+	}
+	return &request, nil
+}
+
+// lockErasablePatient locks the patient, which must be neither erased nor
+// merged into another record; the surviving record is erased instead.
+func lockErasablePatient(tx *gorm.DB, id string) (*models.Patient, error) {
+	var patient models.Patient
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&patient, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if patient.ErasedAt != nil {
+		return nil, ErrPatientErased
+	}
+	if patient.MergedIntoID != nil {
+		return nil, ErrPatientAlreadyMerged
+	}
+	return &patient, nil
+}
+
+// anonymizePatient returns the values Erase writes over the patient's
+// anonymizedPatientFields. The date of birth is reduced to the year.
+func anonymizePatient(patient *models.Patient, at time.Time, userID string) *models.Patient {
+	anonymized := &models.Patient{
+		ID:         patient.ID,
+		MRN:        "ERASED-" + rand.Text()[:12],
+		Name:       "Erased Patient",
+		GivenName:  "Erased",
+		FamilyName: "Patient",
+		Address: models.Address{
+			State:   patient.Address.State,
+			Country: patient.Address.Country,
+		},
+		ErasedAt:  &at,
+		UpdatedBy: userID,
+	}
+	if patient.DateOfBirth != nil {
+		year := time.Date(patient.DateOfBirth.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		anonymized.DateOfBirth = &year
+		anonymized.DateOfBirthEstimated = true
+	}
+	return anonymized
+}
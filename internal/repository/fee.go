@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+)
+
+type FeeRepository interface {
+	Create(ctx context.Context, fee *models.Fee) error
+	GetAll(ctx context.Context, includeInactive bool) ([]*models.Fee, error)
+	GetByID(ctx context.Context, id string) (*models.Fee, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*models.Fee, error)
+	Update(ctx context.Context, fee *models.Fee) error
+}
+
+type feeRepository struct {
+	db *gorm.DB
+}
+
+func NewFeeRepository(db *gorm.DB) FeeRepository {
+	return &feeRepository{db}
+}
+
+func (r *feeRepository) Create(ctx context.Context, fee *models.Fee) error {
+	return r.db.WithContext(ctx).Create(fee).Error
+}
+
+func (r *feeRepository) GetAll(ctx context.Context, includeInactive bool) ([]*models.Fee, error) {
+	query := r.db.WithContext(ctx).Order("code")
+	if !includeInactive {
+		query = query.Where("active")
+	}
+
+	var fees []*models.Fee
+	if err := query.Find(&fees).Error; err != nil {
+		return nil, err
+	}
+	return fees, nil
+}
+
+func (r *feeRepository) GetByID(ctx context.Context, id string) (*models.Fee, error) {
+	var fee models.Fee
+	if err := r.db.WithContext(ctx).First(&fee, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &fee, nil
+}
+
+func (r *feeRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Fee, error) {
+	var fees []*models.Fee
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&fees).Error; err != nil {
+		return nil, err
+	}
+	return fees, nil
+}
+
+// Update saves every field, including Active = false which Updates would
+// skip as a zero value.
+func (r *feeRepository) Update(ctx context.Context, fee *models.Fee) error {
+	return r.db.WithContext(ctx).Save(fee).Error
+}
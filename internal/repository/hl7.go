@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+)
+
+type HL7MessageRepository interface {
+	Create(ctx context.Context, message *models.HL7Message) error
+	Update(ctx context.Context, message *models.HL7Message) error
+	GetAll(ctx context.Context) ([]*models.HL7Message, error)
+	GetByID(ctx context.Context, id string) (*models.HL7Message, error)
+	FindProcessed(ctx context.Context, sendingFacility, controlID string) (*models.HL7Message, error)
+}
+
+type hl7MessageRepository struct {
+	db *gorm.DB
+}
+
+func NewHL7MessageRepository(db *gorm.DB) HL7MessageRepository {
+	return &hl7MessageRepository{db}
+}
+
+func (r *hl7MessageRepository) Create(ctx context.Context, message *models.HL7Message) error {
+	return r.db.WithContext(ctx).Create(message).Error
+}
+
+func (r *hl7MessageRepository) Update(ctx context.Context, message *models.HL7Message) error {
+	return r.db.WithContext(ctx).Save(message).Error
+}
+
+func (r *hl7MessageRepository) GetAll(ctx context.Context) ([]*models.HL7Message, error) {
+	var messages []*models.HL7Message
+	if err := r.db.WithContext(ctx).Order("received_at DESC").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *hl7MessageRepository) GetByID(ctx context.Context, id string) (*models.HL7Message, error) {
+	var message models.HL7Message
+	if err := r.db.WithContext(ctx).First(&message, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (r *hl7MessageRepository) FindProcessed(ctx context.Context, sendingFacility, controlID string) (*models.HL7Message, error) {
+	var message models.HL7Message
+	err := r.db.WithContext(ctx).
+		Where("sending_facility = ? AND control_id = ? AND status = ?", sendingFacility, controlID, "processed").
+		First(&message).Error
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+)
+
+type InsurancePolicyRepository interface {
+	Create(ctx context.Context, policy *models.InsurancePolicy) error
+	GetByPatientID(ctx context.Context, patientID string) ([]*models.InsurancePolicy, error)
+	GetByID(ctx context.Context, patientID, id string) (*models.InsurancePolicy, error)
+	Update(ctx context.Context, policy *models.InsurancePolicy) error
+	Delete(ctx context.Context, patientID, id string) error
+}
+
+type insurancePolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewInsurancePolicyRepository(db *gorm.DB) InsurancePolicyRepository {
+	return &insurancePolicyRepository{db}
+}
+
+func (r *insurancePolicyRepository) Create(ctx context.Context, policy *models.InsurancePolicy) error {
+	return r.db.WithContext(ctx).Create(policy).Error
+}
+
+func (r *insurancePolicyRepository) GetByPatientID(ctx context.Context, patientID string) ([]*models.InsurancePolicy, error) {
+	var policies []*models.InsurancePolicy
+	err := r.db.WithContext(ctx).Where("patient_id = ?", patientID).
+		Order("CASE priority WHEN 'primary' THEN 1 WHEN 'secondary' THEN 2 ELSE 3 END, valid_from DESC").
+		Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *insurancePolicyRepository) GetByID(ctx context.Context, patientID, id string) (*models.InsurancePolicy, error) {
+	var policy models.InsurancePolicy
+	if err := r.db.WithContext(ctx).First(&policy, "patient_id = ? AND id = ?", patientID, id).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *insurancePolicyRepository) Update(ctx context.Context, policy *models.InsurancePolicy) error {
+	return r.db.WithContext(ctx).Save(policy).Error
+}
+
+func (r *insurancePolicyRepository) Delete(ctx context.Context, patientID, id string) error {
+	result := r.db.WithContext(ctx).Where("patient_id = ? AND id = ?", patientID, id).Delete(&models.InsurancePolicy{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvoiceNotDraft    = errors.New("only draft invoices can be changed")
+	ErrInvoiceIsDraft     = errors.New("draft invoices cannot be voided, delete them instead")
+	ErrInvoiceVoided      = errors.New("invoice has been voided")
+	ErrInvoiceHasPayments = errors.New("invoice has payments recorded against it")
+)
+
+type InvoiceFilter struct {
+	PatientID  string
+	Status     string
+	FiscalYear int
+	Limit      int
+	Offset     int
+}
+
+type InvoiceRepository interface {
+	Create(ctx context.Context, invoice *models.Invoice) error
+	GetByID(ctx context.Context, id string) (*models.Invoice, error)
+	Search(ctx context.Context, filter InvoiceFilter) ([]*models.Invoice, int64, error)
+	UpdateDraft(ctx context.Context, invoice *models.Invoice) error
+	Issue(ctx context.Context, invoice *models.Invoice, prefix string) error
+	Void(ctx context.Context, invoice *models.Invoice) error
+	Delete(ctx context.Context, id string) error
+}
+
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db}
+}
+
+func (r *invoiceRepository) Create(ctx context.Context, invoice *models.Invoice) error {
+	return r.db.WithContext(ctx).Omit("Patient").Create(invoice).Error
+}
+
+func (r *invoiceRepository) GetByID(ctx context.Context, id string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Patient").
+		First(&invoice, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) Search(ctx context.Context, filter InvoiceFilter) ([]*models.Invoice, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Invoice{})
+	if filter.PatientID != "" {
+		query = query.Where("patient_id = ?", filter.PatientID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.FiscalYear != 0 {
+		query = query.Where("fiscal_year = ?", filter.FiscalYear)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var invoices []*models.Invoice
+	if err := query.Preload("Patient").Order("created_at DESC").Find(&invoices).Error; err != nil {
+		return nil, 0, err
+	}
+	return invoices, total, nil
+}
+
+// UpdateDraft replaces the invoice fields and all of its items.
+func (r *invoiceRepository) UpdateDraft(ctx context.Context, invoice *models.Invoice) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockInvoice(tx, invoice.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != models.InvoiceStatusDraft {
+			return ErrInvoiceNotDraft
+		}
+
+		if err := tx.Where("invoice_id = ?", invoice.ID).Delete(&models.InvoiceItem{}).Error; err != nil {
+			return err
+		}
+		for i := range invoice.Items {
+			invoice.Items[i].ID = ""
+			invoice.Items[i].InvoiceID = invoice.ID
+		}
+		return tx.Omit("Patient").Save(invoice).Error
+	})
+}
+
+// Issue takes the next number for the invoice's fiscal year. The sequence row
+// is locked until the transaction commits, so concurrent issues queue up and a
+// rollback hands the number back.
+func (r *invoiceRepository) Issue(ctx context.Context, invoice *models.Invoice, prefix string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockInvoice(tx, invoice.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != models.InvoiceStatusDraft {
+			return ErrInvoiceNotDraft
+		}
+
+		var seq int
+		err = tx.Raw(`
+			INSERT INTO invoice_sequences (fiscal_year, last_number) VALUES (?, 1)
+			ON CONFLICT (fiscal_year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+			RETURNING last_number`, *invoice.FiscalYear).Scan(&seq).Error
+		if err != nil {
+			return err
+		}
+
+		number := utils.FormatInvoiceNumber(prefix, *invoice.FiscalYear, seq)
+		invoice.Number = &number
+		invoice.Status = models.InvoiceStatusIssued
+		return tx.Model(&models.Invoice{ID: invoice.ID}).Updates(map[string]any{
+			"number":      number,
+			"fiscal_year": *invoice.FiscalYear,
+			"status":      invoice.Status,
+			"issued_at":   invoice.IssuedAt,
+			"due_date":    invoice.DueDate,
+			"updated_by":  invoice.UpdatedBy,
+			"updated_at":  time.Now(),
+		}).Error
+	})
+}
+
+// Void keeps the invoice and its number so the sequence has no gaps. Drafts
+// have no number yet and are deleted instead.
+func (r *invoiceRepository) Void(ctx context.Context, invoice *models.Invoice) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockInvoice(tx, invoice.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status == models.InvoiceStatusDraft {
+			return ErrInvoiceIsDraft
+		}
+		if current.Status == models.InvoiceStatusVoid {
+			return ErrInvoiceVoided
+		}
+		if current.AmountPaid.IsPositive() {
+			return ErrInvoiceHasPayments
+		}
+
+		invoice.Status = models.InvoiceStatusVoid
+		return tx.Model(&models.Invoice{ID: invoice.ID}).Updates(map[string]any{
+			"status":      invoice.Status,
+			"voided_at":   invoice.VoidedAt,
+			"void_reason": invoice.VoidReason,
+			"updated_by":  invoice.UpdatedBy,
+			"updated_at":  time.Now(),
+		}).Error
+	})
+}
+
+// Delete removes a draft. Issued invoices can only be voided.
+func (r *invoiceRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := lockInvoice(tx, id)
+		if err != nil {
+			return err
+		}
+		if current.Status != models.InvoiceStatusDraft {
+			return ErrInvoiceNotDraft
+		}
+		return tx.Delete(current).Error
+	})
+}
+
+func lockInvoice(tx *gorm.DB, id string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+)
+
+type LabResultFilter struct {
+	PatientID string
+	Code      string
+	Limit     int
+	Offset    int
+}
+
+type LabResultRepository interface {
+	ReplaceForMessage(ctx context.Context, messageID string, results []*models.LabResult) error
+	GetByPatientID(ctx context.Context, patientID string) ([]*models.LabResult, error)
+	GetByID(ctx context.Context, id string) (*models.LabResult, error)
+	Search(ctx context.Context, filter LabResultFilter) ([]*models.LabResult, int64, error)
+}
+
+type labResultRepository struct {
+	db *gorm.DB
+}
+
+func NewLabResultRepository(db *gorm.DB) LabResultRepository {
+	return &labResultRepository{db}
+}
+
+func (r *labResultRepository) ReplaceForMessage(ctx context.Context, messageID string, results []*models.LabResult) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("hl7_message_id = ?", messageID).Delete(&models.LabResult{}).Error; err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+		return tx.Create(&results).Error
+	})
+}
+
+func (r *labResultRepository) GetByPatientID(ctx context.Context, patientID string) ([]*models.LabResult, error) {
+	var results []*models.LabResult
+	err := r.db.WithContext(ctx).
+		Where("patient_id = ?", patientID).
+		Order("observed_at DESC NULLS LAST, created_at DESC").
+		Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *labResultRepository) GetByID(ctx context.Context, id string) (*models.LabResult, error) {
+	var result models.LabResult
+	if err := r.db.WithContext(ctx).First(&result, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *labResultRepository) Search(ctx context.Context, filter LabResultFilter) ([]*models.LabResult, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.LabResult{})
+	if filter.PatientID != "" {
+		query = query.Where("patient_id = ?", filter.PatientID)
+	}
+	if filter.Code != "" {
+		query = query.Where("test_code = ?", filter.Code)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var results []*models.LabResult
+	if err := query.Order("observed_at DESC NULLS LAST, created_at DESC").Find(&results).Error; err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
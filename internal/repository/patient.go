@@ -1,76 +1,291 @@
 package repository
 
 import (
+	"context"
+	"time"
+
+	"github.com/max-programming/clinic/internal/db"
+	"github.com/max-programming/clinic/internal/encryption"
 	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/utils"
 	"gorm.io/gorm"
 )
 
+type PatientFilter struct {
+	ID     string
+	Name   string
+	Gender string
+	MRN    string
+	// Identifier matches either the MRN or any external identifier value,
+	// optionally restricted to IdentifierType.
+	Identifier     string
+	IdentifierType string
+	// Phone matches the phone number exactly, ignoring formatting.
+	Phone string
+	// BornFrom is inclusive and BornBefore exclusive.
+	BornFrom   *time.Time
+	BornBefore *time.Time
+	Limit      int
+	Offset     int
+}
+
+type PatientLookup struct {
+	MRN            string
+	IdentifierType string
+	Issuer         string
+	Value          string
+	Phone          string
+}
+
 type PatientRepository interface {
-	Create(patient *models.Patient) error
-	GetAll() ([]*models.Patient, error)
-	GetByID(id string) (*models.Patient, error)
-	GetByIDWithUsers(id string) (*models.Patient, *models.User, *models.User, error)
-	Update(id string, updatedPatient *models.Patient) error
-	Delete(id string) error
+	Create(ctx context.Context, patient *models.Patient) error
+	CreateBatch(ctx context.Context, patients []*models.Patient) error
+	GetAll(ctx context.Context) ([]*models.Patient, error)
+	GetByID(ctx context.Context, id string) (*models.Patient, error)
+	GetByMRN(ctx context.Context, mrn string) (*models.Patient, error)
+	NextMRNSequence(ctx context.Context) (int64, error)
+	Lookup(ctx context.Context, lookup PatientLookup) ([]*models.Patient, error)
+	FindDuplicateCandidates(ctx context.Context, patient *models.Patient) ([]*models.Patient, error)
+	Search(ctx context.Context, filter PatientFilter) ([]*models.Patient, int64, error)
+	FindInBatches(ctx context.Context, filter PatientFilter, batchSize int, fn func(patients []*models.Patient) error) error
+	GetByIDWithUsers(ctx context.Context, id string) (*models.Patient, *models.User, *models.User, error)
+	Update(ctx context.Context, id string, updatedPatient *models.Patient) error
+	Delete(ctx context.Context, id string) error
 }
 
 type patientRepository struct {
-	db *gorm.DB
+	db         *gorm.DB
+	phoneIndex *encryption.BlindIndex
 }
 
-func NewPatientReposiory(db *gorm.DB) PatientRepository {
-	return &patientRepository{db}
+func NewPatientReposiory(db *gorm.DB, phoneIndex *encryption.BlindIndex) PatientRepository {
+	return &patientRepository{db, phoneIndex}
 }
 
-func (r *patientRepository) Create(patient *models.Patient) error {
-	return r.db.Create(patient).Error
+func (r *patientRepository) Create(ctx context.Context, patient *models.Patient) error {
+	patient.PhoneIndex = r.phoneHash(patient.Phone)
+	return r.db.WithContext(ctx).Create(patient).Error
 }
 
-func (r *patientRepository) GetAll() ([]*models.Patient, error) {
+// CreateBatch inserts the patients with a single statement, so either all
+// of them are created or none are.
+func (r *patientRepository) CreateBatch(ctx context.Context, patients []*models.Patient) error {
+	for _, patient := range patients {
+		patient.PhoneIndex = r.phoneHash(patient.Phone)
+	}
+	return r.db.WithContext(ctx).Create(patients).Error
+}
+
+func (r *patientRepository) GetAll(ctx context.Context) ([]*models.Patient, error) {
 	var patients []*models.Patient
-	if err := r.db.Find(&patients).Order("created_at DESC").Error; err != nil {
+	if err := db.Replica(r.db).WithContext(ctx).Where("merged_into_id IS NULL").Order("created_at DESC").Find(&patients).Error; err != nil {
 		return nil, err
 	}
 	return patients, nil
 }
 
-func (r *patientRepository) GetByID(id string) (*models.Patient, error) {
+func (r *patientRepository) GetByID(ctx context.Context, id string) (*models.Patient, error) {
 	var patient models.Patient
-	if err := r.db.First(&patient, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Identifiers").First(&patient, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &patient, nil
 }
 
-func (r *patientRepository) Update(id string, updatedPatient *models.Patient) error {
-	patient, err := r.GetByID(id)
+func (r *patientRepository) GetByMRN(ctx context.Context, mrn string) (*models.Patient, error) {
+	var patient models.Patient
+	if err := r.db.WithContext(ctx).First(&patient, "mrn = ?", mrn).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+func (r *patientRepository) Search(ctx context.Context, filter PatientFilter) ([]*models.Patient, int64, error) {
+	query := r.filterQuery(ctx, filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var patients []*models.Patient
+	if err := query.Preload("Identifiers").Order("created_at DESC").Find(&patients).Error; err != nil {
+		return nil, 0, err
+	}
+	return patients, total, nil
+}
+
+// FindInBatches passes the patients matching filter to fn batchSize at a
+// time, in ID order, so they never have to be held in memory all at once.
+// Limit and Offset are ignored.
+func (r *patientRepository) FindInBatches(ctx context.Context, filter PatientFilter, batchSize int, fn func(patients []*models.Patient) error) error {
+	var patients []*models.Patient
+	return r.filterQuery(ctx, filter).FindInBatches(&patients, batchSize, func(_ *gorm.DB, _ int) error {
+		return fn(patients)
+	}).Error
+}
+
+func (r *patientRepository) filterQuery(ctx context.Context, filter PatientFilter) *gorm.DB {
+	query := db.Replica(r.db).WithContext(ctx).Model(&models.Patient{}).Where("merged_into_id IS NULL")
+	if filter.ID != "" {
+		query = query.Where("id = ?", filter.ID)
+	}
+	if filter.Name != "" {
+		pattern := "%" + filter.Name + "%"
+		query = query.Where("name ILIKE ? OR preferred_name ILIKE ?", pattern, pattern)
+	}
+	if filter.Gender != "" {
+		query = query.Where("LOWER(gender) = LOWER(?)", filter.Gender)
+	}
+	if filter.MRN != "" {
+		query = query.Where(r.mrnCondition(filter.MRN))
+	}
+	if filter.Identifier != "" {
+		identifiers := r.db.Model(&models.PatientIdentifier{}).Select("patient_id").Where("value = ?", filter.Identifier)
+		if filter.IdentifierType != "" {
+			query = query.Where("id IN (?)", identifiers.Where("type = ?", filter.IdentifierType))
+		} else {
+			query = query.Where(r.mrnCondition(filter.Identifier).Or("id IN (?)", identifiers))
+		}
+	}
+	if filter.Phone != "" {
+		query = query.Where("phone_index = ?", r.phoneHash(filter.Phone))
+	}
+	if filter.BornFrom != nil {
+		query = query.Where("date_of_birth >= ?", *filter.BornFrom)
+	}
+	if filter.BornBefore != nil {
+		query = query.Where("date_of_birth < ?", *filter.BornBefore)
+	}
+	return query
+}
+
+func (r *patientRepository) NextMRNSequence(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := r.db.WithContext(ctx).Raw("SELECT nextval('patient_mrn_seq')").Scan(&seq).Error; err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+func (r *patientRepository) Lookup(ctx context.Context, lookup PatientLookup) ([]*models.Patient, error) {
+	query := db.Replica(r.db).WithContext(ctx).Model(&models.Patient{}).Where("merged_into_id IS NULL")
+	if lookup.MRN != "" {
+		query = query.Where(r.mrnCondition(lookup.MRN))
+	}
+	if lookup.Value != "" {
+		identifiers := r.db.Model(&models.PatientIdentifier{}).Select("patient_id").Where("value = ?", lookup.Value)
+		if lookup.IdentifierType != "" {
+			identifiers = identifiers.Where("type = ?", lookup.IdentifierType)
+		}
+		if lookup.Issuer != "" {
+			identifiers = identifiers.Where("issuer = ?", lookup.Issuer)
+		}
+		query = query.Where("id IN (?)", identifiers)
+	}
+	if lookup.Phone != "" {
+		query = query.Where("phone_index = ?", r.phoneHash(lookup.Phone))
+	}
+
+	var patients []*models.Patient
+	if err := query.Preload("Identifiers").Order("created_at DESC").Find(&patients).Error; err != nil {
+		return nil, err
+	}
+	return patients, nil
+}
+
+// FindDuplicateCandidates returns active patients sharing a date of birth or
+// phone number with patient, for the caller to score by name.
+func (r *patientRepository) FindDuplicateCandidates(ctx context.Context, patient *models.Patient) ([]*models.Patient, error) {
+	conditions := r.db.Where("1 = 0")
+	if patient.DateOfBirth != nil {
+		conditions = conditions.Or("date_of_birth = ?", *patient.DateOfBirth)
+	}
+	if digits := utils.PhoneDigits(patient.Phone); len(digits) >= 7 {
+		conditions = conditions.Or("phone_index = ?", r.phoneHash(digits))
+	}
+
+	query := r.db.WithContext(ctx).Where("merged_into_id IS NULL").Where(conditions)
+	if patient.ID != "" {
+		query = query.Where("id <> ?", patient.ID)
+	}
+
+	var patients []*models.Patient
+	if err := query.Find(&patients).Error; err != nil {
+		return nil, err
+	}
+	return patients, nil
+}
+
+// mrnCondition matches mrn, resolving MRNs of merged patients to the
+// surviving record.
+func (r *patientRepository) mrnCondition(mrn string) *gorm.DB {
+	return r.db.Where("mrn = ? OR id IN (?)", mrn,
+		r.db.Model(&models.Patient{}).Select("merged_into_id").Where("mrn = ? AND merged_into_id IS NOT NULL", mrn))
+}
+
+// phoneHash is the blind index of phone's digits, so "+1 (555) 010-0000" and
+// "15550100000" match.
+func (r *patientRepository) phoneHash(phone string) *string {
+	return r.phoneIndex.Hash(utils.PhoneDigits(phone))
+}
+
+func (r *patientRepository) Update(ctx context.Context, id string, updatedPatient *models.Patient) error {
+	patient, err := r.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
-	return r.db.Model(&patient).Updates(updatedPatient).Error
+	if patient.ErasedAt != nil {
+		return ErrPatientErased
+	}
+	if updatedPatient.Phone != "" {
+		updatedPatient.PhoneIndex = r.phoneHash(updatedPatient.Phone)
+	}
+	// Encrypted fields are bound to the row's ID.
+	updatedPatient.ID = patient.ID
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&patient).Updates(updatedPatient).Error; err != nil {
+			return err
+		}
+		// Updates skips zero values, so a newly supplied date of birth would
+		// otherwise keep the estimated flag from the age backfill.
+		if updatedPatient.DateOfBirth != nil && !updatedPatient.DateOfBirthEstimated {
+			return tx.Model(&patient).Update("date_of_birth_estimated", false).Error
+		}
+		return nil
+	})
 }
 
-func (r *patientRepository) Delete(id string) error {
-	patient, err := r.GetByID(id)
+func (r *patientRepository) Delete(ctx context.Context, id string) error {
+	patient, err := r.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
-	return r.db.Delete(&patient).Error
+	return r.db.WithContext(ctx).Delete(&patient).Error
 }
 
-func (r *patientRepository) GetByIDWithUsers(id string) (*models.Patient, *models.User, *models.User, error) {
+func (r *patientRepository) GetByIDWithUsers(ctx context.Context, id string) (*models.Patient, *models.User, *models.User, error) {
+	db := r.db.WithContext(ctx)
+
 	var patient models.Patient
-	if err := r.db.First(&patient, "id = ?", id).Error; err != nil {
+	if err := db.Preload("Identifiers").First(&patient, "id = ?", id).Error; err != nil {
 		return nil, nil, nil, err
 	}
 
 	var createdByUser models.User
-	if err := r.db.First(&createdByUser, "id = ?", patient.CreatedBy).Error; err != nil {
+	if err := db.First(&createdByUser, "id = ?", patient.CreatedBy).Error; err != nil {
 		return &patient, nil, nil, err
 	}
 
 	var updatedByUser models.User
-	if err := r.db.First(&updatedByUser, "id = ?", patient.UpdatedBy).Error; err != nil {
+	if err := db.First(&updatedByUser, "id = ?", patient.UpdatedBy).Error; err != nil {
 		return &patient, &createdByUser, nil, err
 	}
 
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+)
+
+type PatientDocumentRepository interface {
+	Create(ctx context.Context, document *models.PatientDocument) error
+	GetByPatientID(ctx context.Context, patientID, category string) ([]*models.PatientDocument, error)
+	GetByID(ctx context.Context, patientID, id string) (*models.PatientDocument, error)
+	Delete(ctx context.Context, document *models.PatientDocument) error
+}
+
+type patientDocumentRepository struct {
+	db *gorm.DB
+}
+
+func NewPatientDocumentRepository(db *gorm.DB) PatientDocumentRepository {
+	return &patientDocumentRepository{db}
+}
+
+func (r *patientDocumentRepository) Create(ctx context.Context, document *models.PatientDocument) error {
+	return r.db.WithContext(ctx).Omit("Uploader").Create(document).Error
+}
+
+func (r *patientDocumentRepository) GetByPatientID(ctx context.Context, patientID, category string) ([]*models.PatientDocument, error) {
+	query := r.db.WithContext(ctx).Preload("Uploader").Where("patient_id = ?", patientID)
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	var documents []*models.PatientDocument
+	if err := query.Order("created_at DESC").Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func (r *patientDocumentRepository) GetByID(ctx context.Context, patientID, id string) (*models.PatientDocument, error) {
+	var document models.PatientDocument
+	if err := r.db.WithContext(ctx).Preload("Uploader").First(&document, "patient_id = ? AND id = ?", patientID, id).Error; err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *patientDocumentRepository) Delete(ctx context.Context, document *models.PatientDocument) error {
+	return r.db.WithContext(ctx).Delete(document).Error
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+)
+
+type PatientIdentifierRepository interface {
+	Create(ctx context.Context, identifier *models.PatientIdentifier) error
+	GetByPatientID(ctx context.Context, patientID string) ([]*models.PatientIdentifier, error)
+	Delete(ctx context.Context, patientID, id string) error
+}
+
+type patientIdentifierRepository struct {
+	db *gorm.DB
+}
+
+func NewPatientIdentifierRepository(db *gorm.DB) PatientIdentifierRepository {
+	return &patientIdentifierRepository{db}
+}
+
+func (r *patientIdentifierRepository) Create(ctx context.Context, identifier *models.PatientIdentifier) error {
+	return r.db.WithContext(ctx).Create(identifier).Error
+}
+
+func (r *patientIdentifierRepository) GetByPatientID(ctx context.Context, patientID string) ([]*models.PatientIdentifier, error) {
+	var identifiers []*models.PatientIdentifier
+	if err := r.db.WithContext(ctx).Where("patient_id = ?", patientID).Order("created_at").Find(&identifiers).Error; err != nil {
+		return nil, err
+	}
+	return identifiers, nil
+}
+
+func (r *patientIdentifierRepository) Delete(ctx context.Context, patientID, id string) error {
+	result := r.db.WithContext(ctx).Where("patient_id = ? AND id = ?", patientID, id).Delete(&models.PatientIdentifier{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+)
+
+type PatientImportRepository interface {
+	Create(ctx context.Context, job *models.PatientImport) error
+	Update(ctx context.Context, job *models.PatientImport) error
+	GetByID(ctx context.Context, id string) (*models.PatientImport, error)
+	GetAll(ctx context.Context, limit, offset int) ([]*models.PatientImport, int64, error)
+}
+
+type patientImportRepository struct {
+	db *gorm.DB
+}
+
+func NewPatientImportRepository(db *gorm.DB) PatientImportRepository {
+	return &patientImportRepository{db}
+}
+
+func (r *patientImportRepository) Create(ctx context.Context, job *models.PatientImport) error {
+	return r.db.WithContext(ctx).Omit("Creator").Create(job).Error
+}
+
+func (r *patientImportRepository) Update(ctx context.Context, job *models.PatientImport) error {
+	return r.db.WithContext(ctx).Omit("Creator").Save(job).Error
+}
+
+func (r *patientImportRepository) GetByID(ctx context.Context, id string) (*models.PatientImport, error) {
+	var job models.PatientImport
+	if err := r.db.WithContext(ctx).Preload("Creator").First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetAll lists imports newest first, without their issues.
+func (r *patientImportRepository) GetAll(ctx context.Context, limit, offset int) ([]*models.PatientImport, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.PatientImport{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var jobs []*models.PatientImport
+	err := query.Omit("issues").Preload("Creator").Order("created_at DESC").Limit(limit).Offset(offset).Find(&jobs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
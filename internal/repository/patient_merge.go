@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPatientAlreadyMerged = errors.New("patient has already been merged into another record")
+	ErrMergeAlreadyReversed = errors.New("merge has already been reversed")
+)
+
+type patientLink struct {
+	table  string
+	column string
+}
+
+func (l patientLink) key() string {
+	return l.table + "." + l.column
+}
+
+// patientLinks lists every column that points at a patient. When a patient
+// is merged, the rows referencing it are moved to the surviving record, so
+// tables that gain a patient reference need to be added here.
+var patientLinks = []patientLink{
+	{"lab_results", "patient_id"},
+	{"hl7_messages", "patient_id"},
+	{"patient_identifiers", "patient_id"},
+	{"invoices", "patient_id"},
+	{"insurance_policies", "patient_id"},
+	{"prescriptions", "patient_id"},
+	{"patient_documents", "patient_id"},
+	{"patients", "merged_into_id"},
+}
+
+type PatientMergeRepository interface {
+	Merge(ctx context.Context, merge *models.PatientMerge, entry *models.AuditLog) error
+	Reverse(ctx context.Context, merge *models.PatientMerge, entry *models.AuditLog) error
+	GetByID(ctx context.Context, id string) (*models.PatientMerge, error)
+	GetAll(ctx context.Context) ([]*models.PatientMerge, error)
+}
+
+type patientMergeRepository struct {
+	db *gorm.DB
+}
+
+func NewPatientMergeRepository(db *gorm.DB) PatientMergeRepository {
+	return &patientMergeRepository{db}
+}
+
+// Merge moves everything linked to merge.MergedID onto merge.SurvivorID and
+// leaves the merged patient behind as a tombstone. The audit entry is written
+// in the same transaction with its EntityID set to the new merge.
+func (r *patientMergeRepository) Merge(ctx context.Context, merge *models.PatientMerge, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		survivor, merged, err := lockPatients(tx, merge.SurvivorID, merge.MergedID)
+		if err != nil {
+			return err
+		}
+		if survivor.MergedIntoID != nil || merged.MergedIntoID != nil {
+			return ErrPatientAlreadyMerged
+		}
+		if survivor.ErasedAt != nil || merged.ErasedAt != nil {
+			return ErrPatientErased
+		}
+
+		moved := make(map[string][]string)
+		for _, link := range patientLinks {
+			var ids []string
+			if err := tx.Table(link.table).Where(link.column+" = ?", merged.ID).Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			if err := tx.Table(link.table).Where("id IN ?", ids).Update(link.column, survivor.ID).Error; err != nil {
+				return err
+			}
+			moved[link.key()] = ids
+		}
+
+		movedJSON, err := json.Marshal(moved)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		merge.MovedRecords = movedJSON
+		merge.SurvivorNotes = survivor.MedicalNotes
+		merge.MergedAt = now
+
+		notes := mergeNotes(survivor.MedicalNotes, merged.MedicalNotes)
+		if notes != survivor.MedicalNotes {
+			if err := updateNotes(tx, survivor, notes); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(merged).Updates(map[string]any{"merged_into_id": survivor.ID, "merged_at": now}).Error; err != nil {
+			return err
+		}
+		if err := updatePairStatus(tx, survivor.ID, merged.ID, models.DuplicateStatusMerged); err != nil {
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Create(merge).Error; err != nil {
+			return err
+		}
+		entry.EntityID = merge.ID
+		return tx.Create(entry).Error
+	})
+}
+
+// Reverse moves the rows recorded by Merge back to the merged patient and
+// restores it. The survivor's notes are only restored if nobody has edited
+// them since the merge.
+func (r *patientMergeRepository) Reverse(ctx context.Context, merge *models.PatientMerge, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked models.PatientMerge
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", merge.ID).Error; err != nil {
+			return err
+		}
+		if locked.ReversedAt != nil {
+			return ErrMergeAlreadyReversed
+		}
+
+		survivor, merged, err := lockPatients(tx, locked.SurvivorID, locked.MergedID)
+		if err != nil {
+			return err
+		}
+		// Reversing would split records that a later merge has already
+		// moved on, so that merge has to be undone first.
+		if survivor.MergedIntoID != nil {
+			return ErrPatientAlreadyMerged
+		}
+		if survivor.ErasedAt != nil {
+			return ErrPatientErased
+		}
+
+		var moved map[string][]string
+		if err := json.Unmarshal(locked.MovedRecords, &moved); err != nil {
+			return err
+		}
+		for _, link := range patientLinks {
+			ids := moved[link.key()]
+			if len(ids) == 0 {
+				continue
+			}
+			err := tx.Table(link.table).
+				Where("id IN ? AND "+link.column+" = ?", ids, survivor.ID).
+				Update(link.column, merged.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if survivor.MedicalNotes == mergeNotes(locked.SurvivorNotes, merged.MedicalNotes) {
+			if err := updateNotes(tx, survivor, locked.SurvivorNotes); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(merged).Updates(map[string]any{"merged_into_id": nil, "merged_at": nil}).Error; err != nil {
+			return err
+		}
+		if err := updatePairStatus(tx, survivor.ID, merged.ID, models.DuplicateStatusDismissed); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&locked).Updates(map[string]any{"reversed_by": merge.ReversedBy, "reversed_at": now}).Error; err != nil {
+			return err
+		}
+		merge.ReversedAt = &now
+
+		entry.EntityID = merge.ID
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *patientMergeRepository) GetByID(ctx context.Context, id string) (*models.PatientMerge, error) {
+	var merge models.PatientMerge
+	if err := r.db.WithContext(ctx).Preload("Survivor").Preload("Merged").First(&merge, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &merge, nil
+}
+
+func (r *patientMergeRepository) GetAll(ctx context.Context) ([]*models.PatientMerge, error) {
+	var merges []*models.PatientMerge
+	if err := r.db.WithContext(ctx).Preload("Survivor").Preload("Merged").Order("merged_at DESC").Find(&merges).Error; err != nil {
+		return nil, err
+	}
+	return merges, nil
+}
+
+func lockPatients(tx *gorm.DB, survivorID, mergedID string) (*models.Patient, *models.Patient, error) {
+	var patients []*models.Patient
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", []string{survivorID, mergedID}).
+		Order("id").
+		Find(&patients).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var survivor, merged *models.Patient
+	for _, patient := range patients {
+		switch patient.ID {
+		case survivorID:
+			survivor = patient
+		case mergedID:
+			merged = patient
+		}
+	}
+	if survivor == nil || merged == nil {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	return survivor, merged, nil
+}
+
+func updatePairStatus(tx *gorm.DB, a, b, status string) error {
+	return tx.Model(&models.DuplicateCandidate{}).
+		Where("(patient_id = ? AND duplicate_patient_id = ?) OR (patient_id = ? AND duplicate_patient_id = ?)", a, b, b, a).
+		Updates(map[string]any{"status": status, "updated_at": time.Now()}).Error
+}
+
+// updateNotes writes notes through the model so that they are encrypted;
+// Update with a column name would store them as plaintext.
+func updateNotes(tx *gorm.DB, patient *models.Patient, notes string) error {
+	return tx.Model(patient).Select("MedicalNotes").Updates(&models.Patient{ID: patient.ID, MedicalNotes: notes}).Error
+}
+
+// mergeNotes appends the merged patient's medical notes to the survivor's.
+func mergeNotes(survivor, merged string) string {
+	switch {
+	case merged == "":
+		return survivor
+	case survivor == "":
+		return merged
+	}
+	return survivor + "\n\n" + merged
+}
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/utils"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDayClosed             = errors.New("payments for this day have been closed")
+	ErrDayAlreadyClosed      = errors.New("day has already been closed")
+	ErrInvoiceNotPayable     = errors.New("invoice is not open for payment")
+	ErrPaymentExceedsBalance = errors.New("payment exceeds the invoice balance")
+	ErrRefundExceedsPaid     = errors.New("refund exceeds the amount paid")
+)
+
+type PaymentFilter struct {
+	InvoiceID    string
+	ReceivedBy   string
+	Method       string
+	BusinessDate *time.Time
+	Limit        int
+	Offset       int
+}
+
+// DaySummaryLine is the takings of one receptionist for one payment method.
+type DaySummaryLine struct {
+	ReceivedBy    string
+	Username      string
+	Method        string
+	PaymentCount  int
+	PaymentsTotal decimal.Decimal
+	RefundCount   int
+	RefundsTotal  decimal.Decimal
+}
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment, receiptPrefix string) error
+	GetByID(ctx context.Context, id string) (*models.Payment, error)
+	Search(ctx context.Context, filter PaymentFilter) ([]*models.Payment, int64, error)
+	Update(ctx context.Context, payment *models.Payment, userID string) error
+	GetDaySummary(ctx context.Context, date time.Time) ([]DaySummaryLine, error)
+	GetDayClose(ctx context.Context, date time.Time) (*models.DayClose, error)
+	CloseDay(ctx context.Context, dayClose *models.DayClose) error
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db}
+}
+
+// Create records the payment and updates the invoice's amount paid and
+// status in the same transaction.
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment, receiptPrefix string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockOpenDay(tx, payment.BusinessDate); err != nil {
+			return err
+		}
+
+		invoice, err := lockInvoice(tx, payment.InvoiceID)
+		if err != nil {
+			return err
+		}
+		switch payment.Kind {
+		case models.PaymentKindPayment:
+			if invoice.Status != models.InvoiceStatusIssued && invoice.Status != models.InvoiceStatusPartiallyPaid {
+				return ErrInvoiceNotPayable
+			}
+		case models.PaymentKindRefund:
+			if payment.RefundOfID != nil {
+				refundable, err := refundableAmount(tx, *payment.RefundOfID, payment.InvoiceID, "")
+				if err != nil {
+					return err
+				}
+				if payment.Amount.GreaterThan(refundable) {
+					return ErrRefundExceedsPaid
+				}
+			}
+		}
+
+		if err := applyToInvoice(tx, invoice, payment.SignedAmount(), payment.ReceivedBy); err != nil {
+			return err
+		}
+
+		var seq int64
+		if err := tx.Raw("SELECT nextval('payment_receipt_seq')").Scan(&seq).Error; err != nil {
+			return err
+		}
+		payment.ReceiptNumber = utils.FormatReceiptNumber(receiptPrefix, seq)
+		return tx.Omit("Invoice", "Receiver").Create(payment).Error
+	})
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id string) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.db.WithContext(ctx).Preload("Invoice.Patient").Preload("Receiver").First(&payment, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) Search(ctx context.Context, filter PaymentFilter) ([]*models.Payment, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Payment{})
+	if filter.InvoiceID != "" {
+		query = query.Where("invoice_id = ?", filter.InvoiceID)
+	}
+	if filter.ReceivedBy != "" {
+		query = query.Where("received_by = ?", filter.ReceivedBy)
+	}
+	if filter.Method != "" {
+		query = query.Where("method = ?", filter.Method)
+	}
+	if filter.BusinessDate != nil {
+		query = query.Where("business_date = ?", *filter.BusinessDate)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var payments []*models.Payment
+	if err := query.Preload("Invoice.Patient").Preload("Receiver").Order("received_at DESC").Find(&payments).Error; err != nil {
+		return nil, 0, err
+	}
+	return payments, total, nil
+}
+
+// Update changes the method, amount, reference and notes of a payment from a
+// day that has not been closed yet, adjusting the invoice by the difference.
+func (r *paymentRepository) Update(ctx context.Context, payment *models.Payment, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", payment.ID).Error; err != nil {
+			return err
+		}
+		if err := lockOpenDay(tx, current.BusinessDate); err != nil {
+			return err
+		}
+
+		invoice, err := lockInvoice(tx, current.InvoiceID)
+		if err != nil {
+			return err
+		}
+		if current.Kind == models.PaymentKindPayment {
+			var refunded decimal.Decimal
+			err := tx.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").
+				Where("refund_of_id = ?", current.ID).Row().Scan(&refunded)
+			if err != nil {
+				return err
+			}
+			if payment.Amount.LessThan(refunded) {
+				return ErrRefundExceedsPaid
+			}
+		} else if current.RefundOfID != nil {
+			refundable, err := refundableAmount(tx, *current.RefundOfID, current.InvoiceID, current.ID)
+			if err != nil {
+				return err
+			}
+			if payment.Amount.GreaterThan(refundable) {
+				return ErrRefundExceedsPaid
+			}
+		}
+
+		updated := current
+		updated.Amount = payment.Amount
+		difference := updated.SignedAmount().Sub(current.SignedAmount())
+		if !difference.IsZero() {
+			if err := applyToInvoice(tx, invoice, difference, userID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&current).Updates(map[string]any{
+			"method":     payment.Method,
+			"amount":     payment.Amount,
+			"reference":  payment.Reference,
+			"notes":      payment.Notes,
+			"updated_at": time.Now(),
+		}).Error
+	})
+}
+
+func (r *paymentRepository) GetDaySummary(ctx context.Context, date time.Time) ([]DaySummaryLine, error) {
+	var lines []DaySummaryLine
+	err := r.db.WithContext(ctx).Table("payments").
+		Select(`payments.received_by, users.username, payments.method,
+			COUNT(*) FILTER (WHERE payments.kind = 'payment') AS payment_count,
+			COALESCE(SUM(payments.amount) FILTER (WHERE payments.kind = 'payment'), 0) AS payments_total,
+			COUNT(*) FILTER (WHERE payments.kind = 'refund') AS refund_count,
+			COALESCE(SUM(payments.amount) FILTER (WHERE payments.kind = 'refund'), 0) AS refunds_total`).
+		Joins("JOIN users ON users.id = payments.received_by").
+		Where("payments.business_date = ?", date).
+		Group("payments.received_by, users.username, payments.method").
+		Order("users.username, payments.method").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func (r *paymentRepository) GetDayClose(ctx context.Context, date time.Time) (*models.DayClose, error) {
+	var dayClose models.DayClose
+	if err := r.db.WithContext(ctx).Preload("Closer").First(&dayClose, "business_date = ?", date).Error; err != nil {
+		return nil, err
+	}
+	return &dayClose, nil
+}
+
+// CloseDay totals the day's payments and marks them as closed.
+func (r *paymentRepository) CloseDay(ctx context.Context, dayClose *models.DayClose) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := lockOpenDay(tx, dayClose.BusinessDate)
+		if errors.Is(err, ErrDayClosed) {
+			return ErrDayAlreadyClosed
+		}
+		if err != nil {
+			return err
+		}
+
+		var totals struct {
+			Count int
+			Net   decimal.Decimal
+		}
+		err = tx.Model(&models.Payment{}).
+			Select("COUNT(*) AS count, COALESCE(SUM(CASE WHEN kind = 'refund' THEN -amount ELSE amount END), 0) AS net").
+			Where("business_date = ?", dayClose.BusinessDate).
+			Scan(&totals).Error
+		if err != nil {
+			return err
+		}
+		dayClose.PaymentCount = totals.Count
+		dayClose.NetTotal = totals.Net
+
+		if err := tx.Omit("Closer").Create(dayClose).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Payment{}).
+			Where("business_date = ?", dayClose.BusinessDate).
+			Update("day_close_id", dayClose.ID).Error
+	})
+}
+
+// lockOpenDay serializes writes to the payments of a business date with its
+// close, then fails if the day has already been closed.
+func lockOpenDay(tx *gorm.DB, date time.Time) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "payments:"+date.Format(time.DateOnly)).Error; err != nil {
+		return err
+	}
+	var closed int64
+	if err := tx.Model(&models.DayClose{}).Where("business_date = ?", date).Count(&closed).Error; err != nil {
+		return err
+	}
+	if closed > 0 {
+		return ErrDayClosed
+	}
+	return nil
+}
+
+// applyToInvoice adds amount (negative for refunds) to what has been paid on
+// the invoice and updates its status.
+func applyToInvoice(tx *gorm.DB, invoice *models.Invoice, amount decimal.Decimal, userID string) error {
+	if invoice.Status == models.InvoiceStatusDraft || invoice.Status == models.InvoiceStatusVoid {
+		return ErrInvoiceNotPayable
+	}
+	paid := invoice.AmountPaid.Add(amount)
+	if paid.GreaterThan(invoice.Total) {
+		return ErrPaymentExceedsBalance
+	}
+	if paid.IsNegative() {
+		return ErrRefundExceedsPaid
+	}
+
+	invoice.AmountPaid = paid
+	invoice.UpdatePaymentStatus()
+	return tx.Model(&models.Invoice{ID: invoice.ID}).Updates(map[string]any{
+		"amount_paid": invoice.AmountPaid,
+		"status":      invoice.Status,
+		"updated_by":  userID,
+		"updated_at":  time.Now(),
+	}).Error
+}
+
+// refundableAmount is what is left of payment paymentID after its refunds,
+// not counting refund excludeID.
+func refundableAmount(tx *gorm.DB, paymentID, invoiceID, excludeID string) (decimal.Decimal, error) {
+	var original models.Payment
+	err := tx.First(&original, "id = ? AND invoice_id = ? AND kind = ?", paymentID, invoiceID, models.PaymentKindPayment).Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	query := tx.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Where("refund_of_id = ?", paymentID)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	var refunded decimal.Decimal
+	if err := query.Row().Scan(&refunded); err != nil {
+		return decimal.Zero, err
+	}
+	return original.Amount.Sub(refunded), nil
+}
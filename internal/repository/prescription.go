@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/models"
+	"gorm.io/gorm"
+)
+
+type PrescriptionRepository interface {
+	Create(ctx context.Context, prescription *models.Prescription) error
+	GetByID(ctx context.Context, id string) (*models.Prescription, error)
+	GetByPatientID(ctx context.Context, patientID string) ([]*models.Prescription, error)
+}
+
+type prescriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewPrescriptionRepository(db *gorm.DB) PrescriptionRepository {
+	return &prescriptionRepository{db}
+}
+
+func (r *prescriptionRepository) Create(ctx context.Context, prescription *models.Prescription) error {
+	return r.db.WithContext(ctx).Omit("Patient", "Prescriber").Create(prescription).Error
+}
+
+func (r *prescriptionRepository) GetByID(ctx context.Context, id string) (*models.Prescription, error) {
+	var prescription models.Prescription
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Patient").
+		Preload("Prescriber").
+		First(&prescription, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+func (r *prescriptionRepository) GetByPatientID(ctx context.Context, patientID string) ([]*models.Prescription, error) {
+	var prescriptions []*models.Prescription
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Prescriber").
+		Where("patient_id = ?", patientID).
+		Order("created_at DESC").
+		Find(&prescriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return prescriptions, nil
+}
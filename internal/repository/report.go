@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/max-programming/clinic/internal/db"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ReportRange selects rows from From up to but excluding To, grouped into
+// periods of GroupBy: day, week (starting Monday) or month.
+type ReportRange struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string
+}
+
+type PeriodCount struct {
+	Period time.Time
+	Count  int64
+}
+
+type DemographicsLine struct {
+	Gender  string
+	AgeBand string
+	Count   int64
+}
+
+type DoctorVisitsLine struct {
+	Period   time.Time
+	DoctorID string
+	Username string
+	Visits   int64
+	Patients int64
+}
+
+type InvoicedLine struct {
+	Period       time.Time
+	Currency     string
+	InvoiceCount int64
+	Invoiced     decimal.Decimal
+}
+
+type CollectedLine struct {
+	Period    time.Time
+	Currency  string
+	Collected decimal.Decimal
+	Refunded  decimal.Decimal
+}
+
+type ReportRepository interface {
+	CountNewPatients(ctx context.Context, r ReportRange) ([]PeriodCount, error)
+	CountPatientsByGenderAndAge(ctx context.Context, from *time.Time, to time.Time) ([]DemographicsLine, error)
+	CountVisitsByDoctor(ctx context.Context, r ReportRange) ([]DoctorVisitsLine, error)
+	SumInvoiced(ctx context.Context, r ReportRange) ([]InvoicedLine, error)
+	SumCollected(ctx context.Context, r ReportRange) ([]CollectedLine, error)
+}
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{db}
+}
+
+// CountNewPatients counts registrations. Duplicates merged into another
+// patient are left out.
+func (r *reportRepository) CountNewPatients(ctx context.Context, rng ReportRange) ([]PeriodCount, error) {
+	var counts []PeriodCount
+	err := db.Replica(r.db).WithContext(ctx).Model(&models.Patient{}).
+		Select("date_trunc(?, created_at) AS period, COUNT(*) AS count", rng.GroupBy).
+		Where("merged_into_id IS NULL AND created_at >= ? AND created_at < ?", rng.From, rng.To).
+		Group("period").
+		Order("period").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// CountPatientsByGenderAndAge counts the patients registered before to, and
+// on or after from when given, by gender and by their age on the day before
+// to.
+func (r *reportRepository) CountPatientsByGenderAndAge(ctx context.Context, from *time.Time, to time.Time) ([]DemographicsLine, error) {
+	at := to.AddDate(0, 0, -1)
+	query := db.Replica(r.db).WithContext(ctx).Model(&models.Patient{}).
+		Select(`gender,
+			CASE
+				WHEN date_of_birth IS NULL THEN 'unknown'
+				WHEN date_part('year', age(?::date, date_of_birth)) < 18 THEN '0-17'
+				WHEN date_part('year', age(?::date, date_of_birth)) < 35 THEN '18-34'
+				WHEN date_part('year', age(?::date, date_of_birth)) < 50 THEN '35-49'
+				WHEN date_part('year', age(?::date, date_of_birth)) < 65 THEN '50-64'
+				ELSE '65+'
+			END AS age_band,
+			COUNT(*) AS count`, at, at, at, at).
+		Where("merged_into_id IS NULL AND created_at < ?", to)
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+
+	var lines []DemographicsLine
+	if err := query.Group("gender, age_band").Order("gender, age_band").Scan(&lines).Error; err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// CountVisitsByDoctor counts a visit for each day a doctor prescribed for a
+// patient, however many prescriptions were written that day.
+func (r *reportRepository) CountVisitsByDoctor(ctx context.Context, rng ReportRange) ([]DoctorVisitsLine, error) {
+	var lines []DoctorVisitsLine
+	err := db.Replica(r.db).WithContext(ctx).Table("prescriptions").
+		Select(`date_trunc(?, prescriptions.created_at) AS period,
+			prescriptions.prescribed_by AS doctor_id, users.username,
+			COUNT(DISTINCT (prescriptions.patient_id, prescriptions.created_at::date)) AS visits,
+			COUNT(DISTINCT prescriptions.patient_id) AS patients`, rng.GroupBy).
+		Joins("JOIN users ON users.id = prescriptions.prescribed_by").
+		Where("prescriptions.created_at >= ? AND prescriptions.created_at < ?", rng.From, rng.To).
+		Group("period, prescriptions.prescribed_by, users.username").
+		Order("period, users.username").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// SumInvoiced totals invoices by the period they were issued in. Drafts and
+// voided invoices are left out.
+func (r *reportRepository) SumInvoiced(ctx context.Context, rng ReportRange) ([]InvoicedLine, error) {
+	var lines []InvoicedLine
+	err := db.Replica(r.db).WithContext(ctx).Model(&models.Invoice{}).
+		Select("date_trunc(?, issued_at) AS period, currency, COUNT(*) AS invoice_count, COALESCE(SUM(total), 0) AS invoiced", rng.GroupBy).
+		Where("status NOT IN ? AND issued_at >= ? AND issued_at < ?",
+			[]string{models.InvoiceStatusDraft, models.InvoiceStatusVoid}, rng.From, rng.To).
+		Group("period, currency").
+		Order("period, currency").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// SumCollected totals payments and refunds by business date.
+func (r *reportRepository) SumCollected(ctx context.Context, rng ReportRange) ([]CollectedLine, error) {
+	var lines []CollectedLine
+	err := db.Replica(r.db).WithContext(ctx).Table("payments").
+		Select(`date_trunc(?, payments.business_date::timestamp) AS period, invoices.currency,
+			COALESCE(SUM(payments.amount) FILTER (WHERE payments.kind = 'payment'), 0) AS collected,
+			COALESCE(SUM(payments.amount) FILTER (WHERE payments.kind = 'refund'), 0) AS refunded`, rng.GroupBy).
+		Joins("JOIN invoices ON invoices.id = payments.invoice_id").
+		Where("payments.business_date >= ? AND payments.business_date < ?", rng.From, rng.To).
+		Group("period, invoices.currency").
+		Order("period, invoices.currency").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// NotifyChanges calls fn with the table of every row created, updated or
+// deleted through db. Raw statements are reported with an empty table name.
+// fn runs before the surrounding transaction commits, if any.
+func NotifyChanges(db *gorm.DB, fn func(table string)) error {
+	notify := func(tx *gorm.DB) {
+		if tx.Error == nil && !tx.DryRun {
+			fn(tx.Statement.Table)
+		}
+	}
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("clinic:notify_create", notify); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("clinic:notify_update", notify); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("clinic:notify_delete", notify); err != nil {
+		return err
+	}
+	notifyRaw := func(tx *gorm.DB) {
+		if tx.Error == nil && !tx.DryRun {
+			fn("")
+		}
+	}
+	return callbacks.Raw().After("gorm:raw").Register("clinic:notify_raw", notifyRaw)
+}
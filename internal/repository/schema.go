@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type SchemaRepository interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (version uint, dirty bool, err error)
+}
+
+type schemaRepository struct {
+	db *gorm.DB
+}
+
+func NewSchemaRepository(db *gorm.DB) SchemaRepository {
+	return &schemaRepository{db}
+}
+
+func (r *schemaRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// MigrationVersion reads the version golang-migrate recorded. It is 0 when
+// no migration has been applied. dirty means the last migration failed part
+// way through.
+func (r *schemaRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var row struct {
+		Version uint
+		Dirty   bool
+	}
+	result := r.db.WithContext(ctx).Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&row)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	return row.Version, row.Dirty, nil
+}
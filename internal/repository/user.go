@@ -1,13 +1,15 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/max-programming/clinic/internal/models"
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
-	Create(user *models.User) error
-	FindByUsername(username string) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
 }
 
 type userRepository struct {
@@ -18,13 +20,13 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db}
 }
 
-func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepository) FindByUsername(username string) (*models.User, error) {
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("username = ?", username).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -1,57 +1,81 @@
 package router
 
 import (
-	"log"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-
 	"github.com/gin-gonic/gin"
 	"github.com/max-programming/clinic/internal/config"
 	"github.com/max-programming/clinic/internal/handler"
+	"github.com/max-programming/clinic/internal/metrics"
 	"github.com/max-programming/clinic/internal/middleware"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(h *handler.HandlerSet) *gin.Engine {
-	r := gin.Default()
+func SetupRouter(cfg *config.Config, h *handler.HandlerSet) *gin.Engine {
+	r := gin.New()
+
+	// Registered ahead of the middleware so that scrapes are neither logged
+	// nor counted. With METRICS_ADDR set, metrics are served there instead.
+	if cfg.MetricsAddr == "" && cfg.MetricsToken != "" {
+		r.GET("/metrics", gin.WrapH(metrics.Handler(cfg.MetricsToken)))
+	}
+
+	r.Use(middleware.RequestID(), middleware.Tracing(), middleware.TraceLog(), middleware.AccessLog(), middleware.Metrics(), middleware.Recovery(),
+		middleware.Timeout(cfg.RequestTimeout, longRequestTimeouts(cfg.LongRequestTimeout)))
 
 	origins := []string{}
 
-	if config.Envs.LocalAllowedOrigin != "" {
-		origins = append(origins, config.Envs.LocalAllowedOrigin)
-	}
-	if config.Envs.RemoteAllowedOrigin != "" {
-		origins = append(origins, config.Envs.RemoteAllowedOrigin)
+	if cfg.LocalAllowedOrigin != "" {
+		origins = append(origins, cfg.LocalAllowedOrigin)
 	}
-
-	if len(origins) == 0 {
-		log.Fatal("No valid CORS origins configured")
+	if cfg.RemoteAllowedOrigin != "" {
+		origins = append(origins, cfg.RemoteAllowedOrigin)
 	}
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     origins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 	}))
 
+	auth := middleware.AuthMiddleware([]byte(cfg.JWTSecret))
+
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	api := r.Group("/api")
 	{
-		api.GET("/health", h.Health.Check)
+		health := api.Group("/health")
+		{
+			health.GET("/live", h.Health.Live)
+			health.GET("/ready", h.Health.Ready)
+		}
 
 		api.POST("/register", h.Auth.Register)
 		api.POST("/login", h.Auth.Login)
-		api.GET("/me", middleware.AuthMiddleware(), h.Auth.GetCurrentUser)
+		api.GET("/me", auth, h.Auth.GetCurrentUser)
 
 		patients := api.Group("/patients")
-		patients.Use(middleware.AuthMiddleware())
+		patients.Use(auth, middleware.RequirePatientAccess())
 		{
 			patients.GET("", h.Patient.GetAllPatients)
+			patients.GET("/lookup", h.Patient.LookupPatients)
+			patients.GET("/export", middleware.RequireRole("receptionist", "admin"), h.PatientExport.ExportPatients)
 			patients.GET("/:id", h.Patient.GetPatientByID)
+			patients.GET("/:id/lab-results", h.LabResult.GetPatientLabResults)
+			patients.GET("/:id/identifiers", h.PatientIdentifier.GetPatientIdentifiers)
+			patients.GET("/:id/insurance-policies", h.InsurancePolicy.GetPatientPolicies)
+			patients.GET("/:id/prescriptions", h.Prescription.GetPatientPrescriptions)
+			patients.GET("/:id/summary.pdf", h.Document.GetPatientSummary)
+			patients.GET("/:id/export", middleware.RequireRole("receptionist", "admin"), h.PatientExport.ExportPatientRecord)
+			patients.GET("/:id/documents", h.PatientDocument.GetPatientDocuments)
+			patients.POST("/:id/documents", middleware.RequireRole("receptionist", "doctor"), h.PatientDocument.UploadDocument)
+			patients.GET("/:id/documents/:documentId", h.PatientDocument.GetDocument)
+			patients.GET("/:id/documents/:documentId/content", h.PatientDocument.DownloadDocument)
 
 			receptionistRoutes := patients.Group("")
 			receptionistRoutes.Use(middleware.RequireReceptionist())
@@ -59,15 +83,177 @@ func SetupRouter(h *handler.HandlerSet) *gin.Engine {
 				receptionistRoutes.POST("", h.Patient.AddPatient)
 				receptionistRoutes.PUT("/:id", h.Patient.UpdatePatient)
 				receptionistRoutes.DELETE("/:id", h.Patient.DeletePatient)
+				receptionistRoutes.POST("/:id/identifiers", h.PatientIdentifier.AddPatientIdentifier)
+				receptionistRoutes.DELETE("/:id/identifiers/:identifierId", h.PatientIdentifier.DeletePatientIdentifier)
+				receptionistRoutes.POST("/:id/insurance-policies", h.InsurancePolicy.AddPolicy)
+				receptionistRoutes.PUT("/:id/insurance-policies/:policyId", h.InsurancePolicy.UpdatePolicy)
+				receptionistRoutes.DELETE("/:id/insurance-policies/:policyId", h.InsurancePolicy.DeletePolicy)
+				receptionistRoutes.DELETE("/:id/documents/:documentId", h.PatientDocument.DeleteDocument)
 			}
 
 			doctorRoutes := patients.Group("")
 			doctorRoutes.Use(middleware.RequireDoctor())
 			{
 				doctorRoutes.PATCH("/:id/notes", h.Patient.UpdatePatientNotes)
+				doctorRoutes.POST("/:id/prescriptions", h.Prescription.AddPrescription)
 			}
 		}
+
+		patientImports := api.Group("/patient-imports")
+		patientImports.Use(auth, middleware.RequireRole("receptionist", "admin"))
+		{
+			patientImports.GET("", h.PatientImport.GetImports)
+			patientImports.POST("", h.PatientImport.CreateImport)
+			patientImports.GET("/:id", h.PatientImport.GetImport)
+		}
+
+		prescriptions := api.Group("/prescriptions")
+		prescriptions.Use(auth, middleware.RequirePatientAccess())
+		{
+			prescriptions.GET("/:id", h.Prescription.GetPrescriptionByID)
+			prescriptions.GET("/:id/prescription.pdf", h.Document.GetPrescriptionPDF)
+		}
+
+		fees := api.Group("/fees")
+		fees.Use(auth)
+		{
+			fees.GET("", h.Fee.GetFees)
+			fees.POST("", middleware.RequireAdmin(), h.Fee.CreateFee)
+			fees.PUT("/:id", middleware.RequireAdmin(), h.Fee.UpdateFee)
+		}
+
+		invoices := api.Group("/invoices")
+		invoices.Use(auth, middleware.RequireRole("receptionist", "admin"))
+		{
+			invoices.GET("", h.Invoice.GetInvoices)
+			invoices.POST("", h.Invoice.CreateInvoice)
+			invoices.GET("/:id", h.Invoice.GetInvoiceByID)
+			invoices.PUT("/:id", h.Invoice.UpdateInvoice)
+			invoices.DELETE("/:id", h.Invoice.DeleteInvoice)
+			invoices.POST("/:id/issue", h.Invoice.IssueInvoice)
+			invoices.POST("/:id/void", h.Invoice.VoidInvoice)
+			invoices.GET("/:id/payments", h.Payment.GetInvoicePayments)
+			invoices.POST("/:id/payments", h.Payment.RecordPayment)
+			invoices.POST("/:id/refunds", h.Payment.RecordRefund)
+			invoices.GET("/:id/invoice.pdf", h.Document.GetInvoicePDF)
+		}
+
+		payments := api.Group("/payments")
+		payments.Use(auth, middleware.RequireRole("receptionist", "admin"))
+		{
+			payments.GET("", h.Payment.GetPayments)
+			payments.GET("/day-close", h.Payment.GetDayClose)
+			payments.POST("/day-close", h.Payment.CloseDay)
+			payments.GET("/:id", h.Payment.GetPaymentByID)
+			payments.PUT("/:id", h.Payment.UpdatePayment)
+			payments.GET("/:id/receipt", h.Payment.GetReceipt)
+		}
+
+		claims := api.Group("/claims")
+		claims.Use(auth, middleware.RequireRole("receptionist", "admin"))
+		{
+			claims.GET("", h.Claim.GetClaims)
+			claims.POST("", h.Claim.CreateClaim)
+			claims.POST("/export", h.Claim.ExportClaims)
+			claims.GET("/:id", h.Claim.GetClaimByID)
+			claims.PUT("/:id", h.Claim.UpdateClaim)
+			claims.DELETE("/:id", h.Claim.DeleteClaim)
+			claims.POST("/:id/status", h.Claim.ChangeClaimStatus)
+		}
+
+		admin := api.Group("/admin")
+		admin.Use(auth, middleware.RequireAdmin())
+		{
+			admin.GET("/duplicates", h.Duplicate.GetCandidates)
+			admin.POST("/duplicates/scan", h.Duplicate.ScanDuplicates)
+			admin.POST("/duplicates/:id/dismiss", h.Duplicate.DismissCandidate)
+			admin.POST("/patients/:id/merge", h.PatientMerge.MergePatient)
+			admin.GET("/merges", h.PatientMerge.GetMerges)
+			admin.POST("/merges/:id/reverse", h.PatientMerge.ReverseMerge)
+			admin.GET("/audit-logs", h.Audit.GetAuditLogs)
+			admin.GET("/patients/:id/legal-holds", h.Erasure.GetHolds)
+			admin.POST("/patients/:id/legal-holds", h.Erasure.PlaceHold)
+			admin.POST("/legal-holds/:id/release", h.Erasure.ReleaseHold)
+			admin.POST("/patients/:id/erasure-requests", h.Erasure.RequestErasure)
+			admin.GET("/erasure-requests", h.Erasure.GetRequests)
+			admin.GET("/erasure-requests/:id", h.Erasure.GetRequest)
+			admin.POST("/erasure-requests/:id/execute", h.Erasure.Erase)
+			admin.POST("/erasure-requests/:id/cancel", h.Erasure.CancelRequest)
+			admin.GET("/erasure-requests/:id/certificate", h.Erasure.GetCertificate)
+		}
+
+		reports := api.Group("/reports")
+		reports.Use(auth, middleware.RequireRole("admin", "manager"))
+		{
+			reports.GET("/new-patients", h.Report.GetNewPatients)
+			reports.GET("/demographics", h.Report.GetDemographics)
+			reports.GET("/visits", h.Report.GetVisits)
+			reports.GET("/revenue", h.Report.GetRevenue)
+		}
+
+		hl7 := api.Group("/hl7")
+		hl7.Use(auth, middleware.RequireReceptionist())
+		{
+			hl7.GET("/messages", h.HL7.GetAllMessages)
+			hl7.GET("/messages/:id", h.HL7.GetMessageByID)
+			hl7.POST("/messages/:id/replay", h.HL7.ReplayMessage)
+		}
 	}
 
+	fhirR4 := r.Group("/fhir/R4")
+	fhirR4.Use(h.FHIR.Format())
+	{
+		fhirR4.GET("/metadata", h.FHIR.Metadata)
+
+		resources := fhirR4.Group("")
+		resources.Use(auth, middleware.RequirePatientAccess())
+		{
+			resources.GET("/Patient", h.FHIR.SearchPatients)
+			resources.GET("/Patient/:id", h.FHIR.ReadPatient)
+			resources.GET("/Observation", h.FHIR.SearchObservations)
+			resources.GET("/Observation/:id", h.FHIR.ReadObservation)
+
+			receptionistRoutes := resources.Group("")
+			receptionistRoutes.Use(middleware.RequireReceptionist())
+			{
+				receptionistRoutes.POST("/Patient", h.FHIR.CreatePatient)
+				receptionistRoutes.PUT("/Patient/:id", h.FHIR.UpdatePatient)
+			}
+		}
+	}
+	r.NoRoute(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/fhir/R4/") {
+			h.FHIR.Unsupported(c)
+		}
+	})
+
 	return r
 }
+
+// longRequestTimeouts gives routes that stream files, render PDFs or go
+// through many rows LONG_REQUEST_TIMEOUT instead of REQUEST_TIMEOUT.
+func longRequestTimeouts(timeout time.Duration) map[string]time.Duration {
+	routes := []string{
+		"GET /api/patients/export",
+		"GET /api/patients/:id/export",
+		"GET /api/patients/:id/summary.pdf",
+		"POST /api/patients/:id/documents",
+		"GET /api/patients/:id/documents/:documentId/content",
+		"POST /api/patient-imports",
+		"GET /api/prescriptions/:id/prescription.pdf",
+		"GET /api/invoices/:id/invoice.pdf",
+		"GET /api/payments/:id/receipt",
+		"POST /api/claims/export",
+		"POST /api/admin/duplicates/scan",
+		"POST /api/admin/erasure-requests/:id/execute",
+		"GET /api/reports/new-patients",
+		"GET /api/reports/demographics",
+		"GET /api/reports/visits",
+		"GET /api/reports/revenue",
+	}
+	timeouts := make(map[string]time.Duration, len(routes))
+	for _, route := range routes {
+		timeouts[route] = timeout
+	}
+	return timeouts
+}
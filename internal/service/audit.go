@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+)
+
+const (
+	AuditPatientDuplicateOverride = "patient.duplicate_override"
+	AuditPatientMerge             = "patient.merge"
+	AuditPatientMergeReverse      = "patient.merge_reverse"
+	AuditDuplicateDismiss         = "duplicate.dismiss"
+)
+
+type AuditService interface {
+	Record(ctx context.Context, userID, action, entityType, entityID string, details any) error
+	GetAuditLogs(ctx context.Context, filter repository.AuditLogFilter) ([]*models.AuditLog, int64, error)
+}
+
+type auditService struct {
+	repo repository.AuditLogRepository
+}
+
+func NewAuditService(repo repository.AuditLogRepository) AuditService {
+	return &auditService{repo}
+}
+
+func (s *auditService) Record(ctx context.Context, userID, action, entityType, entityID string, details any) error {
+	entry, err := newAuditLog(userID, action, entityType, entityID, details)
+	if err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, entry)
+}
+
+func (s *auditService) GetAuditLogs(ctx context.Context, filter repository.AuditLogFilter) ([]*models.AuditLog, int64, error) {
+	return s.repo.Search(ctx, filter)
+}
+
+func newAuditLog(userID, action, entityType, entityID string, details any) (*models.AuditLog, error) {
+	entry := &models.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+	if userID != "" {
+		entry.UserID = &userID
+	}
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return nil, err
+		}
+		entry.Details = raw
+	}
+	return entry, nil
+}
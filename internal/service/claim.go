@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/edi"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	AuditClaimStatus = "claim.status"
+	AuditClaimExport = "claim.export"
+)
+
+var (
+	ErrInvalidClaim           = errors.New("invalid claim")
+	ErrDuplicateClaim         = errors.New("invoice has already been claimed against this policy")
+	ErrInvalidClaimTransition = errors.New("claim cannot move to this status")
+)
+
+var icd10Pattern = regexp.MustCompile(`^[A-Z][0-9][0-9A-Z][0-9A-Z]{0,4}$`)
+
+// ClaimStatusChange carries the payer's response that goes with a status.
+type ClaimStatusChange struct {
+	Status           string
+	PayerClaimNumber string
+	PaidAmount       *decimal.Decimal
+	Reason           string
+}
+
+type ClaimExport struct {
+	ControlNumber int
+	Data          []byte
+}
+
+type ClaimService interface {
+	CreateClaim(ctx context.Context, invoiceID, policyID string, diagnosisCodes []string, notes, userID string) (*models.Claim, error)
+	UpdateClaim(ctx context.Context, id string, diagnosisCodes []string, notes, userID string) (*models.Claim, error)
+	ChangeStatus(ctx context.Context, id string, change ClaimStatusChange, userID string) (*models.Claim, error)
+	DeleteClaim(ctx context.Context, id string) error
+	GetClaimByID(ctx context.Context, id string) (*models.Claim, error)
+	SearchClaims(ctx context.Context, filter repository.ClaimFilter) ([]*models.Claim, int64, error)
+	ExportClaims(ctx context.Context, ids []string, userID string) (*ClaimExport, error)
+}
+
+type claimService struct {
+	repo         repository.ClaimRepository
+	invoiceRepo  repository.InvoiceRepository
+	policyRepo   repository.InsurancePolicyRepository
+	feeRepo      repository.FeeRepository
+	auditService AuditService
+	ediSettings  edi.Settings
+}
+
+func NewClaimService(
+	repo repository.ClaimRepository,
+	invoiceRepo repository.InvoiceRepository,
+	policyRepo repository.InsurancePolicyRepository,
+	feeRepo repository.FeeRepository,
+	auditService AuditService,
+	ediSettings edi.Settings,
+) ClaimService {
+	return &claimService{repo, invoiceRepo, policyRepo, feeRepo, auditService, ediSettings}
+}
+
+// CreateClaim drafts a claim for an issued invoice. Every line must come from
+// the fee catalog, whose code is billed as the procedure code.
+func (s *claimService) CreateClaim(ctx context.Context, invoiceID, policyID string, diagnosisCodes []string, notes, userID string) (*models.Claim, error) {
+	codes, err := normalizeDiagnosisCodes(diagnosisCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.invoiceRepo.GetByID(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status == models.InvoiceStatusDraft || invoice.Status == models.InvoiceStatusVoid {
+		return nil, fmt.Errorf("%w: only issued invoices can be claimed", ErrInvalidClaim)
+	}
+	for i, item := range invoice.Items {
+		if item.FeeID == nil {
+			return nil, fmt.Errorf("%w: invoice line %d is not from the fee catalog", ErrInvalidClaim, i+1)
+		}
+	}
+
+	policy, err := s.policyRepo.GetByID(ctx, invoice.PatientID, policyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: policy does not belong to the invoice's patient", ErrInvalidClaim)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !policy.CoversDate(*invoice.IssuedAt) {
+		return nil, fmt.Errorf("%w: policy is not valid on %s", ErrInvalidClaim, invoice.IssuedAt.Format(time.DateOnly))
+	}
+
+	claim := &models.Claim{
+		InvoiceID:      invoice.ID,
+		PolicyID:       policy.ID,
+		Status:         models.ClaimStatusDraft,
+		DiagnosisCodes: codes,
+		TotalCharge:    invoice.Total,
+		ExpectedAmount: policy.ExpectedPayment(invoice.Total),
+		Notes:          notes,
+		CreatedBy:      userID,
+		UpdatedBy:      userID,
+	}
+	if err := s.repo.Create(ctx, claim); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrDuplicateClaim
+		}
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, claim.ID)
+}
+
+func (s *claimService) UpdateClaim(ctx context.Context, id string, diagnosisCodes []string, notes, userID string) (*models.Claim, error) {
+	codes, err := normalizeDiagnosisCodes(diagnosisCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	claim, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Status != models.ClaimStatusDraft {
+		return nil, fmt.Errorf("%w: only draft claims can be edited", ErrInvalidClaimTransition)
+	}
+
+	claim.DiagnosisCodes = codes
+	claim.Notes = notes
+	claim.UpdatedBy = userID
+	if err := s.repo.Update(ctx, claim, models.ClaimStatusDraft); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *claimService) ChangeStatus(ctx context.Context, id string, change ClaimStatusChange, userID string) (*models.Claim, error) {
+	claim, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !claim.CanTransitionTo(change.Status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidClaimTransition, claim.Status, change.Status)
+	}
+
+	from := claim.Status
+	now := time.Now()
+	switch change.Status {
+	case models.ClaimStatusSubmitted:
+		claim.SubmittedAt = &now
+	case models.ClaimStatusAccepted:
+		claim.AdjudicatedAt = &now
+	case models.ClaimStatusRejected:
+		if strings.TrimSpace(change.Reason) == "" {
+			return nil, fmt.Errorf("%w: a rejection reason is required", ErrInvalidClaim)
+		}
+		claim.RejectionReason = change.Reason
+		claim.AdjudicatedAt = &now
+	case models.ClaimStatusPaid:
+		if change.PaidAmount == nil || change.PaidAmount.IsNegative() {
+			return nil, fmt.Errorf("%w: the amount paid by the payer is required", ErrInvalidClaim)
+		}
+		paid := change.PaidAmount.Round(2)
+		claim.PaidAmount = &paid
+		claim.AdjudicatedAt = &now
+	}
+	if change.PayerClaimNumber != "" {
+		claim.PayerClaimNumber = change.PayerClaimNumber
+	}
+	claim.Status = change.Status
+	claim.UpdatedBy = userID
+
+	if err := s.repo.Update(ctx, claim, from); err != nil {
+		return nil, err
+	}
+
+	details := map[string]string{"from": from, "to": change.Status}
+	if change.Reason != "" {
+		details["reason"] = change.Reason
+	}
+	if claim.PaidAmount != nil && change.Status == models.ClaimStatusPaid {
+		details["paidAmount"] = claim.PaidAmount.StringFixed(2)
+	}
+	if err := s.auditService.Record(ctx, userID, AuditClaimStatus, "claim", id, details); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit claim status change", slog.String("claim_id", id), slog.Any("error", err))
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *claimService) DeleteClaim(ctx context.Context, id string) error {
+	err := s.repo.Delete(ctx, id)
+	if errors.Is(err, repository.ErrClaimStatusChanged) {
+		return fmt.Errorf("%w: only draft claims can be deleted", ErrInvalidClaimTransition)
+	}
+	return err
+}
+
+func (s *claimService) GetClaimByID(ctx context.Context, id string) (*models.Claim, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *claimService) SearchClaims(ctx context.Context, filter repository.ClaimFilter) ([]*models.Claim, int64, error) {
+	return s.repo.Search(ctx, filter)
+}
+
+// ExportClaims writes draft or previously submitted claims to an 837P file
+// and marks the drafts as submitted.
+func (s *claimService) ExportClaims(ctx context.Context, ids []string, userID string) (*ClaimExport, error) {
+	if err := s.ediSettings.Validate(); err != nil {
+		return nil, err
+	}
+
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	claims, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims) != len(ids) {
+		return nil, fmt.Errorf("%w: %d of %d claims not found", gorm.ErrRecordNotFound, len(ids)-len(claims), len(ids))
+	}
+
+	var feeIDs []string
+	for _, claim := range claims {
+		if claim.Status != models.ClaimStatusDraft && claim.Status != models.ClaimStatusSubmitted {
+			return nil, fmt.Errorf("%w: claim %s is %s", ErrInvalidClaimTransition, claim.ClaimNumber, claim.Status)
+		}
+		for _, item := range claim.Invoice.Items {
+			if item.FeeID != nil {
+				feeIDs = append(feeIDs, *item.FeeID)
+			}
+		}
+	}
+	fees, err := s.feeRepo.GetByIDs(ctx, feeIDs)
+	if err != nil {
+		return nil, err
+	}
+	procedureCodes := make(map[string]string, len(fees))
+	for _, fee := range fees {
+		procedureCodes[fee.ID] = fee.Code
+	}
+
+	controlNumber, err := s.repo.NextInterchangeControlNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	data, err := edi.Build837P(s.ediSettings, claims, procedureCodes, controlNumber, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.MarkSubmitted(ctx, ids, controlNumber, now, userID); err != nil {
+		return nil, err
+	}
+
+	details := map[string]any{"controlNumber": controlNumber, "claimIds": ids}
+	if err := s.auditService.Record(ctx, userID, AuditClaimExport, "edi_interchange", strconv.Itoa(controlNumber), details); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit claim export", slog.Any("control_number", controlNumber), slog.Any("error", err))
+	}
+	return &ClaimExport{ControlNumber: controlNumber, Data: data}, nil
+}
+
+// normalizeDiagnosisCodes upper-cases ICD-10 codes, drops the dot and
+// duplicates, and returns them comma-separated.
+func normalizeDiagnosisCodes(codes []string) (string, error) {
+	var normalized []string
+	for _, code := range codes {
+		code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), ".", ""))
+		if code == "" || slices.Contains(normalized, code) {
+			continue
+		}
+		if !icd10Pattern.MatchString(code) {
+			return "", fmt.Errorf("%w: %q is not an ICD-10 code", ErrInvalidClaim, code)
+		}
+		normalized = append(normalized, code)
+	}
+	if len(normalized) == 0 {
+		return "", fmt.Errorf("%w: at least one diagnosis code is required", ErrInvalidClaim)
+	}
+	if len(normalized) > 12 {
+		return "", fmt.Errorf("%w: a claim can carry at most 12 diagnosis codes", ErrInvalidClaim)
+	}
+	return strings.Join(normalized, ","), nil
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/utils"
+)
+
+// DuplicateThreshold is the score at which two patients are reported as a
+// probable duplicate. A name scores up to 50, an exact date of birth 30 and a
+// matching phone number 20, so a name match alone is never enough.
+const DuplicateThreshold = 70
+
+type DuplicateMatch struct {
+	Patient *models.Patient
+	Score   int
+	Reasons []string
+}
+
+type DuplicatePatientError struct {
+	Matches []DuplicateMatch
+}
+
+func (e *DuplicatePatientError) Error() string {
+	return fmt.Sprintf("patient may already be registered (%d probable matches)", len(e.Matches))
+}
+
+type DuplicateService interface {
+	ScanDuplicates(ctx context.Context) (int, error)
+	GetCandidates(ctx context.Context, status string, limit, offset int) ([]*models.DuplicateCandidate, int64, error)
+	DismissCandidate(ctx context.Context, id, userID string) (*models.DuplicateCandidate, error)
+}
+
+type duplicateService struct {
+	patientRepo   repository.PatientRepository
+	candidateRepo repository.DuplicateCandidateRepository
+	auditService  AuditService
+}
+
+func NewDuplicateService(
+	patientRepo repository.PatientRepository,
+	candidateRepo repository.DuplicateCandidateRepository,
+	auditService AuditService,
+) DuplicateService {
+	return &duplicateService{patientRepo, candidateRepo, auditService}
+}
+
+// ScanDuplicates compares every pair of active patients that share a date of
+// birth or phone number and records the pairs scoring above the threshold.
+func (s *duplicateService) ScanDuplicates(ctx context.Context) (int, error) {
+	patients, err := s.patientRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	blocks := make(map[string][]*models.Patient)
+	for _, patient := range patients {
+		for _, key := range duplicateBlockKeys(patient) {
+			blocks[key] = append(blocks[key], patient)
+		}
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	var candidates []*models.DuplicateCandidate
+	for _, block := range blocks {
+		for i := 0; i < len(block); i++ {
+			for j := i + 1; j < len(block); j++ {
+				a, b := block[i], block[j]
+				if b.ID < a.ID {
+					a, b = b, a
+				}
+				if seen[a.ID+b.ID] {
+					continue
+				}
+				seen[a.ID+b.ID] = true
+
+				score, reasons := scoreDuplicate(a, b)
+				if score < DuplicateThreshold {
+					continue
+				}
+				candidates = append(candidates, &models.DuplicateCandidate{
+					PatientID:          a.ID,
+					DuplicatePatientID: b.ID,
+					Score:              score,
+					Reasons:            strings.Join(reasons, ", "),
+					Status:             models.DuplicateStatusOpen,
+					DetectedAt:         now,
+				})
+			}
+		}
+	}
+
+	if err := s.candidateRepo.Upsert(ctx, candidates); err != nil {
+		return 0, err
+	}
+	return len(candidates), nil
+}
+
+func (s *duplicateService) GetCandidates(ctx context.Context, status string, limit, offset int) ([]*models.DuplicateCandidate, int64, error) {
+	return s.candidateRepo.GetByStatus(ctx, status, limit, offset)
+}
+
+func (s *duplicateService) DismissCandidate(ctx context.Context, id, userID string) (*models.DuplicateCandidate, error) {
+	if err := s.candidateRepo.UpdateStatus(ctx, id, models.DuplicateStatusDismissed); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.Record(ctx, userID, AuditDuplicateDismiss, "duplicate_candidate", id, nil); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit duplicate dismissal", slog.String("candidate_id", id), slog.Any("error", err))
+	}
+	return s.candidateRepo.GetByID(ctx, id)
+}
+
+func findDuplicates(ctx context.Context, repo repository.PatientRepository, patient *models.Patient) ([]DuplicateMatch, error) {
+	candidates, err := repo.FindDuplicateCandidates(ctx, patient)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []DuplicateMatch
+	for _, candidate := range candidates {
+		score, reasons := scoreDuplicate(patient, candidate)
+		if score >= DuplicateThreshold {
+			matches = append(matches, DuplicateMatch{Patient: candidate, Score: score, Reasons: reasons})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches, nil
+}
+
+// duplicateBlockKeys groups patients that are worth comparing: only those
+// sharing a date of birth or phone number can reach the threshold.
+func duplicateBlockKeys(patient *models.Patient) []string {
+	var keys []string
+	if patient.DateOfBirth != nil {
+		keys = append(keys, "dob:"+patient.DateOfBirth.Format("2006-01-02"))
+	}
+	if digits := utils.PhoneDigits(patient.Phone); len(digits) >= 7 {
+		keys = append(keys, "phone:"+digits)
+	}
+	return keys
+}
+
+func scoreDuplicate(a, b *models.Patient) (int, []string) {
+	score := 0
+	var reasons []string
+
+	if similarity := nameSimilarity(a, b); similarity == 1 {
+		score += 50
+		reasons = append(reasons, "same name")
+	} else if similarity >= 0.85 {
+		score += int(math.Round(50 * similarity))
+		reasons = append(reasons, "similar name")
+	}
+
+	if a.DateOfBirth != nil && b.DateOfBirth != nil {
+		x, y := a.DateOfBirth.UTC(), b.DateOfBirth.UTC()
+		switch {
+		case x.Equal(y):
+			score += 30
+			reasons = append(reasons, "same date of birth")
+		case x.Year() == y.Year() && (x.Month() == y.Month() || x.Day() == y.Day() ||
+			(int(x.Month()) == y.Day() && x.Day() == int(y.Month()))):
+			score += 15
+			reasons = append(reasons, "similar date of birth")
+		case (a.DateOfBirthEstimated || b.DateOfBirthEstimated) && abs(x.Year()-y.Year()) <= 1:
+			score += 10
+			reasons = append(reasons, "similar date of birth")
+		}
+	}
+
+	if phonesMatch(a.Phone, b.Phone) {
+		score += 20
+		reasons = append(reasons, "same phone")
+	}
+
+	return score, reasons
+}
+
+// nameSimilarity compares given and family names with Jaro-Winkler, also
+// trying them swapped since they are often entered the wrong way round.
+func nameSimilarity(a, b *models.Patient) float64 {
+	ag, af := normalizeName(a.GivenName), normalizeName(a.FamilyName)
+	bg, bf := normalizeName(b.GivenName), normalizeName(b.FamilyName)
+	if ag == "" && af == "" || bg == "" && bf == "" {
+		return jaroWinkler(normalizeName(a.Name), normalizeName(b.Name))
+	}
+	straight := (jaroWinkler(ag, bg) + jaroWinkler(af, bf)) / 2
+	swapped := (jaroWinkler(ag, bf) + jaroWinkler(af, bg)) / 2
+	return math.Max(straight, swapped)
+}
+
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+func phonesMatch(a, b string) bool {
+	a, b = utils.PhoneDigits(a), utils.PhoneDigits(b)
+	if len(a) < 7 || len(b) < 7 {
+		return false
+	}
+	// Allow one side to carry a country code the other was entered without.
+	return strings.HasSuffix(a, b) || strings.HasSuffix(b, a)
+}
+
+func jaroWinkler(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 || len(t) == 0 {
+		return 0
+	}
+
+	window := max(len(s), len(t))/2 - 1
+	window = max(window, 0)
+	sMatched := make([]bool, len(s))
+	tMatched := make([]bool, len(t))
+	matches := 0
+	for i := range s {
+		for j := max(0, i-window); j < min(len(t), i+window+1); j++ {
+			if !tMatched[j] && s[i] == t[j] {
+				sMatched[i], tMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	k := 0
+	for i := range s {
+		if !sMatched[i] {
+			continue
+		}
+		for !tMatched[k] {
+			k++
+		}
+		if s[i] != t[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s), len(t)) && s[prefix] == t[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
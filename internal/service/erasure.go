@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/encryption"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/storage"
+)
+
+const (
+	AuditErasureRequest   = "patient.erasure_request"
+	AuditErasureCancel    = "patient.erasure_cancel"
+	AuditPatientErase     = "patient.erase"
+	AuditLegalHoldPlace   = "legal_hold.place"
+	AuditLegalHoldRelease = "legal_hold.release"
+)
+
+var (
+	ErrInvalidErasure       = errors.New("invalid erasure request")
+	ErrErasureNotConfigured = errors.New("erasure certificates cannot be signed until ERASURE_SIGNING_KEY is set")
+)
+
+// ErasureCertificate is the signed record of an erasure. It identifies the
+// patient only by ID, so it can be kept after the patient is anonymized.
+type ErasureCertificate struct {
+	RequestID      string           `json:"requestId"`
+	PatientID      string           `json:"patientId"`
+	Reason         string           `json:"reason"`
+	RequestedBy    string           `json:"requestedBy"`
+	RequestedAt    string           `json:"requestedAt"`
+	ErasedBy       string           `json:"erasedBy"`
+	ErasedAt       string           `json:"erasedAt"`
+	LegalHoldCheck string           `json:"legalHoldCheck"`
+	Rows           map[string]int64 `json:"rows"`
+	SigningKeyID   string           `json:"signingKeyId"`
+}
+
+type ErasureService interface {
+	RequestErasure(ctx context.Context, patientID, reason, userID string) (*models.ErasureRequest, error)
+	GetRequests(ctx context.Context, status string, limit, offset int) ([]*models.ErasureRequest, int64, error)
+	GetRequest(ctx context.Context, id string) (*models.ErasureRequest, error)
+	CancelRequest(ctx context.Context, id, userID string) (*models.ErasureRequest, error)
+	Erase(ctx context.Context, id, confirmMRN, userID string) (*models.ErasureRequest, error)
+	VerifyCertificate(ctx context.Context, request *models.ErasureRequest) (publicKey string, valid bool)
+	PlaceHold(ctx context.Context, patientID, reason, userID string) (*models.LegalHold, error)
+	GetHolds(ctx context.Context, patientID string) ([]*models.LegalHold, error)
+	ReleaseHold(ctx context.Context, id, userID string) (*models.LegalHold, error)
+}
+
+type erasureService struct {
+	repo    repository.ErasureRepository
+	storage storage.Storage
+	signer  *encryption.Signer
+}
+
+// NewErasureService creates the service; without a signer, requests can be
+// made and reviewed but not carried out.
+func NewErasureService(repo repository.ErasureRepository, storage storage.Storage, signer *encryption.Signer) ErasureService {
+	return &erasureService{repo, storage, signer}
+}
+
+// RequestErasure records a pending request. It fails with a
+// repository.HoldError when the patient cannot be erased yet.
+func (s *erasureService) RequestErasure(ctx context.Context, patientID, reason, userID string) (*models.ErasureRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidErasure)
+	}
+
+	request := &models.ErasureRequest{
+		PatientID:   patientID,
+		Reason:      reason,
+		RequestedBy: userID,
+	}
+	entry, err := newAuditLog(userID, AuditErasureRequest, "erasure_request", "", map[string]string{"patientId": patientID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRequest(ctx, request, entry); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRequest(ctx, request.ID)
+}
+
+func (s *erasureService) GetRequests(ctx context.Context, status string, limit, offset int) ([]*models.ErasureRequest, int64, error) {
+	return s.repo.GetRequests(ctx, status, limit, offset)
+}
+
+func (s *erasureService) GetRequest(ctx context.Context, id string) (*models.ErasureRequest, error) {
+	return s.repo.GetRequest(ctx, id)
+}
+
+func (s *erasureService) CancelRequest(ctx context.Context, id, userID string) (*models.ErasureRequest, error) {
+	request, err := s.repo.GetRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	request.CancelledBy = &userID
+	entry, err := newAuditLog(userID, AuditErasureCancel, "erasure_request", id, map[string]string{"patientId": request.PatientID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CancelRequest(ctx, request, entry); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRequest(ctx, id)
+}
+
+// Erase carries out a pending request. confirmMRN must repeat the patient's
+// MRN, as the erasure cannot be undone. Stored documents are deleted once the
+// database changes have committed; a file that cannot be deleted is logged.
+func (s *erasureService) Erase(ctx context.Context, id, confirmMRN, userID string) (*models.ErasureRequest, error) {
+	if s.signer == nil {
+		return nil, ErrErasureNotConfigured
+	}
+	request, err := s.repo.GetRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Patient == nil || confirmMRN != request.Patient.MRN {
+		return nil, fmt.Errorf("%w: confirmMrn does not match the patient's MRN", ErrInvalidErasure)
+	}
+
+	request.CompletedBy = &userID
+	entry, err := newAuditLog(userID, AuditPatientErase, "erasure_request", id, map[string]string{"patientId": request.PatientID})
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.repo.Erase(ctx, request, entry, func(result *repository.ErasureResult) error {
+		certificate, err := json.Marshal(ErasureCertificate{
+			RequestID:      request.ID,
+			PatientID:      request.PatientID,
+			Reason:         request.Reason,
+			RequestedBy:    request.RequestedBy,
+			RequestedAt:    request.CreatedAt.UTC().Format(time.RFC3339),
+			ErasedBy:       userID,
+			ErasedAt:       result.ErasedAt.UTC().Format(time.RFC3339),
+			LegalHoldCheck: "passed",
+			Rows:           result.Rows,
+			SigningKeyID:   s.signer.KeyID(),
+		})
+		if err != nil {
+			return err
+		}
+		request.Certificate = string(certificate)
+		request.Signature = s.signer.Sign(certificate)
+		request.SigningKeyID = s.signer.KeyID()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range result.StorageKeys {
+		if err := s.storage.Delete(context.WithoutCancel(ctx), key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			logging.FromContext(ctx).Error("Failed to delete document of erased patient", slog.String("storage_key", key), slog.String("patient_id", request.PatientID), slog.Any("error", err))
+		}
+	}
+	return s.repo.GetRequest(ctx, id)
+}
+
+// VerifyCertificate checks the request's certificate against the configured
+// key. Certificates signed with an earlier key cannot be checked here and
+// are reported as invalid; publicKey is empty in that case.
+func (s *erasureService) VerifyCertificate(ctx context.Context, request *models.ErasureRequest) (string, bool) {
+	if s.signer == nil || request.SigningKeyID != s.signer.KeyID() {
+		return "", false
+	}
+	return s.signer.PublicKey(), s.signer.Verify([]byte(request.Certificate), request.Signature)
+}
+
+func (s *erasureService) PlaceHold(ctx context.Context, patientID, reason, userID string) (*models.LegalHold, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidErasure)
+	}
+
+	hold := &models.LegalHold{PatientID: patientID, Reason: reason, PlacedBy: userID}
+	entry, err := newAuditLog(userID, AuditLegalHoldPlace, "legal_hold", "", map[string]string{"patientId": patientID, "reason": reason})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateHold(ctx, hold, entry); err != nil {
+		return nil, err
+	}
+	return s.repo.GetHold(ctx, hold.ID)
+}
+
+func (s *erasureService) GetHolds(ctx context.Context, patientID string) ([]*models.LegalHold, error) {
+	return s.repo.GetHolds(ctx, patientID)
+}
+
+func (s *erasureService) ReleaseHold(ctx context.Context, id, userID string) (*models.LegalHold, error) {
+	hold, err := s.repo.GetHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	hold.ReleasedBy = &userID
+	entry, err := newAuditLog(userID, AuditLegalHoldRelease, "legal_hold", id, map[string]string{"patientId": hold.PatientID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReleaseHold(ctx, hold, entry); err != nil {
+		return nil, err
+	}
+	return s.repo.GetHold(ctx, id)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidFee       = errors.New("invalid fee")
+	ErrDuplicateFeeCode = errors.New("a fee with this code already exists")
+)
+
+type FeeService interface {
+	CreateFee(ctx context.Context, fee *models.Fee) error
+	GetFees(ctx context.Context, includeInactive bool) ([]*models.Fee, error)
+	GetFeeByID(ctx context.Context, id string) (*models.Fee, error)
+	UpdateFee(ctx context.Context, id string, fee *models.Fee) (*models.Fee, error)
+}
+
+type feeService struct {
+	repo repository.FeeRepository
+}
+
+func NewFeeService(repo repository.FeeRepository) FeeService {
+	return &feeService{repo}
+}
+
+func (s *feeService) CreateFee(ctx context.Context, fee *models.Fee) error {
+	if err := validateFee(fee); err != nil {
+		return err
+	}
+	return translateFeeError(s.repo.Create(ctx, fee))
+}
+
+func (s *feeService) GetFees(ctx context.Context, includeInactive bool) ([]*models.Fee, error) {
+	return s.repo.GetAll(ctx, includeInactive)
+}
+
+func (s *feeService) GetFeeByID(ctx context.Context, id string) (*models.Fee, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *feeService) UpdateFee(ctx context.Context, id string, updated *models.Fee) (*models.Fee, error) {
+	fee, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateFee(updated); err != nil {
+		return nil, err
+	}
+
+	fee.Code = updated.Code
+	fee.Name = updated.Name
+	fee.Description = updated.Description
+	fee.Price = updated.Price
+	fee.TaxRate = updated.TaxRate
+	fee.Active = updated.Active
+	if err := translateFeeError(s.repo.Update(ctx, fee)); err != nil {
+		return nil, err
+	}
+	return fee, nil
+}
+
+func validateFee(fee *models.Fee) error {
+	if fee.Price.IsNegative() {
+		return fmt.Errorf("%w: price cannot be negative", ErrInvalidFee)
+	}
+	if err := validatePercent("tax rate", fee.TaxRate); err != nil {
+		return fmt.Errorf("%w: tax rate must be between 0 and 100", ErrInvalidFee)
+	}
+	return nil
+}
+
+func translateFeeError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateFeeCode
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/repository"
+)
+
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusFailing  = "failing"
+)
+
+// HealthCheck checks one dependency the server needs to serve requests.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type ComponentHealth struct {
+	Name     string
+	Status   string
+	Duration time.Duration
+}
+
+type HealthReport struct {
+	Status     string
+	Components []ComponentHealth
+	CheckedAt  time.Time
+}
+
+type HealthService interface {
+	Ready(ctx context.Context) HealthReport
+}
+
+type healthService struct {
+	checks  []HealthCheck
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu     sync.Mutex
+	report HealthReport
+}
+
+// NewHealthService runs each check with timeout and reuses the results for
+// ttl, so frequent probes do not reach the database every time.
+func NewHealthService(timeout, ttl time.Duration, checks ...HealthCheck) HealthService {
+	return &healthService{checks: checks, timeout: timeout, ttl: ttl}
+}
+
+// DatabaseHealthCheck pings the database.
+func DatabaseHealthCheck(repo repository.SchemaRepository) HealthCheck {
+	return HealthCheck{Name: "database", Check: repo.Ping}
+}
+
+// MigrationHealthCheck fails unless the database is cleanly at version.
+func MigrationHealthCheck(repo repository.SchemaRepository, version uint) HealthCheck {
+	return HealthCheck{Name: "migrations", Check: func(ctx context.Context) error {
+		current, dirty, err := repo.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed part way through", current)
+		}
+		if current != version {
+			return fmt.Errorf("database is at migration %d, expected %d", current, version)
+		}
+		return nil
+	}}
+}
+
+// Ready runs the checks, or returns the results of the last run if it is
+// recent enough. The report is failing when every check failed and
+// degraded when some did.
+func (s *healthService) Ready(ctx context.Context) HealthReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.report.CheckedAt.IsZero() && time.Since(s.report.CheckedAt) < s.ttl {
+		return s.report
+	}
+
+	logger := logging.FromContext(ctx)
+	components := make([]ComponentHealth, len(s.checks))
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Results are shared with other callers, so one giving up must not fail them.
+			checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(checkCtx)
+			components[i] = ComponentHealth{Name: check.Name, Status: HealthStatusOK, Duration: time.Since(start)}
+			if err != nil {
+				components[i].Status = HealthStatusFailing
+				logger.Warn("Health check failed", slog.String("component", check.Name), slog.Any("error", err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	failing := 0
+	for _, component := range components {
+		if component.Status != HealthStatusOK {
+			failing++
+		}
+	}
+	status := HealthStatusOK
+	switch {
+	case failing == len(components) && failing > 0:
+		status = HealthStatusFailing
+	case failing > 0:
+		status = HealthStatusDegraded
+	}
+
+	s.report = HealthReport{Status: status, Components: components, CheckedAt: time.Now()}
+	return s.report
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/max-programming/clinic/internal/repository"
+)
+
+type schemaRepository struct {
+	repository.SchemaRepository
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (r *schemaRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	return r.version, r.dirty, r.err
+}
+
+func TestMigrationHealthCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    schemaRepository
+		wantErr string
+	}{
+		{name: "current", repo: schemaRepository{version: 20250811090000}},
+		{name: "behind", repo: schemaRepository{version: 20250512093000}, wantErr: "expected 20250811090000"},
+		{name: "ahead", repo: schemaRepository{version: 20250901000000}, wantErr: "expected 20250811090000"},
+		{name: "dirty", repo: schemaRepository{version: 20250811090000, dirty: true}, wantErr: "failed part way through"},
+		{name: "unreachable", repo: schemaRepository{err: errors.New("connection refused")}, wantErr: "connection refused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := MigrationHealthCheck(&tt.repo, 20250811090000)
+			err := check.Check(context.Background())
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Check() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
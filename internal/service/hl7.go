@@ -0,0 +1,491 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/hl7"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	HL7StatusReceived  = "received"
+	HL7StatusProcessed = "processed"
+	HL7StatusFailed    = "failed"
+	HL7StatusRejected  = "rejected"
+	HL7StatusDuplicate = "duplicate"
+)
+
+var (
+	ErrUnsupportedHL7Message = errors.New("unsupported HL7 message type")
+	ErrHL7UserNotConfigured  = errors.New("HL7 integration user is not configured")
+)
+
+type HL7Service interface {
+	HandleMessage(ctx context.Context, raw []byte) []byte
+	GetAllMessages(ctx context.Context) ([]*models.HL7Message, error)
+	GetMessageByID(ctx context.Context, id string) (*models.HL7Message, error)
+	ReplayMessage(ctx context.Context, id string) (*models.HL7Message, []byte, error)
+}
+
+type hl7Service struct {
+	messageRepo    repository.HL7MessageRepository
+	labResultRepo  repository.LabResultRepository
+	patientService PatientService
+	userService    UserService
+	username       string
+	// assigningAuthority is the PID-3 CX.4 value that marks an identifier as
+	// an MRN issued by this clinic.
+	assigningAuthority string
+}
+
+func NewHL7Service(
+	messageRepo repository.HL7MessageRepository,
+	labResultRepo repository.LabResultRepository,
+	patientService PatientService,
+	userService UserService,
+	username string,
+	assigningAuthority string,
+) HL7Service {
+	return &hl7Service{messageRepo, labResultRepo, patientService, userService, username, assigningAuthority}
+}
+
+func (s *hl7Service) HandleMessage(ctx context.Context, raw []byte) []byte {
+	logger := logging.FromContext(ctx)
+	record := &models.HL7Message{
+		Raw:        string(raw),
+		Status:     HL7StatusReceived,
+		ReceivedAt: time.Now(),
+	}
+
+	msg, err := hl7.Parse(raw)
+	if err != nil {
+		record.Status = HL7StatusRejected
+		record.Error = err.Error()
+		if err := s.messageRepo.Create(ctx, record); err != nil {
+			logger.Error("Failed to persist HL7 message", slog.Any("error", err))
+		}
+		return hl7.BuildAck(nil, hl7.AckReject, err.Error())
+	}
+
+	record.ControlID = msg.ControlID()
+	record.MessageType = msg.Type() + "^" + msg.Trigger()
+	record.SendingApp = msg.SendingApplication()
+	record.SendingFacility = msg.SendingFacility()
+
+	if err := s.messageRepo.Create(ctx, record); err != nil {
+		logger.Error("Failed to persist HL7 message", slog.String("control_id", record.ControlID), slog.Any("error", err))
+		return hl7.BuildAck(msg, hl7.AckError, "message could not be stored")
+	}
+
+	if record.ControlID != "" {
+		previous, err := s.messageRepo.FindProcessed(ctx, record.SendingFacility, record.ControlID)
+		if err == nil {
+			now := time.Now()
+			record.Status = HL7StatusDuplicate
+			record.Error = "duplicate of message " + previous.ID
+			record.PatientID = previous.PatientID
+			record.ProcessedAt = &now
+			if err := s.messageRepo.Update(ctx, record); err != nil {
+				logger.Error("Failed to update HL7 message", slog.String("hl7_message_id", record.ID), slog.Any("error", err))
+			}
+			return hl7.BuildAck(msg, hl7.AckAccept, "")
+		}
+	}
+
+	return s.process(ctx, record, msg)
+}
+
+func (s *hl7Service) GetAllMessages(ctx context.Context) ([]*models.HL7Message, error) {
+	return s.messageRepo.GetAll(ctx)
+}
+
+func (s *hl7Service) GetMessageByID(ctx context.Context, id string) (*models.HL7Message, error) {
+	return s.messageRepo.GetByID(ctx, id)
+}
+
+func (s *hl7Service) ReplayMessage(ctx context.Context, id string) (*models.HL7Message, []byte, error) {
+	record, err := s.messageRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg, err := hl7.Parse([]byte(record.Raw))
+	if err != nil {
+		return record, nil, err
+	}
+
+	ack := s.process(ctx, record, msg)
+	return record, ack, nil
+}
+
+func (s *hl7Service) process(ctx context.Context, record *models.HL7Message, msg *hl7.Message) []byte {
+	patientID, err := s.dispatch(ctx, msg, record.ID)
+
+	now := time.Now()
+	record.ProcessedAt = &now
+	if patientID != "" {
+		record.PatientID = &patientID
+	}
+
+	code := hl7.AckAccept
+	text := ""
+	switch {
+	case err == nil:
+		record.Status = HL7StatusProcessed
+		record.Error = ""
+	case errors.Is(err, ErrUnsupportedHL7Message):
+		record.Status = HL7StatusRejected
+		code = hl7.AckReject
+	default:
+		record.Status = HL7StatusFailed
+		code = hl7.AckError
+	}
+	if err != nil {
+		record.Error = err.Error()
+		text = err.Error()
+	}
+
+	if err := s.messageRepo.Update(ctx, record); err != nil {
+		logging.FromContext(ctx).Error("Failed to update HL7 message", slog.String("hl7_message_id", record.ID), slog.Any("error", err))
+	}
+
+	return hl7.BuildAck(msg, code, text)
+}
+
+func (s *hl7Service) dispatch(ctx context.Context, msg *hl7.Message, messageID string) (string, error) {
+	switch msg.Type() + "^" + msg.Trigger() {
+	case "ADT^A04":
+		return s.registerPatient(ctx, msg)
+	case "ADT^A08":
+		return s.updatePatient(ctx, msg)
+	case "ORU^R01":
+		return s.storeLabResults(ctx, msg, messageID)
+	}
+	return "", fmt.Errorf("%w: %s^%s", ErrUnsupportedHL7Message, msg.Type(), msg.Trigger())
+}
+
+func (s *hl7Service) registerPatient(ctx context.Context, msg *hl7.Message) (string, error) {
+	pid, ref, err := s.patientSegment(msg)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.integrationUser(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	patient, err := patientFromPID(pid)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := s.findPatient(ctx, ref)
+	if err == nil {
+		patient.UpdatedBy = user.ID
+		return existing.ID, s.patientService.UpdatePatient(ctx, existing.ID, patient)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if ref.mrn != "" {
+		return "", logging.Sensitive(fmt.Errorf("no patient with MRN %s", ref.mrn), ref.mrn)
+	}
+
+	ref.identifier.CreatedBy = &user.ID
+	patient.Gender = cmp.Or(patient.Gender, "Unknown")
+	patient.Identifiers = []models.PatientIdentifier{*ref.identifier}
+	patient.CreatedBy = user.ID
+	patient.UpdatedBy = user.ID
+	if err := s.patientService.CreatePatient(ctx, patient); err != nil {
+		return "", err
+	}
+	return patient.ID, nil
+}
+
+func (s *hl7Service) updatePatient(ctx context.Context, msg *hl7.Message) (string, error) {
+	pid, ref, err := s.patientSegment(msg)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.integrationUser(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := s.findPatient(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	patient, err := patientFromPID(pid)
+	if err != nil {
+		return existing.ID, err
+	}
+	patient.UpdatedBy = user.ID
+
+	return existing.ID, s.patientService.UpdatePatient(ctx, existing.ID, patient)
+}
+
+func (s *hl7Service) storeLabResults(ctx context.Context, msg *hl7.Message, messageID string) (string, error) {
+	_, ref, err := s.patientSegment(msg)
+	if err != nil {
+		return "", err
+	}
+
+	patient, err := s.findPatient(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	var results []*models.LabResult
+	var orderNumber string
+	var orderTime *time.Time
+	for _, seg := range msg.Segments {
+		switch seg.Name {
+		case "OBR":
+			orderNumber = seg.Component(3, 1)
+			if orderNumber == "" {
+				orderNumber = seg.Component(2, 1)
+			}
+			orderTime = parseHL7Time(seg.Component(7, 1))
+		case "OBX":
+			result := &models.LabResult{
+				PatientID:      patient.ID,
+				HL7MessageID:   &messageID,
+				OrderNumber:    orderNumber,
+				TestCode:       seg.Component(3, 1),
+				TestName:       seg.Component(3, 2),
+				ValueType:      seg.Value(2),
+				Value:          observationValue(seg),
+				Units:          seg.Component(6, 1),
+				ReferenceRange: seg.Value(7),
+				AbnormalFlag:   seg.Component(8, 1),
+				ResultStatus:   seg.Value(11),
+				ObservedAt:     parseHL7Time(seg.Component(14, 1)),
+			}
+			if result.TestCode == "" {
+				return patient.ID, errors.New("OBX segment is missing an observation identifier")
+			}
+			if result.ObservedAt == nil {
+				result.ObservedAt = orderTime
+			}
+			results = append(results, result)
+		}
+	}
+
+	if len(results) == 0 {
+		return patient.ID, errors.New("message contains no OBX segments")
+	}
+
+	return patient.ID, s.labResultRepo.ReplaceForMessage(ctx, messageID, results)
+}
+
+func (s *hl7Service) integrationUser(ctx context.Context) (*models.User, error) {
+	if s.username == "" {
+		return nil, ErrHL7UserNotConfigured
+	}
+	return s.userService.GetUserByUsername(ctx, s.username)
+}
+
+// hl7PatientRef identifies the patient a message refers to: either a
+// clinic-issued MRN or an identifier assigned by the sending system.
+type hl7PatientRef struct {
+	mrn        string
+	identifier *models.PatientIdentifier
+}
+
+func (r hl7PatientRef) String() string {
+	if r.mrn != "" {
+		return "MRN " + r.mrn
+	}
+	return fmt.Sprintf("%s identifier %s", r.identifier.Issuer, r.identifier.Value)
+}
+
+// value is the MRN or identifier the sender gave for the patient.
+func (r hl7PatientRef) value() string {
+	if r.mrn != "" {
+		return r.mrn
+	}
+	return r.identifier.Value
+}
+
+func (s *hl7Service) patientSegment(msg *hl7.Message) (*hl7.Segment, hl7PatientRef, error) {
+	pid := msg.Segment("PID")
+	if pid == nil {
+		return nil, hl7PatientRef{}, errors.New("message is missing the PID segment")
+	}
+
+	ref, ok := s.patientRef(pid, msg.SendingFacility())
+	if !ok {
+		return nil, hl7PatientRef{}, errors.New("PID-3 does not contain a medical record number")
+	}
+
+	return pid, ref, nil
+}
+
+// patientRef prefers an MRN assigned by this clinic (CX.4 matching the
+// configured assigning authority). Otherwise the PID-3 repetition typed "MR",
+// or the first one when no type codes are sent, is treated as the sender's
+// own MRN and matched against the patient's hospital_mrn identifiers.
+func (s *hl7Service) patientRef(pid *hl7.Segment, facility string) (hl7PatientRef, bool) {
+	reps := pid.Repetitions(3)
+	if len(reps) == 0 {
+		return hl7PatientRef{}, false
+	}
+
+	if s.assigningAuthority != "" {
+		for _, rep := range reps {
+			if strings.EqualFold(pid.RepetitionComponent(rep, 4), s.assigningAuthority) {
+				if mrn := pid.RepetitionComponent(rep, 1); mrn != "" {
+					return hl7PatientRef{mrn: mrn}, true
+				}
+			}
+		}
+	}
+
+	external := reps[0]
+	for _, rep := range reps {
+		if pid.RepetitionComponent(rep, 5) == "MR" {
+			external = rep
+			break
+		}
+	}
+
+	value := pid.RepetitionComponent(external, 1)
+	if value == "" {
+		return hl7PatientRef{}, false
+	}
+	issuer := pid.RepetitionComponent(external, 4)
+	if issuer == "" {
+		issuer = facility
+	}
+
+	return hl7PatientRef{identifier: &models.PatientIdentifier{
+		Type:   models.IdentifierHospitalMRN,
+		Issuer: issuer,
+		Value:  value,
+	}}, true
+}
+
+func (s *hl7Service) findPatient(ctx context.Context, ref hl7PatientRef) (*models.Patient, error) {
+	var patient *models.Patient
+	if ref.mrn != "" {
+		found, err := s.patientService.GetPatientByMRN(ctx, ref.mrn)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		patient = found
+	} else {
+		patients, err := s.patientService.LookupPatients(ctx, repository.PatientLookup{
+			IdentifierType: ref.identifier.Type,
+			Issuer:         ref.identifier.Issuer,
+			Value:          ref.identifier.Value,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(patients) > 0 {
+			patient = patients[0]
+		}
+	}
+
+	if patient == nil {
+		return nil, logging.Sensitive(fmt.Errorf("no patient with %s: %w", ref, gorm.ErrRecordNotFound), ref.value())
+	}
+	return patient, nil
+}
+
+func patientFromPID(pid *hl7.Segment) (*models.Patient, error) {
+	family := pid.Component(5, 1)
+	given := joinNonEmpty(" ", pid.Component(5, 2), pid.Component(5, 3))
+	if family == "" && given == "" {
+		return nil, errors.New("PID-5 does not contain a patient name")
+	}
+
+	patient := &models.Patient{
+		GivenName:         given,
+		FamilyName:        family,
+		DateOfBirth:       parseHL7Time(pid.Component(7, 1)),
+		Gender:            hl7Gender(pid.Value(8)),
+		PreferredLanguage: pid.Component(15, 1),
+		Address: models.Address{
+			Line1:      pid.Component(11, 1),
+			Line2:      pid.Component(11, 2),
+			City:       pid.Component(11, 3),
+			State:      pid.Component(11, 4),
+			PostalCode: pid.Component(11, 5),
+			Country:    pid.Component(11, 6),
+		},
+	}
+
+	for _, rep := range pid.Repetitions(13) {
+		if pid.RepetitionComponent(rep, 3) == "Internet" || pid.RepetitionComponent(rep, 2) == "NET" {
+			if patient.Email == "" {
+				patient.Email = pid.RepetitionComponent(rep, 4)
+			}
+			continue
+		}
+		if patient.Phone == "" {
+			patient.Phone = pid.RepetitionComponent(rep, 1)
+		}
+	}
+
+	return patient, nil
+}
+
+// hl7Gender returns "" for a missing or unrecognised PID-8, so that an
+// update leaves the stored gender alone.
+func hl7Gender(code string) string {
+	switch strings.ToUpper(code) {
+	case "M":
+		return "Male"
+	case "F":
+		return "Female"
+	case "O":
+		return "Other"
+	case "U":
+		return "Unknown"
+	default:
+		return ""
+	}
+}
+
+func observationValue(obx *hl7.Segment) string {
+	switch obx.Value(2) {
+	case "CE", "CWE":
+		if text := obx.Component(5, 2); text != "" {
+			return text
+		}
+		return obx.Component(5, 1)
+	}
+	return obx.Value(5)
+}
+
+func parseHL7Time(value string) *time.Time {
+	t, ok := hl7.ParseTime(value)
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var parts []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, sep)
+}
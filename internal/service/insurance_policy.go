@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPolicy = errors.New("invalid insurance policy")
+	ErrPolicyInUse   = errors.New("insurance policy has claims and cannot be deleted")
+)
+
+type InsurancePolicyService interface {
+	AddPolicy(ctx context.Context, policy *models.InsurancePolicy) error
+	GetPatientPolicies(ctx context.Context, patientID string) ([]*models.InsurancePolicy, error)
+	UpdatePolicy(ctx context.Context, patientID, id string, policy *models.InsurancePolicy) (*models.InsurancePolicy, error)
+	DeletePolicy(ctx context.Context, patientID, id string) error
+}
+
+type insurancePolicyService struct {
+	repo repository.InsurancePolicyRepository
+}
+
+func NewInsurancePolicyService(repo repository.InsurancePolicyRepository) InsurancePolicyService {
+	return &insurancePolicyService{repo}
+}
+
+func (s *insurancePolicyService) AddPolicy(ctx context.Context, policy *models.InsurancePolicy) error {
+	if err := validatePolicy(policy); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, policy); err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return gorm.ErrRecordNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *insurancePolicyService) GetPatientPolicies(ctx context.Context, patientID string) ([]*models.InsurancePolicy, error) {
+	return s.repo.GetByPatientID(ctx, patientID)
+}
+
+func (s *insurancePolicyService) UpdatePolicy(ctx context.Context, patientID, id string, updated *models.InsurancePolicy) (*models.InsurancePolicy, error) {
+	policy, err := s.repo.GetByID(ctx, patientID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.ID = policy.ID
+	updated.PatientID = policy.PatientID
+	updated.CreatedBy = policy.CreatedBy
+	updated.CreatedAt = policy.CreatedAt
+	if err := validatePolicy(updated); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *insurancePolicyService) DeletePolicy(ctx context.Context, patientID, id string) error {
+	err := s.repo.Delete(ctx, patientID, id)
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return ErrPolicyInUse
+	}
+	return err
+}
+
+func validatePolicy(policy *models.InsurancePolicy) error {
+	priorities := []string{models.PolicyPriorityPrimary, models.PolicyPrioritySecondary, models.PolicyPriorityTertiary}
+	if !slices.Contains(priorities, policy.Priority) {
+		return fmt.Errorf("%w: unknown priority %q", ErrInvalidPolicy, policy.Priority)
+	}
+	relationships := []string{models.SubscriberSelf, models.SubscriberSpouse, models.SubscriberChild, models.SubscriberOther}
+	if !slices.Contains(relationships, policy.Relationship) {
+		return fmt.Errorf("%w: unknown relationship %q", ErrInvalidPolicy, policy.Relationship)
+	}
+	if policy.Relationship != models.SubscriberSelf && (policy.SubscriberGivenName == "" || policy.SubscriberFamilyName == "") {
+		return fmt.Errorf("%w: subscriber name is required for dependents", ErrInvalidPolicy)
+	}
+	if policy.ValidTo != nil && policy.ValidTo.Before(policy.ValidFrom) {
+		return fmt.Errorf("%w: policy ends before it starts", ErrInvalidPolicy)
+	}
+	if policy.CoveragePercent.IsNegative() || policy.CoveragePercent.GreaterThan(hundredPercent) {
+		return fmt.Errorf("%w: coverage must be between 0 and 100", ErrInvalidPolicy)
+	}
+	if policy.Copay.IsNegative() {
+		return fmt.Errorf("%w: copay cannot be negative", ErrInvalidPolicy)
+	}
+	return nil
+}
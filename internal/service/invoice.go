@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+const AuditInvoiceVoid = "invoice.void"
+
+var ErrInvalidInvoice = errors.New("invalid invoice")
+
+var hundredPercent = decimal.NewFromInt(100)
+
+type InvoiceSettings struct {
+	Currency             string
+	NumberPrefix         string
+	FiscalYearStartMonth time.Month
+	DueDays              int
+}
+
+// InvoiceItemInput describes a line to bill. When FeeID is set, a missing
+// Description, UnitPrice or TaxRate is taken from the catalog fee.
+type InvoiceItemInput struct {
+	FeeID           *string
+	Description     string
+	Quantity        decimal.Decimal
+	UnitPrice       *decimal.Decimal
+	DiscountPercent decimal.Decimal
+	TaxRate         *decimal.Decimal
+}
+
+type InvoiceService interface {
+	CreateInvoice(ctx context.Context, invoice *models.Invoice, items []InvoiceItemInput) error
+	UpdateInvoice(ctx context.Context, id string, invoice *models.Invoice, items []InvoiceItemInput) (*models.Invoice, error)
+	IssueInvoice(ctx context.Context, id, userID string, dueDate *time.Time) (*models.Invoice, error)
+	VoidInvoice(ctx context.Context, id, userID, reason string) (*models.Invoice, error)
+	DeleteInvoice(ctx context.Context, id string) error
+	GetInvoiceByID(ctx context.Context, id string) (*models.Invoice, error)
+	SearchInvoices(ctx context.Context, filter repository.InvoiceFilter) ([]*models.Invoice, int64, error)
+}
+
+type invoiceService struct {
+	repo         repository.InvoiceRepository
+	feeRepo      repository.FeeRepository
+	patientRepo  repository.PatientRepository
+	auditService AuditService
+	settings     InvoiceSettings
+}
+
+func NewInvoiceService(
+	repo repository.InvoiceRepository,
+	feeRepo repository.FeeRepository,
+	patientRepo repository.PatientRepository,
+	auditService AuditService,
+	settings InvoiceSettings,
+) InvoiceService {
+	return &invoiceService{repo, feeRepo, patientRepo, auditService, settings}
+}
+
+func (s *invoiceService) CreateInvoice(ctx context.Context, invoice *models.Invoice, items []InvoiceItemInput) error {
+	patient, err := s.patientRepo.GetByID(ctx, invoice.PatientID)
+	if err != nil {
+		return err
+	}
+	if patient.MergedIntoID != nil {
+		return fmt.Errorf("%w: patient has been merged into %s", ErrInvalidInvoice, *patient.MergedIntoID)
+	}
+
+	if err := s.prepare(ctx, invoice, items); err != nil {
+		return err
+	}
+	invoice.Status = models.InvoiceStatusDraft
+	invoice.Currency = s.settings.Currency
+	invoice.AmountPaid = decimal.Zero
+
+	return s.repo.Create(ctx, invoice)
+}
+
+// UpdateInvoice replaces the items, discount and notes of a draft.
+func (s *invoiceService) UpdateInvoice(ctx context.Context, id string, updated *models.Invoice, items []InvoiceItemInput) (*models.Invoice, error) {
+	invoice, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != models.InvoiceStatusDraft {
+		return nil, repository.ErrInvoiceNotDraft
+	}
+
+	invoice.AppointmentID = updated.AppointmentID
+	invoice.DiscountPercent = updated.DiscountPercent
+	invoice.Notes = updated.Notes
+	invoice.UpdatedBy = updated.UpdatedBy
+	if err := s.prepare(ctx, invoice, items); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateDraft(ctx, invoice); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *invoiceService) IssueInvoice(ctx context.Context, id, userID string, dueDate *time.Time) (*models.Invoice, error) {
+	invoice, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != models.InvoiceStatusDraft {
+		return nil, repository.ErrInvoiceNotDraft
+	}
+	if len(invoice.Items) == 0 {
+		return nil, fmt.Errorf("%w: cannot issue an invoice without items", ErrInvalidInvoice)
+	}
+
+	now := time.Now()
+	if dueDate == nil {
+		due := now.AddDate(0, 0, s.settings.DueDays)
+		dueDate = &due
+	} else if dueDate.Before(now.Truncate(24 * time.Hour)) {
+		return nil, fmt.Errorf("%w: due date is in the past", ErrInvalidInvoice)
+	}
+
+	fiscalYear := utils.FiscalYear(now, s.settings.FiscalYearStartMonth)
+	invoice.FiscalYear = &fiscalYear
+	invoice.IssuedAt = &now
+	invoice.DueDate = dueDate
+	invoice.UpdatedBy = userID
+
+	if err := s.repo.Issue(ctx, invoice, s.settings.NumberPrefix); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *invoiceService) VoidInvoice(ctx context.Context, id, userID, reason string) (*models.Invoice, error) {
+	now := time.Now()
+	invoice := &models.Invoice{
+		ID:         id,
+		VoidedAt:   &now,
+		VoidReason: reason,
+		UpdatedBy:  userID,
+	}
+	if err := s.repo.Void(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, userID, AuditInvoiceVoid, "invoice", id, map[string]string{"reason": reason}); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit invoice void", slog.String("invoice_id", id), slog.Any("error", err))
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *invoiceService) DeleteInvoice(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *invoiceService) GetInvoiceByID(ctx context.Context, id string) (*models.Invoice, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *invoiceService) SearchInvoices(ctx context.Context, filter repository.InvoiceFilter) ([]*models.Invoice, int64, error) {
+	return s.repo.Search(ctx, filter)
+}
+
+// prepare builds the invoice items from the inputs, validates the amounts and
+// recalculates the totals. Fee prices are copied onto the item so later
+// catalog changes do not alter existing invoices.
+func (s *invoiceService) prepare(ctx context.Context, invoice *models.Invoice, inputs []InvoiceItemInput) error {
+	if err := validatePercent("discount", invoice.DiscountPercent); err != nil {
+		return err
+	}
+
+	var feeIDs []string
+	for _, input := range inputs {
+		if input.FeeID != nil {
+			feeIDs = append(feeIDs, *input.FeeID)
+		}
+	}
+	fees := make(map[string]*models.Fee)
+	if len(feeIDs) > 0 {
+		found, err := s.feeRepo.GetByIDs(ctx, feeIDs)
+		if err != nil {
+			return err
+		}
+		for _, fee := range found {
+			fees[fee.ID] = fee
+		}
+	}
+
+	invoice.Items = make([]models.InvoiceItem, len(inputs))
+	for i, input := range inputs {
+		item := models.InvoiceItem{
+			FeeID:           input.FeeID,
+			Description:     input.Description,
+			Quantity:        input.Quantity,
+			DiscountPercent: input.DiscountPercent,
+		}
+		if input.FeeID != nil {
+			fee, ok := fees[*input.FeeID]
+			if !ok {
+				return fmt.Errorf("%w: item %d references an unknown fee", ErrInvalidInvoice, i+1)
+			}
+			if !fee.Active {
+				return fmt.Errorf("%w: fee %s is no longer active", ErrInvalidInvoice, fee.Code)
+			}
+			if item.Description == "" {
+				item.Description = fee.Name
+			}
+			item.UnitPrice = fee.Price
+			item.TaxRate = fee.TaxRate
+		} else if input.UnitPrice == nil {
+			return fmt.Errorf("%w: item %d needs a unit price or fee", ErrInvalidInvoice, i+1)
+		}
+		if input.UnitPrice != nil {
+			item.UnitPrice = *input.UnitPrice
+		}
+		if input.TaxRate != nil {
+			item.TaxRate = *input.TaxRate
+		}
+
+		if item.Description == "" {
+			return fmt.Errorf("%w: item %d needs a description or fee", ErrInvalidInvoice, i+1)
+		}
+		if !item.Quantity.IsPositive() {
+			return fmt.Errorf("%w: item %d quantity must be positive", ErrInvalidInvoice, i+1)
+		}
+		if item.UnitPrice.IsNegative() {
+			return fmt.Errorf("%w: item %d unit price cannot be negative", ErrInvalidInvoice, i+1)
+		}
+		if err := validatePercent("discount", item.DiscountPercent); err != nil {
+			return err
+		}
+		if err := validatePercent("tax rate", item.TaxRate); err != nil {
+			return err
+		}
+		invoice.Items[i] = item
+	}
+
+	invoice.Recalculate()
+	return nil
+}
+
+func validatePercent(name string, value decimal.Decimal) error {
+	if value.IsNegative() || value.GreaterThan(hundredPercent) {
+		return fmt.Errorf("%w: %s must be between 0 and 100", ErrInvalidInvoice, name)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+func amount(value string) *decimal.Decimal {
+	d := decimal.RequireFromString(value)
+	return &d
+}
+
+func TestPrepareInvoice(t *testing.T) {
+	tests := []struct {
+		name     string
+		discount string
+		items    []InvoiceItemInput
+		total    string
+		wantErr  bool
+	}{
+		{
+			name:     "valid",
+			discount: "10",
+			items: []InvoiceItemInput{
+				{Description: "Consultation", Quantity: decimal.NewFromInt(1), UnitPrice: amount("50.00"), TaxRate: amount("20")},
+			},
+			total: "54.00",
+		},
+		{
+			name:     "no items",
+			discount: "0",
+			total:    "0",
+		},
+		{
+			name:     "invoice discount over 100",
+			discount: "100.01",
+			wantErr:  true,
+		},
+		{
+			name:     "no unit price or fee",
+			discount: "0",
+			items:    []InvoiceItemInput{{Description: "Consultation", Quantity: decimal.NewFromInt(1)}},
+			wantErr:  true,
+		},
+		{
+			name:     "no description",
+			discount: "0",
+			items:    []InvoiceItemInput{{Quantity: decimal.NewFromInt(1), UnitPrice: amount("50.00")}},
+			wantErr:  true,
+		},
+		{
+			name:     "zero quantity",
+			discount: "0",
+			items:    []InvoiceItemInput{{Description: "Consultation", UnitPrice: amount("50.00")}},
+			wantErr:  true,
+		},
+		{
+			name:     "negative unit price",
+			discount: "0",
+			items:    []InvoiceItemInput{{Description: "Refund", Quantity: decimal.NewFromInt(1), UnitPrice: amount("-5.00")}},
+			wantErr:  true,
+		},
+		{
+			name:     "negative line discount",
+			discount: "0",
+			items: []InvoiceItemInput{
+				{Description: "Consultation", Quantity: decimal.NewFromInt(1), UnitPrice: amount("50.00"), DiscountPercent: decimal.NewFromInt(-1)},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "tax rate over 100",
+			discount: "0",
+			items: []InvoiceItemInput{
+				{Description: "Consultation", Quantity: decimal.NewFromInt(1), UnitPrice: amount("50.00"), TaxRate: amount("150")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &invoiceService{}
+			invoice := &models.Invoice{DiscountPercent: decimal.RequireFromString(tt.discount)}
+			err := s.prepare(context.Background(), invoice, tt.items)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInvoice) {
+					t.Fatalf("prepare() error = %v, want ErrInvalidInvoice", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepare() error = %v", err)
+			}
+			if !invoice.Total.Equal(decimal.RequireFromString(tt.total)) {
+				t.Errorf("total = %s, want %s", invoice.Total, tt.total)
+			}
+		})
+	}
+}
+
+// issueRepository serves one invoice and records what Issue was asked to do.
+type issueRepository struct {
+	repository.InvoiceRepository
+	invoice *models.Invoice
+	issued  *models.Invoice
+	prefix  string
+}
+
+func (r *issueRepository) GetByID(ctx context.Context, id string) (*models.Invoice, error) {
+	invoice := *r.invoice
+	return &invoice, nil
+}
+
+func (r *issueRepository) Issue(ctx context.Context, invoice *models.Invoice, prefix string) error {
+	r.issued = invoice
+	r.prefix = prefix
+	return nil
+}
+
+func TestIssueInvoice(t *testing.T) {
+	item := models.InvoiceItem{Description: "Consultation"}
+	past := time.Now().AddDate(0, 0, -2)
+	future := time.Now().AddDate(0, 0, 7)
+
+	tests := []struct {
+		name    string
+		invoice models.Invoice
+		dueDate *time.Time
+		wantErr error
+		wantDue time.Time
+	}{
+		{
+			name:    "default due date",
+			invoice: models.Invoice{Status: models.InvoiceStatusDraft, Items: []models.InvoiceItem{item}},
+			wantDue: time.Now().AddDate(0, 0, 30),
+		},
+		{
+			name:    "given due date",
+			invoice: models.Invoice{Status: models.InvoiceStatusDraft, Items: []models.InvoiceItem{item}},
+			dueDate: &future,
+			wantDue: future,
+		},
+		{
+			name:    "already issued",
+			invoice: models.Invoice{Status: models.InvoiceStatusIssued, Items: []models.InvoiceItem{item}},
+			wantErr: repository.ErrInvoiceNotDraft,
+		},
+		{
+			name:    "no items",
+			invoice: models.Invoice{Status: models.InvoiceStatusDraft},
+			wantErr: ErrInvalidInvoice,
+		},
+		{
+			name:    "due date in the past",
+			invoice: models.Invoice{Status: models.InvoiceStatusDraft, Items: []models.InvoiceItem{item}},
+			dueDate: &past,
+			wantErr: ErrInvalidInvoice,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &issueRepository{invoice: &tt.invoice}
+			s := &invoiceService{repo: repo, settings: InvoiceSettings{
+				NumberPrefix:         "INV",
+				FiscalYearStartMonth: time.April,
+				DueDays:              30,
+			}}
+
+			_, err := s.IssueInvoice(context.Background(), "inv-1", "user-1", tt.dueDate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("IssueInvoice() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if repo.issued != nil {
+					t.Error("a rejected invoice was numbered")
+				}
+				return
+			}
+
+			if repo.prefix != "INV" {
+				t.Errorf("prefix = %q, want INV", repo.prefix)
+			}
+			wantYear := utils.FiscalYear(time.Now(), time.April)
+			if repo.issued.FiscalYear == nil || *repo.issued.FiscalYear != wantYear {
+				t.Errorf("fiscal year = %v, want %d", repo.issued.FiscalYear, wantYear)
+			}
+			if repo.issued.UpdatedBy != "user-1" || repo.issued.IssuedAt == nil {
+				t.Errorf("issued by %q at %v", repo.issued.UpdatedBy, repo.issued.IssuedAt)
+			}
+			if repo.issued.DueDate == nil || repo.issued.DueDate.Sub(tt.wantDue).Abs() > time.Minute {
+				t.Errorf("due date = %v, want %v", repo.issued.DueDate, tt.wantDue)
+			}
+		})
+	}
+}
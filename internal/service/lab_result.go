@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+)
+
+type LabResultService interface {
+	GetPatientLabResults(ctx context.Context, patientID string) ([]*models.LabResult, error)
+	GetLabResultByID(ctx context.Context, id string) (*models.LabResult, error)
+	SearchLabResults(ctx context.Context, filter repository.LabResultFilter) ([]*models.LabResult, int64, error)
+}
+
+type labResultService struct {
+	repo repository.LabResultRepository
+}
+
+func NewLabResultService(repo repository.LabResultRepository) LabResultService {
+	return &labResultService{repo}
+}
+
+func (s *labResultService) GetPatientLabResults(ctx context.Context, patientID string) ([]*models.LabResult, error) {
+	return s.repo.GetByPatientID(ctx, patientID)
+}
+
+func (s *labResultService) GetLabResultByID(ctx context.Context, id string) (*models.LabResult, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *labResultService) SearchLabResults(ctx context.Context, filter repository.LabResultFilter) ([]*models.LabResult, int64, error) {
+	return s.repo.Search(ctx, filter)
+}
@@ -1,58 +1,183 @@
 package service
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/metrics"
 	"github.com/max-programming/clinic/internal/models"
 	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/utils"
+)
+
+var (
+	ErrInvalidPatient = errors.New("invalid patient")
+	ErrInvalidMRN     = errors.New("invalid medical record number")
 )
 
 type PatientService interface {
-	CreatePatient(patient *models.Patient) error
-	GetAllPatients() ([]*models.Patient, error)
-	GetPatientByID(id string) (*models.Patient, error)
-	GetPatientByIDWithUsers(id string) (*models.Patient, *models.User, *models.User, error)
-	UpdatePatient(id string, patient *models.Patient) error
-	UpdatePatientNotes(id string, notes string, updatedBy string) error
-	DeletePatient(id string) error
+	CreatePatient(ctx context.Context, patient *models.Patient) error
+	RegisterPatient(ctx context.Context, patient *models.Patient, allowDuplicate bool) error
+	GetAllPatients(ctx context.Context) ([]*models.Patient, error)
+	GetPatientByID(ctx context.Context, id string) (*models.Patient, error)
+	GetPatientByMRN(ctx context.Context, mrn string) (*models.Patient, error)
+	SearchPatients(ctx context.Context, filter repository.PatientFilter) ([]*models.Patient, int64, error)
+	LookupPatients(ctx context.Context, lookup repository.PatientLookup) ([]*models.Patient, error)
+	GetPatientByIDWithUsers(ctx context.Context, id string) (*models.Patient, *models.User, *models.User, error)
+	UpdatePatient(ctx context.Context, id string, patient *models.Patient) error
+	DeletePatient(ctx context.Context, id string) error
 }
 
 type patientService struct {
-	repo repository.PatientRepository
+	repo         repository.PatientRepository
+	auditService AuditService
 }
 
-func NewPatientService(repo repository.PatientRepository) PatientService {
-	return &patientService{repo}
+func NewPatientService(repo repository.PatientRepository, auditService AuditService) PatientService {
+	return &tracedPatientService{&patientService{repo, auditService}}
 }
 
-func (s *patientService) CreatePatient(patient *models.Patient) error {
-	return s.repo.Create(patient)
-}
+func (s *patientService) CreatePatient(ctx context.Context, patient *models.Patient) error {
+	if err := validatePatient(patient); err != nil {
+		return err
+	}
+	if patient.GivenName != "" || patient.FamilyName != "" {
+		patient.Name = fullName(patient.GivenName, patient.FamilyName)
+	}
+
+	seq, err := s.repo.NextMRNSequence(ctx)
+	if err != nil {
+		return err
+	}
+	patient.MRN = utils.FormatMRN(seq)
 
-func (s *patientService) GetAllPatients() ([]*models.Patient, error) {
-	return s.repo.GetAll()
+	if err := s.repo.Create(ctx, patient); err != nil {
+		return err
+	}
+	metrics.AddPatientsCreated(metrics.SourceRegistration, 1)
+	return nil
 }
 
-func (s *patientService) GetPatientByID(id string) (*models.Patient, error) {
-	return s.repo.GetByID(id)
+// RegisterPatient creates a patient after checking for probable duplicates.
+// Matches are returned as a *DuplicatePatientError unless allowDuplicate is
+// set, in which case the override is audited.
+func (s *patientService) RegisterPatient(ctx context.Context, patient *models.Patient, allowDuplicate bool) error {
+	if err := validatePatient(patient); err != nil {
+		return err
+	}
+
+	matches, err := findDuplicates(ctx, s.repo, patient)
+	if err != nil {
+		return err
+	}
+	if len(matches) > 0 && !allowDuplicate {
+		return &DuplicatePatientError{Matches: matches}
+	}
+
+	if err := s.CreatePatient(ctx, patient); err != nil {
+		return err
+	}
+
+	if len(matches) > 0 {
+		details := make([]map[string]any, len(matches))
+		for i, match := range matches {
+			details[i] = map[string]any{"patientId": match.Patient.ID, "score": match.Score}
+		}
+		err := s.auditService.Record(ctx, patient.CreatedBy, AuditPatientDuplicateOverride, "patient", patient.ID, map[string]any{"matches": details})
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to audit duplicate override", slog.String("patient_id", patient.ID), slog.Any("error", err))
+		}
+	}
+	return nil
 }
 
-func (s *patientService) GetPatientByIDWithUsers(id string) (*models.Patient, *models.User, *models.User, error) {
-	return s.repo.GetByIDWithUsers(id)
+func (s *patientService) GetAllPatients(ctx context.Context) ([]*models.Patient, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *patientService) UpdatePatient(id string, updatedPatient *models.Patient) error {
-	return s.repo.Update(id, updatedPatient)
+func (s *patientService) GetPatientByID(ctx context.Context, id string) (*models.Patient, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *patientService) UpdatePatientNotes(id string, notes string, updatedBy string) error {
-	patient, err := s.repo.GetByID(id)
+// GetPatientByMRN follows merges, so the MRN of a merged patient resolves to
+// the surviving record.
+func (s *patientService) GetPatientByMRN(ctx context.Context, mrn string) (*models.Patient, error) {
+	patient, err := s.repo.GetByMRN(ctx, mrn)
 	if err != nil {
+		return nil, err
+	}
+	if patient.MergedIntoID != nil {
+		return s.repo.GetByID(ctx, *patient.MergedIntoID)
+	}
+	return patient, nil
+}
+
+func (s *patientService) SearchPatients(ctx context.Context, filter repository.PatientFilter) ([]*models.Patient, int64, error) {
+	return s.repo.Search(ctx, filter)
+}
+
+func (s *patientService) LookupPatients(ctx context.Context, lookup repository.PatientLookup) ([]*models.Patient, error) {
+	if lookup.MRN != "" && !utils.ValidMRN(lookup.MRN) {
+		return nil, fmt.Errorf("%w: check digit does not match", ErrInvalidMRN)
+	}
+	return s.repo.Lookup(ctx, lookup)
+}
+
+func (s *patientService) GetPatientByIDWithUsers(ctx context.Context, id string) (*models.Patient, *models.User, *models.User, error) {
+	return s.repo.GetByIDWithUsers(ctx, id)
+}
+
+func (s *patientService) UpdatePatient(ctx context.Context, id string, updatedPatient *models.Patient) error {
+	if err := validatePatient(updatedPatient); err != nil {
+		return err
+	}
+	renamed := updatedPatient.GivenName != "" || updatedPatient.FamilyName != ""
+	notesChanged := false
+	if renamed || updatedPatient.MedicalNotes != "" {
+		existing, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if renamed {
+			updatedPatient.Name = fullName(
+				cmp.Or(updatedPatient.GivenName, existing.GivenName),
+				cmp.Or(updatedPatient.FamilyName, existing.FamilyName),
+			)
+		}
+		notesChanged = updatedPatient.MedicalNotes != "" && updatedPatient.MedicalNotes != existing.MedicalNotes
+	}
+	if err := s.repo.Update(ctx, id, updatedPatient); err != nil {
 		return err
 	}
-	patient.MedicalNotes = notes
-	patient.UpdatedBy = updatedBy
-	return s.repo.Update(id, patient)
+	if notesChanged {
+		metrics.IncPatientNotesUpdated()
+	}
+	return nil
+}
+
+func (s *patientService) DeletePatient(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func validatePatient(patient *models.Patient) error {
+	if patient.DateOfBirth != nil {
+		now := time.Now()
+		if patient.DateOfBirth.After(now) {
+			return fmt.Errorf("%w: date of birth cannot be in the future", ErrInvalidPatient)
+		}
+		if patient.DateOfBirth.Before(now.AddDate(-150, 0, 0)) {
+			return fmt.Errorf("%w: date of birth is more than 150 years ago", ErrInvalidPatient)
+		}
+	}
+	return nil
 }
 
-func (s *patientService) DeletePatient(id string) error {
-	return s.repo.Delete(id)
+func fullName(given, family string) string {
+	return strings.TrimSpace(given + " " + family)
 }
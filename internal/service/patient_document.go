@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/storage"
+)
+
+const (
+	AuditDocumentDownload = "document.download"
+	AuditDocumentDelete   = "document.delete"
+)
+
+var (
+	ErrInvalidDocument         = errors.New("invalid document")
+	ErrDocumentTooLarge        = errors.New("document is too large")
+	ErrUnsupportedDocumentType = errors.New("unsupported document type")
+)
+
+// documentTypes are the formats accepted for upload, checked against the
+// file's content rather than its name or the client's Content-Type.
+var documentTypes = []string{
+	"application/pdf",
+	"image/jpeg",
+	"image/png",
+	"image/tiff",
+	"image/gif",
+	"image/webp",
+	"image/heic",
+	"text/plain",
+	"application/msword",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// VirusScanner inspects uploads before they are stored.
+type VirusScanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+type DocumentUpload struct {
+	PatientID  string
+	Category   string
+	Title      string
+	FileName   string
+	Content    io.ReadSeeker
+	UploadedBy string
+}
+
+type PatientDocumentService interface {
+	UploadDocument(ctx context.Context, upload DocumentUpload) (*models.PatientDocument, error)
+	GetPatientDocuments(ctx context.Context, patientID, category string) ([]*models.PatientDocument, error)
+	GetDocument(ctx context.Context, patientID, id string) (*models.PatientDocument, error)
+	OpenDocument(ctx context.Context, patientID, id, userID string) (*models.PatientDocument, io.ReadCloser, error)
+	DeleteDocument(ctx context.Context, patientID, id, userID string) error
+}
+
+type patientDocumentService struct {
+	repo         repository.PatientDocumentRepository
+	patientRepo  repository.PatientRepository
+	storage      storage.Storage
+	scanner      VirusScanner
+	auditService AuditService
+	maxSize      int64
+}
+
+// NewPatientDocumentService creates the service; scanner may be nil to store
+// files without scanning them.
+func NewPatientDocumentService(
+	repo repository.PatientDocumentRepository,
+	patientRepo repository.PatientRepository,
+	storage storage.Storage,
+	scanner VirusScanner,
+	auditService AuditService,
+	maxSize int64,
+) PatientDocumentService {
+	return &patientDocumentService{repo, patientRepo, storage, scanner, auditService, maxSize}
+}
+
+// UploadDocument checks the file's type, size and checksum, has it scanned
+// and stores it. Content is read several times, so it must be seekable.
+func (s *patientDocumentService) UploadDocument(ctx context.Context, upload DocumentUpload) (*models.PatientDocument, error) {
+	patient, err := s.patientRepo.GetByID(ctx, upload.PatientID)
+	if err != nil {
+		return nil, err
+	}
+	if patient.MergedIntoID != nil {
+		return nil, fmt.Errorf("%w: patient has been merged into %s", ErrInvalidDocument, *patient.MergedIntoID)
+	}
+
+	fileName := filepath.Base(strings.ReplaceAll(upload.FileName, "\\", "/"))
+	if fileName == "." || fileName == "/" {
+		return nil, fmt.Errorf("%w: file name is required", ErrInvalidDocument)
+	}
+	title := strings.TrimSpace(upload.Title)
+	if title == "" {
+		title = fileName
+	}
+
+	mime, err := mimetype.DetectReader(upload.Content)
+	if err != nil {
+		return nil, err
+	}
+	if !isDocumentType(mime) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDocumentType, mime.String())
+	}
+
+	if _, err := upload.Content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(upload.Content, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidDocument)
+	}
+	if size > s.maxSize {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", ErrDocumentTooLarge, s.maxSize)
+	}
+
+	if s.scanner != nil {
+		if _, err := upload.Content.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := s.scanner.Scan(ctx, upload.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := upload.Content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	document := &models.PatientDocument{
+		PatientID:   upload.PatientID,
+		Category:    upload.Category,
+		Title:       title,
+		FileName:    fileName,
+		ContentType: mime.String(),
+		SizeBytes:   size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  newStorageKey(time.Now()),
+		UploadedBy:  upload.UploadedBy,
+	}
+	if err := s.storage.Put(ctx, document.StorageKey, upload.Content, size, document.ContentType); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, document); err != nil {
+		if err := s.storage.Delete(context.WithoutCancel(ctx), document.StorageKey); err != nil {
+			logging.FromContext(ctx).Error("Failed to remove orphaned document", slog.String("storage_key", document.StorageKey), slog.Any("error", err))
+		}
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, document.PatientID, document.ID)
+}
+
+func (s *patientDocumentService) GetPatientDocuments(ctx context.Context, patientID, category string) ([]*models.PatientDocument, error) {
+	return s.repo.GetByPatientID(ctx, patientID, category)
+}
+
+func (s *patientDocumentService) GetDocument(ctx context.Context, patientID, id string) (*models.PatientDocument, error) {
+	return s.repo.GetByID(ctx, patientID, id)
+}
+
+// OpenDocument returns the document's content for streaming. Every download
+// is written to the audit log.
+func (s *patientDocumentService) OpenDocument(ctx context.Context, patientID, id, userID string) (*models.PatientDocument, io.ReadCloser, error) {
+	document, err := s.repo.GetByID(ctx, patientID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.storage.Get(ctx, document.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	details := map[string]string{"patientId": patientID, "fileName": document.FileName}
+	if err := s.auditService.Record(ctx, userID, AuditDocumentDownload, "patient_document", id, details); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit document download", slog.String("document_id", id), slog.Any("error", err))
+	}
+	return document, content, nil
+}
+
+// DeleteDocument removes the record, then the stored file. A file that
+// cannot be removed is logged and left behind rather than failing the call.
+func (s *patientDocumentService) DeleteDocument(ctx context.Context, patientID, id, userID string) error {
+	document, err := s.repo.GetByID(ctx, patientID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, document); err != nil {
+		return err
+	}
+	if err := s.storage.Delete(ctx, document.StorageKey); err != nil {
+		logging.FromContext(ctx).Error("Failed to remove stored document", slog.String("storage_key", document.StorageKey), slog.Any("error", err))
+	}
+
+	details := map[string]string{"patientId": patientID, "fileName": document.FileName, "sha256": document.SHA256}
+	if err := s.auditService.Record(ctx, userID, AuditDocumentDelete, "patient_document", id, details); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit document deletion", slog.String("document_id", id), slog.Any("error", err))
+	}
+	return nil
+}
+
+func isDocumentType(mime *mimetype.MIME) bool {
+	for _, allowed := range documentTypes {
+		if mime.Is(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// newStorageKey spreads documents over monthly prefixes. Keys do not contain
+// the patient so they stay valid when patients are merged.
+func newStorageKey(now time.Time) string {
+	return "documents/" + now.Format("2006/01") + "/" + strings.ToLower(rand.Text())
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/max-programming/clinic/internal/exporter"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/storage"
+)
+
+const (
+	AuditPatientExport       = "patient.export"
+	AuditPatientRecordExport = "patient.record_export"
+)
+
+const exportBatchSize = 500
+
+// PatientRecord is everything held about one patient, for data-subject
+// access requests.
+type PatientRecord struct {
+	Patient           *models.Patient
+	CreatedBy         *models.User
+	UpdatedBy         *models.User
+	LabResults        []*models.LabResult
+	InsurancePolicies []*models.InsurancePolicy
+	Invoices          []*models.Invoice
+	Payments          []*models.Payment
+	Claims            []*models.Claim
+	Prescriptions     []*models.Prescription
+	Documents         []*models.PatientDocument
+}
+
+type PatientExportService interface {
+	ExportPatients(ctx context.Context, w io.Writer, format string, filter repository.PatientFilter, userID string) (int, error)
+	GetPatientRecord(ctx context.Context, id, format, userID string) (*PatientRecord, error)
+	OpenDocument(ctx context.Context, document *models.PatientDocument) (io.ReadCloser, error)
+}
+
+type patientExportService struct {
+	patientRepo      repository.PatientRepository
+	labResultRepo    repository.LabResultRepository
+	policyRepo       repository.InsurancePolicyRepository
+	invoiceRepo      repository.InvoiceRepository
+	paymentRepo      repository.PaymentRepository
+	claimRepo        repository.ClaimRepository
+	prescriptionRepo repository.PrescriptionRepository
+	documentRepo     repository.PatientDocumentRepository
+	storage          storage.Storage
+	auditService     AuditService
+}
+
+func NewPatientExportService(
+	patientRepo repository.PatientRepository,
+	labResultRepo repository.LabResultRepository,
+	policyRepo repository.InsurancePolicyRepository,
+	invoiceRepo repository.InvoiceRepository,
+	paymentRepo repository.PaymentRepository,
+	claimRepo repository.ClaimRepository,
+	prescriptionRepo repository.PrescriptionRepository,
+	documentRepo repository.PatientDocumentRepository,
+	storage storage.Storage,
+	auditService AuditService,
+) PatientExportService {
+	return &patientExportService{
+		patientRepo,
+		labResultRepo,
+		policyRepo,
+		invoiceRepo,
+		paymentRepo,
+		claimRepo,
+		prescriptionRepo,
+		documentRepo,
+		storage,
+		auditService,
+	}
+}
+
+// ExportPatients writes the patients matching filter to w, a batch at a
+// time, and returns how many were written. The export is audited even when
+// it fails part way, since some rows may already have been sent.
+func (s *patientExportService) ExportPatients(ctx context.Context, w io.Writer, format string, filter repository.PatientFilter, userID string) (int, error) {
+	writer, err := exporter.NewPatientWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = s.patientRepo.FindInBatches(ctx, filter, exportBatchSize, func(patients []*models.Patient) error {
+		for _, patient := range patients {
+			if err := writer.Write(patient); err != nil {
+				return err
+			}
+		}
+		count += len(patients)
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+
+	details := map[string]any{"format": format, "filter": exportFilterDetails(filter), "rows": count}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	if err := s.auditService.Record(ctx, userID, AuditPatientExport, "patient", "", details); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit patient export", slog.Any("error", err))
+	}
+	return count, err
+}
+
+// GetPatientRecord gathers the patient's demographics, notes and linked
+// records. format is only recorded in the audit log.
+func (s *patientExportService) GetPatientRecord(ctx context.Context, id, format, userID string) (*PatientRecord, error) {
+	// A creator or editor that no longer exists is not an error here.
+	patient, createdBy, updatedBy, err := s.patientRepo.GetByIDWithUsers(ctx, id)
+	if patient == nil {
+		return nil, err
+	}
+	record := &PatientRecord{Patient: patient, CreatedBy: createdBy, UpdatedBy: updatedBy}
+
+	if record.LabResults, err = s.labResultRepo.GetByPatientID(ctx, id); err != nil {
+		return nil, err
+	}
+	if record.InsurancePolicies, err = s.policyRepo.GetByPatientID(ctx, id); err != nil {
+		return nil, err
+	}
+	invoices, _, err := s.invoiceRepo.Search(ctx, repository.InvoiceFilter{PatientID: id})
+	if err != nil {
+		return nil, err
+	}
+	for _, invoice := range invoices {
+		// Search does not load the line items.
+		invoice, err := s.invoiceRepo.GetByID(ctx, invoice.ID)
+		if err != nil {
+			return nil, err
+		}
+		record.Invoices = append(record.Invoices, invoice)
+
+		payments, _, err := s.paymentRepo.Search(ctx, repository.PaymentFilter{InvoiceID: invoice.ID})
+		if err != nil {
+			return nil, err
+		}
+		record.Payments = append(record.Payments, payments...)
+	}
+	if record.Claims, _, err = s.claimRepo.Search(ctx, repository.ClaimFilter{PatientID: id}); err != nil {
+		return nil, err
+	}
+	if record.Prescriptions, err = s.prescriptionRepo.GetByPatientID(ctx, id); err != nil {
+		return nil, err
+	}
+	if record.Documents, err = s.documentRepo.GetByPatientID(ctx, id, ""); err != nil {
+		return nil, err
+	}
+
+	details := map[string]string{"format": format, "mrn": patient.MRN}
+	if err := s.auditService.Record(ctx, userID, AuditPatientRecordExport, "patient", id, details); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit patient record export", slog.String("patient_id", id), slog.Any("error", err))
+	}
+	return record, nil
+}
+
+func (s *patientExportService) OpenDocument(ctx context.Context, document *models.PatientDocument) (io.ReadCloser, error) {
+	return s.storage.Get(ctx, document.StorageKey)
+}
+
+func exportFilterDetails(filter repository.PatientFilter) map[string]string {
+	details := map[string]string{}
+	add := func(key, value string) {
+		if value != "" {
+			details[key] = value
+		}
+	}
+	add("name", filter.Name)
+	add("gender", filter.Gender)
+	add("mrn", filter.MRN)
+	add("identifier", filter.Identifier)
+	add("identifierType", filter.IdentifierType)
+	if filter.Phone != "" {
+		// The number itself is not written to the audit log.
+		details["phone"] = "set"
+	}
+	if filter.BornFrom != nil {
+		details["bornFrom"] = filter.BornFrom.Format(time.DateOnly)
+	}
+	if filter.BornBefore != nil {
+		details["bornBefore"] = filter.BornBefore.Format(time.DateOnly)
+	}
+	return details
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"gorm.io/gorm"
+)
+
+var ErrDuplicateIdentifier = errors.New("identifier is already assigned to a patient")
+
+type PatientIdentifierService interface {
+	AddIdentifier(ctx context.Context, identifier *models.PatientIdentifier) error
+	GetPatientIdentifiers(ctx context.Context, patientID string) ([]*models.PatientIdentifier, error)
+	DeleteIdentifier(ctx context.Context, patientID, id string) error
+}
+
+type patientIdentifierService struct {
+	repo repository.PatientIdentifierRepository
+}
+
+func NewPatientIdentifierService(repo repository.PatientIdentifierRepository) PatientIdentifierService {
+	return &patientIdentifierService{repo}
+}
+
+func (s *patientIdentifierService) AddIdentifier(ctx context.Context, identifier *models.PatientIdentifier) error {
+	if err := s.repo.Create(ctx, identifier); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrDuplicateIdentifier
+		}
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return gorm.ErrRecordNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *patientIdentifierService) GetPatientIdentifiers(ctx context.Context, patientID string) ([]*models.PatientIdentifier, error) {
+	return s.repo.GetByPatientID(ctx, patientID)
+}
+
+func (s *patientIdentifierService) DeleteIdentifier(ctx context.Context, patientID, id string) error {
+	return s.repo.Delete(ctx, patientID, id)
+}
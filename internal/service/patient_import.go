@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/max-programming/clinic/internal/importer"
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/metrics"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/utils"
+)
+
+const AuditPatientImport = "patient.import"
+
+type PatientImportService interface {
+	StartImport(ctx context.Context, job *models.PatientImport, rows []importer.Row) error
+	RunImport(ctx context.Context, job *models.PatientImport, rows []importer.Row) error
+	GetImport(ctx context.Context, id string) (*models.PatientImport, error)
+	GetImports(ctx context.Context, limit, offset int) ([]*models.PatientImport, int64, error)
+	Shutdown(ctx context.Context) error
+}
+
+var ErrImportInterrupted = errors.New("import interrupted by server shutdown")
+
+type patientImportService struct {
+	repo         repository.PatientImportRepository
+	patientRepo  repository.PatientRepository
+	auditService AuditService
+	batchSize    int
+
+	running  sync.WaitGroup
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewPatientImportService(
+	repo repository.PatientImportRepository,
+	patientRepo repository.PatientRepository,
+	auditService AuditService,
+	batchSize int,
+) PatientImportService {
+	return &patientImportService{
+		repo:         repo,
+		patientRepo:  patientRepo,
+		auditService: auditService,
+		batchSize:    max(batchSize, 1),
+		stop:         make(chan struct{}),
+	}
+}
+
+// StartImport records the job and processes the rows in the background,
+// logging through ctx's logger. Callers poll GetImport for progress.
+func (s *patientImportService) StartImport(ctx context.Context, job *models.PatientImport, rows []importer.Row) error {
+	if err := s.createJob(ctx, job, rows); err != nil {
+		return err
+	}
+	// The caller gets the job as created; the copy is what gets updated.
+	running := *job
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.process(logging.With(context.WithoutCancel(ctx), slog.String("import_id", job.ID)), &running, rows)
+	}()
+	return nil
+}
+
+// RunImport records the job and processes the rows before returning.
+func (s *patientImportService) RunImport(ctx context.Context, job *models.PatientImport, rows []importer.Row) error {
+	if err := s.createJob(ctx, job, rows); err != nil {
+		return err
+	}
+	s.process(logging.With(ctx, slog.String("import_id", job.ID)), job, rows)
+	if job.Status == models.ImportStatusFailed {
+		return fmt.Errorf("import failed: %s", job.Error)
+	}
+	return nil
+}
+
+func (s *patientImportService) GetImport(ctx context.Context, id string) (*models.PatientImport, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *patientImportService) GetImports(ctx context.Context, limit, offset int) ([]*models.PatientImport, int64, error) {
+	return s.repo.GetAll(ctx, limit, offset)
+}
+
+// Shutdown stops background imports after the batch they are working on,
+// marking them failed so they can be uploaded again, and waits for them
+// until ctx is done.
+func (s *patientImportService) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *patientImportService) createJob(ctx context.Context, job *models.PatientImport, rows []importer.Row) error {
+	job.Status = models.ImportStatusPending
+	job.TotalRows = len(rows)
+	job.Issues = []models.ImportIssue{}
+	return s.repo.Create(ctx, job)
+}
+
+// process checks each row for problems and probable duplicates, both among
+// existing patients and earlier rows of the same file, and creates the rest
+// in batches. A batch that fails to insert is reported row by row and the
+// import carries on with the next one. Progress is saved after every batch.
+func (s *patientImportService) process(ctx context.Context, job *models.PatientImport, rows []importer.Row) {
+	now := time.Now()
+	job.Status = models.ImportStatusRunning
+	job.StartedAt = &now
+	if err := s.repo.Update(ctx, job); err != nil {
+		s.fail(ctx, job, err)
+		return
+	}
+
+	imported := make(map[string][]importedRow)
+	var batch []importer.Row
+	for i, row := range rows {
+		job.ProcessedRows = i + 1
+		if len(row.Issues) > 0 {
+			job.Issues = append(job.Issues, row.Issues...)
+			job.FailedRows++
+			continue
+		}
+
+		patient := row.Patient
+		if err := validatePatient(patient); err != nil {
+			job.Issues = append(job.Issues, models.ImportIssue{Row: row.Line, Field: "dateOfBirth", Message: err.Error()})
+			job.FailedRows++
+			continue
+		}
+		patient.Name = fullName(patient.GivenName, patient.FamilyName)
+		patient.CreatedBy = job.CreatedBy
+		patient.UpdatedBy = job.CreatedBy
+
+		if !job.AllowDuplicates {
+			issue, err := s.findDuplicate(ctx, row, imported)
+			if err != nil {
+				s.fail(ctx, job, err)
+				return
+			}
+			if issue != nil {
+				job.Issues = append(job.Issues, *issue)
+				job.DuplicateRows++
+				continue
+			}
+		}
+		for _, key := range duplicateBlockKeys(patient) {
+			imported[key] = append(imported[key], importedRow{row.Line, patient})
+		}
+
+		batch = append(batch, row)
+		if len(batch) == s.batchSize {
+			if err := s.flush(ctx, job, batch); err != nil {
+				s.fail(ctx, job, err)
+				return
+			}
+			batch = batch[:0]
+			if s.stopping() {
+				s.fail(ctx, job, ErrImportInterrupted)
+				return
+			}
+		}
+	}
+	if err := s.flush(ctx, job, batch); err != nil {
+		s.fail(ctx, job, err)
+		return
+	}
+
+	finished := time.Now()
+	job.Status = models.ImportStatusCompleted
+	job.FinishedAt = &finished
+	if err := s.repo.Update(ctx, job); err != nil {
+		logging.FromContext(ctx).Error("Failed to complete patient import", slog.Any("error", err))
+		return
+	}
+	if !job.DryRun {
+		details := map[string]any{
+			"fileName":        job.FileName,
+			"created":         job.CreatedRows,
+			"duplicates":      job.DuplicateRows,
+			"failed":          job.FailedRows,
+			"allowDuplicates": job.AllowDuplicates,
+		}
+		if err := s.auditService.Record(ctx, job.CreatedBy, AuditPatientImport, "patient_import", job.ID, details); err != nil {
+			logging.FromContext(ctx).Error("Failed to audit patient import", slog.Any("error", err))
+		}
+	}
+}
+
+type importedRow struct {
+	line    int
+	patient *models.Patient
+}
+
+// findDuplicate returns an issue when the row probably duplicates an earlier
+// row of the file or an existing patient.
+func (s *patientImportService) findDuplicate(ctx context.Context, row importer.Row, imported map[string][]importedRow) (*models.ImportIssue, error) {
+	for _, key := range duplicateBlockKeys(row.Patient) {
+		for _, earlier := range imported[key] {
+			if score, _ := scoreDuplicate(row.Patient, earlier.patient); score >= DuplicateThreshold {
+				return &models.ImportIssue{Row: row.Line, Message: fmt.Sprintf("probable duplicate of row %d", earlier.line)}, nil
+			}
+		}
+	}
+
+	matches, err := findDuplicates(ctx, s.patientRepo, row.Patient)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+	mrns := make([]string, len(matches))
+	for i, match := range matches {
+		mrns[i] = match.Patient.MRN
+	}
+	return &models.ImportIssue{Row: row.Line, Message: "probable duplicate of an existing patient", Duplicates: mrns}, nil
+}
+
+// flush creates the batch, unless this is a dry run, and saves progress.
+func (s *patientImportService) flush(ctx context.Context, job *models.PatientImport, batch []importer.Row) error {
+	switch {
+	case len(batch) == 0:
+	case job.DryRun:
+		job.CreatedRows += len(batch)
+	default:
+		patients := make([]*models.Patient, len(batch))
+		for i, row := range batch {
+			seq, err := s.patientRepo.NextMRNSequence(ctx)
+			if err != nil {
+				return err
+			}
+			row.Patient.MRN = utils.FormatMRN(seq)
+			patients[i] = row.Patient
+		}
+		if err := s.patientRepo.CreateBatch(ctx, patients); err != nil {
+			for _, row := range batch {
+				job.Issues = append(job.Issues, models.ImportIssue{Row: row.Line, Message: "not imported: " + err.Error()})
+			}
+			job.FailedRows += len(batch)
+		} else {
+			job.CreatedRows += len(batch)
+			metrics.AddPatientsCreated(metrics.SourceImport, len(batch))
+		}
+	}
+	return s.repo.Update(ctx, job)
+}
+
+func (s *patientImportService) stopping() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *patientImportService) fail(ctx context.Context, job *models.PatientImport, err error) {
+	logger := logging.FromContext(ctx)
+	logger.Error("Patient import failed", slog.Any("error", err))
+	finished := time.Now()
+	job.Status = models.ImportStatusFailed
+	job.Error = err.Error()
+	job.FinishedAt = &finished
+	if err := s.repo.Update(ctx, job); err != nil {
+		logger.Error("Failed to record patient import failure", slog.Any("error", err))
+	}
+}
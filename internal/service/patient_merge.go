@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+)
+
+var ErrInvalidMerge = errors.New("a patient cannot be merged into itself")
+
+type PatientMergeService interface {
+	MergePatients(ctx context.Context, survivorID, mergedID, userID string) (*models.PatientMerge, error)
+	ReverseMerge(ctx context.Context, id, userID string) (*models.PatientMerge, error)
+	GetMerges(ctx context.Context) ([]*models.PatientMerge, error)
+	GetMergeByID(ctx context.Context, id string) (*models.PatientMerge, error)
+}
+
+type patientMergeService struct {
+	repo repository.PatientMergeRepository
+}
+
+func NewPatientMergeService(repo repository.PatientMergeRepository) PatientMergeService {
+	return &patientMergeService{repo}
+}
+
+func (s *patientMergeService) MergePatients(ctx context.Context, survivorID, mergedID, userID string) (*models.PatientMerge, error) {
+	if survivorID == mergedID {
+		return nil, ErrInvalidMerge
+	}
+
+	merge := &models.PatientMerge{
+		SurvivorID: survivorID,
+		MergedID:   mergedID,
+		MergedBy:   userID,
+	}
+	entry, err := newAuditLog(userID, AuditPatientMerge, "patient_merge", "", map[string]string{
+		"survivorId": survivorID,
+		"mergedId":   mergedID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Merge(ctx, merge, entry); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, merge.ID)
+}
+
+func (s *patientMergeService) ReverseMerge(ctx context.Context, id, userID string) (*models.PatientMerge, error) {
+	merge, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	merge.ReversedBy = &userID
+	entry, err := newAuditLog(userID, AuditPatientMergeReverse, "patient_merge", id, map[string]string{
+		"survivorId": merge.SurvivorID,
+		"mergedId":   merge.MergedID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Reverse(ctx, merge, entry); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *patientMergeService) GetMerges(ctx context.Context) ([]*models.PatientMerge, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *patientMergeService) GetMergeByID(ctx context.Context, id string) (*models.PatientMerge, error) {
+	return s.repo.GetByID(ctx, id)
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/max-programming/clinic/internal/logging"
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	AuditPaymentUpdate = "payment.update"
+	AuditDayClose      = "payment.day_close"
+)
+
+var ErrInvalidPayment = errors.New("invalid payment")
+
+var paymentMethods = []string{
+	models.PaymentMethodCash,
+	models.PaymentMethodCard,
+	models.PaymentMethodBankTransfer,
+}
+
+// DaySummary is the takings of a business date and, once the day has been
+// closed, the close record.
+type DaySummary struct {
+	Date  time.Time
+	Close *models.DayClose
+	Lines []repository.DaySummaryLine
+}
+
+type PaymentService interface {
+	RecordPayment(ctx context.Context, payment *models.Payment) error
+	RecordRefund(ctx context.Context, refund *models.Payment) error
+	UpdatePayment(ctx context.Context, id, userID string, updated *models.Payment) (*models.Payment, error)
+	GetPaymentByID(ctx context.Context, id string) (*models.Payment, error)
+	SearchPayments(ctx context.Context, filter repository.PaymentFilter) ([]*models.Payment, int64, error)
+	GetDaySummary(ctx context.Context, date time.Time) (*DaySummary, error)
+	CloseDay(ctx context.Context, date time.Time, userID, notes string) (*DaySummary, error)
+}
+
+type paymentService struct {
+	repo          repository.PaymentRepository
+	auditService  AuditService
+	receiptPrefix string
+}
+
+func NewPaymentService(repo repository.PaymentRepository, auditService AuditService, receiptPrefix string) PaymentService {
+	return &paymentService{repo, auditService, receiptPrefix}
+}
+
+func (s *paymentService) RecordPayment(ctx context.Context, payment *models.Payment) error {
+	payment.Kind = models.PaymentKindPayment
+	payment.RefundOfID = nil
+	return s.record(ctx, payment)
+}
+
+// RecordRefund hands money back on an invoice, optionally against a specific
+// earlier payment.
+func (s *paymentService) RecordRefund(ctx context.Context, refund *models.Payment) error {
+	refund.Kind = models.PaymentKindRefund
+	if refund.RefundOfID != nil {
+		original, err := s.repo.GetByID(ctx, *refund.RefundOfID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: refunded payment not found", ErrInvalidPayment)
+		}
+		if err != nil {
+			return err
+		}
+		if original.InvoiceID != refund.InvoiceID || original.Kind != models.PaymentKindPayment {
+			return fmt.Errorf("%w: refunded payment does not belong to this invoice", ErrInvalidPayment)
+		}
+	}
+	return s.record(ctx, refund)
+}
+
+func (s *paymentService) record(ctx context.Context, payment *models.Payment) error {
+	if err := validatePayment(payment); err != nil {
+		return err
+	}
+	now := time.Now()
+	payment.ReceivedAt = now
+	payment.BusinessDate = utils.BusinessDate(now)
+	return s.repo.Create(ctx, payment, s.receiptPrefix)
+}
+
+// UpdatePayment corrects the method, amount, reference or notes of a payment
+// taken on a day that has not been closed.
+func (s *paymentService) UpdatePayment(ctx context.Context, id, userID string, updated *models.Payment) (*models.Payment, error) {
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.ID = id
+	if err := validatePayment(updated); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, updated, userID); err != nil {
+		return nil, err
+	}
+
+	details := map[string]any{
+		"before": paymentAuditDetails(current),
+		"after":  paymentAuditDetails(updated),
+	}
+	if err := s.auditService.Record(ctx, userID, AuditPaymentUpdate, "payment", id, details); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit payment update", slog.String("payment_id", id), slog.Any("error", err))
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *paymentService) GetPaymentByID(ctx context.Context, id string) (*models.Payment, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *paymentService) SearchPayments(ctx context.Context, filter repository.PaymentFilter) ([]*models.Payment, int64, error) {
+	return s.repo.Search(ctx, filter)
+}
+
+func (s *paymentService) GetDaySummary(ctx context.Context, date time.Time) (*DaySummary, error) {
+	lines, err := s.repo.GetDaySummary(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	dayClose, err := s.repo.GetDayClose(ctx, date)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &DaySummary{Date: date, Close: dayClose, Lines: lines}, nil
+}
+
+// CloseDay locks the payments of date against further changes. Payments can
+// no longer be taken on a closed day, so closing today ends the day's takings.
+func (s *paymentService) CloseDay(ctx context.Context, date time.Time, userID, notes string) (*DaySummary, error) {
+	if date.After(utils.BusinessDate(time.Now())) {
+		return nil, fmt.Errorf("%w: cannot close a future day", ErrInvalidPayment)
+	}
+
+	dayClose := &models.DayClose{
+		BusinessDate: date,
+		Notes:        notes,
+		ClosedBy:     userID,
+		ClosedAt:     time.Now(),
+	}
+	if err := s.repo.CloseDay(ctx, dayClose); err != nil {
+		return nil, err
+	}
+
+	details := map[string]any{
+		"date":         date.Format(time.DateOnly),
+		"paymentCount": dayClose.PaymentCount,
+		"netTotal":     dayClose.NetTotal.StringFixed(2),
+	}
+	if err := s.auditService.Record(ctx, userID, AuditDayClose, "day_close", dayClose.ID, details); err != nil {
+		logging.FromContext(ctx).Error("Failed to audit day close", slog.String("date", date.Format(time.DateOnly)), slog.Any("error", err))
+	}
+	return s.GetDaySummary(ctx, date)
+}
+
+func validatePayment(payment *models.Payment) error {
+	if !slices.Contains(paymentMethods, payment.Method) {
+		return fmt.Errorf("%w: unknown payment method %q", ErrInvalidPayment, payment.Method)
+	}
+	if !payment.Amount.IsPositive() {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidPayment)
+	}
+	if !payment.Amount.Equal(payment.Amount.Round(2)) {
+		return fmt.Errorf("%w: amount cannot have more than two decimal places", ErrInvalidPayment)
+	}
+	return nil
+}
+
+func paymentAuditDetails(payment *models.Payment) map[string]string {
+	return map[string]string{
+		"method":    payment.Method,
+		"amount":    payment.Amount.StringFixed(2),
+		"reference": payment.Reference,
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/utils"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestValidatePayment(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		amount  string
+		wantErr bool
+	}{
+		{name: "cash", method: models.PaymentMethodCash, amount: "20.00"},
+		{name: "card", method: models.PaymentMethodCard, amount: "0.01"},
+		{name: "bank transfer", method: models.PaymentMethodBankTransfer, amount: "1500"},
+		{name: "unknown method", method: "cheque", amount: "20.00", wantErr: true},
+		{name: "no method", amount: "20.00", wantErr: true},
+		{name: "zero", method: models.PaymentMethodCash, amount: "0", wantErr: true},
+		{name: "negative", method: models.PaymentMethodCash, amount: "-20.00", wantErr: true},
+		{name: "fractions of a cent", method: models.PaymentMethodCard, amount: "20.005", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := &models.Payment{Method: tt.method, Amount: decimal.RequireFromString(tt.amount)}
+			err := validatePayment(payment)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("validatePayment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPayment) {
+				t.Errorf("validatePayment() error = %v, want ErrInvalidPayment", err)
+			}
+		})
+	}
+}
+
+// refundRepository holds earlier payments by ID and records what is created.
+type refundRepository struct {
+	repository.PaymentRepository
+	payments map[string]*models.Payment
+	created  *models.Payment
+}
+
+func (r *refundRepository) GetByID(ctx context.Context, id string) (*models.Payment, error) {
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return payment, nil
+}
+
+func (r *refundRepository) Create(ctx context.Context, payment *models.Payment, receiptPrefix string) error {
+	r.created = payment
+	return nil
+}
+
+func TestRecordRefund(t *testing.T) {
+	payments := map[string]*models.Payment{
+		"pay-1": {ID: "pay-1", InvoiceID: "inv-1", Kind: models.PaymentKindPayment},
+		"pay-2": {ID: "pay-2", InvoiceID: "inv-2", Kind: models.PaymentKindPayment},
+		"ref-1": {ID: "ref-1", InvoiceID: "inv-1", Kind: models.PaymentKindRefund},
+	}
+
+	tests := []struct {
+		name       string
+		refundOfID string
+		wantErr    bool
+	}{
+		{name: "against the invoice"},
+		{name: "against a payment", refundOfID: "pay-1"},
+		{name: "unknown payment", refundOfID: "pay-9", wantErr: true},
+		{name: "payment on another invoice", refundOfID: "pay-2", wantErr: true},
+		{name: "refund of a refund", refundOfID: "ref-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &refundRepository{payments: payments}
+			s := &paymentService{repo: repo, receiptPrefix: "RCT"}
+			refund := &models.Payment{
+				InvoiceID: "inv-1",
+				Method:    models.PaymentMethodCash,
+				Amount:    decimal.RequireFromString("10.00"),
+			}
+			if tt.refundOfID != "" {
+				refund.RefundOfID = &tt.refundOfID
+			}
+
+			err := s.RecordRefund(context.Background(), refund)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPayment) {
+					t.Fatalf("RecordRefund() error = %v, want ErrInvalidPayment", err)
+				}
+				if repo.created != nil {
+					t.Error("a rejected refund was recorded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RecordRefund() error = %v", err)
+			}
+			if repo.created.Kind != models.PaymentKindRefund {
+				t.Errorf("kind = %q, want refund", repo.created.Kind)
+			}
+			if !repo.created.BusinessDate.Equal(utils.BusinessDate(repo.created.ReceivedAt)) {
+				t.Errorf("business date = %v for a payment received at %v", repo.created.BusinessDate, repo.created.ReceivedAt)
+			}
+		})
+	}
+}
+
+func TestRecordPaymentIgnoresRefundOf(t *testing.T) {
+	repo := &refundRepository{}
+	s := &paymentService{repo: repo}
+	original := "pay-1"
+	payment := &models.Payment{
+		Kind:       models.PaymentKindRefund,
+		RefundOfID: &original,
+		Method:     models.PaymentMethodCard,
+		Amount:     decimal.RequireFromString("25.00"),
+	}
+
+	if err := s.RecordPayment(context.Background(), payment); err != nil {
+		t.Fatalf("RecordPayment() error = %v", err)
+	}
+	if repo.created.Kind != models.PaymentKindPayment || repo.created.RefundOfID != nil {
+		t.Errorf("kind, refund of = %q, %v, want a plain payment", repo.created.Kind, repo.created.RefundOfID)
+	}
+}
+
+func TestCloseDayRejectsFutureDays(t *testing.T) {
+	s := &paymentService{}
+	tomorrow := utils.BusinessDate(time.Now()).AddDate(0, 0, 1)
+	if _, err := s.CloseDay(context.Background(), tomorrow, "user-1", ""); !errors.Is(err, ErrInvalidPayment) {
+		t.Errorf("CloseDay() error = %v, want ErrInvalidPayment", err)
+	}
+}
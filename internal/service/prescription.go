@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+)
+
+var ErrInvalidPrescription = errors.New("invalid prescription")
+
+type PrescriptionService interface {
+	CreatePrescription(ctx context.Context, prescription *models.Prescription) (*models.Prescription, error)
+	GetPrescriptionByID(ctx context.Context, id string) (*models.Prescription, error)
+	GetPatientPrescriptions(ctx context.Context, patientID string) ([]*models.Prescription, error)
+}
+
+type prescriptionService struct {
+	repo        repository.PrescriptionRepository
+	patientRepo repository.PatientRepository
+}
+
+func NewPrescriptionService(repo repository.PrescriptionRepository, patientRepo repository.PatientRepository) PrescriptionService {
+	return &prescriptionService{repo, patientRepo}
+}
+
+func (s *prescriptionService) CreatePrescription(ctx context.Context, prescription *models.Prescription) (*models.Prescription, error) {
+	patient, err := s.patientRepo.GetByID(ctx, prescription.PatientID)
+	if err != nil {
+		return nil, err
+	}
+	if patient.MergedIntoID != nil {
+		return nil, fmt.Errorf("%w: patient has been merged into %s", ErrInvalidPrescription, *patient.MergedIntoID)
+	}
+
+	if len(prescription.Items) == 0 {
+		return nil, fmt.Errorf("%w: at least one medication is required", ErrInvalidPrescription)
+	}
+	for i := range prescription.Items {
+		item := &prescription.Items[i]
+		item.Position = i + 1
+		if strings.TrimSpace(item.Medication) == "" || strings.TrimSpace(item.Dosage) == "" || strings.TrimSpace(item.Frequency) == "" {
+			return nil, fmt.Errorf("%w: item %d needs a medication, dosage and frequency", ErrInvalidPrescription, i+1)
+		}
+	}
+
+	if err := s.repo.Create(ctx, prescription); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, prescription.ID)
+}
+
+func (s *prescriptionService) GetPrescriptionByID(ctx context.Context, id string) (*models.Prescription, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *prescriptionService) GetPatientPrescriptions(ctx context.Context, patientID string) ([]*models.Prescription, error) {
+	return s.repo.GetByPatientID(ctx, patientID)
+}
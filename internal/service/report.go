@@ -0,0 +1,237 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+var ErrInvalidReport = errors.New("invalid report")
+
+const maxCachedReports = 500
+
+// ReportRange covers the days From to To, both included.
+type ReportRange struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string
+}
+
+// RevenueLine is what was invoiced and taken in one currency in one period.
+type RevenueLine struct {
+	Period       time.Time
+	Currency     string
+	InvoiceCount int64
+	Invoiced     decimal.Decimal
+	Collected    decimal.Decimal
+	Refunded     decimal.Decimal
+}
+
+// Net is the money kept: collected less refunded.
+func (l RevenueLine) Net() decimal.Decimal {
+	return l.Collected.Sub(l.Refunded)
+}
+
+type ReportService interface {
+	NewPatients(ctx context.Context, r ReportRange) ([]repository.PeriodCount, error)
+	Demographics(ctx context.Context, from *time.Time, to time.Time) ([]repository.DemographicsLine, error)
+	VisitsByDoctor(ctx context.Context, r ReportRange) ([]repository.DoctorVisitsLine, error)
+	Revenue(ctx context.Context, r ReportRange) ([]RevenueLine, error)
+	Invalidate(table string)
+}
+
+type reportService struct {
+	repo  repository.ReportRepository
+	cache *reportCache
+}
+
+// NewReportService caches each report for ttl, or until a table it reads
+// from changes. A ttl of 0 disables the cache.
+func NewReportService(repo repository.ReportRepository, ttl time.Duration) ReportService {
+	return &reportService{repo, &reportCache{ttl: ttl, entries: make(map[string]reportCacheEntry)}}
+}
+
+func (s *reportService) NewPatients(ctx context.Context, r ReportRange) ([]repository.PeriodCount, error) {
+	rng, err := toRepositoryRange(r)
+	if err != nil {
+		return nil, err
+	}
+	return cachedReport(s.cache, reportKey("new-patients", r), []string{"patients"}, func() ([]repository.PeriodCount, error) {
+		return s.repo.CountNewPatients(ctx, rng)
+	})
+}
+
+func (s *reportService) Demographics(ctx context.Context, from *time.Time, to time.Time) ([]repository.DemographicsLine, error) {
+	if from != nil && from.After(to) {
+		return nil, fmt.Errorf("%w: from is after to", ErrInvalidReport)
+	}
+	key := "demographics|" + to.Format(time.DateOnly)
+	if from != nil {
+		key += "|" + from.Format(time.DateOnly)
+	}
+	return cachedReport(s.cache, key, []string{"patients"}, func() ([]repository.DemographicsLine, error) {
+		return s.repo.CountPatientsByGenderAndAge(ctx, from, to.AddDate(0, 0, 1))
+	})
+}
+
+func (s *reportService) VisitsByDoctor(ctx context.Context, r ReportRange) ([]repository.DoctorVisitsLine, error) {
+	rng, err := toRepositoryRange(r)
+	if err != nil {
+		return nil, err
+	}
+	return cachedReport(s.cache, reportKey("visits", r), []string{"prescriptions", "users"}, func() ([]repository.DoctorVisitsLine, error) {
+		return s.repo.CountVisitsByDoctor(ctx, rng)
+	})
+}
+
+// Revenue combines invoices issued and payments taken in each period and
+// currency. Periods without either are left out.
+func (s *reportService) Revenue(ctx context.Context, r ReportRange) ([]RevenueLine, error) {
+	rng, err := toRepositoryRange(r)
+	if err != nil {
+		return nil, err
+	}
+	return cachedReport(s.cache, reportKey("revenue", r), []string{"invoices", "payments"}, func() ([]RevenueLine, error) {
+		invoiced, err := s.repo.SumInvoiced(ctx, rng)
+		if err != nil {
+			return nil, err
+		}
+		collected, err := s.repo.SumCollected(ctx, rng)
+		if err != nil {
+			return nil, err
+		}
+
+		type lineKey struct {
+			period   time.Time
+			currency string
+		}
+		byKey := make(map[lineKey]*RevenueLine)
+		line := func(period time.Time, currency string) *RevenueLine {
+			key := lineKey{period.UTC(), currency}
+			if byKey[key] == nil {
+				byKey[key] = &RevenueLine{Period: key.period, Currency: currency}
+			}
+			return byKey[key]
+		}
+		for _, l := range invoiced {
+			revenue := line(l.Period, l.Currency)
+			revenue.InvoiceCount = l.InvoiceCount
+			revenue.Invoiced = l.Invoiced
+		}
+		for _, l := range collected {
+			revenue := line(l.Period, l.Currency)
+			revenue.Collected = l.Collected
+			revenue.Refunded = l.Refunded
+		}
+
+		lines := make([]RevenueLine, 0, len(byKey))
+		for _, l := range byKey {
+			lines = append(lines, *l)
+		}
+		slices.SortFunc(lines, func(a, b RevenueLine) int {
+			if c := a.Period.Compare(b.Period); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.Currency, b.Currency)
+		})
+		return lines, nil
+	})
+}
+
+// Invalidate drops the cached reports that read from table, or every report
+// when table is empty.
+func (s *reportService) Invalidate(table string) {
+	s.cache.invalidate(table)
+}
+
+func toRepositoryRange(r ReportRange) (repository.ReportRange, error) {
+	if r.From.After(r.To) {
+		return repository.ReportRange{}, fmt.Errorf("%w: from is after to", ErrInvalidReport)
+	}
+	switch r.GroupBy {
+	case "day", "week", "month":
+	default:
+		return repository.ReportRange{}, fmt.Errorf("%w: groupBy must be day, week or month", ErrInvalidReport)
+	}
+	return repository.ReportRange{From: r.From, To: r.To.AddDate(0, 0, 1), GroupBy: r.GroupBy}, nil
+}
+
+func reportKey(name string, r ReportRange) string {
+	return fmt.Sprintf("%s|%s|%s|%s", name, r.From.Format(time.DateOnly), r.To.Format(time.DateOnly), r.GroupBy)
+}
+
+type reportCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]reportCacheEntry
+}
+
+type reportCacheEntry struct {
+	value   any
+	tables  []string
+	expires time.Time
+}
+
+// cachedReport returns the cached value for key, or loads and caches it.
+// Concurrent misses each load the report.
+func cachedReport[T any](c *reportCache, key string, tables []string, load func() (T, error)) (T, error) {
+	if value, ok := c.get(key); ok {
+		return value.(T), nil
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.set(key, tables, value)
+	return value, nil
+}
+
+func (c *reportCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *reportCache) set(key string, tables []string, value any) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxCachedReports {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCachedReports {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = reportCacheEntry{value, tables, now.Add(c.ttl)}
+}
+
+func (c *reportCache) invalidate(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if table == "" || slices.Contains(entry.tables, table) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+
+	"github.com/max-programming/clinic/internal/models"
+	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/tracing"
+)
+
+// tracedPatientService wraps every PatientService call in a span. Calls a
+// service makes to itself, such as RegisterPatient to CreatePatient, are
+// not traced separately.
+type tracedPatientService struct {
+	next PatientService
+}
+
+func (s *tracedPatientService) CreatePatient(ctx context.Context, patient *models.Patient) error {
+	ctx, span := tracing.Start(ctx, "PatientService.CreatePatient")
+	err := s.next.CreatePatient(ctx, patient)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedPatientService) RegisterPatient(ctx context.Context, patient *models.Patient, allowDuplicate bool) error {
+	ctx, span := tracing.Start(ctx, "PatientService.RegisterPatient")
+	err := s.next.RegisterPatient(ctx, patient, allowDuplicate)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedPatientService) GetAllPatients(ctx context.Context) ([]*models.Patient, error) {
+	ctx, span := tracing.Start(ctx, "PatientService.GetAllPatients")
+	patients, err := s.next.GetAllPatients(ctx)
+	tracing.End(span, err)
+	return patients, err
+}
+
+func (s *tracedPatientService) GetPatientByID(ctx context.Context, id string) (*models.Patient, error) {
+	ctx, span := tracing.Start(ctx, "PatientService.GetPatientByID")
+	patient, err := s.next.GetPatientByID(ctx, id)
+	tracing.End(span, err)
+	return patient, err
+}
+
+func (s *tracedPatientService) GetPatientByMRN(ctx context.Context, mrn string) (*models.Patient, error) {
+	ctx, span := tracing.Start(ctx, "PatientService.GetPatientByMRN")
+	patient, err := s.next.GetPatientByMRN(ctx, mrn)
+	tracing.End(span, err)
+	return patient, err
+}
+
+func (s *tracedPatientService) SearchPatients(ctx context.Context, filter repository.PatientFilter) ([]*models.Patient, int64, error) {
+	ctx, span := tracing.Start(ctx, "PatientService.SearchPatients")
+	patients, total, err := s.next.SearchPatients(ctx, filter)
+	tracing.End(span, err)
+	return patients, total, err
+}
+
+func (s *tracedPatientService) LookupPatients(ctx context.Context, lookup repository.PatientLookup) ([]*models.Patient, error) {
+	ctx, span := tracing.Start(ctx, "PatientService.LookupPatients")
+	patients, err := s.next.LookupPatients(ctx, lookup)
+	tracing.End(span, err)
+	return patients, err
+}
+
+func (s *tracedPatientService) GetPatientByIDWithUsers(ctx context.Context, id string) (*models.Patient, *models.User, *models.User, error) {
+	ctx, span := tracing.Start(ctx, "PatientService.GetPatientByIDWithUsers")
+	patient, createdBy, updatedBy, err := s.next.GetPatientByIDWithUsers(ctx, id)
+	tracing.End(span, err)
+	return patient, createdBy, updatedBy, err
+}
+
+func (s *tracedPatientService) UpdatePatient(ctx context.Context, id string, patient *models.Patient) error {
+	ctx, span := tracing.Start(ctx, "PatientService.UpdatePatient")
+	err := s.next.UpdatePatient(ctx, id, patient)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedPatientService) DeletePatient(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "PatientService.DeletePatient")
+	err := s.next.DeletePatient(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedUserService struct {
+	next UserService
+}
+
+func (s *tracedUserService) RegisterUser(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.RegisterUser")
+	err := s.next.RegisterUser(ctx, user)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedUserService) LoginUser(ctx context.Context, username, password string) (string, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginUser")
+	token, err := s.next.LoginUser(ctx, username, password)
+	tracing.End(span, err)
+	return token, err
+}
+
+func (s *tracedUserService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByUsername")
+	user, err := s.next.GetUserByUsername(ctx, username)
+	tracing.End(span, err)
+	return user, err
+}
@@ -1,56 +1,68 @@
 package service
 
 import (
+	"context"
 	"errors"
 
+	"github.com/max-programming/clinic/internal/metrics"
 	"github.com/max-programming/clinic/internal/models"
 	"github.com/max-programming/clinic/internal/repository"
+	"github.com/max-programming/clinic/internal/tracing"
 	"github.com/max-programming/clinic/internal/utils"
 )
 
 type UserService interface {
-	RegisterUser(user *models.User) error
-	LoginUser(username, password string) (string, error)
-	GetUserByUsername(username string) (*models.User, error)
+	RegisterUser(ctx context.Context, user *models.User) error
+	LoginUser(ctx context.Context, username, password string) (string, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo      repository.UserRepository
+	jwtSecret []byte
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo}
+func NewUserService(repo repository.UserRepository, jwtSecret []byte) UserService {
+	return &tracedUserService{&userService{repo, jwtSecret}}
 }
 
-func (s *userService) RegisterUser(user *models.User) error {
+func (s *userService) RegisterUser(ctx context.Context, user *models.User) error {
+	_, span := tracing.Start(ctx, "bcrypt.Hash")
 	hashedPassword, err := utils.HashPassword(user.Password)
+	tracing.End(span, err)
 	if err != nil {
 		return err
 	}
 	user.Password = hashedPassword
 
-	return s.repo.Create(user)
+	return s.repo.Create(ctx, user)
 }
 
-func (s *userService) LoginUser(username, password string) (string, error) {
-	user, err := s.repo.FindByUsername(username)
+func (s *userService) LoginUser(ctx context.Context, username, password string) (string, error) {
+	user, err := s.repo.FindByUsername(ctx, username)
 	if err != nil {
+		metrics.ObserveLogin(false)
 		return "", err
 	}
 
-	if !utils.ComparePassword(user.Password, password) {
+	_, span := tracing.Start(ctx, "bcrypt.Compare")
+	match := utils.ComparePassword(user.Password, password)
+	span.End()
+	if !match {
+		metrics.ObserveLogin(false)
 		return "", errors.New("invalid credentials")
 	}
 
-	tokenString, err := utils.CreateJWT(user.ID, user.Username, user.Role)
+	tokenString, err := utils.CreateJWT(s.jwtSecret, user.ID, user.Username, user.Role)
 
 	if err != nil {
 		return "", err
 	}
+	metrics.ObserveLogin(true)
 
 	return tokenString, nil
 }
 
-func (s *userService) GetUserByUsername(username string) (*models.User, error) {
-	return s.repo.FindByUsername(username)
+func (s *userService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return s.repo.FindByUsername(ctx, username)
 }
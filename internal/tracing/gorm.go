@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const parentContextKey = "clinic:trace_parent"
+
+// InstrumentGorm starts a span for every statement run through db, as a
+// child of the span in the statement's context. Statements are recorded
+// with placeholders, never with their parameters.
+func InstrumentGorm(db *gorm.DB) error {
+	callbacks := db.Callback()
+	operations := []struct {
+		name          string
+		before, after func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("*").Register, callbacks.Create().After("*").Register},
+		{"query", callbacks.Query().Before("*").Register, callbacks.Query().After("*").Register},
+		{"update", callbacks.Update().Before("*").Register, callbacks.Update().After("*").Register},
+		{"delete", callbacks.Delete().Before("*").Register, callbacks.Delete().After("*").Register},
+		{"row", callbacks.Row().Before("*").Register, callbacks.Row().After("*").Register},
+		{"raw", callbacks.Raw().Before("*").Register, callbacks.Raw().After("*").Register},
+	}
+	for _, op := range operations {
+		if err := op.before("clinic:trace_"+op.name+"_start", startSpan(op.name)); err != nil {
+			return err
+		}
+		if err := op.after("clinic:trace_"+op.name+"_end", endSpan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startSpan(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx, _ := tracer.Start(tx.Statement.Context, "gorm."+operation, trace.WithSpanKind(trace.SpanKindClient))
+		tx.InstanceSet(parentContextKey, tx.Statement.Context)
+		tx.Statement.Context = ctx
+	}
+}
+
+func endSpan(tx *gorm.DB) {
+	span := trace.SpanFromContext(tx.Statement.Context)
+	if parent, ok := tx.InstanceGet(parentContextKey); ok {
+		tx.Statement.Context = parent.(context.Context)
+	}
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.sql.table", tx.Statement.Table),
+		attribute.String("db.statement", tx.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+	err := tx.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
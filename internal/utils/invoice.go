@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// FiscalYear returns the year in which the fiscal year containing t starts.
+func FiscalYear(t time.Time, startMonth time.Month) int {
+	if t.Month() < startMonth {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+// FormatInvoiceNumber renders e.g. INV-2025-000042.
+func FormatInvoiceNumber(prefix string, fiscalYear, seq int) string {
+	return fmt.Sprintf("%s-%d-%06d", prefix, fiscalYear, seq)
+}
+
+// FormatReceiptNumber renders e.g. RCT-000123.
+func FormatReceiptNumber(prefix string, seq int64) string {
+	return fmt.Sprintf("%s-%06d", prefix, seq)
+}
+
+// BusinessDate returns the local calendar day of t as a UTC midnight, the
+// form in which dates are stored.
+func BusinessDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// FormatClaimNumber renders e.g. CLM-000042.
+func FormatClaimNumber(seq int64) string {
+	return fmt.Sprintf("CLM-%06d", seq)
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFiscalYear(t *testing.T) {
+	tests := []struct {
+		name       string
+		date       time.Time
+		startMonth time.Month
+		want       int
+	}{
+		{name: "calendar year", date: time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC), startMonth: time.January, want: 2025},
+		{name: "before start", date: time.Date(2025, time.March, 31, 23, 59, 0, 0, time.UTC), startMonth: time.April, want: 2024},
+		{name: "on start", date: time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC), startMonth: time.April, want: 2025},
+		{name: "after start", date: time.Date(2026, time.February, 14, 0, 0, 0, 0, time.UTC), startMonth: time.July, want: 2025},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FiscalYear(tt.date, tt.startMonth); got != tt.want {
+				t.Errorf("FiscalYear() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	tests := []struct {
+		prefix string
+		year   int
+		seq    int
+		want   string
+	}{
+		{prefix: "INV", year: 2025, seq: 1, want: "INV-2025-000001"},
+		{prefix: "INV", year: 2025, seq: 42, want: "INV-2025-000042"},
+		{prefix: "CL", year: 2024, seq: 1234567, want: "CL-2024-1234567"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatInvoiceNumber(tt.prefix, tt.year, tt.seq); got != tt.want {
+			t.Errorf("FormatInvoiceNumber(%q, %d, %d) = %q, want %q", tt.prefix, tt.year, tt.seq, got, tt.want)
+		}
+	}
+}
+
+func TestBusinessDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		time time.Time
+		want time.Time
+	}{
+		{name: "utc", time: time.Date(2025, time.May, 12, 15, 4, 5, 0, time.UTC), want: time.Date(2025, time.May, 12, 0, 0, 0, 0, time.UTC)},
+		{name: "midnight", time: time.Date(2025, time.May, 12, 0, 0, 0, 0, time.UTC), want: time.Date(2025, time.May, 12, 0, 0, 0, 0, time.UTC)},
+		// Late evening in New York is already the next day in UTC; the
+		// business date stays on the local day.
+		{name: "local evening", time: time.Date(2025, time.May, 12, 22, 30, 0, 0, newYork), want: time.Date(2025, time.May, 12, 0, 0, 0, 0, time.UTC)},
+		{name: "year end", time: time.Date(2025, time.December, 31, 23, 59, 59, 0, newYork), want: time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BusinessDate(tt.time); !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("BusinessDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatReceiptNumber(t *testing.T) {
+	tests := []struct {
+		prefix string
+		seq    int64
+		want   string
+	}{
+		{prefix: "RCT", seq: 1, want: "RCT-000001"},
+		{prefix: "RCT", seq: 123, want: "RCT-000123"},
+		{prefix: "R", seq: 10000000, want: "R-10000000"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatReceiptNumber(tt.prefix, tt.seq); got != tt.want {
+			t.Errorf("FormatReceiptNumber(%q, %d) = %q, want %q", tt.prefix, tt.seq, got, tt.want)
+		}
+	}
+}
@@ -2,16 +2,15 @@ package utils
 
 import (
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/max-programming/clinic/internal/config"
 )
 
-func CreateJWT(userID string, username, role string) (string, error) {
+func CreateJWT(secret []byte, userID string, username, role string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"userID":   userID,
 		"username": username,
 		"role":     role,
 	})
-	tokenString, err := token.SignedString([]byte(config.Envs.JWTSecret))
+	tokenString, err := token.SignedString(secret)
 	if err != nil {
 		return "", err
 	}
@@ -19,12 +18,12 @@ func CreateJWT(userID string, username, role string) (string, error) {
 	return tokenString, nil
 }
 
-func VerifyJWT(tokenString string) (*jwt.Token, error) {
+func VerifyJWT(secret []byte, tokenString string) (*jwt.Token, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
 		}
-		return []byte(config.Envs.JWTSecret), nil
+		return secret, nil
 	})
 
 	return token, err
@@ -0,0 +1,41 @@
+package utils
+
+import "fmt"
+
+// FormatMRN turns a sequence number into a medical record number by
+// appending a Luhn check digit, e.g. 100000 becomes "1000009".
+func FormatMRN(seq int64) string {
+	payload := fmt.Sprintf("%06d", seq)
+	return payload + string(rune('0'+luhnCheckDigit(payload)))
+}
+
+// ValidMRN reports whether mrn is all digits and its check digit matches.
+func ValidMRN(mrn string) bool {
+	if len(mrn) < 2 {
+		return false
+	}
+	for _, r := range mrn {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	payload := mrn[:len(mrn)-1]
+	return int(mrn[len(mrn)-1]-'0') == luhnCheckDigit(payload)
+}
+
+func luhnCheckDigit(payload string) int {
+	sum := 0
+	double := true
+	for i := len(payload) - 1; i >= 0; i-- {
+		digit := int(payload[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,14 @@
+package utils
+
+import "strings"
+
+// PhoneDigits strips everything but digits from a phone number so that
+// "(555) 010-2030" and "555-010-2030" compare equal.
+func PhoneDigits(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
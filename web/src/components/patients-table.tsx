@@ -49,7 +49,7 @@ import { Patient } from "@/types/patient";
 import { Loading } from "@/components/ui/loading";
 import { Link } from "@tanstack/react-router";
 import { usePatients, useDeletePatient } from "@/lib/usePatients";
-import { cn, formatDate } from "@/lib/utils";
+import { cn, formatAddress, formatAge, formatDate } from "@/lib/utils";
 import { authService } from "@/lib/auth-service";
 import { UserResponseData } from "@/types/auth";
 
@@ -152,7 +152,9 @@ export function PatientsTable() {
         );
       },
       cell: ({ row }) => (
-        <div className="hidden md:block">{row.getValue("age")}</div>
+        <div className="hidden md:block">
+          {formatAge(row.original.age, row.original.ageMonths)}
+        </div>
       ),
     },
     {
@@ -193,7 +195,7 @@ export function PatientsTable() {
       header: "Address",
       enableSorting: false,
       cell: ({ row }) => {
-        const address = row.getValue<string>("address") || "N/A";
+        const address = formatAddress(row.original.address) || "N/A";
         return (
           <div className="hidden lg:block">
             <Tooltip>
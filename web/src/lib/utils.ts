@@ -1,5 +1,6 @@
 import { type ClassValue, clsx } from "clsx";
 import { twMerge } from "tailwind-merge";
+import type { PatientAddress } from "@/types/patient";
 
 export function cn(...inputs: ClassValue[]) {
   return twMerge(clsx(inputs));
@@ -22,3 +23,24 @@ export function truncateText(text: string, maxLength = 50): string {
   if (text.length <= maxLength) return text;
   return text.substring(0, maxLength) + "...";
 }
+
+export function formatAddress(address?: PatientAddress) {
+  if (!address) return "";
+  return [
+    address.line1,
+    address.line2,
+    address.city,
+    address.state,
+    address.postalCode,
+    address.country,
+  ]
+    .filter(Boolean)
+    .join(", ");
+}
+
+export function formatAge(age: number, ageMonths: number) {
+  if (ageMonths < 24) {
+    return `${ageMonths} ${ageMonths === 1 ? "month" : "months"}`;
+  }
+  return `${age} years`;
+}
@@ -19,7 +19,7 @@ import {
 } from "@/components/ui/card";
 import { Loading } from "@/components/ui/loading";
 import { useDeletePatient, usePatient } from "@/lib/usePatients";
-import { formatDate } from "@/lib/utils";
+import { formatAddress, formatAge, formatDate } from "@/lib/utils";
 import { createFileRoute, Link, useNavigate } from "@tanstack/react-router";
 import { ArrowLeft, Edit, FileEdit, Trash2 } from "lucide-react";
 import { useState } from "react";
@@ -104,9 +104,18 @@ function RouteComponent() {
               </h3>
               <p>{patient.name}</p>
             </div>
+            <div>
+              <h3 className="text-sm font-medium text-muted-foreground">
+                Date of Birth
+              </h3>
+              <p>
+                {patient.dateOfBirth || "N/A"}
+                {patient.dateOfBirthEstimated && " (estimated)"}
+              </p>
+            </div>
             <div>
               <h3 className="text-sm font-medium text-muted-foreground">Age</h3>
-              <p>{patient.age}</p>
+              <p>{formatAge(patient.age, patient.ageMonths)}</p>
             </div>
             <div>
               <h3 className="text-sm font-medium text-muted-foreground">
@@ -118,7 +127,7 @@ function RouteComponent() {
               <h3 className="text-sm font-medium text-muted-foreground">
                 Address
               </h3>
-              <p>{patient.address || "N/A"}</p>
+              <p>{formatAddress(patient.address) || "N/A"}</p>
             </div>
             <div>
               <h3 className="text-sm font-medium text-muted-foreground">
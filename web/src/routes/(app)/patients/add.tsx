@@ -22,22 +22,27 @@ import { useState } from "react";
 import { useForm } from "react-hook-form";
 import { z } from "zod";
 import { patientService } from "@/lib/patient-service";
+import { formatAge } from "@/lib/utils";
+import type { DuplicateMatch } from "@/types/patient";
+import { isAxiosError } from "axios";
 
 export const Route = createFileRoute("/(app)/patients/add")({
   component: RouteComponent,
 });
 
 const formSchema = z.object({
-  name: z.string().min(2, {
-    message: "Name must be at least 2 characters.",
+  givenName: z.string().min(1, {
+    message: "Given name is required.",
   }),
-  age: z.coerce.number().int().positive({
-    message: "Age must be a positive number.",
+  familyName: z.string().min(1, {
+    message: "Family name is required.",
   }),
+  dateOfBirth: z.string().date("Please enter a valid date of birth."),
   gender: z.enum(["Male", "Female"], {
     required_error: "Please select a gender.",
   }),
   address: z.string().optional(),
+  city: z.string().optional(),
   phone: z.string().optional(),
   medicalNotes: z.string().optional(),
 });
@@ -48,10 +53,12 @@ function RouteComponent() {
   const form = useForm<z.infer<typeof formSchema>>({
     resolver: zodResolver(formSchema),
     defaultValues: {
-      name: "",
-      age: undefined,
+      givenName: "",
+      familyName: "",
+      dateOfBirth: "",
       gender: undefined,
       address: "",
+      city: "",
       phone: "",
       medicalNotes: "",
     },
@@ -59,22 +66,33 @@ function RouteComponent() {
 
   const [isSubmitting, setIsSubmitting] = useState(false);
   const [error, setError] = useState<string | null>(null);
+  const [duplicates, setDuplicates] = useState<DuplicateMatch[]>([]);
 
-  async function onSubmit(values: z.infer<typeof formSchema>) {
+  async function onSubmit(
+    values: z.infer<typeof formSchema>,
+    allowDuplicate = false
+  ) {
     try {
       setIsSubmitting(true);
       setError(null);
+      setDuplicates([]);
 
       await patientService.addPatient({
-        name: values.name,
-        age: values.age,
+        givenName: values.givenName,
+        familyName: values.familyName,
+        dateOfBirth: values.dateOfBirth,
         gender: values.gender,
-        address: values.address,
+        address: { line1: values.address, city: values.city },
         phone: values.phone,
+        allowDuplicate,
       });
 
       navigate({ to: "/" });
     } catch (err) {
+      if (isAxiosError(err) && err.response?.status === 409) {
+        setDuplicates(err.response.data.matches ?? []);
+        return;
+      }
       console.error("Failed to add patient:", err);
       setError(
         err instanceof Error
@@ -101,33 +119,48 @@ function RouteComponent() {
         <h1 className="text-3xl font-bold mb-6">Add New Patient</h1>
 
         <Form {...form}>
-          <form onSubmit={form.handleSubmit(onSubmit)} className="space-y-6">
-            <FormField
-              control={form.control}
-              name="name"
-              render={({ field }) => (
-                <FormItem>
-                  <FormLabel>Name</FormLabel>
-                  <FormControl>
-                    <Input placeholder="Enter patient name" {...field} />
-                  </FormControl>
-                  <FormMessage />
-                </FormItem>
-              )}
-            />
+          <form
+            onSubmit={form.handleSubmit(values => onSubmit(values))}
+            className="space-y-6"
+          >
+            <div className="grid grid-cols-1 md:grid-cols-2 gap-6">
+              <FormField
+                control={form.control}
+                name="givenName"
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>Given Name</FormLabel>
+                    <FormControl>
+                      <Input placeholder="Enter given name" {...field} />
+                    </FormControl>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+
+              <FormField
+                control={form.control}
+                name="familyName"
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>Family Name</FormLabel>
+                    <FormControl>
+                      <Input placeholder="Enter family name" {...field} />
+                    </FormControl>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+            </div>
 
             <FormField
               control={form.control}
-              name="age"
+              name="dateOfBirth"
               render={({ field }) => (
                 <FormItem>
-                  <FormLabel>Age</FormLabel>
+                  <FormLabel>Date of Birth</FormLabel>
                   <FormControl>
-                    <Input
-                      type="number"
-                      placeholder="Enter patient age"
-                      {...field}
-                    />
+                    <Input type="date" {...field} />
                   </FormControl>
                   <FormMessage />
                 </FormItem>
@@ -166,7 +199,21 @@ function RouteComponent() {
                 <FormItem>
                   <FormLabel>Address</FormLabel>
                   <FormControl>
-                    <Input placeholder="Enter patient address" {...field} />
+                    <Input placeholder="Enter street address" {...field} />
+                  </FormControl>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name="city"
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>City</FormLabel>
+                  <FormControl>
+                    <Input placeholder="Enter city" {...field} />
                   </FormControl>
                   <FormMessage />
                 </FormItem>
@@ -196,6 +243,38 @@ function RouteComponent() {
               </div>
             )}
 
+            {duplicates.length > 0 && (
+              <div className="bg-amber-50 border border-amber-200 text-amber-800 px-4 py-3 rounded mb-4 space-y-2">
+                <p className="font-medium">
+                  This patient may already be registered:
+                </p>
+                <ul className="list-disc pl-5">
+                  {duplicates.map(match => (
+                    <li key={match.patient.id}>
+                      <Link
+                        to="/patients/$patientId"
+                        params={{ patientId: match.patient.id }}
+                        className="underline"
+                      >
+                        {match.patient.name}
+                      </Link>{" "}
+                      ({match.patient.mrn},{" "}
+                      {formatAge(match.patient.age, match.patient.ageMonths)})
+                      &mdash; {match.reasons.join(", ")}
+                    </li>
+                  ))}
+                </ul>
+                <Button
+                  type="button"
+                  variant="outline"
+                  disabled={isSubmitting}
+                  onClick={form.handleSubmit(values => onSubmit(values, true))}
+                >
+                  Register anyway
+                </Button>
+              </div>
+            )}
+
             <div className="flex justify-end gap-4">
               <Button type="button" variant="outline" asChild>
                 <Link to="/">Cancel</Link>
@@ -33,16 +33,18 @@ export const Route = createFileRoute("/(app)/patients_/$patientId/edit")({
 });
 
 const formSchema = z.object({
-  name: z.string().min(2, {
-    message: "Name must be at least 2 characters.",
+  givenName: z.string().min(1, {
+    message: "Given name is required.",
   }),
-  age: z.coerce.number().int().positive({
-    message: "Age must be a positive number.",
+  familyName: z.string().min(1, {
+    message: "Family name is required.",
   }),
+  dateOfBirth: z.string().date("Please enter a valid date of birth."),
   gender: z.enum(["Male", "Female"], {
     required_error: "Please select a gender.",
   }),
   address: z.string().optional(),
+  city: z.string().optional(),
   phone: z.string().optional(),
   medicalNotes: z.string().optional(),
 });
@@ -58,9 +60,12 @@ function EditPatientPage() {
   const form = useForm<z.infer<typeof formSchema>>({
     resolver: zodResolver(formSchema),
     defaultValues: {
-      name: "",
+      givenName: "",
+      familyName: "",
+      dateOfBirth: "",
       gender: undefined,
       address: "",
+      city: "",
       phone: "",
       medicalNotes: "",
     },
@@ -79,10 +84,12 @@ function EditPatientPage() {
   useEffect(() => {
     if (patient) {
       form.reset({
-        name: patient.name,
-        age: patient.age,
+        givenName: patient.givenName,
+        familyName: patient.familyName,
+        dateOfBirth: patient.dateOfBirth,
         gender: patient.gender as "Male" | "Female",
-        address: patient.address || "",
+        address: patient.address.line1 || "",
+        city: patient.address.city || "",
         phone: patient.phone || "",
         medicalNotes: patient.medicalNotes || "",
       });
@@ -92,9 +99,10 @@ function EditPatientPage() {
   async function onSubmit(values: z.infer<typeof formSchema>) {
     try {
       setError(null);
+      const { address, city, ...rest } = values;
       await updatePatientMutation.mutateAsync({
         id: patientId,
-        data: values,
+        data: { ...rest, address: { line1: address, city } },
       });
       navigate({ to: "/patients/$patientId", params: { patientId } });
     } catch (error) {
@@ -170,29 +178,45 @@ function EditPatientPage() {
 
         <Form {...form}>
           <form onSubmit={form.handleSubmit(onSubmit)} className="space-y-6">
-            <FormField
-              control={form.control}
-              name="name"
-              render={({ field }) => (
-                <FormItem>
-                  <FormLabel>Name</FormLabel>
-                  <FormControl>
-                    <Input {...field} />
-                  </FormControl>
-                  <FormMessage />
-                </FormItem>
-              )}
-            />
+            <div className="grid grid-cols-1 md:grid-cols-2 gap-6">
+              <FormField
+                control={form.control}
+                name="givenName"
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>Given Name</FormLabel>
+                    <FormControl>
+                      <Input {...field} />
+                    </FormControl>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+
+              <FormField
+                control={form.control}
+                name="familyName"
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>Family Name</FormLabel>
+                    <FormControl>
+                      <Input {...field} />
+                    </FormControl>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+            </div>
 
             <div className="grid grid-cols-1 md:grid-cols-2 gap-6">
               <FormField
                 control={form.control}
-                name="age"
+                name="dateOfBirth"
                 render={({ field }) => (
                   <FormItem>
-                    <FormLabel>Age</FormLabel>
+                    <FormLabel>Date of Birth</FormLabel>
                     <FormControl>
-                      <Input type="number" {...field} />
+                      <Input type="date" {...field} />
                     </FormControl>
                     <FormMessage />
                   </FormItem>
@@ -240,6 +264,20 @@ function EditPatientPage() {
               )}
             />
 
+            <FormField
+              control={form.control}
+              name="city"
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>City</FormLabel>
+                  <FormControl>
+                    <Input {...field} />
+                  </FormControl>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
             <FormField
               control={form.control}
               name="phone"
@@ -4,12 +4,40 @@ export interface PatientUser {
   role: string;
 }
 
+export interface PatientAddress {
+  line1?: string;
+  line2?: string;
+  city?: string;
+  state?: string;
+  postalCode?: string;
+  country?: string;
+}
+
+export interface EmergencyContact {
+  name?: string;
+  relationship?: string;
+  phone?: string;
+}
+
+export interface PatientIdentifier {
+  id: string;
+  type: "national_id" | "insurance_member_id" | "hospital_mrn" | "other";
+  issuer: string;
+  value: string;
+  createdAt: string;
+}
+
 export interface Patient {
   id: string;
+  mrn: string;
   name: string;
+  preferredName?: string;
+  dateOfBirth: string;
+  dateOfBirthEstimated: boolean;
   age: number;
+  ageMonths: number;
   gender: string;
-  address?: string;
+  address: PatientAddress;
   phone?: string;
   medicalNotes: string;
   createdAt: string;
@@ -17,30 +45,49 @@ export interface Patient {
 }
 
 export interface PatientDetail extends Patient {
+  givenName: string;
+  familyName: string;
+  sexAtBirth?: string;
+  genderIdentity?: string;
+  email?: string;
+  preferredLanguage?: string;
+  emergencyContact: EmergencyContact;
+  identifiers: PatientIdentifier[];
   createdBy: PatientUser;
   updatedBy: PatientUser;
+  mergedIntoId?: string;
 }
 
 export interface AddPatientRequest {
-  name: string;
-  age: number;
+  givenName: string;
+  familyName: string;
+  preferredName?: string;
+  dateOfBirth: string;
   gender: string;
-  address?: string;
+  sexAtBirth?: string;
+  genderIdentity?: string;
+  email?: string;
   phone?: string;
+  preferredLanguage?: string;
+  address?: PatientAddress;
+  emergencyContact?: EmergencyContact;
+  allowDuplicate?: boolean;
+}
+
+export interface DuplicateMatch {
+  patient: Patient;
+  score: number;
+  reasons: string[];
 }
 
 export interface AddPatientResponse {
   id: string;
+  mrn: string;
   createdBy: string;
   createdAt: string;
 }
 
-export interface UpdatePatientRequest {
-  name?: string;
-  age?: number;
-  gender?: string;
-  address?: string;
-  phone?: string;
+export interface UpdatePatientRequest extends Partial<AddPatientRequest> {
   medicalNotes?: string;
 }
 